@@ -0,0 +1,149 @@
+package commands
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+
+	"github.com/skycoin/dmsg/buildinfo"
+	"github.com/skycoin/dmsg/cmdutil"
+	"github.com/skycoin/skycoin/src/util/logging"
+	"github.com/spf13/cobra"
+
+	"github.com/skycoin/skywire/internal/httpauth"
+	"github.com/skycoin/skywire/pkg/snet/arserver"
+	"github.com/skycoin/skywire/pkg/syslog"
+)
+
+var (
+	addr         string
+	udpAddr      string
+	syslogAddr   string
+	tag          string
+	cfgFromStdin bool
+)
+
+func init() {
+	rootCmd.Flags().StringVarP(&addr, "addr", "a", "", "address to bind to, overrides config")
+	rootCmd.Flags().StringVar(&udpAddr, "udp-addr", "", "UDP address to bind to, overrides config")
+	rootCmd.Flags().StringVar(&syslogAddr, "syslog", "", "syslog server address. E.g. localhost:514")
+	rootCmd.Flags().StringVar(&tag, "tag", "address_resolver", "logging tag")
+	rootCmd.Flags().BoolVarP(&cfgFromStdin, "stdin", "i", false, "read config from STDIN")
+}
+
+var rootCmd = &cobra.Command{
+	Use:   "address-resolver [config.json]",
+	Short: "Address Resolver for skywire",
+	Run: func(_ *cobra.Command, args []string) {
+		mLog := logging.NewMasterLogger()
+		log := logging.MustGetLogger(tag)
+
+		if _, err := buildinfo.Get().WriteTo(mLog.Out); err != nil {
+			mLog.Printf("Failed to output build info: %v", err)
+		}
+
+		if syslogAddr != "" {
+			hook, err := syslog.SetupHook(syslogAddr, tag)
+			if err != nil {
+				log.Fatalf("Error setting up syslog: %v", err)
+			}
+
+			logging.AddHook(hook)
+		}
+
+		var rdr io.Reader
+		var err error
+
+		if !cfgFromStdin {
+			configFile := "config.json"
+
+			if len(args) > 0 {
+				configFile = args[0]
+			}
+			rdr, err = os.Open(configFile)
+			if err != nil {
+				log.Fatalf("Failed to open config: %v", err)
+			}
+		} else {
+			log.Info("Reading config from STDIN")
+			rdr = bufio.NewReader(os.Stdin)
+		}
+
+		conf := arserver.Config{}
+
+		raw, err := ioutil.ReadAll(rdr)
+		if err != nil {
+			log.Fatalf("Failed to read config: %v", err)
+		}
+
+		if err := json.Unmarshal(raw, &conf); err != nil {
+			log.WithField("raw", string(raw)).Fatalf("Failed to decode config: %s", err)
+		}
+
+		if addr != "" {
+			conf.Addr = addr
+		}
+
+		if udpAddr != "" {
+			conf.UDPAddr = udpAddr
+		}
+
+		conf.SetDefaults()
+
+		if lvl, err := logging.LevelFromString(conf.LogLevel); err == nil {
+			logging.SetLevel(lvl)
+		}
+
+		log.WithField("addr", conf.Addr).WithField("udp_addr", conf.UDPAddr).Info("Starting address resolver.")
+
+		udpConn, err := net.ListenPacket("udp", conf.UDPAddr)
+		if err != nil {
+			log.Fatalf("Failed to listen on UDP: %v", err)
+		}
+
+		trustedProxies, err := conf.ParseTrustedProxies()
+		if err != nil {
+			log.Fatalf("Failed to parse config: %v", err)
+		}
+
+		api := arserver.New(log, httpauth.NewMemoryNonceStore(), trustedProxies)
+		srv := &http.Server{Addr: conf.Addr, Handler: api}
+
+		ctx, cancel := cmdutil.SignalContext(context.Background(), log)
+		defer cancel()
+
+		go func() {
+			if err := api.ServeUDP(udpConn); err != nil {
+				log.WithError(err).Error("Stopped serving UDP.")
+			}
+		}()
+
+		go func() {
+			<-ctx.Done()
+
+			if err := srv.Shutdown(context.Background()); err != nil {
+				log.WithError(err).Error("Failed to shut down HTTP server.")
+			}
+
+			if err := udpConn.Close(); err != nil {
+				log.WithError(err).Error("Failed to close UDP connection.")
+			}
+		}()
+
+		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Fatalf("Failed to serve: %v", err)
+		}
+	},
+}
+
+// Execute executes root CLI command.
+func Execute() {
+	if err := rootCmd.Execute(); err != nil {
+		panic(err)
+	}
+}
@@ -52,6 +52,7 @@ func dialServer(appCl *app.Client, pk cipher.PubKey) (net.Conn, error) {
 func main() {
 	appC := app.NewClient(nil)
 	defer appC.Close()
+	appC.EnablePassthrough()
 
 	skysocks.Log = log
 
@@ -28,6 +28,7 @@ var log = logrus.New()
 func main() {
 	appC := app.NewClient(nil)
 	defer appC.Close()
+	appC.EnablePassthrough()
 
 	skysocks.Log = log
 
@@ -6,6 +6,7 @@ import (
 	"net"
 	"os"
 	"os/signal"
+	"sync"
 	"syscall"
 
 	"github.com/skycoin/dmsg/cipher"
@@ -21,6 +22,10 @@ var (
 	localSKStr  = flag.String("sk", "", "Local SecKey")
 	passcode    = flag.String("passcode", "", "Passcode to authenticate connection")
 	killswitch  = flag.Bool("killswitch", false, "If set, the Internet won't be restored during reconnection attempts")
+	includeStr  = flag.String("include", "", "Comma-separated CIDRs, IPs or hostnames to route through VPN, all traffic if empty")
+	excludeStr  = flag.String("exclude", "", "Comma-separated CIDRs, IPs or hostnames to route directly")
+	dns         = flag.Bool("dns", true, "Resolve names through DNS servers advertised by VPN server")
+	requireDNS  = flag.Bool("require-dns", false, "Refuse VPN server which doesn't advertise DNS servers, instead of resolving names outside of VPN")
 )
 
 func main() {
@@ -55,6 +60,18 @@ func main() {
 		}
 	}
 
+	includeRoutes, err := vpn.ParseRoutes(*includeStr)
+	if err != nil {
+		fmt.Printf("Invalid included routes: %v\n", err)
+		os.Exit(1)
+	}
+
+	excludeRoutes, err := vpn.ParseRoutes(*excludeStr)
+	if err != nil {
+		fmt.Printf("Invalid excluded routes: %v\n", err)
+		os.Exit(1)
+	}
+
 	var directIPsCh, nonDirectIPsCh = make(chan net.IP, 100), make(chan net.IP, 100)
 	defer close(directIPsCh)
 	defer close(nonDirectIPsCh)
@@ -87,15 +104,28 @@ func main() {
 		}
 	})
 
+	visorShutdownCh := make(chan struct{})
+	var visorShutdownOnce sync.Once
+
+	// the visor stops apps right after this event, closing the client restores system routes in time
+	eventSub.OnVisorShutdown(func(appevent.VisorShutdownData) {
+		visorShutdownOnce.Do(func() { close(visorShutdownCh) })
+	})
+
 	appClient := app.NewClient(eventSub)
 	defer appClient.Close()
+	appClient.EnablePassthrough()
 
 	fmt.Printf("Connecting to VPN server %s\n", serverPK.String())
 
 	vpnClientCfg := vpn.ClientConfig{
-		Passcode:   *passcode,
-		Killswitch: *killswitch,
-		ServerPK:   serverPK,
+		Passcode:      *passcode,
+		Killswitch:    *killswitch,
+		ServerPK:      serverPK,
+		IncludeRoutes: includeRoutes,
+		ExcludeRoutes: excludeRoutes,
+		DNS:           *dns,
+		RequireDNS:    *requireDNS,
 	}
 	vpnClient, err := vpn.NewClient(vpnClientCfg, appClient)
 	if err != nil {
@@ -137,7 +167,10 @@ func main() {
 	}
 
 	go func() {
-		<-osSigs
+		select {
+		case <-osSigs:
+		case <-visorShutdownCh:
+		}
 		vpnClient.Close()
 	}()
 
@@ -31,6 +31,8 @@ var (
 	localSKStr = flag.String("sk", "", "Local SecKey")
 	passcode   = flag.String("passcode", "", "Passcode to authenticate connecting users")
 	secure     = flag.Bool("secure", true, "Forbid connections from clients to server local network")
+	dnsStr     = flag.String("dns", "", "Comma-separated DNS server IPs advertised to clients, upstreams of DNS forwarder if it's enabled, host ones if empty")
+	dnsFwd     = flag.Bool("dns-forwarder", false, "Answer DNS queries of clients on their TUN gateway address")
 )
 
 func main() {
@@ -54,8 +56,14 @@ func main() {
 		}
 	}
 
+	dnsServers, err := vpn.ParseDNSServers(*dnsStr)
+	if err != nil {
+		log.WithError(err).Fatalln("Invalid DNS servers")
+	}
+
 	appClient := app.NewClient(nil)
 	defer appClient.Close()
+	appClient.EnablePassthrough()
 
 	osSigs := make(chan os.Signal, 2)
 
@@ -73,8 +81,10 @@ func main() {
 	log.Infof("Got app listener, bound to %d", vpnPort)
 
 	srvCfg := vpn.ServerConfig{
-		Passcode: *passcode,
-		Secure:   *secure,
+		Passcode:     *passcode,
+		Secure:       *secure,
+		DNS:          dnsServers,
+		DNSForwarder: *dnsFwd,
 	}
 	srv, err := vpn.NewServer(srvCfg, log)
 	if err != nil {
@@ -0,0 +1,133 @@
+package commands
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+
+	"github.com/skycoin/dmsg/buildinfo"
+	"github.com/skycoin/dmsg/cipher"
+	"github.com/skycoin/dmsg/cmdutil"
+	"github.com/skycoin/skycoin/src/util/logging"
+	"github.com/spf13/cobra"
+
+	"github.com/skycoin/skywire/pkg/routefinder/rfserver"
+	"github.com/skycoin/skywire/pkg/syslog"
+	"github.com/skycoin/skywire/pkg/transport/tpdclient"
+)
+
+var (
+	addr         string
+	syslogAddr   string
+	tag          string
+	cfgFromStdin bool
+)
+
+func init() {
+	rootCmd.Flags().StringVarP(&addr, "addr", "a", "", "address to bind to, overrides config")
+	rootCmd.Flags().StringVar(&syslogAddr, "syslog", "", "syslog server address. E.g. localhost:514")
+	rootCmd.Flags().StringVar(&tag, "tag", "route_finder", "logging tag")
+	rootCmd.Flags().BoolVarP(&cfgFromStdin, "stdin", "i", false, "read config from STDIN")
+}
+
+var rootCmd = &cobra.Command{
+	Use:   "route-finder [config.json]",
+	Short: "Route Finder for skywire",
+	Run: func(_ *cobra.Command, args []string) {
+		mLog := logging.NewMasterLogger()
+		log := logging.MustGetLogger(tag)
+
+		if _, err := buildinfo.Get().WriteTo(mLog.Out); err != nil {
+			mLog.Printf("Failed to output build info: %v", err)
+		}
+
+		if syslogAddr != "" {
+			hook, err := syslog.SetupHook(syslogAddr, tag)
+			if err != nil {
+				log.Fatalf("Error setting up syslog: %v", err)
+			}
+
+			logging.AddHook(hook)
+		}
+
+		var rdr io.Reader
+		var err error
+
+		if !cfgFromStdin {
+			configFile := "config.json"
+
+			if len(args) > 0 {
+				configFile = args[0]
+			}
+			rdr, err = os.Open(configFile)
+			if err != nil {
+				log.Fatalf("Failed to open config: %v", err)
+			}
+		} else {
+			log.Info("Reading config from STDIN")
+			rdr = bufio.NewReader(os.Stdin)
+		}
+
+		conf := rfserver.Config{}
+
+		raw, err := ioutil.ReadAll(rdr)
+		if err != nil {
+			log.Fatalf("Failed to read config: %v", err)
+		}
+
+		if err := json.Unmarshal(raw, &conf); err != nil {
+			log.WithField("raw", string(raw)).Fatalf("Failed to decode config: %s", err)
+		}
+
+		if addr != "" {
+			conf.Addr = addr
+		}
+
+		conf.SetDefaults()
+
+		if lvl, err := logging.LevelFromString(conf.LogLevel); err == nil {
+			logging.SetLevel(lvl)
+		}
+
+		if conf.PK.Null() {
+			log.Info("No keys provided, generating new ones.")
+			conf.PK, conf.SK = cipher.GenerateKeyPair()
+		}
+
+		log.WithField("pk", conf.PK).WithField("addr", conf.Addr).Info("Starting route finder.")
+
+		tpd, err := tpdclient.NewHTTP(conf.TransportDiscovery, conf.PK, conf.SK)
+		if err != nil {
+			log.Fatalf("Failed to create transport discovery client: %v", err)
+		}
+
+		api := rfserver.New(log, tpd, conf)
+		srv := &http.Server{Addr: conf.Addr, Handler: api}
+
+		ctx, cancel := cmdutil.SignalContext(context.Background(), log)
+		defer cancel()
+
+		go func() {
+			<-ctx.Done()
+
+			if err := srv.Shutdown(context.Background()); err != nil {
+				log.WithError(err).Error("Failed to shut down HTTP server.")
+			}
+		}()
+
+		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Fatalf("Failed to serve: %v", err)
+		}
+	},
+}
+
+// Execute executes root CLI command.
+func Execute() {
+	if err := rootCmd.Execute(); err != nil {
+		panic(err)
+	}
+}
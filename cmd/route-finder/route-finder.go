@@ -0,0 +1,9 @@
+package main
+
+import (
+	"github.com/skycoin/skywire/cmd/route-finder/commands"
+)
+
+func main() {
+	commands.Execute()
+}
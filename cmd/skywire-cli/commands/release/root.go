@@ -0,0 +1,118 @@
+package release
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/skycoin/dmsg/cipher"
+	"github.com/spf13/cobra"
+
+	"github.com/skycoin/skywire/cmd/skywire-cli/internal"
+	"github.com/skycoin/skywire/pkg/app/appbundle"
+	"github.com/skycoin/skywire/pkg/util/updater"
+)
+
+// releaseSKEnv is the environment variable release secret key is read from if no flag is given.
+const releaseSKEnv = "SKYWIRE_RELEASE_SK"
+
+var (
+	sk       cipher.SecKey
+	output   string
+	manifest string
+)
+
+func init() {
+	signCmd.Flags().Var(&sk, "sk", "release secret key, read from "+releaseSKEnv+" if not set")
+	signCmd.Flags().StringVarP(&output, "output", "o", "", "signature file path (default <checksums-file>.sig)")
+
+	bundleCmd.Flags().Var(&sk, "sk", "app bundle secret key, read from "+releaseSKEnv+" if not set")
+	bundleCmd.Flags().StringVarP(&manifest, "manifest", "m", "manifest.json", "app manifest file path")
+	bundleCmd.Flags().StringVarP(&output, "output", "o", "", "bundle file path (default <name>-<version>-<os>-<arch>.tar.gz)")
+
+	pkCmd.Flags().Var(&sk, "sk", "release secret key, read from "+releaseSKEnv+" if not set")
+
+	RootCmd.AddCommand(signCmd, bundleCmd, pkCmd)
+}
+
+// RootCmd contains commands for preparing skywire releases.
+var RootCmd = &cobra.Command{
+	Use:   "release",
+	Short: "Contains sub-commands for preparing skywire releases",
+}
+
+var signCmd = &cobra.Command{
+	Use:   "sign <checksums-file>",
+	Short: "Signs checksums file of a release, visors only install releases signed by their release public keys",
+	Args:  cobra.ExactArgs(1),
+	Run: func(_ *cobra.Command, args []string) {
+		readSK()
+
+		checksums, err := ioutil.ReadFile(args[0])
+		internal.Catch(err)
+
+		sig, err := updater.SignChecksums(checksums, sk)
+		internal.Catch(err)
+
+		if output == "" {
+			output = args[0] + ".sig"
+		}
+
+		internal.Catch(ioutil.WriteFile(output, []byte(sig.Hex()+"\n"), 0644)) // nolint:gosec
+
+		fmt.Println(output)
+	},
+}
+
+var bundleCmd = &cobra.Command{
+	Use:   "bundle <binary>",
+	Short: "Creates a signed app bundle, visors only install bundles signed by their bundle public keys",
+	Long: `Creates a signed app bundle of the binary described by the manifest.
+The manifest declares app name, version, default port and args, required permissions,
+os and arch. Checksum of the binary is filled in automatically.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(_ *cobra.Command, args []string) {
+		readSK()
+
+		data, err := ioutil.ReadFile(manifest)
+		internal.Catch(err)
+
+		var m appbundle.Manifest
+		internal.Catch(json.Unmarshal(data, &m), "failed to decode manifest:")
+
+		binary, err := ioutil.ReadFile(args[0])
+		internal.Catch(err)
+
+		bundle, err := appbundle.Create(m, binary, sk)
+		internal.Catch(err)
+
+		if output == "" {
+			output = fmt.Sprintf("%s-%s-%s-%s.tar.gz", m.Name, m.Version, m.OS, m.Arch)
+		}
+
+		internal.Catch(ioutil.WriteFile(output, bundle, 0644)) // nolint:gosec
+
+		fmt.Println(output)
+	},
+}
+
+var pkCmd = &cobra.Command{
+	Use:   "pk",
+	Short: "Prints release public key of the release secret key, release binaries are built with it",
+	Args:  cobra.NoArgs,
+	Run: func(_ *cobra.Command, _ []string) {
+		readSK()
+
+		pk, err := sk.PubKey()
+		internal.Catch(err)
+
+		fmt.Println(pk)
+	},
+}
+
+func readSK() {
+	if sk.Null() {
+		internal.Catch(sk.Set(os.Getenv(releaseSKEnv)), "failed to parse secret key:")
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"github.com/spf13/cobra"
 
 	"github.com/skycoin/skywire/cmd/skywire-cli/commands/mdisc"
+	"github.com/skycoin/skywire/cmd/skywire-cli/commands/release"
 	"github.com/skycoin/skywire/cmd/skywire-cli/commands/rtfind"
 	"github.com/skycoin/skywire/cmd/skywire-cli/commands/visor"
 )
@@ -20,6 +21,7 @@ func init() {
 		visor.RootCmd,
 		mdisc.RootCmd,
 		rtfind.RootCmd,
+		release.RootCmd,
 	)
 }
 
@@ -2,6 +2,7 @@ package visor
 
 import (
 	"fmt"
+	"io/ioutil"
 	"os"
 	"strconv"
 	"strings"
@@ -14,13 +15,22 @@ import (
 	"github.com/skycoin/skywire/pkg/app/launcher"
 )
 
+var grantedPerms []string
+
 func init() {
+	for _, cmd := range []*cobra.Command{installAppCmd, upgradeAppCmd} {
+		cmd.Flags().StringSliceVar(&grantedPerms, "grant", nil, "permissions granted to the app (net_admin, filesystem)")
+	}
+
 	RootCmd.AddCommand(
 		lsAppsCmd,
 		startAppCmd,
 		stopAppCmd,
 		setAppAutostartCmd,
 		appLogsSinceCmd,
+		installAppCmd,
+		upgradeAppCmd,
+		uninstallAppCmd,
 		execCmd,
 	)
 }
@@ -112,6 +122,44 @@ var appLogsSinceCmd = &cobra.Command{
 	},
 }
 
+var installAppCmd = &cobra.Command{
+	Use:   "install-app <bundle>",
+	Short: "Installs an app from a signed bundle",
+	Args:  cobra.ExactArgs(1),
+	Run: func(_ *cobra.Command, args []string) {
+		bundle, err := ioutil.ReadFile(args[0])
+		internal.Catch(err)
+
+		manifest, err := rpcClient().InstallApp(bundle, grantedPerms)
+		internal.Catch(err)
+		fmt.Printf("Installed %s %s on port %d\n", manifest.Name, manifest.Version, manifest.Port)
+	},
+}
+
+var upgradeAppCmd = &cobra.Command{
+	Use:   "upgrade-app <bundle>",
+	Short: "Upgrades an app installed from a bundle to a newer version",
+	Args:  cobra.ExactArgs(1),
+	Run: func(_ *cobra.Command, args []string) {
+		bundle, err := ioutil.ReadFile(args[0])
+		internal.Catch(err)
+
+		manifest, err := rpcClient().UpgradeApp(bundle, grantedPerms)
+		internal.Catch(err)
+		fmt.Printf("Upgraded %s to %s\n", manifest.Name, manifest.Version)
+	},
+}
+
+var uninstallAppCmd = &cobra.Command{
+	Use:   "uninstall-app <name>",
+	Short: "Uninstalls an app installed from a bundle",
+	Args:  cobra.ExactArgs(1),
+	Run: func(_ *cobra.Command, args []string) {
+		internal.Catch(rpcClient().UninstallApp(args[0]))
+		fmt.Println("OK")
+	},
+}
+
 var execCmd = &cobra.Command{
 	Use:   "exec <command>",
 	Short: "Executes the given command",
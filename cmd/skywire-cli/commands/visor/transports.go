@@ -81,7 +81,7 @@ var (
 func init() {
 	const (
 		typeFlagUsage = "type of transport to add; if unspecified, cli will attempt to establish a transport " +
-			"in the following order: stcp, stcpr, sudph, dmsg"
+			"in the following order: stcp, stcpr, sudph, quic, sws, dmsg"
 		publicFlagUsage  = "whether to make the transport public"
 		timeoutFlagUsage = "if specified, sets an operation timeout"
 	)
@@ -117,6 +117,8 @@ var addTpCmd = &cobra.Command{
 				tptypes.STCP,
 				tptypes.STCPR,
 				tptypes.SUDPH,
+				tptypes.QUIC,
+				tptypes.SWS,
 				dmsg.Type,
 			}
 
@@ -0,0 +1,103 @@
+package commands
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/skycoin/dmsg/buildinfo"
+	"github.com/skycoin/dmsg/cmdutil"
+	"github.com/skycoin/skycoin/src/util/logging"
+	"github.com/spf13/cobra"
+
+	"github.com/skycoin/skywire/pkg/syslog"
+	"github.com/skycoin/skywire/pkg/transport/tpdserver"
+)
+
+var (
+	addr         string
+	dbPath       string
+	entryTimeout time.Duration
+	logLevel     string
+	syslogAddr   string
+	tag          string
+)
+
+func init() {
+	rootCmd.Flags().StringVarP(&addr, "addr", "a", ":9091", "address to bind to")
+	rootCmd.Flags().StringVar(&dbPath, "db", "", "path to bbolt database, transports are kept in memory if empty")
+	rootCmd.Flags().DurationVar(&entryTimeout, "entry-timeout", tpdserver.DefaultEntryTimeout, "time after which transports without status updates are removed")
+	rootCmd.Flags().StringVar(&logLevel, "log-level", "info", "log level")
+	rootCmd.Flags().StringVar(&syslogAddr, "syslog", "", "syslog server address. E.g. localhost:514")
+	rootCmd.Flags().StringVar(&tag, "tag", "transport_discovery", "logging tag")
+}
+
+var rootCmd = &cobra.Command{
+	Use:   "transport-discovery",
+	Short: "Transport Discovery for skywire",
+	Run: func(_ *cobra.Command, _ []string) {
+		mLog := logging.NewMasterLogger()
+		log := logging.MustGetLogger(tag)
+
+		if _, err := buildinfo.Get().WriteTo(mLog.Out); err != nil {
+			mLog.Printf("Failed to output build info: %v", err)
+		}
+
+		if syslogAddr != "" {
+			hook, err := syslog.SetupHook(syslogAddr, tag)
+			if err != nil {
+				log.Fatalf("Error setting up syslog: %v", err)
+			}
+
+			logging.AddHook(hook)
+		}
+
+		if lvl, err := logging.LevelFromString(logLevel); err == nil {
+			logging.SetLevel(lvl)
+		}
+
+		store := tpdserver.NewMemoryStore()
+
+		if dbPath != "" {
+			var err error
+			if store, err = tpdserver.NewBBoltStore(dbPath); err != nil {
+				log.Fatalf("Failed to open store: %v", err)
+			}
+		}
+
+		defer func() {
+			if err := store.Close(); err != nil {
+				log.WithError(err).Error("Failed to close store.")
+			}
+		}()
+
+		log.WithField("addr", addr).WithField("db", dbPath).Info("Starting transport discovery.")
+
+		api := tpdserver.New(log, store, tpdserver.Config{EntryTimeout: entryTimeout})
+		srv := &http.Server{Addr: addr, Handler: api}
+
+		ctx, cancel := cmdutil.SignalContext(context.Background(), log)
+		defer cancel()
+
+		go api.RemoveExpired(ctx)
+
+		go func() {
+			<-ctx.Done()
+
+			if err := srv.Shutdown(context.Background()); err != nil {
+				log.WithError(err).Error("Failed to shut down HTTP server.")
+			}
+		}()
+
+		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Errorf("Failed to serve: %v", err)
+		}
+	},
+}
+
+// Execute executes root CLI command.
+func Execute() {
+	if err := rootCmd.Execute(); err != nil {
+		panic(err)
+	}
+}
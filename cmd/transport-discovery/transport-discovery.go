@@ -0,0 +1,9 @@
+package main
+
+import (
+	"github.com/skycoin/skywire/cmd/transport-discovery/commands"
+)
+
+func main() {
+	commands.Execute()
+}
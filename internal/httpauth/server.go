@@ -0,0 +1,210 @@
+package httpauth
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"path"
+	"sync"
+
+	"github.com/skycoin/dmsg/cipher"
+)
+
+// maxBodySize is the max size of the request body read to verify its signature.
+const maxBodySize = 1 << 20
+
+var (
+	// ErrInvalidSignature is returned when request signature doesn't match the payload.
+	ErrInvalidSignature = errors.New("invalid signature")
+)
+
+// NonceStore keeps nonces expected from the clients.
+type NonceStore interface {
+	Nonce(ctx context.Context, pk cipher.PubKey) (Nonce, error)
+	IncrementNonce(ctx context.Context, pk cipher.PubKey) (Nonce, error)
+}
+
+type memNonceStore struct {
+	mu     sync.Mutex
+	nonces map[cipher.PubKey]Nonce
+}
+
+// NewMemoryNonceStore returns NonceStore which keeps nonces in memory.
+func NewMemoryNonceStore() NonceStore {
+	return &memNonceStore{nonces: make(map[cipher.PubKey]Nonce)}
+}
+
+func (s *memNonceStore) Nonce(_ context.Context, pk cipher.PubKey) (Nonce, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.nonces[pk], nil
+}
+
+func (s *memNonceStore) IncrementNonce(_ context.Context, pk cipher.PubKey) (Nonce, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.nonces[pk]++
+
+	return s.nonces[pk], nil
+}
+
+type ctxKey int
+
+const ctxKeyPK ctxKey = iota
+
+// PKFromContext returns public key of the client authenticated by the middleware.
+func PKFromContext(ctx context.Context) (cipher.PubKey, bool) {
+	pk, ok := ctx.Value(ctxKeyPK).(cipher.PubKey)
+	return pk, ok
+}
+
+// Server authenticates requests signed by Client.
+type Server struct {
+	store NonceStore
+
+	mu    sync.Mutex
+	locks map[cipher.PubKey]*pkLock
+}
+
+// pkLock serializes requests of a single client. It's removed once no request holds it.
+type pkLock struct {
+	mu   sync.Mutex
+	refs int
+}
+
+// NewServer constructs a new Server keeping nonces in `store`.
+func NewServer(store NonceStore) *Server {
+	return &Server{
+		store: store,
+		locks: make(map[cipher.PubKey]*pkLock),
+	}
+}
+
+// NonceHandler serves the next nonce expected from the public key
+// which is the last element of the request path.
+func (s *Server) NonceHandler(w http.ResponseWriter, r *http.Request) {
+	var pk cipher.PubKey
+	if err := pk.UnmarshalText([]byte(path.Base(r.URL.Path))); err != nil {
+		WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	nonce, err := s.store.Nonce(r.Context(), pk)
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	if err := json.NewEncoder(w).Encode(NextNonceResponse{Edge: pk, NextNonce: nonce}); err != nil {
+		log.WithError(err).Warn("Failed to write nonce response")
+	}
+}
+
+// Middleware verifies request signature and nonce and passes the public key
+// of the client to the handler via request context, see PKFromContext.
+// Nonce is only incremented on successful responses, just like Client does.
+func (s *Server) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth, err := AuthFromHeaders(r.Header)
+		if err != nil {
+			WriteError(w, http.StatusUnauthorized, err)
+			return
+		}
+
+		body, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, maxBodySize))
+		if err != nil {
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				WriteError(w, http.StatusRequestEntityTooLarge, err)
+				return
+			}
+
+			WriteError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		r.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+		// requests of a single client are handled one at a time, so that a nonce can't be used twice
+		s.lock(auth.Key)
+		defer s.unlock(auth.Key)
+
+		nonce, err := s.store.Nonce(r.Context(), auth.Key)
+		if err != nil {
+			WriteError(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		if auth.Nonce != nonce {
+			WriteError(w, http.StatusUnauthorized, errors.New(invalidNonceErrorMessage))
+			return
+		}
+
+		if err := auth.Verify(body); err != nil {
+			WriteError(w, http.StatusUnauthorized, ErrInvalidSignature)
+			return
+		}
+
+		sw := &statusWriter{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(sw, r.WithContext(context.WithValue(r.Context(), ctxKeyPK, auth.Key)))
+
+		if sw.status == http.StatusOK {
+			if _, err := s.store.IncrementNonce(r.Context(), auth.Key); err != nil {
+				log.WithError(err).Error("Failed to increment nonce")
+			}
+		}
+	})
+}
+
+func (s *Server) lock(pk cipher.PubKey) {
+	s.mu.Lock()
+	l, ok := s.locks[pk]
+	if !ok {
+		l = new(pkLock)
+		s.locks[pk] = l
+	}
+	l.refs++
+	s.mu.Unlock()
+
+	l.mu.Lock()
+}
+
+func (s *Server) unlock(pk cipher.PubKey) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	l := s.locks[pk]
+	l.mu.Unlock()
+
+	if l.refs--; l.refs == 0 {
+		delete(s.locks, pk)
+	}
+}
+
+// WriteError writes error in the format understood by Client.
+func WriteError(w http.ResponseWriter, code int, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+
+	resp := HTTPResponse{Error: &HTTPError{Message: err.Error(), Code: code}}
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		log.WithError(err).Warn("Failed to write error response")
+	}
+}
+
+type statusWriter struct {
+	http.ResponseWriter
+	status int
+}
+
+func (w *statusWriter) WriteHeader(code int) {
+	w.status = code
+	w.ResponseWriter.WriteHeader(code)
+}
@@ -0,0 +1,102 @@
+package httpauth
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/skycoin/dmsg/cipher"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServer(t *testing.T) {
+	pk, sk := cipher.GenerateKeyPair()
+
+	store := NewMemoryNonceStore()
+	srv := NewServer(store)
+
+	pkCh := make(chan cipher.PubKey, 1)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/security/nonces/", srv.NonceHandler)
+	mux.Handle("/foo", srv.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		reqPK, ok := PKFromContext(r.Context())
+		require.True(t, ok)
+		pkCh <- reqPK
+
+		if r.URL.Query().Get("fail") != "" {
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})))
+
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+
+	c, err := NewClient(context.TODO(), ts.URL, pk, sk)
+	require.NoError(t, err)
+
+	do := func(query string) *http.Response {
+		req, err := http.NewRequest(http.MethodPost, ts.URL+"/foo"+query, bytes.NewBufferString(payload))
+		require.NoError(t, err)
+
+		res, err := c.Do(req)
+		require.NoError(t, err)
+		require.NoError(t, res.Body.Close())
+
+		return res
+	}
+
+	res := do("")
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, pk, <-pkCh)
+
+	nonce, err := store.Nonce(context.TODO(), pk)
+	require.NoError(t, err)
+	assert.Equal(t, Nonce(1), nonce)
+
+	// failed requests don't use up the nonce
+	res = do("?fail=1")
+	assert.Equal(t, http.StatusNotFound, res.StatusCode)
+	<-pkCh
+
+	nonce, err = store.Nonce(context.TODO(), pk)
+	require.NoError(t, err)
+	assert.Equal(t, Nonce(1), nonce)
+
+	// client recovers from the outdated nonce
+	c.SetNonce(0)
+	res = do("")
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	<-pkCh
+
+	// requests with a wrong signature are rejected
+	req, err := http.NewRequest(http.MethodPost, ts.URL+"/foo", bytes.NewBufferString(payload))
+	require.NoError(t, err)
+
+	hdr, err := c.Header()
+	require.NoError(t, err)
+	req.Header = hdr
+
+	res, err = http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	require.NoError(t, res.Body.Close())
+	assert.Equal(t, http.StatusUnauthorized, res.StatusCode)
+
+	// bodies are only read up to the limit
+	req, err = http.NewRequest(http.MethodPost, ts.URL+"/foo", bytes.NewReader(make([]byte, maxBodySize+1)))
+	require.NoError(t, err)
+	req.Header = hdr
+
+	res, err = http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	require.NoError(t, res.Body.Close())
+	assert.Equal(t, http.StatusRequestEntityTooLarge, res.StatusCode)
+
+	// locks of clients are only kept while their requests are handled
+	srv.mu.Lock()
+	assert.Empty(t, srv.locks)
+	srv.mu.Unlock()
+}
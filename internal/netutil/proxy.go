@@ -0,0 +1,91 @@
+package netutil
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// ErrUnsupportedProxyScheme is returned if proxy URL has scheme other than http.
+var ErrUnsupportedProxyScheme = errors.New("unsupported proxy scheme")
+
+// DialHTTPProxy dials `addr` through HTTP proxy `proxyURL` using CONNECT method.
+// Credentials of the proxy are taken from user info of `proxyURL`, if any.
+func DialHTTPProxy(ctx context.Context, proxyURL *url.URL, addr string) (net.Conn, error) {
+	if proxyURL.Scheme != "http" {
+		return nil, fmt.Errorf("%w: %q", ErrUnsupportedProxyScheme, proxyURL.Scheme)
+	}
+
+	proxyAddr := proxyURL.Host
+	if proxyURL.Port() == "" {
+		proxyAddr = net.JoinHostPort(proxyURL.Hostname(), "80")
+	}
+
+	var d net.Dialer
+
+	conn, err := d.DialContext(ctx, "tcp", proxyAddr)
+	if err != nil {
+		return nil, err
+	}
+
+	if deadline, ok := ctx.Deadline(); ok {
+		if err := conn.SetDeadline(deadline); err != nil {
+			_ = conn.Close() // nolint:errcheck
+			return nil, err
+		}
+	}
+
+	if err := connect(conn, proxyURL, addr); err != nil {
+		_ = conn.Close() // nolint:errcheck
+		return nil, fmt.Errorf("proxy CONNECT %s: %w", addr, err)
+	}
+
+	if err := conn.SetDeadline(time.Time{}); err != nil {
+		_ = conn.Close() // nolint:errcheck
+		return nil, err
+	}
+
+	return conn, nil
+}
+
+func connect(conn net.Conn, proxyURL *url.URL, addr string) error {
+	req := &http.Request{
+		Method: http.MethodConnect,
+		URL:    &url.URL{Opaque: addr},
+		Host:   addr,
+		Header: make(http.Header),
+	}
+
+	if u := proxyURL.User; u != nil {
+		password, _ := u.Password()
+		credentials := base64.StdEncoding.EncodeToString([]byte(u.Username() + ":" + password))
+		req.Header.Set("Proxy-Authorization", "Basic "+credentials)
+	}
+
+	if err := req.Write(conn); err != nil {
+		return err
+	}
+
+	// Tunneled protocol is expected to be started by the client,
+	// so nothing but the response may be buffered here.
+	resp, err := http.ReadResponse(bufio.NewReader(conn), req)
+	if err != nil {
+		return err
+	}
+
+	if err := resp.Body.Close(); err != nil {
+		return err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status: %s", resp.Status)
+	}
+
+	return nil
+}
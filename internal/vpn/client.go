@@ -25,7 +25,11 @@ import (
 const (
 	ipv4FirstHalfAddr      = "0.0.0.0/1"
 	ipv4SecondHalfAddr     = "128.0.0.0/1"
+	ipv6FirstHalfAddr      = "::/1"
+	ipv6SecondHalfAddr     = "8000::/1"
 	directRouteNetmaskCIDR = "/32"
+	// directRouteNetmaskCIDRv6 is the IPv6 counterpart of directRouteNetmaskCIDR.
+	directRouteNetmaskCIDRv6 = "/128"
 )
 
 // Client is a VPN client.
@@ -37,12 +41,24 @@ type Client struct {
 	directIPSMu    sync.Mutex
 	directIPs      []net.IP
 	defaultGateway net.IP
-	closeC         chan struct{}
-	closeOnce      sync.Once
+	// defaultGatewayIPv6 is empty if the system has no IPv6 connectivity of its own.
+	defaultGatewayIPv6 string
+	closeC             chan struct{}
+	closeOnce          sync.Once
 
 	prevTUNGateway   net.IP
 	prevTUNGatewayMu sync.Mutex
 
+	// routesMu guards routes set up for split tunneling.
+	routesMu             sync.Mutex
+	tunRoutes            []string
+	tunRoutesGateway     net.IP
+	tunRoutesGatewayIPv6 net.IP
+	excludedRoutes       []string
+	// blockedRoutes are IPv6 routes made unreachable while IPv6 isn't tunneled.
+	blockedRoutes []string
+	lookupIP      ipLookupFunc
+
 	suidMu sync.Mutex
 	suid   int
 
@@ -123,14 +139,23 @@ func NewClient(cfg ClientConfig, appCl *app.Client) (*Client, error) {
 
 	fmt.Printf("Got default network gateway IP: %s\n", defaultGateway)
 
+	defaultGatewayIPv6, err := DefaultNetworkGatewayIPv6()
+	if err != nil {
+		fmt.Printf("No default IPv6 network gateway: %v\n", err)
+	} else {
+		fmt.Printf("Got default IPv6 network gateway IP: %s\n", defaultGatewayIPv6)
+	}
+
 	return &Client{
-		log:            log,
-		cfg:            cfg,
-		appCl:          appCl,
-		r:              r,
-		directIPs:      filterOutEqualIPs(directIPs),
-		defaultGateway: defaultGateway,
-		closeC:         make(chan struct{}),
+		log:                log,
+		cfg:                cfg,
+		appCl:              appCl,
+		r:                  r,
+		directIPs:          filterOutEqualIPs(directIPs),
+		defaultGateway:     defaultGateway,
+		defaultGatewayIPv6: defaultGatewayIPv6,
+		closeC:             make(chan struct{}),
+		lookupIP:           lookupIP,
 	}, nil
 }
 
@@ -145,6 +170,11 @@ func (c *Client) Serve() error {
 		c.releaseSysPrivileges()
 		return fmt.Errorf("error setting up direct routes: %w", err)
 	}
+
+	// DNS configuration is left changed if the previous session didn't end cleanly
+	if err := RestoreDNS(); err != nil {
+		fmt.Printf("Error restoring DNS configuration of the previous session: %v\n", err)
+	}
 	c.releaseSysPrivileges()
 
 	defer func() {
@@ -155,6 +185,7 @@ func (c *Client) Serve() error {
 		defer c.releaseSysPrivileges()
 
 		c.removeDirectRoutes()
+		c.removeExcludedRoutes()
 	}()
 
 	// we call this preliminary, so it will be called on app stop
@@ -169,7 +200,7 @@ func (c *Client) Serve() error {
 				c.prevTUNGatewayMu.Lock()
 				if len(c.prevTUNGateway) > 0 {
 					fmt.Printf("Routing traffic directly, previous TUN gateway: %s\n", c.prevTUNGateway.String())
-					c.routeTrafficDirectly(c.prevTUNGateway)
+					c.routeTrafficDirectly()
 				}
 				c.prevTUNGateway = nil
 				c.prevTUNGatewayMu.Unlock()
@@ -188,6 +219,11 @@ func (c *Client) Serve() error {
 	for {
 		if err := c.dialServeConn(); err != nil {
 			fmt.Printf("dialServeConn: %v\n", err)
+
+			// reconnecting to the same server doesn't change its DNS configuration
+			if errors.Is(err, errNoServerDNS) {
+				return err
+			}
 		}
 
 		if c.isClosed() {
@@ -316,12 +352,32 @@ func (c *Client) setupTUN(tunIP, tunGateway net.IP) error {
 	return SetupTUN(c.tun.Name(), tunIP.String()+TUNNetmaskCIDR, tunGateway.String(), TUNMTU)
 }
 
+func (c *Client) setupTUNIPv6(tunIP net.IP) error {
+	c.tunMu.Lock()
+	defer c.tunMu.Unlock()
+
+	if !c.tunCreated {
+		return errors.New("TUN is not created")
+	}
+
+	return SetupTUNIPv6(c.tun.Name(), tunIP.String()+TUNNetmaskCIDRv6)
+}
+
 func (c *Client) serveConn(conn net.Conn) error {
-	tunIP, tunGateway, err := c.shakeHands(conn)
+	sHello, err := c.shakeHands(conn)
 	if err != nil {
 		return fmt.Errorf("error during client/server handshake: %w", err)
 	}
 
+	if c.cfg.DNS && c.cfg.RequireDNS && len(sHello.DNS) == 0 {
+		return errNoServerDNS
+	}
+
+	tunIP, tunGateway := sHello.TUNIP, sHello.TUNGateway
+
+	// hostnames are resolved on each connect, since their IPs may change between sessions
+	includedRoutes, excludedRoutes := c.resolveSplitTunnelRoutes()
+
 	fmt.Printf("Performed handshake with %s\n", conn.RemoteAddr())
 	fmt.Printf("Local TUN IP: %s\n", tunIP.String())
 	fmt.Printf("Local TUN gateway: %s\n", tunGateway.String())
@@ -351,6 +407,19 @@ func (c *Client) serveConn(conn net.Conn) error {
 		return fmt.Errorf("error setting up TUN %s: %w", tun.Name(), err)
 	}
 
+	tunGatewayIPv6 := sHello.TUNGatewayIPv6
+	if sHello.TUNIPv6 == nil || tunGatewayIPv6 == nil {
+		fmt.Println("Server doesn't tunnel IPv6")
+		tunGatewayIPv6 = nil
+	} else if err := c.setupTUNIPv6(sHello.TUNIPv6); err != nil {
+		// this fails if IPv6 is disabled in the system, in which case there's no IPv6 traffic to tunnel
+		fmt.Printf("Failed to set up IPv6 of TUN %s, IPv6 won't be tunneled: %v\n", tun.Name(), err)
+		tunGatewayIPv6 = nil
+	} else {
+		fmt.Printf("Local TUN IPv6: %s\n", sHello.TUNIPv6.String())
+		fmt.Printf("Local TUN IPv6 gateway: %s\n", tunGatewayIPv6.String())
+	}
+
 	if runtime.GOOS == "windows" {
 		// okay, so, here's done because after the `SetupTUN` call,
 		// interface doesn't get its values immediately. Reason is unknown,
@@ -359,28 +428,58 @@ func (c *Client) serveConn(conn net.Conn) error {
 		time.Sleep(10 * time.Second)
 	}
 
-	isNewRoute := true
 	if c.cfg.Killswitch {
 		c.prevTUNGatewayMu.Lock()
-		if len(c.prevTUNGateway) > 0 {
-			isNewRoute = false
-		}
 		c.prevTUNGateway = tunGateway
 		c.prevTUNGatewayMu.Unlock()
 	}
 
-	fmt.Printf("Routing all traffic through TUN %s: %v\n", tun.Name(), err)
-	if err := c.routeTrafficThroughTUN(tunGateway, isNewRoute); err != nil {
+	c.setupExcludedRoutes(excludedRoutes)
+
+	var dnsServers []net.IP
+	if c.cfg.DNS {
+		dnsServers = tunneledDNSServers(sHello.DNS, tunGatewayIPv6 != nil)
+	}
+
+	// included routes may all be resolved away or excluded, which must not turn into routing everything
+	if len(c.cfg.IncludeRoutes) == 0 {
+		fmt.Printf("Routing all traffic through TUN %s\n", tun.Name())
+		// IPv6 halves are blocked instead if IPv6 isn't tunneled, otherwise IPv6 traffic leaks around VPN
+		includedRoutes = []string{ipv4FirstHalfAddr, ipv4SecondHalfAddr, ipv6FirstHalfAddr, ipv6SecondHalfAddr}
+	} else {
+		// DNS queries go through VPN even with split tunneling, otherwise they leak
+		includedRoutes = filterOutEqualStrings(append(includedRoutes, dnsRoutes(dnsServers)...))
+		fmt.Printf("Routing %v through TUN %s\n", includedRoutes, tun.Name())
+	}
+
+	if err := c.routeTrafficThroughTUN(tunGateway, tunGatewayIPv6, includedRoutes); err != nil {
 		return fmt.Errorf("error routing traffic through TUN %s: %w", tun.Name(), err)
 	}
 
 	defer func() {
 		if !c.cfg.Killswitch {
 			fmt.Println("serveConn done, killswitch disabled, routing traffic directly")
-			c.routeTrafficDirectly(tunGateway)
+			c.routeTrafficDirectly()
 		}
 	}()
 
+	if len(dnsServers) != 0 {
+		if restoreDNS, err := SetupDNS(tun.Name(), dnsServers); err != nil {
+			fmt.Printf("Failed to set DNS servers %v, host DNS servers are used: %v\n", dnsServers, err)
+		} else {
+			fmt.Printf("Resolving names through %v\n", dnsServers)
+
+			defer func() {
+				if err := restoreDNS(); err != nil {
+					fmt.Printf("Error restoring DNS servers: %v\n", err)
+				}
+			}()
+		}
+	} else if c.cfg.DNS {
+		fmt.Println("WARNING: server doesn't advertise DNS servers, names are resolved by host DNS servers " +
+			"outside of VPN. Use -require-dns to refuse such servers")
+	}
+
 	// we release privileges here (user is not root for Mac OS systems from here on)
 	c.releaseSysPrivileges()
 
@@ -442,36 +541,192 @@ func (c *Client) dialServeConn() error {
 	return nil
 }
 
-func (c *Client) routeTrafficThroughTUN(tunGateway net.IP, isNewRoute bool) error {
-	// route all traffic through TUN gateway
-	if isNewRoute {
-		if err := AddRoute(ipv4FirstHalfAddr, tunGateway.String()); err != nil {
-			return err
+// routeTrafficThroughTUN routes `routes` through TUN gateway. IPv6 routes go through `tunGatewayIPv6`,
+// they're blocked if it's nil, so that IPv6 traffic doesn't go around VPN. Routes left from the previous
+// session (with killswitch enabled) are moved to the new gateway or removed if no longer needed.
+func (c *Client) routeTrafficThroughTUN(tunGateway, tunGatewayIPv6 net.IP, routes []string) error {
+	c.routesMu.Lock()
+	defer c.routesMu.Unlock()
+
+	prevRoutes := c.tunRoutes
+	for _, route := range prevRoutes {
+		if !containsString(routes, route) || (isIPv6CIDR(route) && tunGatewayIPv6 == nil) {
+			if err := DeleteRoute(route, c.tunRouteGateway(route)); err != nil {
+				fmt.Printf("Error removing stale route to %s: %v\n", route, err)
+			}
 		}
-		if err := AddRoute(ipv4SecondHalfAddr, tunGateway.String()); err != nil {
-			return err
+	}
+
+	// blocked routes have to be gone before the same routes are added through TUN
+	prevBlockedRoutes := c.blockedRoutes
+	c.blockedRoutes = nil
+	for _, route := range prevBlockedRoutes {
+		if tunGatewayIPv6 == nil && containsString(routes, route) {
+			c.blockedRoutes = append(c.blockedRoutes, route)
+			continue
 		}
-	} else {
-		if err := ChangeRoute(ipv4FirstHalfAddr, tunGateway.String()); err != nil {
-			return err
+
+		c.unblockRoute(route)
+	}
+
+	c.tunRoutes, c.tunRoutesGateway, c.tunRoutesGatewayIPv6 = nil, tunGateway, tunGatewayIPv6
+	for _, route := range routes {
+		if isIPv6CIDR(route) && tunGatewayIPv6 == nil {
+			if !containsString(c.blockedRoutes, route) {
+				c.blockRoute(route)
+			}
+
+			continue
+		}
+
+		var err error
+		if containsString(prevRoutes, route) {
+			err = ChangeRoute(route, c.tunRouteGateway(route))
+		} else {
+			err = AddRoute(route, c.tunRouteGateway(route))
 		}
-		if err := ChangeRoute(ipv4SecondHalfAddr, tunGateway.String()); err != nil {
+		if err != nil {
 			return err
 		}
+
+		c.tunRoutes = append(c.tunRoutes, route)
 	}
 
 	return nil
 }
 
-func (c *Client) routeTrafficDirectly(tunGateway net.IP) {
+// tunRouteGateway returns TUN gateway of the address family of `route`.
+func (c *Client) tunRouteGateway(route string) string {
+	if isIPv6CIDR(route) {
+		return c.tunRoutesGatewayIPv6.String()
+	}
+
+	return c.tunRoutesGateway.String()
+}
+
+func (c *Client) routeTrafficDirectly() {
 	fmt.Println("Routing all traffic through default network gateway")
 
-	// remove main route
-	if err := DeleteRoute(ipv4FirstHalfAddr, tunGateway.String()); err != nil {
-		fmt.Printf("Error routing traffic through default network gateway: %v\n", err)
+	c.routesMu.Lock()
+	defer c.routesMu.Unlock()
+
+	// remove main routes
+	for _, route := range c.tunRoutes {
+		if err := DeleteRoute(route, c.tunRouteGateway(route)); err != nil {
+			fmt.Printf("Error routing traffic through default network gateway: %v\n", err)
+		}
+	}
+
+	c.tunRoutes = nil
+
+	for _, route := range c.blockedRoutes {
+		c.unblockRoute(route)
+	}
+
+	c.blockedRoutes = nil
+}
+
+// blockRoute makes `route` unreachable. Failing to block isn't fatal: it fails if the system has
+// no IPv6, in which case there's nothing to leak.
+func (c *Client) blockRoute(route string) {
+	fmt.Printf("IPv6 is not tunneled, blocking %s\n", route)
+	if err := AddUnreachableRoute(route); err != nil {
+		fmt.Printf("Error blocking %s: %v\n", route, err)
+		return
+	}
+
+	c.blockedRoutes = append(c.blockedRoutes, route)
+}
+
+func (c *Client) unblockRoute(route string) {
+	fmt.Printf("Unblocking %s\n", route)
+	if err := DeleteUnreachableRoute(route); err != nil {
+		fmt.Printf("Error unblocking %s: %v\n", route, err)
+	}
+}
+
+// tunneledDNSServers filters out IPv6 `servers` if IPv6 is not tunneled, queries to them
+// would go around the tunnel.
+func tunneledDNSServers(servers []net.IP, ipv6 bool) []net.IP {
+	var tunneled []net.IP
+	for _, server := range servers {
+		if server.To4() != nil || ipv6 {
+			tunneled = append(tunneled, server)
+		}
+	}
+
+	return tunneled
+}
+
+// dnsRoutes makes routes to each of DNS `servers`.
+func dnsRoutes(servers []net.IP) []string {
+	routes := make([]string, 0, len(servers))
+	for _, server := range servers {
+		routes = append(routes, hostRoute(server))
+	}
+
+	return routes
+}
+
+// resolveSplitTunnelRoutes resolves configured split tunneling routes into CIDRs. Included routes
+// covered by excluded ones are dropped, so that exclusion always wins.
+func (c *Client) resolveSplitTunnelRoutes() (included, excluded []string) {
+	excluded = resolveRoutes(c.lookupIP, c.cfg.ExcludeRoutes)
+	included = filterOutExcludedRoutes(resolveRoutes(c.lookupIP, c.cfg.IncludeRoutes), excluded)
+
+	return included, excluded
+}
+
+// setupExcludedRoutes routes `routes` directly through the default network gateway, replacing
+// routes excluded during the previous session. Failing to exclude a route isn't fatal, traffic
+// just keeps going through VPN.
+func (c *Client) setupExcludedRoutes(routes []string) {
+	c.routesMu.Lock()
+	defer c.routesMu.Unlock()
+
+	for _, route := range c.excludedRoutes {
+		if !containsString(routes, route) {
+			c.removeExcludedRoute(route)
+		}
+	}
+
+	prevRoutes := c.excludedRoutes
+	c.excludedRoutes = nil
+	for _, route := range routes {
+		gateway, ok := c.directRouteGateway(route)
+		if !ok {
+			fmt.Printf("No default IPv6 network gateway, can't exclude %s from VPN\n", route)
+			continue
+		}
+
+		if !containsString(prevRoutes, route) {
+			fmt.Printf("Excluding %s from VPN, via %s\n", route, gateway)
+			if err := AddRoute(route, gateway); err != nil {
+				fmt.Printf("Error excluding %s from VPN: %v\n", route, err)
+				continue
+			}
+		}
+
+		c.excludedRoutes = append(c.excludedRoutes, route)
+	}
+}
+
+func (c *Client) removeExcludedRoutes() {
+	c.routesMu.Lock()
+	defer c.routesMu.Unlock()
+
+	for _, route := range c.excludedRoutes {
+		c.removeExcludedRoute(route)
 	}
-	if err := DeleteRoute(ipv4SecondHalfAddr, tunGateway.String()); err != nil {
-		fmt.Printf("Error routing traffic through default network gateway: %v\n", err)
+
+	c.excludedRoutes = nil
+}
+
+func (c *Client) removeExcludedRoute(route string) {
+	fmt.Printf("Removing excluded route to %s\n", route)
+	gateway, _ := c.directRouteGateway(route)
+	if err := DeleteRoute(route, gateway); err != nil {
+		fmt.Printf("Error removing excluded route to %s: %v\n", route, err)
 	}
 }
 
@@ -489,9 +744,10 @@ func (c *Client) setupDirectRoutes() error {
 }
 
 func (c *Client) setupDirectRoute(ip net.IP) error {
-	if !ip.IsLoopback() {
-		fmt.Printf("Adding direct route to %s, via %s", ip.String(), c.defaultGateway.String())
-		if err := AddRoute(ip.String()+directRouteNetmaskCIDR, c.defaultGateway.String()); err != nil {
+	route, gateway, ok := c.directRoute(ip)
+	if ok {
+		fmt.Printf("Adding direct route to %s, via %s", ip.String(), gateway)
+		if err := AddRoute(route, gateway); err != nil {
 			return fmt.Errorf("error adding direct route to %s: %w", ip.String(), err)
 		}
 	}
@@ -500,9 +756,10 @@ func (c *Client) setupDirectRoute(ip net.IP) error {
 }
 
 func (c *Client) removeDirectRoute(ip net.IP) error {
-	if !ip.IsLoopback() {
+	route, gateway, ok := c.directRoute(ip)
+	if ok {
 		fmt.Printf("Removing direct route to %s\n", ip.String())
-		if err := DeleteRoute(ip.String()+directRouteNetmaskCIDR, c.defaultGateway.String()); err != nil {
+		if err := DeleteRoute(route, gateway); err != nil {
 			return err
 		}
 	}
@@ -510,6 +767,29 @@ func (c *Client) removeDirectRoute(ip net.IP) error {
 	return nil
 }
 
+// directRoute returns route and gateway to reach `ip` directly. IPv6 addresses can't
+// be reached directly without IPv6 default gateway, so these don't need direct routes.
+func (c *Client) directRoute(ip net.IP) (route, gateway string, ok bool) {
+	if ip.IsLoopback() {
+		return "", "", false
+	}
+
+	route = hostRoute(ip)
+	gateway, ok = c.directRouteGateway(route)
+
+	return route, gateway, ok
+}
+
+// directRouteGateway returns default network gateway of the address family of `route`.
+// It's not ok for IPv6 routes if the system has no IPv6 default gateway.
+func (c *Client) directRouteGateway(route string) (string, bool) {
+	if isIPv6CIDR(route) {
+		return c.defaultGatewayIPv6, c.defaultGatewayIPv6 != ""
+	}
+
+	return c.defaultGateway.String(), true
+}
+
 func (c *Client) removeDirectRoutes() {
 	c.directIPSMu.Lock()
 	defer c.directIPSMu.Unlock()
@@ -625,10 +905,10 @@ func stcpEntitiesFromEnv() ([]net.IP, error) {
 	return stcpEntities, nil
 }
 
-func (c *Client) shakeHands(conn net.Conn) (TUNIP, TUNGateway net.IP, err error) {
+func (c *Client) shakeHands(conn net.Conn) (ServerHello, error) {
 	unavailableIPs, err := LocalNetworkInterfaceIPs()
 	if err != nil {
-		return nil, nil, fmt.Errorf("error getting unavailable private IPs: %w", err)
+		return ServerHello{}, fmt.Errorf("error getting unavailable private IPs: %w", err)
 	}
 
 	unavailableIPs = append(unavailableIPs, c.defaultGateway)
@@ -643,21 +923,21 @@ func (c *Client) shakeHands(conn net.Conn) (TUNIP, TUNGateway net.IP, err error)
 	fmt.Printf("Sending client hello: %v\n", cHello)
 
 	if err := WriteJSONWithTimeout(conn, &cHello, handshakeTimeout); err != nil {
-		return nil, nil, fmt.Errorf("error sending client hello: %w", err)
+		return ServerHello{}, fmt.Errorf("error sending client hello: %w", err)
 	}
 
 	var sHello ServerHello
 	if err := ReadJSONWithTimeout(conn, &sHello, handshakeTimeout); err != nil {
-		return nil, nil, fmt.Errorf("error reading server hello: %w", err)
+		return ServerHello{}, fmt.Errorf("error reading server hello: %w", err)
 	}
 
 	fmt.Printf("Got server hello: %v", sHello)
 
 	if sHello.Status != HandshakeStatusOK {
-		return nil, nil, fmt.Errorf("got status %d (%s) from the server", sHello.Status, sHello.Status)
+		return ServerHello{}, fmt.Errorf("got status %d (%s) from the server", sHello.Status, sHello.Status)
 	}
 
-	return sHello.TUNIP, sHello.TUNGateway, nil
+	return sHello, nil
 }
 
 func (c *Client) releaseSysPrivileges() {
@@ -7,4 +7,16 @@ type ClientConfig struct {
 	Passcode   string
 	Killswitch bool
 	ServerPK   cipher.PubKey
+	// IncludeRoutes are CIDRs, IPs or hostnames to route through VPN. If empty, all traffic goes through VPN.
+	IncludeRoutes []string
+	// ExcludeRoutes are CIDRs, IPs or hostnames to route directly. They win over IncludeRoutes:
+	// included routes equal to or within an excluded one are dropped, and narrower exclusions
+	// take precedence by the longest prefix match.
+	ExcludeRoutes []string
+	// DNS makes the client resolve names through the DNS servers advertised by the server
+	// for the session, so lookups don't leak outside the tunnel.
+	DNS bool
+	// RequireDNS makes the client refuse sessions in which the server advertises no DNS servers,
+	// instead of resolving names outside of the tunnel. It has effect only with DNS set.
+	RequireDNS bool
 }
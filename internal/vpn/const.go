@@ -3,6 +3,9 @@ package vpn
 const (
 	// TUNNetmaskCIDR is a general netmask used for all TUN interfaces in CIDR format (only suffix).
 	TUNNetmaskCIDR = "/29"
+	// TUNNetmaskCIDRv6 is a general IPv6 netmask used for all TUN interfaces in CIDR format (only suffix).
+	// It covers the same number of addresses as TUNNetmaskCIDR.
+	TUNNetmaskCIDRv6 = "/125"
 	// TUNMTU is MTU value used for all TUN interfaces.
 	TUNMTU = 1500
 )
@@ -0,0 +1,125 @@
+package vpn
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"strings"
+)
+
+const (
+	resolvConfPath = "/etc/resolv.conf"
+	// resolvConfBackupPath keeps the original resolv.conf while it's rewritten by the client.
+	resolvConfBackupPath = "/etc/resolv.conf.skywire-vpn"
+	dnsPort              = 53
+)
+
+// ParseDNSServers parses comma-separated list of DNS server IPs.
+func ParseDNSServers(s string) ([]net.IP, error) {
+	if strings.TrimSpace(s) == "" {
+		return nil, nil
+	}
+
+	var servers []net.IP
+	for _, server := range strings.Split(s, ",") {
+		ip := net.ParseIP(strings.TrimSpace(server))
+		if ip == nil {
+			return nil, errInvalidDNSServer
+		}
+
+		servers = append(servers, ip)
+	}
+
+	return servers, nil
+}
+
+// hostDNSServers reads DNS servers the host resolves names with.
+func hostDNSServers() ([]net.IP, error) {
+	conf, err := ioutil.ReadFile(resolvConfPath)
+	if err != nil {
+		return nil, err
+	}
+
+	return parseResolvConfServers(conf), nil
+}
+
+// defaultClientDNS picks DNS servers advertised to clients when the server is configured with neither
+// DNS servers nor forwarder. Host DNS servers are advertised, unless they are only reachable on the host
+// (like systemd-resolved stub), then the forwarder is enabled to relay queries to them.
+func defaultClientDNS(hostDNS []net.IP) (dns []net.IP, forward bool) {
+	for _, ip := range hostDNS {
+		if !ip.IsLoopback() && !ip.IsUnspecified() {
+			dns = append(dns, ip)
+		}
+	}
+
+	return dns, len(dns) == 0 && len(hostDNS) != 0
+}
+
+// parseResolvConfServers gets nameservers from resolv.conf content.
+func parseResolvConfServers(conf []byte) []net.IP {
+	var servers []net.IP
+	for _, l := range bytes.Split(conf, []byte{'\n'}) {
+		fields := strings.Fields(string(l))
+		if len(fields) < 2 || fields[0] != "nameserver" {
+			continue
+		}
+
+		// IPv6 nameservers may come with zone
+		addr, _ := splitZone(fields[1])
+		if ip := net.ParseIP(addr); ip != nil {
+			servers = append(servers, ip)
+		}
+	}
+
+	return servers
+}
+
+// resolvConfWithServers replaces nameservers of resolv.conf content `conf` with `servers`,
+// keeping the rest of the options.
+func resolvConfWithServers(conf []byte, servers []net.IP) []byte {
+	var b bytes.Buffer
+	b.WriteString("# Generated by skywire VPN client, the original file is restored on disconnect\n")
+
+	for _, l := range bytes.Split(conf, []byte{'\n'}) {
+		fields := strings.Fields(string(l))
+		if len(fields) == 0 || fields[0] == "nameserver" || fields[0][0] == '#' || fields[0][0] == ';' {
+			continue
+		}
+
+		b.Write(l)
+		b.WriteByte('\n')
+	}
+
+	for _, server := range servers {
+		b.WriteString("nameserver " + server.String() + "\n")
+	}
+
+	return b.Bytes()
+}
+
+// restoreResolvConf moves resolv.conf backup at `backupPath` back to `path`. Missing backup means
+// there's nothing to restore.
+func restoreResolvConf(path, backupPath string) error {
+	conf, err := ioutil.ReadFile(backupPath) //nolint:gosec
+	if os.IsNotExist(err) {
+		return nil
+	}
+
+	if err != nil {
+		return fmt.Errorf("error reading %s: %w", backupPath, err)
+	}
+
+	info, err := os.Stat(backupPath)
+	if err != nil {
+		return fmt.Errorf("error getting info of %s: %w", backupPath, err)
+	}
+
+	if err := ioutil.WriteFile(path, conf, info.Mode()); err != nil {
+		return fmt.Errorf("error writing %s: %w", path, err)
+	}
+
+	return os.Remove(backupPath)
+}
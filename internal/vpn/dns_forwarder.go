@@ -0,0 +1,263 @@
+package vpn
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"strconv"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// dnsQueryTimeout is the time given to each upstream to answer a query.
+	dnsQueryTimeout = 5 * time.Second
+	// dnsMaxMsgSize is the largest DNS message received over UDP.
+	dnsMaxMsgSize = 65535
+	// dnsMaxInFlight is the number of queries forwarded at once, queries above it are dropped
+	// and retried by the client resolver.
+	dnsMaxInFlight = 256
+
+	dnsHeaderLen = 12
+	// dnsTypeOPT is the type of EDNS pseudo-record, its class is the UDP payload size of the sender.
+	dnsTypeOPT = 41
+	// dnsFlagTC is the truncation flag in the 3rd byte of DNS header.
+	dnsFlagTC = 0x02
+)
+
+// dnsForwarder answers DNS queries sent by clients to their TUN gateway address,
+// passing them to upstream resolvers. Queries are taken right from the packets clients send,
+// so nothing listens on the TUN and secure mode doesn't need an exception for it. Only UDP
+// is served, so answers are kept within a single TUN packet: EDNS payload size of queries
+// is clamped and answers still not fitting are truncated.
+type dnsForwarder struct {
+	log       logrus.FieldLogger
+	upstreams []string
+	inFlight  chan struct{}
+}
+
+func newDNSForwarder(log logrus.FieldLogger, upstreams []net.IP) *dnsForwarder {
+	addrs := make([]string, 0, len(upstreams))
+	for _, ip := range upstreams {
+		addrs = append(addrs, net.JoinHostPort(ip.String(), strconv.Itoa(dnsPort)))
+	}
+
+	return &dnsForwarder{
+		log:       log,
+		upstreams: addrs,
+		inFlight:  make(chan struct{}, dnsMaxInFlight),
+	}
+}
+
+// handle checks whether `packet` is a DNS query to the DNS address of `sess`, in which case
+// the query is answered in the background. Returns false for other packets.
+func (f *dnsForwarder) handle(sess *clientSession, packet []byte) bool {
+	udp, ok := parseUDPPacket(packet)
+	if !ok || udp.dstPort != dnsPort || !sess.isDNSIP(udp.dst) {
+		return false
+	}
+
+	select {
+	case f.inFlight <- struct{}{}:
+	default:
+		return true
+	}
+
+	// `packet` is reused by the caller
+	query := udpPacket{
+		src:     append(net.IP(nil), udp.src...),
+		dst:     append(net.IP(nil), udp.dst...),
+		srcPort: udp.srcPort,
+		dstPort: udp.dstPort,
+		payload: append([]byte(nil), udp.payload...),
+	}
+
+	maxPayload := dnsMaxPayload(query.dst.To4() == nil)
+	clampEDNSPayloadSize(query.payload, maxPayload)
+
+	go func() {
+		defer func() { <-f.inFlight }()
+
+		resp, err := f.forward(query.payload)
+		if err != nil {
+			f.log.WithError(err).Debugf("Error forwarding DNS query of %s", query.src)
+			return
+		}
+
+		resp = truncateDNSAnswer(resp, maxPayload)
+
+		sess.push(udpPacket{
+			src:     query.dst,
+			dst:     query.src,
+			srcPort: query.dstPort,
+			dstPort: query.srcPort,
+			payload: resp,
+		}.marshal())
+	}()
+
+	return true
+}
+
+// forward passes `query` to upstreams in turn till one of them answers.
+func (f *dnsForwarder) forward(query []byte) ([]byte, error) {
+	err := errNoDNSUpstreams
+	for _, upstream := range f.upstreams {
+		var resp []byte
+		if resp, err = f.exchange(upstream, query); err == nil {
+			return resp, nil
+		}
+	}
+
+	return nil, err
+}
+
+func (f *dnsForwarder) exchange(upstream string, query []byte) ([]byte, error) {
+	conn, err := net.DialTimeout("udp", upstream, dnsQueryTimeout)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := conn.Close(); err != nil {
+			f.log.WithError(err).Debugf("Error closing conn to DNS server %s", upstream)
+		}
+	}()
+
+	if err := conn.SetDeadline(time.Now().Add(dnsQueryTimeout)); err != nil {
+		return nil, err
+	}
+
+	if _, err := conn.Write(query); err != nil {
+		return nil, err
+	}
+
+	buf := make([]byte, dnsMaxMsgSize)
+	for {
+		n, err := conn.Read(buf)
+		if err != nil {
+			return nil, fmt.Errorf("error reading answer of %s: %w", upstream, err)
+		}
+
+		// answer carries the ID of the query, anything else is ignored
+		if n >= 2 && len(query) >= 2 && bytes.Equal(buf[:2], query[:2]) {
+			return buf[:n], nil
+		}
+	}
+}
+
+// dnsMaxPayload is the size of the largest DNS message fitting into a single TUN packet.
+func dnsMaxPayload(ipv6 bool) int {
+	if ipv6 {
+		return TUNMTU - ipv6HeaderLen - udpHeaderLen
+	}
+
+	return TUNMTU - ipv4HeaderLen - udpHeaderLen
+}
+
+// clampEDNSPayloadSize lowers UDP payload size advertised by EDNS record of `msg` to `size`,
+// so that upstreams truncate answers not fitting into it themselves. Malformed `msg` is left as is.
+func clampEDNSPayloadSize(msg []byte, size int) {
+	off, ok := dnsQuestionsEnd(msg)
+	if !ok {
+		return
+	}
+
+	// EDNS record is in the additional section, which follows answers and authorities
+	records := int(binary.BigEndian.Uint16(msg[6:])) + int(binary.BigEndian.Uint16(msg[8:])) +
+		int(binary.BigEndian.Uint16(msg[10:]))
+	for i := 0; i < records; i++ {
+		next, typeOff, ok := skipDNSRecord(msg, off)
+		if !ok {
+			return
+		}
+
+		if binary.BigEndian.Uint16(msg[typeOff:]) == dnsTypeOPT && int(binary.BigEndian.Uint16(msg[typeOff+2:])) > size {
+			binary.BigEndian.PutUint16(msg[typeOff+2:], uint16(size))
+		}
+
+		off = next
+	}
+}
+
+// truncateDNSAnswer cuts `msg` longer than `size` down to its header and questions, setting
+// the truncation flag, the way DNS servers truncate answers not fitting into UDP payload.
+func truncateDNSAnswer(msg []byte, size int) []byte {
+	if len(msg) <= size || len(msg) < dnsHeaderLen {
+		return msg
+	}
+
+	truncated := append([]byte(nil), msg[:dnsHeaderLen]...)
+	if end, ok := dnsQuestionsEnd(msg); ok && end <= size {
+		truncated = append(truncated, msg[dnsHeaderLen:end]...)
+	} else {
+		binary.BigEndian.PutUint16(truncated[4:], 0)
+	}
+
+	truncated[2] |= dnsFlagTC
+	for _, countOff := range []int{6, 8, 10} {
+		binary.BigEndian.PutUint16(truncated[countOff:], 0)
+	}
+
+	return truncated
+}
+
+// dnsQuestionsEnd returns offset of the end of the question section of `msg`.
+func dnsQuestionsEnd(msg []byte) (int, bool) {
+	if len(msg) < dnsHeaderLen {
+		return 0, false
+	}
+
+	off := dnsHeaderLen
+	for i := 0; i < int(binary.BigEndian.Uint16(msg[4:])); i++ {
+		var ok bool
+		if off, ok = skipDNSName(msg, off); !ok || off+4 > len(msg) {
+			return 0, false
+		}
+
+		// type and class
+		off += 4
+	}
+
+	return off, true
+}
+
+// skipDNSRecord returns offset of the resource record following the one at `off`, along with
+// offset of its type, which is followed by class.
+func skipDNSRecord(msg []byte, off int) (next, typeOff int, ok bool) {
+	if typeOff, ok = skipDNSName(msg, off); !ok || typeOff+10 > len(msg) {
+		return 0, 0, false
+	}
+
+	// type, class, TTL and length of data
+	next = typeOff + 10 + int(binary.BigEndian.Uint16(msg[typeOff+8:]))
+	if next > len(msg) {
+		return 0, 0, false
+	}
+
+	return next, typeOff, true
+}
+
+// skipDNSName returns offset following the domain name at `off`.
+func skipDNSName(msg []byte, off int) (int, bool) {
+	for off < len(msg) {
+		l := int(msg[off])
+		switch {
+		case l == 0:
+			return off + 1, true
+		case l&0xc0 == 0xc0:
+			// compression pointer ends the name
+			if off+2 > len(msg) {
+				return 0, false
+			}
+
+			return off + 2, true
+		case l&0xc0 != 0:
+			return 0, false
+		}
+
+		off += 1 + l
+	}
+
+	return 0, false
+}
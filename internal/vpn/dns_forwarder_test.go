@@ -0,0 +1,107 @@
+package vpn
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDNSForwarder_handle(t *testing.T) {
+	upstream, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, upstream.Close())
+	}()
+
+	// upstream answers with the query prefixed with a mark
+	go func() {
+		buf := make([]byte, dnsMaxMsgSize)
+		for {
+			n, addr, err := upstream.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+
+			if _, err := upstream.WriteTo(append(buf[:n:n], "answer"...), addr); err != nil {
+				return
+			}
+		}
+	}()
+
+	f := newDNSForwarder(logrus.New(), nil)
+	f.upstreams = []string{upstream.LocalAddr().String()}
+
+	sess := newClientSession(nil)
+	sess.clientIP = net.IPv4(10, 0, 0, 4)
+	sess.dnsIPs = []net.IP{net.IPv4(10, 0, 0, 3)}
+
+	query := udpPacket{
+		src:     sess.clientIP,
+		dst:     sess.dnsIPs[0],
+		srcPort: 45000,
+		dstPort: dnsPort,
+		payload: []byte{0x12, 0x34, 'q'},
+	}
+
+	notDNS := query
+	notDNS.dst = net.IPv4(1, 1, 1, 1)
+	require.False(t, f.handle(sess, notDNS.marshal()))
+
+	require.True(t, f.handle(sess, query.marshal()))
+
+	select {
+	case p := <-sess.packets:
+		resp, ok := parseUDPPacket(p)
+		require.True(t, ok)
+		require.True(t, query.dst.Equal(resp.src))
+		require.True(t, query.src.Equal(resp.dst))
+		require.Equal(t, query.dstPort, resp.srcPort)
+		require.Equal(t, query.srcPort, resp.dstPort)
+		require.Equal(t, []byte{0x12, 0x34, 'q', 'a', 'n', 's', 'w', 'e', 'r'}, resp.payload)
+	case <-time.After(dnsQueryTimeout):
+		t.Fatal("no answer pushed to the client")
+	}
+}
+
+// dnsTestMsg makes DNS message with a question for example.com, `answers` A records
+// of it and EDNS record advertising `payloadSize`.
+func dnsTestMsg(answers int, payloadSize uint16) []byte {
+	msg := []byte{0x12, 0x34, 0x81, 0x80, 0, 1, 0, byte(answers), 0, 0, 0, 1}
+	msg = append(msg, 7, 'e', 'x', 'a', 'm', 'p', 'l', 'e', 3, 'c', 'o', 'm', 0, 0, 1, 0, 1)
+
+	for i := 0; i < answers; i++ {
+		// pointer to the name in the question, type A, class IN, TTL, 4 bytes of data
+		msg = append(msg, 0xc0, dnsHeaderLen, 0, 1, 0, 1, 0, 0, 0, 60, 0, 4, 10, 0, 0, byte(i))
+	}
+
+	return append(msg, 0, 0, dnsTypeOPT, byte(payloadSize>>8), byte(payloadSize), 0, 0, 0, 0, 0, 0)
+}
+
+func TestClampEDNSPayloadSize(t *testing.T) {
+	msg := dnsTestMsg(1, 4096)
+	clampEDNSPayloadSize(msg, 1472)
+	require.Equal(t, dnsTestMsg(1, 1472), msg)
+
+	msg = dnsTestMsg(1, 512)
+	clampEDNSPayloadSize(msg, 1472)
+	require.Equal(t, dnsTestMsg(1, 512), msg)
+
+	// malformed message is left as is
+	malformed := dnsTestMsg(1, 4096)[:20]
+	clampEDNSPayloadSize(malformed, 1472)
+	require.Equal(t, dnsTestMsg(1, 4096)[:20], malformed)
+}
+
+func TestTruncateDNSAnswer(t *testing.T) {
+	msg := dnsTestMsg(100, 4096)
+	require.Equal(t, msg, truncateDNSAnswer(msg, len(msg)))
+
+	truncated := truncateDNSAnswer(msg, dnsMaxPayload(false))
+	require.Len(t, truncated, dnsHeaderLen+17)
+	require.Equal(t, byte(0x81|dnsFlagTC), truncated[2])
+	require.Equal(t, []byte{0, 1, 0, 0, 0, 0, 0, 0}, truncated[4:dnsHeaderLen])
+	require.Equal(t, msg[dnsHeaderLen:dnsHeaderLen+17], truncated[dnsHeaderLen:])
+}
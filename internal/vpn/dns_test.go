@@ -0,0 +1,76 @@
+package vpn
+
+import (
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseDNSServers(t *testing.T) {
+	servers, err := ParseDNSServers("1.1.1.1, 2606:4700:4700::1111")
+	require.NoError(t, err)
+	require.Equal(t, []net.IP{net.ParseIP("1.1.1.1"), net.ParseIP("2606:4700:4700::1111")}, servers)
+
+	servers, err = ParseDNSServers("")
+	require.NoError(t, err)
+	require.Empty(t, servers)
+
+	_, err = ParseDNSServers("1.1.1.1,dns.example.com")
+	require.Equal(t, errInvalidDNSServer, err)
+}
+
+func TestResolvConf(t *testing.T) {
+	conf := []byte("# comment\nnameserver 192.168.1.1\nnameserver fe80::1%eth0\nsearch lan\noptions edns0\n")
+
+	servers := parseResolvConfServers(conf)
+	require.Equal(t, []net.IP{net.ParseIP("192.168.1.1"), net.ParseIP("fe80::1")}, servers)
+
+	vpnServers := []net.IP{net.IPv4(10, 0, 0, 3), net.ParseIP("fd01:203:405::a00:3")}
+	vpnConf := resolvConfWithServers(conf, vpnServers)
+	require.Equal(t, vpnServers, parseResolvConfServers(vpnConf))
+	require.Contains(t, string(vpnConf), "search lan\noptions edns0\n")
+	require.NotContains(t, string(vpnConf), "# comment")
+}
+
+func TestDefaultClientDNS(t *testing.T) {
+	lan, stub := net.ParseIP("192.168.1.1"), net.IPv4(127, 0, 0, 53)
+
+	dns, forward := defaultClientDNS([]net.IP{stub, lan})
+	require.Equal(t, []net.IP{lan}, dns)
+	require.False(t, forward)
+
+	// stub resolver is only reachable on the server, so queries are forwarded to it
+	dns, forward = defaultClientDNS([]net.IP{stub})
+	require.Empty(t, dns)
+	require.True(t, forward)
+
+	dns, forward = defaultClientDNS(nil)
+	require.Empty(t, dns)
+	require.False(t, forward)
+}
+
+func TestRestoreResolvConf(t *testing.T) {
+	dir := t.TempDir()
+	path, backupPath := filepath.Join(dir, "resolv.conf"), filepath.Join(dir, "resolv.conf.backup")
+
+	// nothing to restore
+	require.NoError(t, restoreResolvConf(path, backupPath))
+	_, err := os.Stat(path)
+	require.True(t, os.IsNotExist(err))
+
+	require.NoError(t, ioutil.WriteFile(path, []byte("nameserver 10.0.0.3\n"), 0644))
+	require.NoError(t, ioutil.WriteFile(backupPath, []byte("nameserver 192.168.1.1\n"), 0644))
+
+	require.NoError(t, restoreResolvConf(path, backupPath))
+
+	conf, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+	require.Equal(t, "nameserver 192.168.1.1\n", string(conf))
+
+	_, err = os.Stat(backupPath)
+	require.True(t, os.IsNotExist(err))
+}
@@ -138,9 +138,9 @@ func ParseIP(addr string) (net.IP, bool, error) {
 		}
 	}
 
-	// filter out port if it exists
-	if strings.Contains(addr, ":") {
-		addr = strings.Split(addr, ":")[0]
+	// filter out port if it exists, IPv6 addresses with port are in brackets
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		addr = host
 	}
 
 	ip := net.ParseIP(addr)
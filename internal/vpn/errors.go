@@ -6,6 +6,13 @@ import (
 
 var (
 	errCouldFindDefaultNetworkGateway = errors.New("could not find default network gateway")
+	errEmptyRoute                     = errors.New("empty route")
+	errInvalidRoute                   = errors.New("route is neither a CIDR, nor an IP, nor a hostname")
+	errInvalidIPPacket                = errors.New("invalid IP packet")
+	errInvalidDNSServer               = errors.New("DNS server is not an IP")
+	errDNSNotSupported                = errors.New("setting DNS servers is not supported for this OS")
+	errNoDNSUpstreams                 = errors.New("no upstream DNS servers")
+	errNoServerDNS                    = errors.New("server doesn't advertise DNS servers")
 )
 
 // ErrorWithStderr is an error raised by the external process.
@@ -0,0 +1,110 @@
+//+build linux
+
+package vpn
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net"
+	"os/exec"
+	"strings"
+)
+
+// iptablesFirewall is the fallback firewall for hosts without nftables or with legacy iptables.
+type iptablesFirewall struct{}
+
+// ForwardPolicy gets current policy for iptables `forward` chain.
+func (iptablesFirewall) ForwardPolicy(ipv6 bool) (string, error) {
+	out, err := iptables(ipv6, "-S", "FORWARD")
+	if err != nil {
+		return "", err
+	}
+
+	return parseIPTablesPolicy(out, "FORWARD")
+}
+
+// SetForwardPolicy sets `policy` for iptables `forward` chain.
+func (iptablesFirewall) SetForwardPolicy(ipv6 bool, policy string) error {
+	_, err := iptables(ipv6, "-P", "FORWARD", policy)
+	return err
+}
+
+// EnableMasquerading enables masquerading for the interface with name `ifcName`.
+func (iptablesFirewall) EnableMasquerading(ifcName string, ipv6 bool) error {
+	_, err := iptables(ipv6, "-t", "nat", "-A", "POSTROUTING", "-o", ifcName, "-j", "MASQUERADE")
+	return err
+}
+
+// DisableMasquerading disables masquerading for the interface with name `ifcName`.
+func (iptablesFirewall) DisableMasquerading(ifcName string, ipv6 bool) error {
+	_, err := iptables(ipv6, "-t", "nat", "-D", "POSTROUTING", "-o", ifcName, "-j", "MASQUERADE")
+	return err
+}
+
+// AllowForwarding does nothing, forwarding in legacy iptables is allowed by the `forward` chain policy.
+func (iptablesFirewall) AllowForwarding(string, bool) error {
+	return nil
+}
+
+// DisallowForwarding does nothing, see AllowForwarding.
+func (iptablesFirewall) DisallowForwarding(string, bool) error {
+	return nil
+}
+
+// BlockIPToLocalNetwork blocks all the packets coming from `src` to local network.
+func (iptablesFirewall) BlockIPToLocalNetwork(src net.IP) error {
+	return localNetworkRules(src, "-I")
+}
+
+// AllowIPToLocalNetwork removes rules added by BlockIPToLocalNetwork.
+func (iptablesFirewall) AllowIPToLocalNetwork(src net.IP) error {
+	return localNetworkRules(src, "-D")
+}
+
+func localNetworkRules(src net.IP, op string) error {
+	ipv6 := src.To4() == nil
+	dst := strings.Join(localNetworksOf(src), ",")
+
+	for _, chain := range []string{"FORWARD", "INPUT"} {
+		if _, err := iptables(ipv6, op, chain, "-d", dst, "-s", src.String(), "-j", "DROP"); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// iptables runs iptables, or ip6tables if `ipv6` is set, with `args`.
+func iptables(ipv6 bool, args ...string) ([]byte, error) {
+	bin := "iptables"
+	if ipv6 {
+		bin = "ip6tables"
+	}
+
+	out, err := exec.Command(bin, args...).Output() //nolint:gosec
+	if err != nil {
+		fullCmd := bin + " " + strings.Join(args, " ")
+
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return nil, NewErrorWithStderr(fmt.Errorf("error running command \"%s\": %w", fullCmd, err), exitErr.Stderr)
+		}
+
+		return nil, fmt.Errorf("error running command \"%s\": %w", fullCmd, err)
+	}
+
+	return out, nil
+}
+
+// parseIPTablesPolicy gets policy of `chain` from `iptables -S` output.
+func parseIPTablesPolicy(output []byte, chain string) (string, error) {
+	for _, l := range bytes.Split(output, []byte{'\n'}) {
+		fields := strings.Fields(string(l))
+		if len(fields) == 3 && fields[0] == "-P" && fields[1] == chain {
+			return fields[2], nil
+		}
+	}
+
+	return "", fmt.Errorf("no policy of chain %s in output: %s", chain, output)
+}
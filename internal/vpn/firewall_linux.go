@@ -0,0 +1,75 @@
+//+build linux
+
+package vpn
+
+import (
+	"net"
+	"os/exec"
+	"strings"
+	"sync"
+)
+
+var (
+	// localNetworks are private IPv4 ranges blocked for clients of a secure server.
+	localNetworks = []string{"192.168.0.0/16", "172.16.0.0/12", "10.0.0.0/8"}
+	// localNetworksIPv6 are ULA and link-local IPv6 ranges blocked for clients of a secure server.
+	localNetworksIPv6 = []string{"fc00::/7", "fe80::/10"}
+)
+
+// firewall configures masquerading, forward policy and blocking of local network
+// for the VPN server.
+type firewall interface {
+	ForwardPolicy(ipv6 bool) (string, error)
+	SetForwardPolicy(ipv6 bool, policy string) error
+	EnableMasquerading(ifcName string, ipv6 bool) error
+	DisableMasquerading(ifcName string, ipv6 bool) error
+	AllowForwarding(ifcName string, ipv6 bool) error
+	DisallowForwarding(ifcName string, ipv6 bool) error
+	BlockIPToLocalNetwork(src net.IP) error
+	AllowIPToLocalNetwork(src net.IP) error
+}
+
+var (
+	fwOnce sync.Once
+	fw     firewall
+)
+
+// defaultFirewall picks firewall backend once. nftables is used unless it's unavailable
+// or the host has legacy iptables, rules of which nftables can't override.
+func defaultFirewall() firewall {
+	fwOnce.Do(func() {
+		if !iptablesLegacy() {
+			if nft, err := newNFTablesFirewall(); err == nil {
+				fw = nft
+				return
+			}
+		}
+
+		fw = iptablesFirewall{}
+	})
+
+	return fw
+}
+
+// iptablesLegacy checks whether iptables binary is present and uses the legacy (non-nftables) backend.
+func iptablesLegacy() bool {
+	path, err := exec.LookPath("iptables")
+	if err != nil {
+		return false
+	}
+
+	out, err := exec.Command(path, "-V").Output() //nolint:gosec
+	if err != nil {
+		return false
+	}
+
+	return !strings.Contains(string(out), "nf_tables")
+}
+
+func localNetworksOf(src net.IP) []string {
+	if src.To4() == nil {
+		return localNetworksIPv6
+	}
+
+	return localNetworks
+}
@@ -0,0 +1,96 @@
+//+build linux
+
+package vpn
+
+import (
+	"net"
+	"testing"
+
+	"github.com/google/nftables/expr"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseIPTablesPolicy(t *testing.T) {
+	out := []byte("-P FORWARD DROP\n-A FORWARD -s 192.168.2.12/32 -d 10.0.0.0/8 -j DROP\n")
+
+	policy, err := parseIPTablesPolicy(out, "FORWARD")
+	require.NoError(t, err)
+	require.Equal(t, forwardPolicyDrop, policy)
+
+	_, err = parseIPTablesPolicy(out, "INPUT")
+	require.Error(t, err)
+}
+
+func TestBlockExprs(t *testing.T) {
+	tt := []struct {
+		name      string
+		src       string
+		dst       string
+		srcOffset uint32
+		dstOffset uint32
+		wantSrc   []byte
+		wantDst   []byte
+	}{
+		{
+			name:      "IPv4",
+			src:       "192.168.2.12",
+			dst:       "172.16.0.0/12",
+			srcOffset: 12,
+			dstOffset: 16,
+			wantSrc:   []byte{192, 168, 2, 12},
+			wantDst:   []byte{172, 16, 0, 0},
+		},
+		{
+			name:      "IPv6",
+			src:       "fd01:203:405::c0a8:20c",
+			dst:       "fc00::/7",
+			srcOffset: 8,
+			dstOffset: 24,
+			wantSrc:   net.ParseIP("fd01:203:405::c0a8:20c"),
+			wantDst:   net.ParseIP("fc00::"),
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			_, dst, err := net.ParseCIDR(tc.dst)
+			require.NoError(t, err)
+
+			exprs := blockExprs(net.ParseIP(tc.src), dst)
+			require.Len(t, exprs, 6)
+
+			srcPayload := exprs[0].(*expr.Payload)
+			require.Equal(t, tc.srcOffset, srcPayload.Offset)
+			require.Equal(t, uint32(len(tc.wantSrc)), srcPayload.Len)
+			require.Equal(t, tc.wantSrc, exprs[1].(*expr.Cmp).Data)
+
+			dstPayload := exprs[2].(*expr.Payload)
+			require.Equal(t, tc.dstOffset, dstPayload.Offset)
+			require.Equal(t, []byte(dst.Mask), exprs[3].(*expr.Bitwise).Mask)
+			require.Equal(t, tc.wantDst, exprs[4].(*expr.Cmp).Data)
+
+			require.Equal(t, expr.VerdictDrop, exprs[5].(*expr.Verdict).Kind)
+		})
+	}
+}
+
+func TestParseRouteDst(t *testing.T) {
+	dst, err := parseRouteDst("0.0.0.0/1")
+	require.NoError(t, err)
+	require.Equal(t, "0.0.0.0/1", dst.String())
+
+	dst, err = parseRouteDst("1.1.1.1")
+	require.NoError(t, err)
+	require.Equal(t, "1.1.1.1/32", dst.String())
+
+	dst, err = parseRouteDst("2001:db8::1")
+	require.NoError(t, err)
+	require.Equal(t, "2001:db8::1/128", dst.String())
+
+	_, err = parseRouteDst("example.com")
+	require.Error(t, err)
+
+	addr, zone := splitZone("fe80::1%eth0")
+	require.Equal(t, "fe80::1", addr)
+	require.Equal(t, "eth0", zone)
+}
@@ -0,0 +1,423 @@
+//+build linux
+
+package vpn
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"sync"
+
+	"github.com/google/nftables"
+	"github.com/google/nftables/expr"
+	"golang.org/x/sys/unix"
+)
+
+const (
+	// nftTableName is the table holding all the rules of the VPN server. It's removed
+	// once the last rule is deleted.
+	nftTableName = "skywire_vpn"
+
+	nftChainPostrouting = "postrouting"
+	nftChainForward     = "forward"
+	nftChainInput       = "input"
+
+	// iptables-nft keeps `iptables` chains in these
+	nftIPTablesFilterTable  = "filter"
+	nftIPTablesForwardChain = "FORWARD"
+)
+
+// nftablesFirewall keeps the VPN server rules in a table of its own.
+type nftablesFirewall struct {
+	mx   sync.Mutex
+	conn *nftables.Conn
+}
+
+func newNFTablesFirewall() (*nftablesFirewall, error) {
+	conn, err := nftables.New()
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := conn.ListTables(); err != nil {
+		return nil, fmt.Errorf("error listing nftables tables: %w", err)
+	}
+
+	return &nftablesFirewall{conn: conn}, nil
+}
+
+// ForwardPolicy gets policy of the `forward` chain managed by iptables-nft.
+// Without such chain forwarding isn't restricted, so the policy is ACCEPT.
+func (f *nftablesFirewall) ForwardPolicy(ipv6 bool) (string, error) {
+	f.mx.Lock()
+	defer f.mx.Unlock()
+
+	chain, err := f.iptablesForwardChain(ipv6)
+	if err != nil {
+		return "", err
+	}
+
+	if chain == nil || chain.Policy == nil || *chain.Policy == nftables.ChainPolicyAccept {
+		return forwardPolicyAccept, nil
+	}
+
+	return forwardPolicyDrop, nil
+}
+
+// SetForwardPolicy sets `policy` of the `forward` chain managed by iptables-nft.
+func (f *nftablesFirewall) SetForwardPolicy(ipv6 bool, policy string) error {
+	f.mx.Lock()
+	defer f.mx.Unlock()
+
+	var p nftables.ChainPolicy
+	switch policy {
+	case forwardPolicyAccept:
+		p = nftables.ChainPolicyAccept
+	case forwardPolicyDrop:
+		p = nftables.ChainPolicyDrop
+	default:
+		return fmt.Errorf("invalid forward policy %s", policy)
+	}
+
+	chain, err := f.iptablesForwardChain(ipv6)
+	if err != nil {
+		return err
+	}
+
+	if chain == nil {
+		if policy == forwardPolicyAccept {
+			return nil
+		}
+
+		return fmt.Errorf("no %s chain to set policy %s", nftIPTablesForwardChain, policy)
+	}
+
+	chain.Policy = &p
+	f.conn.AddChain(chain)
+
+	return f.conn.Flush()
+}
+
+// EnableMasquerading enables masquerading for the interface with name `ifcName`.
+func (f *nftablesFirewall) EnableMasquerading(ifcName string, ipv6 bool) error {
+	f.mx.Lock()
+	defer f.mx.Unlock()
+
+	table := f.addTable(ipv6)
+	f.conn.AddRule(&nftables.Rule{
+		Table:    table,
+		Chain:    nftChain(table, nftChainPostrouting),
+		Exprs:    masqueradeExprs(ifcName),
+		UserData: masqueradeTag(ifcName),
+	})
+
+	return f.conn.Flush()
+}
+
+// DisableMasquerading disables masquerading for the interface with name `ifcName`.
+func (f *nftablesFirewall) DisableMasquerading(ifcName string, ipv6 bool) error {
+	f.mx.Lock()
+	defer f.mx.Unlock()
+
+	return f.deleteRules(nftTable(ipv6), masqueradeTag(ifcName), nftChainPostrouting)
+}
+
+// BlockIPToLocalNetwork blocks all the packets coming from `src` to local network.
+func (f *nftablesFirewall) BlockIPToLocalNetwork(src net.IP) error {
+	f.mx.Lock()
+	defer f.mx.Unlock()
+
+	table := f.addTable(src.To4() == nil)
+	for _, chainName := range []string{nftChainForward, nftChainInput} {
+		for _, network := range localNetworksOf(src) {
+			_, dst, err := net.ParseCIDR(network)
+			if err != nil {
+				return err
+			}
+
+			f.conn.InsertRule(&nftables.Rule{
+				Table:    table,
+				Chain:    nftChain(table, chainName),
+				Exprs:    blockExprs(src, dst),
+				UserData: blockTag(src),
+			})
+		}
+	}
+
+	return f.conn.Flush()
+}
+
+// AllowIPToLocalNetwork removes rules added by BlockIPToLocalNetwork.
+func (f *nftablesFirewall) AllowIPToLocalNetwork(src net.IP) error {
+	f.mx.Lock()
+	defer f.mx.Unlock()
+
+	return f.deleteRules(nftTable(src.To4() == nil), blockTag(src), nftChainForward, nftChainInput)
+}
+
+// AllowForwarding accepts packets forwarded from and to the interface with name `ifcName` in
+// native nftables forward chains with DROP policy, such as the one of `inet filter` table. Packets
+// dropped by any chain are dropped for good, so neither the `iptables` policy, nor the VPN server
+// table can let them through. `inet` chains are handled along with IPv4.
+func (f *nftablesFirewall) AllowForwarding(ifcName string, ipv6 bool) error {
+	f.mx.Lock()
+	defer f.mx.Unlock()
+
+	chains, err := f.dropForwardChains(ipv6)
+	if err != nil {
+		return err
+	}
+
+	for _, chain := range chains {
+		for _, key := range []expr.MetaKey{expr.MetaKeyIIFNAME, expr.MetaKeyOIFNAME} {
+			f.conn.InsertRule(&nftables.Rule{
+				Table:    chain.Table,
+				Chain:    chain,
+				Exprs:    acceptIfcExprs(key, ifcName),
+				UserData: forwardTag(ifcName),
+			})
+		}
+	}
+
+	return f.conn.Flush()
+}
+
+// DisallowForwarding removes rules added by AllowForwarding.
+func (f *nftablesFirewall) DisallowForwarding(ifcName string, ipv6 bool) error {
+	f.mx.Lock()
+	defer f.mx.Unlock()
+
+	chains, err := f.dropForwardChains(ipv6)
+	if err != nil {
+		return err
+	}
+
+	for _, chain := range chains {
+		rules, err := f.conn.GetRules(chain.Table, chain)
+		if err != nil {
+			return fmt.Errorf("error getting rules of chain %s: %w", chain.Name, err)
+		}
+
+		for _, rule := range rules {
+			if !bytes.Equal(rule.UserData, forwardTag(ifcName)) {
+				continue
+			}
+
+			rule.Chain = chain
+			if err := f.conn.DelRule(rule); err != nil {
+				return err
+			}
+		}
+	}
+
+	return f.conn.Flush()
+}
+
+// dropForwardChains lists forward base chains with DROP policy other than the one managed
+// by iptables-nft and the ones of the VPN server.
+func (f *nftablesFirewall) dropForwardChains(ipv6 bool) ([]*nftables.Chain, error) {
+	families := []nftables.TableFamily{nftables.TableFamilyIPv4, nftables.TableFamilyINet}
+	if ipv6 {
+		families = []nftables.TableFamily{nftables.TableFamilyIPv6}
+	}
+
+	var dropChains []*nftables.Chain
+	for _, family := range families {
+		chains, err := f.conn.ListChainsOfTableFamily(family)
+		if err != nil {
+			return nil, fmt.Errorf("error listing nftables chains: %w", err)
+		}
+
+		for _, chain := range chains {
+			// only base chains have policy
+			if chain.Policy == nil || *chain.Policy != nftables.ChainPolicyDrop ||
+				chain.Hooknum != nftables.ChainHookForward {
+				continue
+			}
+
+			if chain.Table.Name == nftTableName ||
+				(chain.Table.Name == nftIPTablesFilterTable && chain.Name == nftIPTablesForwardChain) {
+				continue
+			}
+
+			dropChains = append(dropChains, chain)
+		}
+	}
+
+	return dropChains, nil
+}
+
+func (f *nftablesFirewall) iptablesForwardChain(ipv6 bool) (*nftables.Chain, error) {
+	family := nftables.TableFamilyIPv4
+	if ipv6 {
+		family = nftables.TableFamilyIPv6
+	}
+
+	chains, err := f.conn.ListChainsOfTableFamily(family)
+	if err != nil {
+		return nil, fmt.Errorf("error listing nftables chains: %w", err)
+	}
+
+	for _, chain := range chains {
+		if chain.Table.Name == nftIPTablesFilterTable && chain.Name == nftIPTablesForwardChain {
+			return chain, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// addTable queues creation of the VPN server table with all of its chains, unless they exist.
+func (f *nftablesFirewall) addTable(ipv6 bool) *nftables.Table {
+	table := f.conn.AddTable(nftTable(ipv6))
+
+	for _, name := range []string{nftChainPostrouting, nftChainForward, nftChainInput} {
+		f.conn.AddChain(nftChain(table, name))
+	}
+
+	return table
+}
+
+// deleteRules deletes rules tagged with `tag` from `chains`. The table is deleted
+// along with its last rule.
+func (f *nftablesFirewall) deleteRules(table *nftables.Table, tag []byte, chains ...string) error {
+	deleted := 0
+	for _, name := range chains {
+		chain := nftChain(table, name)
+
+		rules, err := f.conn.GetRules(table, chain)
+		if err != nil {
+			return fmt.Errorf("error getting rules of chain %s: %w", name, err)
+		}
+
+		for _, rule := range rules {
+			if !bytes.Equal(rule.UserData, tag) {
+				continue
+			}
+
+			rule.Chain = chain
+			if err := f.conn.DelRule(rule); err != nil {
+				return err
+			}
+
+			deleted++
+		}
+	}
+
+	if deleted == 0 {
+		return fmt.Errorf("no rules %q in table %s", tag, table.Name)
+	}
+
+	if err := f.conn.Flush(); err != nil {
+		return err
+	}
+
+	return f.deleteTableIfEmpty(table)
+}
+
+func (f *nftablesFirewall) deleteTableIfEmpty(table *nftables.Table) error {
+	for _, name := range []string{nftChainPostrouting, nftChainForward, nftChainInput} {
+		rules, err := f.conn.GetRules(table, nftChain(table, name))
+		if err != nil {
+			return fmt.Errorf("error getting rules of chain %s: %w", name, err)
+		}
+
+		if len(rules) != 0 {
+			return nil
+		}
+	}
+
+	f.conn.DelTable(table)
+
+	return f.conn.Flush()
+}
+
+func nftTable(ipv6 bool) *nftables.Table {
+	family := nftables.TableFamilyIPv4
+	if ipv6 {
+		family = nftables.TableFamilyIPv6
+	}
+
+	return &nftables.Table{Name: nftTableName, Family: family}
+}
+
+func nftChain(table *nftables.Table, name string) *nftables.Chain {
+	chain := &nftables.Chain{
+		Name:     name,
+		Table:    table,
+		Type:     nftables.ChainTypeFilter,
+		Priority: nftables.ChainPriorityFilter,
+	}
+
+	switch name {
+	case nftChainPostrouting:
+		chain.Type = nftables.ChainTypeNAT
+		chain.Hooknum = nftables.ChainHookPostrouting
+		chain.Priority = nftables.ChainPriorityNATSource
+	case nftChainForward:
+		chain.Hooknum = nftables.ChainHookForward
+	case nftChainInput:
+		chain.Hooknum = nftables.ChainHookInput
+	}
+
+	return chain
+}
+
+// masqueradeExprs makes `oifname <ifcName> masquerade` rule.
+func masqueradeExprs(ifcName string) []expr.Any {
+	return []expr.Any{
+		&expr.Meta{Key: expr.MetaKeyOIFNAME, Register: 1},
+		&expr.Cmp{Op: expr.CmpOpEq, Register: 1, Data: nftIfcName(ifcName)},
+		&expr.Masq{},
+	}
+}
+
+// acceptIfcExprs makes `iifname <ifcName> accept` or `oifname <ifcName> accept` rule, depending on `key`.
+func acceptIfcExprs(key expr.MetaKey, ifcName string) []expr.Any {
+	return []expr.Any{
+		&expr.Meta{Key: key, Register: 1},
+		&expr.Cmp{Op: expr.CmpOpEq, Register: 1, Data: nftIfcName(ifcName)},
+		&expr.Verdict{Kind: expr.VerdictAccept},
+	}
+}
+
+// blockExprs makes `saddr <src> daddr <dst> drop` rule.
+func blockExprs(src net.IP, dst *net.IPNet) []expr.Any {
+	// offsets of source and destination addresses in IP header
+	srcOffset, dstOffset, addrLen := uint32(12), uint32(16), uint32(net.IPv4len)
+
+	srcIP := src.To4()
+	if srcIP == nil {
+		srcIP = src.To16()
+		srcOffset, dstOffset, addrLen = 8, 24, net.IPv6len
+	}
+
+	return []expr.Any{
+		&expr.Payload{DestRegister: 1, Base: expr.PayloadBaseNetworkHeader, Offset: srcOffset, Len: addrLen},
+		&expr.Cmp{Op: expr.CmpOpEq, Register: 1, Data: srcIP},
+		&expr.Payload{DestRegister: 1, Base: expr.PayloadBaseNetworkHeader, Offset: dstOffset, Len: addrLen},
+		&expr.Bitwise{SourceRegister: 1, DestRegister: 1, Len: addrLen, Mask: dst.Mask, Xor: make([]byte, addrLen)},
+		&expr.Cmp{Op: expr.CmpOpEq, Register: 1, Data: dst.IP.Mask(dst.Mask)},
+		&expr.Verdict{Kind: expr.VerdictDrop},
+	}
+}
+
+// nftIfcName pads interface name the way the kernel stores it.
+func nftIfcName(name string) []byte {
+	b := make([]byte, unix.IFNAMSIZ)
+	copy(b, name)
+
+	return b
+}
+
+func masqueradeTag(ifcName string) []byte {
+	return []byte("skywire-vpn masquerade " + ifcName)
+}
+
+func forwardTag(ifcName string) []byte {
+	return []byte("skywire-vpn forward " + ifcName)
+}
+
+func blockTag(src net.IP) []byte {
+	return []byte("skywire-vpn block " + src.String())
+}
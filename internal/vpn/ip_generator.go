@@ -1,21 +1,35 @@
 package vpn
 
 import (
+	"crypto/rand"
 	"errors"
 	"net"
 	"sync"
 )
 
+// ulaPrefixLen is the length in bytes of the unique local IPv6 prefix (fd00::/8 + 40-bit global ID).
+const ulaPrefixLen = 6
+
 // IPGenerator is used to generate IPs for TUN interfaces.
+// IPv6 addresses are derived from IPv4 ones by embedding them into a random unique local
+// address (ULA) prefix, so each IPv4 subnet maps to its own IPv6 subnet.
 type IPGenerator struct {
 	mx           sync.Mutex
 	currentRange int
 	ranges       []*subnetIPIncrementer
+	ulaPrefix    [ulaPrefixLen]byte
 }
 
 // NewIPGenerator creates IP generator.
 func NewIPGenerator() *IPGenerator {
+	var ulaPrefix [ulaPrefixLen]byte
+	ulaPrefix[0] = 0xfd
+	if _, err := rand.Read(ulaPrefix[1:]); err != nil {
+		panic(err)
+	}
+
 	return &IPGenerator{
+		ulaPrefix: ulaPrefix,
 		ranges: []*subnetIPIncrementer{
 			// exclude some most commonly used addresses in local networks
 			newSubnetIPIncrementer([4]uint8{192, 168, 2, 0}, [4]uint8{192, 168, 255, 255}, 8),
@@ -26,7 +40,16 @@ func NewIPGenerator() *IPGenerator {
 }
 
 // Reserve reserves `ip` so it will be excluded from the IP generation.
+// IPv6 addresses only matter if they belong to the ULA prefix of the generator.
 func (g *IPGenerator) Reserve(ip net.IP) error {
+	if ip.To4() == nil && len(ip) == net.IPv6len {
+		if !g.isULA(ip) {
+			return nil
+		}
+
+		ip = ip[net.IPv6len-net.IPv4len:]
+	}
+
 	octets, err := fetchIPv4Octets(ip)
 	if err != nil {
 		return err
@@ -63,6 +86,44 @@ func (g *IPGenerator) Next() (net.IP, error) {
 	return nil, errors.New("no free IPs left")
 }
 
+// Release makes subnet `ip` got from Next available for generation again.
+func (g *IPGenerator) Release(ip net.IP) error {
+	octets, err := fetchIPv4Octets(ip)
+	if err != nil {
+		return err
+	}
+
+	for _, inc := range g.ranges {
+		inc.release(octets)
+	}
+
+	return nil
+}
+
+// IPv6 returns IPv6 address within the ULA prefix of the generator corresponding to IPv4 address `ip`.
+func (g *IPGenerator) IPv6(ip net.IP) (net.IP, error) {
+	octets, err := fetchIPv4Octets(ip)
+	if err != nil {
+		return nil, err
+	}
+
+	ip6 := make(net.IP, net.IPv6len)
+	copy(ip6, g.ulaPrefix[:])
+	copy(ip6[net.IPv6len-net.IPv4len:], octets[:])
+
+	return ip6, nil
+}
+
+func (g *IPGenerator) isULA(ip net.IP) bool {
+	for i := range g.ulaPrefix {
+		if ip[i] != g.ulaPrefix[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
 func fetchIPv4Octets(ip net.IP) ([4]uint8, error) {
 	ip = ip.To4()
 	if ip == nil {
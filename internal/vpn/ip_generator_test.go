@@ -0,0 +1,98 @@
+package vpn
+
+import (
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestIPGenerator_IPv6(t *testing.T) {
+	g := NewIPGenerator()
+
+	ip, err := g.Next()
+	require.NoError(t, err)
+
+	ip6, err := g.IPv6(ip)
+	require.NoError(t, err)
+	require.Nil(t, ip6.To4())
+
+	_, ula, err := net.ParseCIDR("fd00::/8")
+	require.NoError(t, err)
+	require.True(t, ula.Contains(ip6))
+	require.Equal(t, []byte(ip.To4()), []byte(ip6[net.IPv6len-net.IPv4len:]))
+
+	// subnets of different IPv4 addresses don't overlap
+	next, err := g.Next()
+	require.NoError(t, err)
+
+	next6, err := g.IPv6(next)
+	require.NoError(t, err)
+
+	_, subnet, err := net.ParseCIDR(ip6.String() + TUNNetmaskCIDRv6)
+	require.NoError(t, err)
+	require.False(t, subnet.Contains(next6))
+
+	_, err = g.IPv6(ip6)
+	require.Error(t, err)
+}
+
+func TestIPGenerator_Reserve(t *testing.T) {
+	g := NewIPGenerator()
+
+	// IPv6 addresses outside of the ULA prefix don't matter
+	require.NoError(t, g.Reserve(net.ParseIP("2001:db8::1")))
+
+	// IPv6 address within the ULA prefix reserves the IPv4 address it embeds,
+	// which is the first one to be generated otherwise
+	first := net.IPv4(172, 16, 0, 8)
+	require.True(t, first.Equal(mustNextIP(t, NewIPGenerator())))
+
+	first6, err := g.IPv6(first)
+	require.NoError(t, err)
+	require.NoError(t, g.Reserve(first6))
+
+	ip, err := g.Next()
+	require.NoError(t, err)
+	require.False(t, ip.Equal(first))
+}
+
+func TestParseIPv6Gateway(t *testing.T) {
+	gateway, err := parseIPv6Gateway([]byte("fe80::1%eth0\n2001:db8::1%eth1\n"))
+	require.NoError(t, err)
+	require.Equal(t, "fe80::1%eth0", gateway)
+
+	gateway, err = parseIPv6Gateway([]byte("2001:db8::1\n"))
+	require.NoError(t, err)
+	require.Equal(t, "2001:db8::1", gateway)
+
+	_, err = parseIPv6Gateway([]byte("%\n10.0.0.1\n"))
+	require.Equal(t, errCouldFindDefaultNetworkGateway, err)
+}
+
+func mustNextIP(t *testing.T, g *IPGenerator) net.IP {
+	ip, err := g.Next()
+	require.NoError(t, err)
+
+	return ip
+}
+
+func TestIPGenerator_Release(t *testing.T) {
+	g := NewIPGenerator()
+
+	first := mustNextIP(t, g)
+	_, ok := g.ranges[1].allocated[[4]uint8{172, 16, 0, 8}]
+	require.True(t, ok)
+
+	// released subnet may be generated again once the generator wraps around
+	require.NoError(t, g.Release(first))
+	_, ok = g.ranges[1].allocated[[4]uint8{172, 16, 0, 8}]
+	require.False(t, ok)
+
+	// releasing reserved IP doesn't make it available
+	unavailable := net.IPv4(172, 16, 0, 16)
+	require.NoError(t, g.Reserve(unavailable))
+	require.NoError(t, g.Release(unavailable))
+	_, ok = g.ranges[1].reserved[[4]uint8{172, 16, 0, 16}]
+	require.True(t, ok)
+}
@@ -75,6 +75,31 @@ func localNetworkInterfaceIPs(ifcName string) ([]net.IP, []net.IP, error) {
 	return ips, ifcIPs, nil
 }
 
+// parseIPv6Gateway takes the first valid gateway from `output` lines, each holding
+// an IPv6 address with optional zone.
+func parseIPv6Gateway(output []byte) (string, error) {
+	for _, l := range bytes.Split(bytes.TrimRight(output, "\n"), []byte{'\n'}) {
+		gateway := string(bytes.TrimSpace(l))
+
+		ip, _ := splitZone(gateway)
+
+		if parsed := net.ParseIP(ip); parsed != nil && parsed.To4() == nil {
+			return gateway, nil
+		}
+	}
+
+	return "", errCouldFindDefaultNetworkGateway
+}
+
+// splitZone splits IPv6 address with zone (fe80::1%eth0) into address and zone.
+func splitZone(ip string) (addr, zone string) {
+	if i := strings.IndexByte(ip, '%'); i != -1 {
+		return ip[:i], ip[i+1:]
+	}
+
+	return ip, ""
+}
+
 func parseCIDR(ipCIDR string) (ipStr, netmask string, err error) {
 	ip, net, err := net.ParseCIDR(ipCIDR)
 	if err != nil {
@@ -84,6 +109,10 @@ func parseCIDR(ipCIDR string) (ipStr, netmask string, err error) {
 	return ip.String(), fmt.Sprintf("%d.%d.%d.%d", net.Mask[0], net.Mask[1], net.Mask[2], net.Mask[3]), nil
 }
 
+func isIPv6CIDR(ipCIDR string) bool {
+	return strings.Contains(ipCIDR, ":")
+}
+
 //nolint:unparam
 func run(bin string, args ...string) error {
 	fullCmd := bin + " " + strings.Join(args, " ")
@@ -11,7 +11,8 @@ import (
 )
 
 const (
-	defaultNetworkGatewayCMD = "netstat -rn | sed -n '/Internet/,/Internet6/p' | grep default | awk '{print $2}'"
+	defaultNetworkGatewayCMD     = "netstat -rn | sed -n '/Internet/,/Internet6/p' | grep default | awk '{print $2}'"
+	defaultNetworkGatewayIPv6CMD = "netstat -rn -f inet6 | awk '$1 == \"default\" {print $2}'"
 )
 
 // DefaultNetworkGateway fetches system's default network gateway.
@@ -38,6 +39,27 @@ func DefaultNetworkGateway() (net.IP, error) {
 	return nil, errCouldFindDefaultNetworkGateway
 }
 
+// DefaultNetworkGatewayIPv6 fetches system's default IPv6 network gateway along with
+// the interface zone, e.g. fe80::1%en0.
+func DefaultNetworkGatewayIPv6() (string, error) {
+	outBytes, err := exec.Command("sh", "-c", defaultNetworkGatewayIPv6CMD).Output()
+	if err != nil {
+		return "", fmt.Errorf("error running command %s: %w", defaultNetworkGatewayIPv6CMD, err)
+	}
+
+	return parseIPv6Gateway(outBytes)
+}
+
+// SetupDNS makes the system resolve names through `servers`. Not supported on macOS yet.
+func SetupDNS(_ string, _ []net.IP) (restore func() error, err error) {
+	return nil, errDNSNotSupported
+}
+
+// RestoreDNS brings back DNS configuration changed by SetupDNS. Nothing to restore on macOS.
+func RestoreDNS() error {
+	return nil
+}
+
 func setupClientSysPrivileges() (suid int, err error) {
 	suid = syscall.Getuid()
 
@@ -3,44 +3,144 @@
 package vpn
 
 import (
-	"bytes"
 	"fmt"
+	"io/ioutil"
 	"net"
+	"os"
 	"os/exec"
 	"sync"
 
 	"github.com/syndtr/gocapability/capability"
+	"github.com/vishvananda/netlink"
 	"golang.org/x/sys/unix"
 )
 
-const (
-	defaultNetworkGatewayCMD = `ip r | grep "default via" | awk '{print $3}'`
-)
-
 // DefaultNetworkGateway fetches system's default network gateway.
 func DefaultNetworkGateway() (net.IP, error) {
-	outBytes, err := exec.Command("sh", "-c", defaultNetworkGatewayCMD).Output() //nolint:gosec
+	route, ok, err := defaultRoute(netlink.FAMILY_V4)
+	if err != nil {
+		return nil, err
+	}
+
+	if gw, _ := routeGateway(route); ok && gw.To4() != nil {
+		return gw.To4(), nil
+	}
+
+	return nil, errCouldFindDefaultNetworkGateway
+}
+
+// DefaultNetworkGatewayIPv6 fetches system's default IPv6 network gateway along with
+// the interface zone, e.g. fe80::1%eth0.
+func DefaultNetworkGatewayIPv6() (string, error) {
+	route, ok, err := defaultRoute(netlink.FAMILY_V6)
+	if err != nil {
+		return "", err
+	}
+
+	gw, linkIndex := routeGateway(route)
+	if !ok || gw == nil {
+		return "", errCouldFindDefaultNetworkGateway
+	}
+
+	link, err := netlink.LinkByIndex(linkIndex)
+	if err != nil {
+		return "", fmt.Errorf("error getting interface of default IPv6 route: %w", err)
+	}
+
+	return gw.String() + "%" + link.Attrs().Name, nil
+}
+
+// resolvedStubServer is the address systemd-resolved listens on, resolv.conf pointing to it
+// means names are resolved by systemd-resolved.
+var resolvedStubServer = net.IPv4(127, 0, 0, 53)
+
+// SetupDNS makes the system resolve names through `servers`. Returned `restore` brings
+// the previous DNS configuration back. With systemd-resolved servers are set for TUN `ifcName`
+// and it's made the route for all domains, otherwise resolv.conf is rewritten, keeping the original
+// in a backup file till `restore`.
+func SetupDNS(ifcName string, servers []net.IP) (restore func() error, err error) {
+	if err := RestoreDNS(); err != nil {
+		return nil, err
+	}
+
+	conf, err := ioutil.ReadFile(resolvConfPath)
+	if err != nil {
+		return nil, fmt.Errorf("error reading %s: %w", resolvConfPath, err)
+	}
+
+	if usesResolved(conf) {
+		return setupResolvedDNS(ifcName, servers)
+	}
+
+	info, err := os.Stat(resolvConfPath)
 	if err != nil {
-		return nil, fmt.Errorf("error running command %s: %w", defaultNetworkGatewayCMD, err)
+		return nil, fmt.Errorf("error getting info of %s: %w", resolvConfPath, err)
 	}
 
-	outBytes = bytes.TrimRight(outBytes, "\n")
+	if err := ioutil.WriteFile(resolvConfBackupPath, conf, info.Mode()); err != nil {
+		return nil, fmt.Errorf("error backing up %s: %w", resolvConfPath, err)
+	}
 
-	outLines := bytes.Split(outBytes, []byte{'\n'})
+	if err := ioutil.WriteFile(resolvConfPath, resolvConfWithServers(conf, servers), info.Mode()); err != nil {
+		if rmErr := os.Remove(resolvConfBackupPath); rmErr != nil {
+			fmt.Printf("Error removing %s: %v\n", resolvConfBackupPath, rmErr)
+		}
 
-	for _, l := range outLines {
-		if bytes.Count(l, []byte{'.'}) != 3 {
-			// initially look for IPv4 address
-			continue
+		return nil, fmt.Errorf("error writing %s: %w", resolvConfPath, err)
+	}
+
+	return RestoreDNS, nil
+}
+
+// RestoreDNS brings back resolv.conf rewritten by SetupDNS. It's needed on start in case
+// the previous session didn't end cleanly, otherwise `restore` of SetupDNS does it.
+func RestoreDNS() error {
+	return restoreResolvConf(resolvConfPath, resolvConfBackupPath)
+}
+
+func usesResolved(conf []byte) bool {
+	if _, err := exec.LookPath("resolvectl"); err != nil {
+		return false
+	}
+
+	for _, server := range parseResolvConfServers(conf) {
+		if server.Equal(resolvedStubServer) {
+			return true
 		}
+	}
 
-		ip := net.ParseIP(string(l))
-		if ip != nil {
-			return ip, nil
+	return false
+}
+
+func setupResolvedDNS(ifcName string, servers []net.IP) (restore func() error, err error) {
+	args := []string{"dns", ifcName}
+	for _, server := range servers {
+		args = append(args, server.String())
+	}
+
+	restore = func() error {
+		return run("resolvectl", "revert", ifcName)
+	}
+
+	if err := run("resolvectl", args...); err != nil {
+		return nil, err
+	}
+
+	// `~.` routing domain makes the TUN servers take queries for all the domains
+	if err := run("resolvectl", "domain", ifcName, "~."); err != nil {
+		if rErr := restore(); rErr != nil {
+			fmt.Printf("Error reverting DNS of %s: %v\n", ifcName, rErr)
 		}
+
+		return nil, err
 	}
 
-	return nil, errCouldFindDefaultNetworkGateway
+	// older systemd versions don't have it, routing domain is enough there
+	if err := run("resolvectl", "default-route", ifcName, "yes"); err != nil {
+		fmt.Printf("Error making %s the default DNS route: %v\n", ifcName, err)
+	}
+
+	return restore, nil
 }
 
 var setupClientOnce sync.Once
@@ -51,6 +51,22 @@ func DefaultNetworkGateway() (net.IP, error) {
 	return nil, errCouldFindDefaultNetworkGateway
 }
 
+// DefaultNetworkGatewayIPv6 fetches system's default IPv6 network gateway.
+// Not supported on Windows yet.
+func DefaultNetworkGatewayIPv6() (string, error) {
+	return "", errCouldFindDefaultNetworkGateway
+}
+
+// SetupDNS makes the system resolve names through `servers`. Not supported on Windows yet.
+func SetupDNS(_ string, _ []net.IP) (restore func() error, err error) {
+	return nil, errDNSNotSupported
+}
+
+// RestoreDNS brings back DNS configuration changed by SetupDNS. Nothing to restore on Windows.
+func RestoreDNS() error {
+	return nil
+}
+
 func setupSysPrivileges() (suid int, err error) {
 	return 0, nil
 }
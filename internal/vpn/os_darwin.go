@@ -5,6 +5,7 @@ package vpn
 import (
 	"errors"
 	"fmt"
+	"net"
 	"strconv"
 	"strings"
 )
@@ -19,6 +20,18 @@ func SetupTUN(ifcName, ipCIDR, gateway string, mtu int) error {
 	return run("ifconfig", ifcName, ip, gateway, "mtu", strconv.Itoa(mtu), "netmask", netmask, "up")
 }
 
+// SetupTUNIPv6 assigns IPv6 address to the TUN interface which is already set up by SetupTUN.
+func SetupTUNIPv6(ifcName, ipCIDR string) error {
+	ip, ipNet, err := net.ParseCIDR(ipCIDR)
+	if err != nil {
+		return fmt.Errorf("error parsing IP CIDR: %w", err)
+	}
+
+	prefixLen, _ := ipNet.Mask.Size()
+
+	return run("ifconfig", ifcName, "inet6", ip.String(), "prefixlen", strconv.Itoa(prefixLen), "alias")
+}
+
 // ChangeRoute changes current route to `ipCIDR` to go through the `gateway`
 // in the OS routing table.
 func ChangeRoute(ipCIDR, gateway string) error {
@@ -46,7 +59,29 @@ func DeleteRoute(ipCIDR, gateway string) error {
 	return modifyRoutingTable("delete", ipCIDR, gateway)
 }
 
+// AddUnreachableRoute adds route making `ipCIDR` unreachable to the OS routing table.
+func AddUnreachableRoute(ipCIDR string) error {
+	return modifyUnreachableRoute("add", ipCIDR)
+}
+
+// DeleteUnreachableRoute removes route making `ipCIDR` unreachable from the OS routing table.
+func DeleteUnreachableRoute(ipCIDR string) error {
+	return modifyUnreachableRoute("delete", ipCIDR)
+}
+
+func modifyUnreachableRoute(action, ipCIDR string) error {
+	if isIPv6CIDR(ipCIDR) {
+		return run("route", action, "-inet6", "-net", ipCIDR, "::1", "-reject")
+	}
+
+	return run("route", action, "-net", ipCIDR, "127.0.0.1", "-reject")
+}
+
 func modifyRoutingTable(action, ipCIDR, gateway string) error {
+	if isIPv6CIDR(ipCIDR) {
+		return run("route", action, "-inet6", "-net", ipCIDR, gateway)
+	}
+
 	ip, netmask, err := parseCIDR(ipCIDR)
 	if err != nil {
 		return fmt.Errorf("error parsing IP CIDR: %w", err)
@@ -5,17 +5,25 @@ package vpn
 import (
 	"errors"
 	"fmt"
-	"strconv"
+	"net"
 	"strings"
+
+	"github.com/vishvananda/netlink"
+	"golang.org/x/sys/unix"
 )
 
 // SetupTUN sets the allocated TUN interface up, setting its IP, gateway, netmask and MTU.
 func SetupTUN(ifcName, ipCIDR, gateway string, mtu int) error {
-	if err := run("ip", "a", "add", ipCIDR, "dev", ifcName); err != nil {
+	link, err := netlink.LinkByName(ifcName)
+	if err != nil {
+		return fmt.Errorf("error getting interface %s: %w", ifcName, err)
+	}
+
+	if err := addAddress(link, ipCIDR); err != nil {
 		return fmt.Errorf("error assigning IP: %w", err)
 	}
 
-	if err := run("ip", "link", "set", "dev", ifcName, "mtu", strconv.Itoa(mtu)); err != nil {
+	if err := netlink.LinkSetMTU(link, mtu); err != nil {
 		return fmt.Errorf("error setting MTU: %w", err)
 	}
 
@@ -24,7 +32,7 @@ func SetupTUN(ifcName, ipCIDR, gateway string, mtu int) error {
 		return fmt.Errorf("error parsing IP CIDR: %w", err)
 	}
 
-	if err := run("ip", "link", "set", ifcName, "up"); err != nil {
+	if err := netlink.LinkSetUp(link); err != nil {
 		return fmt.Errorf("error setting interface up: %w", err)
 	}
 
@@ -35,27 +43,211 @@ func SetupTUN(ifcName, ipCIDR, gateway string, mtu int) error {
 	return nil
 }
 
+// SetupTUNIPv6 assigns IPv6 address to the TUN interface which is already set up by SetupTUN.
+func SetupTUNIPv6(ifcName, ipCIDR string) error {
+	link, err := netlink.LinkByName(ifcName)
+	if err != nil {
+		return fmt.Errorf("error getting interface %s: %w", ifcName, err)
+	}
+
+	if err := addAddress(link, ipCIDR); err != nil {
+		return fmt.Errorf("error assigning IPv6: %w", err)
+	}
+
+	return nil
+}
+
 // ChangeRoute changes current route to `ip` to go through the `gateway`
 // in the OS routing table.
 func ChangeRoute(ip, gateway string) error {
-	return run("ip", "r", "change", ip, "via", gateway)
+	route, err := newRoute(ip, gateway)
+	if err != nil {
+		return err
+	}
+
+	if err := netlink.RouteReplace(route); err != nil {
+		return fmt.Errorf("error changing route to %s via %s: %w", ip, gateway, err)
+	}
+
+	return nil
 }
 
 // AddRoute adds route to `ip` with `netmask` through the `gateway` to the OS routing table.
 func AddRoute(ip, gateway string) error {
-	err := run("ip", "r", "add", ip, "via", gateway)
+	route, err := newRoute(ip, gateway)
+	if err != nil {
+		return err
+	}
 
-	var e *ErrorWithStderr
-	if errors.As(err, &e) {
-		if strings.Contains(string(e.Stderr), "File exists") {
-			return nil
-		}
+	if err := netlink.RouteAdd(route); err != nil && !errors.Is(err, unix.EEXIST) {
+		return fmt.Errorf("error adding route to %s via %s: %w", ip, gateway, err)
 	}
 
-	return err
+	return nil
 }
 
 // DeleteRoute removes route to `ip` with `netmask` through the `gateway` from the OS routing table.
 func DeleteRoute(ip, gateway string) error {
-	return run("ip", "r", "del", ip, "via", gateway)
+	route, err := newRoute(ip, gateway)
+	if err != nil {
+		return err
+	}
+
+	if err := netlink.RouteDel(route); err != nil {
+		return fmt.Errorf("error deleting route to %s via %s: %w", ip, gateway, err)
+	}
+
+	return nil
+}
+
+// AddUnreachableRoute adds route making `ipCIDR` unreachable to the OS routing table. Route to
+// the same destination, if any, is replaced.
+func AddUnreachableRoute(ipCIDR string) error {
+	route, err := newUnreachableRoute(ipCIDR)
+	if err != nil {
+		return err
+	}
+
+	if err := netlink.RouteReplace(route); err != nil {
+		return fmt.Errorf("error adding unreachable route to %s: %w", ipCIDR, err)
+	}
+
+	return nil
+}
+
+// DeleteUnreachableRoute removes route making `ipCIDR` unreachable from the OS routing table.
+func DeleteUnreachableRoute(ipCIDR string) error {
+	route, err := newUnreachableRoute(ipCIDR)
+	if err != nil {
+		return err
+	}
+
+	if err := netlink.RouteDel(route); err != nil {
+		return fmt.Errorf("error deleting unreachable route to %s: %w", ipCIDR, err)
+	}
+
+	return nil
+}
+
+func newUnreachableRoute(dst string) (*netlink.Route, error) {
+	dstNet, err := parseRouteDst(dst)
+	if err != nil {
+		return nil, err
+	}
+
+	return &netlink.Route{
+		Dst:  dstNet,
+		Type: unix.RTN_UNREACHABLE,
+	}, nil
+}
+
+func addAddress(link netlink.Link, ipCIDR string) error {
+	addr, err := netlink.ParseAddr(ipCIDR)
+	if err != nil {
+		return err
+	}
+
+	return netlink.AddrAdd(link, addr)
+}
+
+func deleteAddress(link netlink.Link, ipCIDR string) error {
+	addr, err := netlink.ParseAddr(ipCIDR)
+	if err != nil {
+		return err
+	}
+
+	return netlink.AddrDel(link, addr)
+}
+
+// newRoute makes route to `dst` through the `gateway`. Link-local IPv6 gateways come with
+// the interface zone (fe80::1%eth0), the route is bound to that interface.
+func newRoute(dst, gateway string) (*netlink.Route, error) {
+	dstNet, err := parseRouteDst(dst)
+	if err != nil {
+		return nil, err
+	}
+
+	gwIP, zone := splitZone(gateway)
+
+	gw := net.ParseIP(gwIP)
+	if gw == nil {
+		return nil, fmt.Errorf("invalid gateway %s", gateway)
+	}
+
+	route := &netlink.Route{
+		Dst: dstNet,
+		Gw:  gw,
+	}
+
+	if zone != "" {
+		link, err := netlink.LinkByName(zone)
+		if err != nil {
+			return nil, fmt.Errorf("error getting interface %s: %w", zone, err)
+		}
+
+		route.LinkIndex = link.Attrs().Index
+	}
+
+	return route, nil
+}
+
+// parseRouteDst parses route destination, either a CIDR or a single address.
+func parseRouteDst(dst string) (*net.IPNet, error) {
+	if strings.Contains(dst, "/") {
+		_, dstNet, err := net.ParseCIDR(dst)
+		if err != nil {
+			return nil, fmt.Errorf("invalid route destination %s: %w", dst, err)
+		}
+
+		return dstNet, nil
+	}
+
+	ip := net.ParseIP(dst)
+	if ip == nil {
+		return nil, fmt.Errorf("invalid route destination %s", dst)
+	}
+
+	if ip4 := ip.To4(); ip4 != nil {
+		return &net.IPNet{IP: ip4, Mask: net.CIDRMask(8*net.IPv4len, 8*net.IPv4len)}, nil
+	}
+
+	return &net.IPNet{IP: ip, Mask: net.CIDRMask(8*net.IPv6len, 8*net.IPv6len)}, nil
+}
+
+// defaultRoute finds the default route of `family` with the lowest metric in the main routing table.
+func defaultRoute(family int) (netlink.Route, bool, error) {
+	routes, err := netlink.RouteList(nil, family)
+	if err != nil {
+		return netlink.Route{}, false, fmt.Errorf("error listing routes: %w", err)
+	}
+
+	var (
+		def   netlink.Route
+		found bool
+	)
+
+	for _, route := range routes {
+		if route.Dst != nil {
+			if ones, _ := route.Dst.Mask.Size(); ones != 0 {
+				continue
+			}
+		}
+
+		if !found || route.Priority < def.Priority {
+			def = route
+			found = true
+		}
+	}
+
+	return def, found, nil
+}
+
+// routeGateway returns gateway of `route` along with its interface. Routes with multiple
+// next hops use the first one.
+func routeGateway(route netlink.Route) (net.IP, int) {
+	if route.Gw == nil && len(route.MultiPath) != 0 {
+		return route.MultiPath[0].Gw, route.MultiPath[0].LinkIndex
+	}
+
+	return route.Gw, route.LinkIndex
 }
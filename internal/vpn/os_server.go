@@ -26,6 +26,21 @@ func SetIPTablesForwardAcceptPolicy() error {
 	return errServerMethodsNotSupported
 }
 
+// GetIP6TablesForwardPolicy gets current policy for ip6tables `forward` chain.
+func GetIP6TablesForwardPolicy() (string, error) {
+	return "", errServerMethodsNotSupported
+}
+
+// SetIP6TablesForwardPolicy sets `policy` for ip6tables `forward` chain.
+func SetIP6TablesForwardPolicy(_ string) error {
+	return errServerMethodsNotSupported
+}
+
+// SetIP6TablesForwardAcceptPolicy sets ACCEPT policy for ip6tables `forward` chain.
+func SetIP6TablesForwardAcceptPolicy() error {
+	return errServerMethodsNotSupported
+}
+
 // AllowIPToLocalNetwork allows all the packets coming from `source`
 // to private IP ranges.
 func AllowIPToLocalNetwork(_, _ net.IP) error {
@@ -38,11 +53,31 @@ func BlockIPToLocalNetwork(_, _ net.IP) error {
 	return errServerMethodsNotSupported
 }
 
+// SetupServerTUN sets the TUN interface shared by all clients up.
+func SetupServerTUN(_ string, _ int) error {
+	return errServerMethodsNotSupported
+}
+
+// AddTUNAddress adds `ipCIDR` to the interface with name `ifcName`, routing the whole subnet through it.
+func AddTUNAddress(_, _ string) error {
+	return errServerMethodsNotSupported
+}
+
+// DeleteTUNAddress removes `ipCIDR` from the interface with name `ifcName`.
+func DeleteTUNAddress(_, _ string) error {
+	return errServerMethodsNotSupported
+}
+
 // DefaultNetworkInterface fetches default network interface name.
 func DefaultNetworkInterface() (string, error) {
 	return "", errServerMethodsNotSupported
 }
 
+// DefaultNetworkInterfaceIPv6 fetches name of the interface with default IPv6 route.
+func DefaultNetworkInterfaceIPv6() (string, error) {
+	return "", errServerMethodsNotSupported
+}
+
 // GetIPv4ForwardingValue gets current value of IPv4 forwarding.
 func GetIPv4ForwardingValue() (string, error) {
 	return "", errServerMethodsNotSupported
@@ -82,3 +117,24 @@ func EnableIPMasquerading(_ string) error {
 func DisableIPMasquerading(_ string) error {
 	return errServerMethodsNotSupported
 }
+
+// EnableIPv6Masquerading enables IPv6 masquerading (NAT66) for the interface with name `ifcName`.
+func EnableIPv6Masquerading(_ string) error {
+	return errServerMethodsNotSupported
+}
+
+// DisableIPv6Masquerading disables IPv6 masquerading (NAT66) for the interface with name `ifcName`.
+func DisableIPv6Masquerading(_ string) error {
+	return errServerMethodsNotSupported
+}
+
+// AllowForwarding accepts packets forwarded from and to the interface with name `ifcName`
+// in firewall chains dropping them by default.
+func AllowForwarding(_ string, _ bool) error {
+	return errServerMethodsNotSupported
+}
+
+// DisallowForwarding removes rules added by AllowForwarding.
+func DisallowForwarding(_ string, _ bool) error {
+	return errServerMethodsNotSupported
+}
@@ -3,116 +3,145 @@
 package vpn
 
 import (
-	"bytes"
 	"fmt"
+	"io/ioutil"
 	"net"
-	"os/exec"
 	"strings"
+
+	"github.com/vishvananda/netlink"
 )
 
 const (
-	defaultNetworkInterfaceCMD     = "ip r | awk '$1 == \"default\" {print $5}'"
-	getIPv4ForwardingCMD           = "sysctl net.ipv4.ip_forward"
-	getIPv6ForwardingCMD           = "sysctl net.ipv6.conf.all.forwarding"
-	setIPv4ForwardingCMDFmt        = "sysctl -w net.ipv4.ip_forward=%s"
-	setIPv6ForwardingCMDFmt        = "sysctl -w net.ipv6.conf.all.forwarding=%s"
-	getIPTablesForwardPolicyCMD    = "iptables -L | grep \"Chain FORWARD\" | tr -d '()' | awk '{print $4}'"
-	setIPTablesForwardPolicyCMDFmt = "iptables --policy FORWARD %s"
-	enableIPMasqueradingCMDFmt     = "iptables -t nat -A POSTROUTING -o %s -j MASQUERADE"
-	disableIPMasqueradingCMDFmt    = "iptables -t nat -D POSTROUTING -o %s -j MASQUERADE"
-	blockIPToLocalNetCMDFmt        = "iptables -I FORWARD -d 192.168.0.0/16,172.16.0.0/12,10.0.0.0/8 -s %s -j DROP && iptables -I INPUT -d 192.168.0.0/16,172.16.0.0/12,10.0.0.0/8 -s %s -j DROP"
-	allowIPToLocalNetCMDFmt        = "iptables -D FORWARD -d 192.168.0.0/16,172.16.0.0/12,10.0.0.0/8 -s %s -j DROP && iptables -D INPUT -d 192.168.0.0/16,172.16.0.0/12,10.0.0.0/8 -s %s -j DROP"
+	ipv4ForwardingSysctl = "/proc/sys/net/ipv4/ip_forward"
+	ipv6ForwardingSysctl = "/proc/sys/net/ipv6/conf/all/forwarding"
 )
 
 // GetIPTablesForwardPolicy gets current policy for iptables `forward` chain.
 func GetIPTablesForwardPolicy() (string, error) {
-	outputBytes, err := exec.Command("sh", "-c", getIPTablesForwardPolicyCMD).Output()
-	if err != nil {
-		return "", fmt.Errorf("error running command %s: %w", getIPTablesForwardPolicyCMD, err)
-	}
-
-	return strings.TrimRight(string(outputBytes), "\n"), nil
+	return defaultFirewall().ForwardPolicy(false)
 }
 
 // SetIPTablesForwardPolicy sets `policy` for iptables `forward` chain.
 func SetIPTablesForwardPolicy(policy string) error {
-	cmd := fmt.Sprintf(setIPTablesForwardPolicyCMDFmt, policy)
-	if err := exec.Command("sh", "-c", cmd).Run(); err != nil { //nolint:gosec
-		return fmt.Errorf("error running command %s: %w", cmd, err)
-	}
-
-	return nil
+	return defaultFirewall().SetForwardPolicy(false, policy)
 }
 
 // SetIPTablesForwardAcceptPolicy sets ACCEPT policy for iptables `forward` chain.
 func SetIPTablesForwardAcceptPolicy() error {
-	const policy = "ACCEPT"
-	return SetIPTablesForwardPolicy(policy)
+	return SetIPTablesForwardPolicy(forwardPolicyAccept)
+}
+
+// GetIP6TablesForwardPolicy gets current policy for ip6tables `forward` chain.
+func GetIP6TablesForwardPolicy() (string, error) {
+	return defaultFirewall().ForwardPolicy(true)
+}
+
+// SetIP6TablesForwardPolicy sets `policy` for ip6tables `forward` chain.
+func SetIP6TablesForwardPolicy(policy string) error {
+	return defaultFirewall().SetForwardPolicy(true, policy)
+}
+
+// SetIP6TablesForwardAcceptPolicy sets ACCEPT policy for ip6tables `forward` chain.
+func SetIP6TablesForwardAcceptPolicy() error {
+	return SetIP6TablesForwardPolicy(forwardPolicyAccept)
 }
 
 // AllowIPToLocalNetwork allows all the packets coming from `source`
 // to private IP ranges.
-func AllowIPToLocalNetwork(src, dst net.IP) error {
-	cmd := fmt.Sprintf(allowIPToLocalNetCMDFmt, src, src)
-	if err := exec.Command("sh", "-c", cmd).Run(); err != nil { //nolint:gosec
-		return fmt.Errorf("error running command %s: %w", cmd, err)
-	}
-
-	return nil
+func AllowIPToLocalNetwork(src, _ net.IP) error {
+	return defaultFirewall().AllowIPToLocalNetwork(src)
 }
 
 // BlockIPToLocalNetwork blocks all the packets coming from `source`
 // to private IP ranges.
-func BlockIPToLocalNetwork(src, dst net.IP) error {
-	cmd := fmt.Sprintf(blockIPToLocalNetCMDFmt, src, src)
-	if err := exec.Command("sh", "-c", cmd).Run(); err != nil { //nolint:gosec
-		return fmt.Errorf("error running command %s: %w", cmd, err)
+func BlockIPToLocalNetwork(src, _ net.IP) error {
+	return defaultFirewall().BlockIPToLocalNetwork(src)
+}
+
+// SetupServerTUN sets the TUN interface shared by all clients up. Addresses are added
+// with AddTUNAddress as clients connect.
+func SetupServerTUN(ifcName string, mtu int) error {
+	link, err := netlink.LinkByName(ifcName)
+	if err != nil {
+		return fmt.Errorf("error getting interface %s: %w", ifcName, err)
+	}
+
+	if err := netlink.LinkSetMTU(link, mtu); err != nil {
+		return fmt.Errorf("error setting MTU: %w", err)
+	}
+
+	if err := netlink.LinkSetUp(link); err != nil {
+		return fmt.Errorf("error setting interface up: %w", err)
 	}
 
 	return nil
 }
 
+// AddTUNAddress adds `ipCIDR` to the interface with name `ifcName`, routing the whole subnet through it.
+func AddTUNAddress(ifcName, ipCIDR string) error {
+	link, err := netlink.LinkByName(ifcName)
+	if err != nil {
+		return fmt.Errorf("error getting interface %s: %w", ifcName, err)
+	}
+
+	return addAddress(link, ipCIDR)
+}
+
+// DeleteTUNAddress removes `ipCIDR` from the interface with name `ifcName`.
+func DeleteTUNAddress(ifcName, ipCIDR string) error {
+	link, err := netlink.LinkByName(ifcName)
+	if err != nil {
+		return fmt.Errorf("error getting interface %s: %w", ifcName, err)
+	}
+
+	return deleteAddress(link, ipCIDR)
+}
+
 // DefaultNetworkInterface fetches default network interface name.
 func DefaultNetworkInterface() (string, error) {
-	outputBytes, err := exec.Command("sh", "-c", defaultNetworkInterfaceCMD).Output()
-	if err != nil {
-		return "", fmt.Errorf("error running command %s: %w", defaultNetworkInterfaceCMD, err)
+	return defaultRouteInterface(netlink.FAMILY_V4)
+}
+
+// DefaultNetworkInterfaceIPv6 fetches name of the interface with default IPv6 route.
+// Returns empty string if there's no such route.
+func DefaultNetworkInterfaceIPv6() (string, error) {
+	return defaultRouteInterface(netlink.FAMILY_V6)
+}
+
+func defaultRouteInterface(family int) (string, error) {
+	route, ok, err := defaultRoute(family)
+	if err != nil || !ok {
+		return "", err
 	}
 
-	// just in case
-	outputBytes = bytes.TrimRight(outputBytes, "\n")
+	_, linkIndex := routeGateway(route)
+
+	link, err := netlink.LinkByIndex(linkIndex)
+	if err != nil {
+		return "", fmt.Errorf("error getting interface of default route: %w", err)
+	}
 
-	return string(outputBytes), nil
+	return link.Attrs().Name, nil
 }
 
 // GetIPv4ForwardingValue gets current value of IPv4 forwarding.
 func GetIPv4ForwardingValue() (string, error) {
-	return getIPForwardingValue(getIPv4ForwardingCMD)
+	return readSysctl(ipv4ForwardingSysctl)
 }
 
 // GetIPv6ForwardingValue gets current value of IPv6 forwarding.
 func GetIPv6ForwardingValue() (string, error) {
-	return getIPForwardingValue(getIPv6ForwardingCMD)
+	return readSysctl(ipv6ForwardingSysctl)
 }
 
 // SetIPv4ForwardingValue sets `val` value of IPv4 forwarding.
 func SetIPv4ForwardingValue(val string) error {
-	cmd := fmt.Sprintf(setIPv4ForwardingCMDFmt, val)
-	if err := exec.Command("sh", "-c", cmd).Run(); err != nil { //nolint:gosec
-		return fmt.Errorf("error running command %s: %w", cmd, err)
-	}
-
-	return nil
+	return writeSysctl(ipv4ForwardingSysctl, val)
 }
 
 // SetIPv6ForwardingValue sets `val` value of IPv6 forwarding.
 func SetIPv6ForwardingValue(val string) error {
-	cmd := fmt.Sprintf(setIPv6ForwardingCMDFmt, val)
-	if err := exec.Command("sh", "-c", cmd).Run(); err != nil { //nolint:gosec
-		return fmt.Errorf("error running command %s: %w", cmd, err)
-	}
-
-	return nil
+	return writeSysctl(ipv6ForwardingSysctl, val)
 }
 
 // EnableIPv4Forwarding enables IPv4 forwarding.
@@ -127,47 +156,48 @@ func EnableIPv6Forwarding() error {
 
 // EnableIPMasquerading enables IP masquerading for the interface with name `ifcName`.
 func EnableIPMasquerading(ifcName string) error {
-	cmd := fmt.Sprintf(enableIPMasqueradingCMDFmt, ifcName)
-	//nolint:gosec
-	if err := exec.Command("sh", "-c", cmd).Run(); err != nil {
-		return fmt.Errorf("error running command %s: %w", cmd, err)
-	}
-
-	return nil
+	return defaultFirewall().EnableMasquerading(ifcName, false)
 }
 
 // DisableIPMasquerading disables IP masquerading for the interface with name `ifcName`.
 func DisableIPMasquerading(ifcName string) error {
-	cmd := fmt.Sprintf(disableIPMasqueradingCMDFmt, ifcName)
-	//nolint:gosec
-	if err := exec.Command("sh", "-c", cmd).Run(); err != nil {
-		return fmt.Errorf("error running command %s: %w", cmd, err)
-	}
+	return defaultFirewall().DisableMasquerading(ifcName, false)
+}
 
-	return nil
+// EnableIPv6Masquerading enables IPv6 masquerading (NAT66) for the interface with name `ifcName`.
+func EnableIPv6Masquerading(ifcName string) error {
+	return defaultFirewall().EnableMasquerading(ifcName, true)
 }
 
-func getIPForwardingValue(cmd string) (string, error) {
-	outBytes, err := exec.Command("sh", "-c", cmd).Output() //nolint:gosec
-	if err != nil {
-		return "", fmt.Errorf("error running command %s: %w", cmd, err)
-	}
+// DisableIPv6Masquerading disables IPv6 masquerading (NAT66) for the interface with name `ifcName`.
+func DisableIPv6Masquerading(ifcName string) error {
+	return defaultFirewall().DisableMasquerading(ifcName, true)
+}
+
+// AllowForwarding accepts packets forwarded from and to the interface with name `ifcName`
+// in firewall chains dropping them by default.
+func AllowForwarding(ifcName string, ipv6 bool) error {
+	return defaultFirewall().AllowForwarding(ifcName, ipv6)
+}
 
-	val, err := parseIPForwardingOutput(outBytes)
+// DisallowForwarding removes rules added by AllowForwarding.
+func DisallowForwarding(ifcName string, ipv6 bool) error {
+	return defaultFirewall().DisallowForwarding(ifcName, ipv6)
+}
+
+func readSysctl(path string) (string, error) {
+	val, err := ioutil.ReadFile(path) //nolint:gosec
 	if err != nil {
-		return "", fmt.Errorf("error parsing output of command %s: %w", cmd, err)
+		return "", fmt.Errorf("error reading %s: %w", path, err)
 	}
 
-	return val, nil
+	return strings.TrimSpace(string(val)), nil
 }
 
-func parseIPForwardingOutput(output []byte) (string, error) {
-	output = bytes.TrimRight(output, "\n")
-
-	outTokens := bytes.Split(output, []byte{'='})
-	if len(outTokens) != 2 {
-		return "", fmt.Errorf("invalid output: %s", output)
+func writeSysctl(path, val string) error {
+	if err := ioutil.WriteFile(path, []byte(val), 0644); err != nil { //nolint:gosec
+		return fmt.Errorf("error writing %s: %w", path, err)
 	}
 
-	return string(bytes.Trim(outTokens[1], " ")), nil
+	return nil
 }
@@ -1,4 +1,5 @@
-//+build windows
+//go:build windows
+// +build windows
 
 package vpn
 
@@ -7,9 +8,15 @@ import (
 )
 
 const (
-	tunSetupCMDFmt    = "netsh interface ip set address name=\"%s\" source=static addr=%s mask=%s gateway=%s"
-	tunMTUSetupCMDFmt = "netsh interface ipv4 set subinterface \"%s\" mtu=%d"
-	modifyRouteCMDFmt = "route %s %s mask %s %s"
+	tunSetupCMDFmt        = "netsh interface ip set address name=\"%s\" source=static addr=%s mask=%s gateway=%s"
+	tunMTUSetupCMDFmt     = "netsh interface ipv4 set subinterface \"%s\" mtu=%d"
+	tunIPv6SetupCMDFmt    = "netsh interface ipv6 add address \"%s\" %s"
+	modifyRouteCMDFmt     = "route %s %s mask %s %s"
+	modifyRouteIPv6CMDFmt = "route %s %s %s"
+	// loopbackIfcIndex is the index of Windows loopback pseudo-interface, routes
+	// pointing to it make destinations unreachable.
+	loopbackIfcIndex       = 1
+	unreachableRouteCMDFmt = "netsh interface ipv6 %s route %s interface=%d store=active"
 )
 
 // SetupTUN sets the allocated TUN interface up, setting its IP, gateway, netmask and MTU.
@@ -32,6 +39,16 @@ func SetupTUN(ifcName, ipCIDR, gateway string, mtu int) error {
 	return nil
 }
 
+// SetupTUNIPv6 assigns IPv6 address to the TUN interface which is already set up by SetupTUN.
+func SetupTUNIPv6(ifcName, ipCIDR string) error {
+	setupCmd := fmt.Sprintf(tunIPv6SetupCMDFmt, ifcName, ipCIDR)
+	if err := run("cmd", "/C", setupCmd); err != nil {
+		return fmt.Errorf("error running command %s: %w", setupCmd, err)
+	}
+
+	return nil
+}
+
 // ChangeRoute changes current route to `ipCIDR` to go through the `gateway`
 // in the OS routing table.
 func ChangeRoute(ipCIDR, gateway string) error {
@@ -48,7 +65,21 @@ func DeleteRoute(ipCIDR, gateway string) error {
 	return modifyRoutingTable("delete", ipCIDR, gateway)
 }
 
+// AddUnreachableRoute adds route making IPv6 `ipCIDR` unreachable to the OS routing table.
+func AddUnreachableRoute(ipCIDR string) error {
+	return run("cmd", "/C", fmt.Sprintf(unreachableRouteCMDFmt, "add", ipCIDR, loopbackIfcIndex))
+}
+
+// DeleteUnreachableRoute removes route making IPv6 `ipCIDR` unreachable from the OS routing table.
+func DeleteUnreachableRoute(ipCIDR string) error {
+	return run("cmd", "/C", fmt.Sprintf(unreachableRouteCMDFmt, "delete", ipCIDR, loopbackIfcIndex))
+}
+
 func modifyRoutingTable(action, ipCIDR, gateway string) error {
+	if isIPv6CIDR(ipCIDR) {
+		return run("cmd", "/C", fmt.Sprintf(modifyRouteIPv6CMDFmt, action, ipCIDR, gateway))
+	}
+
 	ip, netmask, err := parseCIDR(ipCIDR)
 	if err != nil {
 		return fmt.Errorf("error parsing IP CIDR: %w", err)
@@ -0,0 +1,162 @@
+package vpn
+
+import (
+	"encoding/binary"
+	"net"
+)
+
+const (
+	ipv4HeaderLen = 20
+	ipv6HeaderLen = 40
+	udpHeaderLen  = 8
+	protocolUDP   = 17
+	// defaultTTL is TTL, or hop limit for IPv6, of packets made by the server.
+	defaultTTL = 64
+)
+
+// ipPacketAddrs returns source and destination addresses of IPv4 or IPv6 packet `p`.
+// Returned addresses share memory with `p`.
+func ipPacketAddrs(p []byte) (src, dst net.IP, err error) {
+	if len(p) == 0 {
+		return nil, nil, errInvalidIPPacket
+	}
+
+	switch p[0] >> 4 {
+	case 4:
+		if len(p) < ipv4HeaderLen {
+			return nil, nil, errInvalidIPPacket
+		}
+
+		return net.IP(p[12:16]), net.IP(p[16:20]), nil
+	case 6:
+		if len(p) < ipv6HeaderLen {
+			return nil, nil, errInvalidIPPacket
+		}
+
+		return net.IP(p[8:24]), net.IP(p[24:40]), nil
+	default:
+		return nil, nil, errInvalidIPPacket
+	}
+}
+
+// udpPacket is UDP datagram carried by IPv4 or IPv6 packet.
+type udpPacket struct {
+	src, dst         net.IP
+	srcPort, dstPort uint16
+	payload          []byte
+}
+
+// parseUDPPacket parses IP packet `p` carrying UDP datagram. Fragmented IPv4 packets
+// and IPv6 packets with extension headers are not supported. Returned packet shares memory with `p`.
+func parseUDPPacket(p []byte) (udpPacket, bool) {
+	src, dst, err := ipPacketAddrs(p)
+	if err != nil {
+		return udpPacket{}, false
+	}
+
+	var udp []byte
+	if p[0]>>4 == 4 {
+		headerLen := int(p[0]&0x0f) * 4
+		fragmented := binary.BigEndian.Uint16(p[6:8])&0x3fff != 0
+		if p[9] != protocolUDP || fragmented || headerLen < ipv4HeaderLen || len(p) < headerLen+udpHeaderLen {
+			return udpPacket{}, false
+		}
+
+		udp = p[headerLen:]
+	} else {
+		if p[6] != protocolUDP || len(p) < ipv6HeaderLen+udpHeaderLen {
+			return udpPacket{}, false
+		}
+
+		udp = p[ipv6HeaderLen:]
+	}
+
+	udpLen := int(binary.BigEndian.Uint16(udp[4:6]))
+	if udpLen < udpHeaderLen || udpLen > len(udp) {
+		return udpPacket{}, false
+	}
+
+	return udpPacket{
+		src:     src,
+		dst:     dst,
+		srcPort: binary.BigEndian.Uint16(udp[0:2]),
+		dstPort: binary.BigEndian.Uint16(udp[2:4]),
+		payload: udp[udpHeaderLen:udpLen],
+	}, true
+}
+
+// marshal makes IP packet carrying the UDP datagram, IPv6 one if addresses are IPv6.
+func (u udpPacket) marshal() []byte {
+	udpLen := udpHeaderLen + len(u.payload)
+
+	src4, dst4 := u.src.To4(), u.dst.To4()
+	if src4 != nil && dst4 != nil {
+		p := make([]byte, ipv4HeaderLen+udpLen)
+		p[0] = 4<<4 | ipv4HeaderLen/4
+		binary.BigEndian.PutUint16(p[2:4], uint16(len(p)))
+		p[8] = defaultTTL
+		p[9] = protocolUDP
+		copy(p[12:16], src4)
+		copy(p[16:20], dst4)
+		binary.BigEndian.PutUint16(p[10:12], ^checksum(0, p[:ipv4HeaderLen]))
+
+		// UDP checksum is optional for IPv4, zero means it's not computed
+		u.marshalUDP(p[ipv4HeaderLen:], false, 0)
+
+		return p
+	}
+
+	p := make([]byte, ipv6HeaderLen+udpLen)
+	p[0] = 6 << 4
+	binary.BigEndian.PutUint16(p[4:6], uint16(udpLen))
+	p[6] = protocolUDP
+	p[7] = defaultTTL
+	copy(p[8:24], u.src.To16())
+	copy(p[24:40], u.dst.To16())
+
+	// pseudo header: addresses, UDP length and next header
+	sum := checksum(0, p[8:40])
+	sum = checksum(sum, []byte{0, 0, byte(udpLen >> 8), byte(udpLen), 0, 0, 0, protocolUDP})
+	u.marshalUDP(p[ipv6HeaderLen:], true, sum)
+
+	return p
+}
+
+// marshalUDP writes UDP header and payload to `p`. If `withChecksum` is set, checksum
+// is computed starting from `pseudoSum` of the pseudo header.
+func (u udpPacket) marshalUDP(p []byte, withChecksum bool, pseudoSum uint16) {
+	binary.BigEndian.PutUint16(p[0:2], u.srcPort)
+	binary.BigEndian.PutUint16(p[2:4], u.dstPort)
+	binary.BigEndian.PutUint16(p[4:6], uint16(len(p)))
+	copy(p[udpHeaderLen:], u.payload)
+
+	if !withChecksum {
+		return
+	}
+
+	sum := ^checksum(pseudoSum, p)
+	if sum == 0 {
+		// zero is reserved for no checksum
+		sum = 0xffff
+	}
+
+	binary.BigEndian.PutUint16(p[6:8], sum)
+}
+
+// checksum adds `b` to the one's complement sum `initial`.
+func checksum(initial uint16, b []byte) uint16 {
+	sum := uint32(initial)
+	for i := 0; i+1 < len(b); i += 2 {
+		sum += uint32(b[i])<<8 | uint32(b[i+1])
+	}
+
+	if len(b)%2 == 1 {
+		sum += uint32(b[len(b)-1]) << 8
+	}
+
+	for sum > 0xffff {
+		sum = sum>>16 + sum&0xffff
+	}
+
+	return uint16(sum)
+}
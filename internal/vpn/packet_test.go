@@ -0,0 +1,88 @@
+package vpn
+
+import (
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestIPPacketAddrs(t *testing.T) {
+	src, dst := net.IPv4(10, 0, 0, 1), net.IPv4(1, 1, 1, 1)
+
+	gotSrc, gotDst, err := ipPacketAddrs(ipv4Packet(src, dst))
+	require.NoError(t, err)
+	require.True(t, src.Equal(gotSrc))
+	require.True(t, dst.Equal(gotDst))
+
+	src6, dst6 := net.ParseIP("fd00::1"), net.ParseIP("2001:db8::1")
+	p := make([]byte, ipv6HeaderLen+8)
+	p[0] = 6 << 4
+	copy(p[8:24], src6)
+	copy(p[24:40], dst6)
+
+	gotSrc, gotDst, err = ipPacketAddrs(p)
+	require.NoError(t, err)
+	require.True(t, src6.Equal(gotSrc))
+	require.True(t, dst6.Equal(gotDst))
+
+	for _, p := range [][]byte{nil, {4 << 4, 0, 0}, make([]byte, ipv6HeaderLen)} {
+		_, _, err := ipPacketAddrs(p)
+		require.Equal(t, errInvalidIPPacket, err)
+	}
+}
+
+func TestUDPPacket(t *testing.T) {
+	tt := []struct {
+		name string
+		src  net.IP
+		dst  net.IP
+	}{
+		{
+			name: "IPv4",
+			src:  net.IPv4(10, 0, 0, 4),
+			dst:  net.IPv4(10, 0, 0, 3),
+		},
+		{
+			name: "IPv6",
+			src:  net.ParseIP("fd01:203:405::a00:4"),
+			dst:  net.ParseIP("fd01:203:405::a00:3"),
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			want := udpPacket{
+				src:     tc.src,
+				dst:     tc.dst,
+				srcPort: 45000,
+				dstPort: dnsPort,
+				payload: []byte("query"),
+			}
+
+			p := want.marshal()
+
+			got, ok := parseUDPPacket(p)
+			require.True(t, ok)
+			require.True(t, want.src.Equal(got.src))
+			require.True(t, want.dst.Equal(got.dst))
+			require.Equal(t, want.srcPort, got.srcPort)
+			require.Equal(t, want.dstPort, got.dstPort)
+			require.Equal(t, want.payload, got.payload)
+
+			// checksums are valid if sums of the checksummed data come to all ones
+			if tc.src.To4() != nil {
+				require.Equal(t, uint16(0xffff), checksum(0, p[:ipv4HeaderLen]))
+				return
+			}
+
+			udpLen := len(p) - ipv6HeaderLen
+			sum := checksum(0, p[8:40])
+			sum = checksum(sum, []byte{0, 0, byte(udpLen >> 8), byte(udpLen), 0, 0, 0, protocolUDP})
+			require.Equal(t, uint16(0xffff), checksum(sum, p[ipv6HeaderLen:]))
+		})
+	}
+
+	_, ok := parseUDPPacket(ipv4Packet(net.IPv4(10, 0, 0, 4), net.IPv4(1, 1, 1, 1)))
+	require.False(t, ok)
+}
@@ -23,136 +23,293 @@ type Server struct {
 	ipv4ForwardingVal          string
 	ipv6ForwardingVal          string
 	iptablesForwardPolicy      string
+	ip6tablesForwardPolicy     string
+	// ipv6NetworkInterface is the interface IPv6 traffic of clients is masqueraded through.
+	ipv6NetworkInterface string
+	// ipv6 is set if IPv6 is tunneled to clients along with IPv4.
+	ipv6 bool
+	// tun is shared by all clients, sessions route packets read from it.
+	tun      TUNDevice
+	sessions *sessionTable
+	// osNet is changed only through the methods registering rollback, or per client session.
+	osNet    serverNetwork
+	rollback netRollback
+	// dns answers DNS queries of clients, nil if the forwarder is disabled.
+	dns *dnsForwarder
+
+	connsMx sync.Mutex
+	conns   map[net.Conn]struct{}
+	connsWG sync.WaitGroup
 }
 
 // NewServer creates VPN server instance.
 func NewServer(cfg ServerConfig, l logrus.FieldLogger) (*Server, error) {
+	return newServer(cfg, l, osServerNetwork{})
+}
+
+func newServer(cfg ServerConfig, l logrus.FieldLogger, osNet serverNetwork) (*Server, error) {
 	s := &Server{
-		cfg:   cfg,
-		log:   l,
-		ipGen: NewIPGenerator(),
+		cfg:      cfg,
+		log:      l,
+		ipGen:    NewIPGenerator(),
+		sessions: newSessionTable(),
+		osNet:    osNet,
+		conns:    make(map[net.Conn]struct{}),
 	}
 
-	defaultNetworkIfc, err := DefaultNetworkInterface()
+	defaultNetworkIfc, err := osNet.DefaultNetworkInterface(false)
 	if err != nil {
 		return nil, fmt.Errorf("error getting default network interface: %w", err)
 	}
 
 	l.Infof("Got default network interface: %s", defaultNetworkIfc)
 
-	defaultNetworkIfcIPs, err := NetworkInterfaceIPs(defaultNetworkIfc)
+	defaultNetworkIfcIPs, err := osNet.NetworkInterfaceIPs(defaultNetworkIfc)
 	if err != nil {
 		return nil, fmt.Errorf("error getting IPs of interface %s: %w", defaultNetworkIfc, err)
 	}
 
 	l.Infof("Got IPs of interface %s: %v", defaultNetworkIfc, defaultNetworkIfcIPs)
 
-	ipv4ForwardingVal, err := GetIPv4ForwardingValue()
+	ipv4ForwardingVal, err := osNet.ForwardingValue(false)
 	if err != nil {
 		return nil, fmt.Errorf("error getting IPv4 forwarding value: %w", err)
 	}
-	ipv6ForwardingVal, err := GetIPv6ForwardingValue()
-	if err != nil {
-		return nil, fmt.Errorf("error getting IPv6 forwarding value")
-	}
-
-	l.Infoln("Old IP forwarding values:")
-	l.Infof("IPv4: %s, IPv6: %s", ipv4ForwardingVal, ipv6ForwardingVal)
+	l.Infof("Old IPv4 forwarding value: %s", ipv4ForwardingVal)
 
-	iptablesForwarPolicy, err := GetIPTablesForwardPolicy()
+	iptablesForwarPolicy, err := osNet.ForwardPolicy(false)
 	if err != nil {
 		return nil, fmt.Errorf("error getting iptables forward policy: %w", err)
 	}
 
 	l.Infof("Old iptables forward policy: %s", iptablesForwarPolicy)
 
+	// IPv6 is optional, server keeps serving IPv4 only if it can't be set up
+	if ipv6ForwardingVal, err := osNet.ForwardingValue(true); err != nil {
+		l.WithError(err).Warnln("Error getting IPv6 forwarding value, IPv6 won't be tunneled")
+	} else if ip6tablesForwardPolicy, err := osNet.ForwardPolicy(true); err != nil {
+		l.WithError(err).Warnln("Error getting ip6tables forward policy, IPv6 won't be tunneled")
+	} else {
+		l.Infof("Old IPv6 forwarding value: %s", ipv6ForwardingVal)
+		l.Infof("Old ip6tables forward policy: %s", ip6tablesForwardPolicy)
+		s.ipv6ForwardingVal = ipv6ForwardingVal
+		s.ip6tablesForwardPolicy = ip6tablesForwardPolicy
+		s.ipv6 = true
+	}
+
+	// without default IPv6 route clients still get IPv6, their IPv6 traffic is answered
+	// with unreachable by the server instead of leaking around the tunnel
+	ipv6NetworkIfc, err := osNet.DefaultNetworkInterface(true)
+	if err != nil || ipv6NetworkIfc == "" {
+		ipv6NetworkIfc = defaultNetworkIfc
+	}
+
+	s.ipv6NetworkInterface = ipv6NetworkIfc
+
+	// clients left without DNS servers resolve names outside of the tunnel
+	if !cfg.DNSForwarder && len(cfg.DNS) == 0 {
+		hostDNS, err := hostDNSServers()
+		if err != nil {
+			l.WithError(err).Warnln("Error getting host DNS servers")
+		}
+
+		cfg.DNS, cfg.DNSForwarder = defaultClientDNS(hostDNS)
+		switch {
+		case cfg.DNSForwarder:
+			l.Infoln("Host DNS servers are only reachable locally, enabling DNS forwarder")
+		case len(cfg.DNS) != 0:
+			l.Infof("Advertising host DNS servers %v to clients", cfg.DNS)
+		default:
+			l.Warnln("No DNS servers to advertise, clients resolve names outside of VPN")
+		}
+
+		s.cfg = cfg
+	}
+
+	if cfg.DNSForwarder {
+		upstreams := cfg.DNS
+		if len(upstreams) == 0 {
+			if upstreams, err = hostDNSServers(); err != nil {
+				return nil, fmt.Errorf("error getting host DNS servers: %w", err)
+			}
+		}
+
+		if len(upstreams) == 0 {
+			return nil, errNoDNSUpstreams
+		}
+
+		l.Infof("Forwarding DNS queries of clients to %v", upstreams)
+
+		s.dns = newDNSForwarder(l, upstreams)
+	}
+
 	s.defaultNetworkInterface = defaultNetworkIfc
 	s.defaultNetworkInterfaceIPs = defaultNetworkIfcIPs
 	s.ipv4ForwardingVal = ipv4ForwardingVal
-	s.ipv6ForwardingVal = ipv6ForwardingVal
 	s.iptablesForwardPolicy = iptablesForwarPolicy
 
 	return s, nil
 }
 
-// Serve accepts connections from `l` and serves them.
+// Serve accepts connections from `l` and serves them. Once `l` is closed, all clients
+// are disconnected and every change made to the OS network configuration is reverted.
 func (s *Server) Serve(l net.Listener) error {
 	serveErr := errors.New("already serving")
 	s.serveOnce.Do(func() {
-		if err := EnableIPv4Forwarding(); err != nil {
-			serveErr = fmt.Errorf("error enabling IPv4 forwarding: %w", err)
-			return
-		}
-		s.log.Infoln("Set IPv4 forwarding = 1")
-		defer func() {
-			if err := SetIPv4ForwardingValue(s.ipv4ForwardingVal); err != nil {
-				s.log.WithError(err).Errorln("Error reverting IPv4 forwarding")
-			} else {
-				s.log.Infof("Set IPv4 forwarding = %s", s.ipv4ForwardingVal)
-			}
-		}()
+		defer s.rollback.run(s.log)
 
-		if err := EnableIPv6Forwarding(); err != nil {
-			serveErr = fmt.Errorf("error enabling IPv6 forwarding: %w", err)
+		if err := s.setupNetwork(); err != nil {
+			serveErr = err
 			return
 		}
-		s.log.Infoln("Set IPv6 forwarding = 1")
-		defer func() {
-			if err := SetIPv6ForwardingValue(s.ipv6ForwardingVal); err != nil {
-				s.log.WithError(err).Errorln("Error reverting IPv6 forwarding")
-			} else {
-				s.log.Infof("Set IPv6 forwarding = %s", s.ipv6ForwardingVal)
-			}
-		}()
 
-		if err := EnableIPMasquerading(s.defaultNetworkInterface); err != nil {
-			serveErr = fmt.Errorf("error enabling IP masquerading for %s: %w", s.defaultNetworkInterface, err)
+		tun, err := newTUNDevice()
+		if err != nil {
+			serveErr = fmt.Errorf("error allocating TUN interface: %w", err)
 			return
 		}
 
-		s.log.Infoln("Enabled IP masquerading")
+		tunName := tun.Name()
+		s.rollback.add("allocated TUN "+tunName, tun.Close)
 
-		defer func() {
-			if err := DisableIPMasquerading(s.defaultNetworkInterface); err != nil {
-				s.log.WithError(err).Errorf("Error disabling IP masquerading for %s", s.defaultNetworkInterface)
-			} else {
-				s.log.Infof("Disabled IP masquerading for %s", s.defaultNetworkInterface)
-			}
-		}()
+		s.log.Infof("Allocated TUN %s", tunName)
 
-		if err := SetIPTablesForwardAcceptPolicy(); err != nil {
-			serveErr = fmt.Errorf("error settings iptables forward policy to ACCEPT")
+		if err := s.osNet.SetupTUN(tunName, TUNMTU); err != nil {
+			serveErr = fmt.Errorf("error setting up TUN %s: %w", tunName, err)
 			return
 		}
 
-		s.log.Infoln("Set iptables forward policy to ACCEPT")
+		s.allowForwarding(tunName)
 
-		defer func() {
-			if err := SetIPTablesForwardPolicy(s.iptablesForwardPolicy); err != nil {
-				s.log.WithError(err).Errorf("Error setting iptables forward policy to %s", s.iptablesForwardPolicy)
-			} else {
-				s.log.Infof("Restored iptables forward policy to %s", s.iptablesForwardPolicy)
-			}
-		}()
+		s.tun = tun
+		go s.serveTUN()
 
 		s.lisMx.Lock()
 		s.lis = l
 		s.lisMx.Unlock()
 
+		// clients are disconnected before the rollback, so that they revert their own changes first
+		defer s.closeConns()
+
 		for {
-			conn, err := s.lis.Accept()
+			conn, err := l.Accept()
 			if err != nil {
 				serveErr = fmt.Errorf("failed to accept client connection: %w", err)
 				return
 			}
 
-			go s.serveConn(conn)
+			s.connsMx.Lock()
+			s.conns[conn] = struct{}{}
+			s.connsMx.Unlock()
+
+			s.connsWG.Add(1)
+			go func() {
+				defer s.connsWG.Done()
+				s.serveConn(conn)
+			}()
 		}
 	})
 
 	return serveErr
 }
 
+// setupNetwork enables forwarding and masquerading of clients' traffic. Each change
+// is registered to be reverted on shutdown.
+func (s *Server) setupNetwork() error {
+	if err := s.osNet.SetForwardingValue(false, "1"); err != nil {
+		return fmt.Errorf("error enabling IPv4 forwarding: %w", err)
+	}
+	s.log.Infoln("Set IPv4 forwarding = 1")
+	s.rollback.add("IPv4 forwarding = "+s.ipv4ForwardingVal, func() error {
+		return s.osNet.SetForwardingValue(false, s.ipv4ForwardingVal)
+	})
+
+	if err := s.osNet.EnableMasquerading(s.defaultNetworkInterface, false); err != nil {
+		return fmt.Errorf("error enabling IP masquerading for %s: %w", s.defaultNetworkInterface, err)
+	}
+	s.log.Infoln("Enabled IP masquerading")
+	s.rollback.add("IP masquerading for "+s.defaultNetworkInterface, func() error {
+		return s.osNet.DisableMasquerading(s.defaultNetworkInterface, false)
+	})
+
+	if err := s.osNet.SetForwardPolicy(false, forwardPolicyAccept); err != nil {
+		return fmt.Errorf("error settings iptables forward policy to ACCEPT: %w", err)
+	}
+	s.log.Infoln("Set iptables forward policy to ACCEPT")
+	s.rollback.add("iptables forward policy = "+s.iptablesForwardPolicy, func() error {
+		return s.osNet.SetForwardPolicy(false, s.iptablesForwardPolicy)
+	})
+
+	if s.ipv6 {
+		s.ipv6 = s.setupIPv6Network()
+	}
+
+	return nil
+}
+
+// setupIPv6Network enables forwarding and masquerading of clients' IPv6 traffic. Returns false
+// if it failed, in which case IPv6 isn't tunneled.
+func (s *Server) setupIPv6Network() bool {
+	if err := s.osNet.SetForwardingValue(true, "1"); err != nil {
+		s.log.WithError(err).Warnln("Error enabling IPv6 forwarding, IPv6 won't be tunneled")
+		return false
+	}
+
+	s.log.Infoln("Set IPv6 forwarding = 1")
+	s.rollback.add("IPv6 forwarding = "+s.ipv6ForwardingVal, func() error {
+		return s.osNet.SetForwardingValue(true, s.ipv6ForwardingVal)
+	})
+
+	if err := s.osNet.EnableMasquerading(s.ipv6NetworkInterface, true); err != nil {
+		s.log.WithError(err).Warnf("Error enabling IPv6 masquerading for %s, IPv6 won't be tunneled", s.ipv6NetworkInterface)
+		return false
+	}
+
+	s.log.Infof("Enabled IPv6 masquerading for %s", s.ipv6NetworkInterface)
+
+	if err := s.osNet.SetForwardPolicy(true, forwardPolicyAccept); err != nil {
+		s.log.WithError(err).Warnln("Error setting ip6tables forward policy to ACCEPT, IPv6 won't be tunneled")
+
+		if err := s.osNet.DisableMasquerading(s.ipv6NetworkInterface, true); err != nil {
+			s.log.WithError(err).Errorf("Error disabling IPv6 masquerading for %s", s.ipv6NetworkInterface)
+		}
+
+		return false
+	}
+
+	s.log.Infoln("Set ip6tables forward policy to ACCEPT")
+
+	s.rollback.add("IPv6 masquerading for "+s.ipv6NetworkInterface, func() error {
+		return s.osNet.DisableMasquerading(s.ipv6NetworkInterface, true)
+	})
+	s.rollback.add("ip6tables forward policy = "+s.ip6tablesForwardPolicy, func() error {
+		return s.osNet.SetForwardPolicy(true, s.ip6tablesForwardPolicy)
+	})
+
+	return true
+}
+
+// allowForwarding accepts clients' traffic in firewall chains dropping forwarded packets by default,
+// like `inet filter` of nftables. Failing isn't fatal, but clients may be left without connectivity.
+func (s *Server) allowForwarding(tunName string) {
+	for _, ipv6 := range []bool{false, true} {
+		if ipv6 && !s.ipv6 {
+			continue
+		}
+
+		ipv6 := ipv6
+		if err := s.osNet.AllowForwarding(tunName, ipv6); err != nil {
+			s.log.WithError(err).Errorf("Error allowing forwarding through %s (IPv6: %v), "+
+				"clients' traffic may be dropped by the firewall, it has to accept it manually", tunName, ipv6)
+			continue
+		}
+
+		s.rollback.add(fmt.Sprintf("forwarding through %s (IPv6: %v)", tunName, ipv6), func() error {
+			return s.osNet.DisallowForwarding(tunName, ipv6)
+		})
+	}
+}
+
 // Close shuts server down.
 func (s *Server) Close() error {
 	s.lisMx.Lock()
@@ -168,55 +325,71 @@ func (s *Server) Close() error {
 	return err
 }
 
+// closeConn closes client connection, unless it's already closed on shutdown.
 func (s *Server) closeConn(conn net.Conn) {
+	s.connsMx.Lock()
+	_, ok := s.conns[conn]
+	delete(s.conns, conn)
+	s.connsMx.Unlock()
+
+	if !ok {
+		return
+	}
+
 	if err := conn.Close(); err != nil {
 		s.log.WithError(err).Errorf("Error closing client %s connection", conn.RemoteAddr())
 	}
 }
 
+// closeConns disconnects all clients and waits till their sessions are closed.
+func (s *Server) closeConns() {
+	s.connsMx.Lock()
+	conns := s.conns
+	s.conns = make(map[net.Conn]struct{})
+	s.connsMx.Unlock()
+
+	for conn := range conns {
+		if err := conn.Close(); err != nil {
+			s.log.WithError(err).Errorf("Error closing client %s connection", conn.RemoteAddr())
+		}
+	}
+
+	s.connsWG.Wait()
+}
+
 func (s *Server) serveConn(conn net.Conn) {
 	defer s.closeConn(conn)
 
-	tunIP, tunGateway, allowTrafficToLocalNet, err := s.shakeHands(conn)
+	sess, err := s.shakeHands(conn)
 	if err != nil {
 		s.log.WithError(err).Errorf("Error negotiating with client %s", conn.RemoteAddr())
 		return
 	}
-	defer allowTrafficToLocalNet()
+	defer s.closeSession(sess)
 
-	tun, err := newTUNDevice()
-	if err != nil {
-		s.log.WithError(err).Errorln("Error allocating TUN interface")
+	if err := s.addTUNAddresses(sess); err != nil {
+		s.log.WithError(err).Errorf("Error setting up TUN %s for client %s", s.tun.Name(), conn.RemoteAddr())
 		return
 	}
-	defer func() {
-		tunName := tun.Name()
-		if err := tun.Close(); err != nil {
-			s.log.WithError(err).Errorf("Error closing TUN %s", tunName)
-		}
-	}()
 
-	s.log.Infof("Allocated TUN %s", tun.Name())
+	s.sessions.add(sess)
 
-	if err := SetupTUN(tun.Name(), tunIP.String()+TUNNetmaskCIDR, tunGateway.String(), TUNMTU); err != nil {
-		s.log.WithError(err).Errorf("Error setting up TUN %s", tun.Name())
-		return
-	}
+	s.log.Infof("Serving client %s with IPs %v", conn.RemoteAddr(), sess.clientIPs())
 
 	connToTunDoneCh := make(chan struct{})
 	tunToConnCh := make(chan struct{})
 	go func() {
 		defer close(connToTunDoneCh)
 
-		if _, err := io.Copy(tun, conn); err != nil {
-			s.log.WithError(err).Errorf("Error resending traffic from VPN client to TUN %s", tun.Name())
+		if err := s.copyConnToTUN(sess); err != nil {
+			s.log.WithError(err).Errorf("Error resending traffic from VPN client to TUN %s", s.tun.Name())
 		}
 	}()
 	go func() {
 		defer close(tunToConnCh)
 
-		if _, err := io.Copy(conn, tun); err != nil {
-			s.log.WithError(err).Errorf("Error resending traffic from TUN %s to VPN client", tun.Name())
+		if err := s.copyTUNToConn(sess); err != nil {
+			s.log.WithError(err).Errorf("Error resending traffic from TUN %s to VPN client", s.tun.Name())
 		}
 	}()
 
@@ -227,40 +400,151 @@ func (s *Server) serveConn(conn net.Conn) {
 	}
 }
 
-func (s *Server) shakeHands(conn net.Conn) (tunIP, tunGateway net.IP, unsecureVPN func(), err error) {
+// addTUNAddresses adds server-side IPs of the client subnet to the shared TUN,
+// so that the kernel routes packets to the client through it.
+func (s *Server) addTUNAddresses(sess *clientSession) error {
+	addrs := []string{sess.tunIP.String() + TUNNetmaskCIDR}
+	if sess.tunIPv6 != nil {
+		addrs = append(addrs, sess.tunIPv6.String()+TUNNetmaskCIDRv6)
+	}
+
+	for _, addr := range addrs {
+		if err := s.osNet.AddTUNAddress(s.tun.Name(), addr); err != nil {
+			return fmt.Errorf("error adding address %s: %w", addr, err)
+		}
+
+		sess.tunAddrs = append(sess.tunAddrs, addr)
+	}
+
+	return nil
+}
+
+// closeSession removes all the state of the client from the server.
+func (s *Server) closeSession(sess *clientSession) {
+	s.sessions.remove(sess)
+	close(sess.done)
+
+	for _, addr := range sess.tunAddrs {
+		if err := s.osNet.DeleteTUNAddress(s.tun.Name(), addr); err != nil {
+			s.log.WithError(err).Errorf("Error removing address %s from TUN %s", addr, s.tun.Name())
+		}
+	}
+
+	sess.unsecureVPN()
+
+	if err := s.ipGen.Release(sess.subnet); err != nil {
+		s.log.WithError(err).Errorf("Error releasing subnet %s", sess.subnet)
+	}
+}
+
+// serveTUN reads packets from the shared TUN and passes each one to the client
+// it's destined to. Packets to unknown destinations are dropped.
+func (s *Server) serveTUN() {
+	buf := make([]byte, TUNMTU)
+	for {
+		n, err := s.tun.Read(buf)
+		if err != nil {
+			s.log.WithError(err).Infof("Stopped reading TUN %s", s.tun.Name())
+			return
+		}
+
+		_, dst, err := ipPacketAddrs(buf[:n])
+		if err != nil {
+			continue
+		}
+
+		sess, ok := s.sessions.get(dst)
+		if !ok {
+			continue
+		}
+
+		packet := make([]byte, n)
+		copy(packet, buf[:n])
+		sess.push(packet)
+	}
+}
+
+// copyTUNToConn sends packets passed by serveTUN to the client.
+func (s *Server) copyTUNToConn(sess *clientSession) error {
+	for {
+		select {
+		case <-sess.done:
+			return nil
+		case packet := <-sess.packets:
+			if _, err := sess.conn.Write(packet); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// copyConnToTUN writes packets from the client to the shared TUN. Packets not coming from
+// the client addresses are dropped, so a client can't pretend to be another one.
+func (s *Server) copyConnToTUN(sess *clientSession) error {
+	buf := make([]byte, TUNMTU)
+	for {
+		n, err := sess.conn.Read(buf)
+		if err != nil {
+			if err == io.EOF {
+				return nil
+			}
+
+			return err
+		}
+
+		src, _, err := ipPacketAddrs(buf[:n])
+		if err != nil || !sess.ownsIP(src) {
+			continue
+		}
+
+		if s.dns != nil && s.dns.handle(sess, buf[:n]) {
+			continue
+		}
+
+		if _, err := s.tun.Write(buf[:n]); err != nil {
+			return err
+		}
+	}
+}
+
+func (s *Server) shakeHands(conn net.Conn) (*clientSession, error) {
 	var cHello ClientHello
 	if err := ReadJSON(conn, &cHello); err != nil {
-		return nil, nil, nil, fmt.Errorf("error reading client hello: %w", err)
+		return nil, fmt.Errorf("error reading client hello: %w", err)
 	}
 
-	// default value
-	unsecureVPN = func() {}
-
 	s.log.Debugf("Got client hello: %v", cHello)
 
 	if s.cfg.Passcode != "" && cHello.Passcode != s.cfg.Passcode {
 		s.sendServerErrHello(conn, HandshakeStatusForbidden)
-		return nil, nil, nil, errors.New("got wrong passcode from client")
+		return nil, errors.New("got wrong passcode from client")
 	}
 
 	for _, ip := range cHello.UnavailablePrivateIPs {
 		if err := s.ipGen.Reserve(ip); err != nil {
 			// this happens only on malformed IP
 			s.sendServerErrHello(conn, HandshakeStatusBadRequest)
-			return nil, nil, nil, fmt.Errorf("error reserving IP %s: %w", ip.String(), err)
+			return nil, fmt.Errorf("error reserving IP %s: %w", ip.String(), err)
 		}
 	}
 
 	subnet, err := s.ipGen.Next()
 	if err != nil {
 		s.sendServerErrHello(conn, HandshakeNoFreeIPs)
-		return nil, nil, nil, fmt.Errorf("error getting free subnet IP: %w", err)
+		return nil, fmt.Errorf("error getting free subnet IP: %w", err)
+	}
+
+	releaseSubnet := func() {
+		if err := s.ipGen.Release(subnet); err != nil {
+			s.log.WithError(err).Errorf("Error releasing subnet %s", subnet)
+		}
 	}
 
 	subnetOctets, err := fetchIPv4Octets(subnet)
 	if err != nil {
+		releaseSubnet()
 		s.sendServerErrHello(conn, HandshakeStatusInternalError)
-		return nil, nil, nil, fmt.Errorf("error breaking IP into octets: %w", err)
+		return nil, fmt.Errorf("error breaking IP into octets: %w", err)
 	}
 
 	// basically IP address comprised of `subnetOctets` items is the IP address of the subnet,
@@ -270,26 +554,17 @@ func (s *Server) shakeHands(conn net.Conn) (tunIP, tunGateway net.IP, unsecureVP
 	// - Server-side TUN IP = subnet IP + 2
 	// - Client-side TUN gateway = subnet IP + 3
 	// - Client-site TUN IP = subnet IP + 4
+	// Server-side TUN IPs of all clients are added to the same shared TUN.
 
 	sTUNIP := net.IPv4(subnetOctets[0], subnetOctets[1], subnetOctets[2], subnetOctets[3]+2)
-	sTUNGateway := net.IPv4(subnetOctets[0], subnetOctets[1], subnetOctets[2], subnetOctets[3]+1)
 
 	cTUNIP := net.IPv4(subnetOctets[0], subnetOctets[1], subnetOctets[2], subnetOctets[3]+4)
 	cTUNGateway := net.IPv4(subnetOctets[0], subnetOctets[1], subnetOctets[2], subnetOctets[3]+3)
 
-	if s.cfg.Secure {
-		if err := BlockIPToLocalNetwork(cTUNIP, sTUNIP); err != nil {
-			s.sendServerErrHello(conn, HandshakeStatusInternalError)
-			return nil, nil, nil,
-				fmt.Errorf("error securing local network for IP %s: %w", cTUNIP, err)
-		}
-
-		unsecureVPN = func() {
-			if err := AllowIPToLocalNetwork(cTUNIP, sTUNIP); err != nil {
-				s.log.WithError(err).Errorln("Error allowing traffic to local network")
-			}
-		}
-	}
+	sess := newClientSession(conn)
+	sess.subnet = subnet
+	sess.tunIP = sTUNIP
+	sess.clientIP = cTUNIP
 
 	sHello := ServerHello{
 		Status:     HandshakeStatusOK,
@@ -297,12 +572,59 @@ func (s *Server) shakeHands(conn net.Conn) (tunIP, tunGateway net.IP, unsecureVP
 		TUNGateway: cTUNGateway,
 	}
 
+	if s.ipv6 {
+		// IPv6 addresses are the IPv4 ones embedded into the ULA prefix, so they can't fail
+		sess.tunIPv6, _ = s.ipGen.IPv6(sTUNIP)               //nolint:errcheck
+		sHello.TUNIPv6, _ = s.ipGen.IPv6(cTUNIP)             //nolint:errcheck
+		sHello.TUNGatewayIPv6, _ = s.ipGen.IPv6(cTUNGateway) //nolint:errcheck
+		sess.clientIPv6 = sHello.TUNIPv6
+	}
+
+	if s.dns != nil {
+		// forwarder answers on the client TUN gateway, so queries go through the tunnel
+		// along with the rest of the traffic
+		sess.dnsIPs = []net.IP{sHello.TUNGateway}
+		if sHello.TUNGatewayIPv6 != nil {
+			sess.dnsIPs = append(sess.dnsIPs, sHello.TUNGatewayIPv6)
+		}
+
+		sHello.DNS = sess.dnsIPs
+	} else {
+		sHello.DNS = s.cfg.DNS
+	}
+
+	if s.cfg.Secure {
+		secured := sess.clientIPs()
+		for i, ip := range secured {
+			if err := s.osNet.BlockIPToLocalNetwork(ip, sTUNIP); err != nil {
+				for _, blocked := range secured[:i] {
+					if err := s.osNet.AllowIPToLocalNetwork(blocked, sTUNIP); err != nil {
+						s.log.WithError(err).Errorln("Error allowing traffic to local network")
+					}
+				}
+
+				releaseSubnet()
+				s.sendServerErrHello(conn, HandshakeStatusInternalError)
+				return nil, fmt.Errorf("error securing local network for IP %s: %w", ip, err)
+			}
+		}
+
+		sess.unsecureVPN = func() {
+			for _, ip := range secured {
+				if err := s.osNet.AllowIPToLocalNetwork(ip, sTUNIP); err != nil {
+					s.log.WithError(err).Errorln("Error allowing traffic to local network")
+				}
+			}
+		}
+	}
+
 	if err := WriteJSON(conn, &sHello); err != nil {
-		unsecureVPN()
-		return nil, nil, nil, fmt.Errorf("error finishing hadnshake: error sending server hello: %w", err)
+		sess.unsecureVPN()
+		releaseSubnet()
+		return nil, fmt.Errorf("error finishing hadnshake: error sending server hello: %w", err)
 	}
 
-	return sTUNIP, sTUNGateway, unsecureVPN, nil
+	return sess, nil
 }
 
 func (s *Server) sendServerErrHello(conn net.Conn, status HandshakeStatus) {
@@ -1,7 +1,15 @@
 package vpn
 
+import "net"
+
 // ServerConfig is a configuration for VPN server.
 type ServerConfig struct {
 	Passcode string
 	Secure   bool
+	// DNS are resolvers advertised to clients. With DNSForwarder set, these are the upstreams
+	// of the forwarder instead, the host ones are used if empty. If neither is set, the host
+	// resolvers are advertised, or forwarded to if they are only reachable on the host.
+	DNS []net.IP
+	// DNSForwarder makes the server answer DNS queries of clients on their TUN gateway address.
+	DNSForwarder bool
 }
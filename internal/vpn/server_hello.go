@@ -3,8 +3,13 @@ package vpn
 import "net"
 
 // ServerHello is a message sent by server during the Client/Server handshake.
+// IPv6 fields are empty if the server doesn't tunnel IPv6. DNS is empty if the server
+// doesn't advertise resolvers, in which case the client keeps the host ones.
 type ServerHello struct {
-	Status     HandshakeStatus `json:"status"`
-	TUNIP      net.IP          `json:"tun_ip"`
-	TUNGateway net.IP          `json:"tun_gateway"`
+	Status         HandshakeStatus `json:"status"`
+	TUNIP          net.IP          `json:"tun_ip"`
+	TUNGateway     net.IP          `json:"tun_gateway"`
+	TUNIPv6        net.IP          `json:"tun_ipv6,omitempty"`
+	TUNGatewayIPv6 net.IP          `json:"tun_gateway_ipv6,omitempty"`
+	DNS            []net.IP        `json:"dns,omitempty"`
 }
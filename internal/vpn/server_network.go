@@ -0,0 +1,160 @@
+package vpn
+
+import (
+	"net"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Policies of the `forward` chain.
+const (
+	forwardPolicyAccept = "ACCEPT"
+	forwardPolicyDrop   = "DROP"
+)
+
+// serverNetwork is the OS network configuration read and changed by the server.
+type serverNetwork interface {
+	DefaultNetworkInterface(ipv6 bool) (string, error)
+	NetworkInterfaceIPs(ifcName string) ([]net.IP, error)
+	ForwardingValue(ipv6 bool) (string, error)
+	SetForwardingValue(ipv6 bool, val string) error
+	ForwardPolicy(ipv6 bool) (string, error)
+	SetForwardPolicy(ipv6 bool, policy string) error
+	EnableMasquerading(ifcName string, ipv6 bool) error
+	DisableMasquerading(ifcName string, ipv6 bool) error
+	AllowForwarding(ifcName string, ipv6 bool) error
+	DisallowForwarding(ifcName string, ipv6 bool) error
+	BlockIPToLocalNetwork(src, dst net.IP) error
+	AllowIPToLocalNetwork(src, dst net.IP) error
+	SetupTUN(ifcName string, mtu int) error
+	AddTUNAddress(ifcName, ipCIDR string) error
+	DeleteTUNAddress(ifcName, ipCIDR string) error
+}
+
+// osServerNetwork configures network of the host OS.
+type osServerNetwork struct{}
+
+func (osServerNetwork) DefaultNetworkInterface(ipv6 bool) (string, error) {
+	if ipv6 {
+		return DefaultNetworkInterfaceIPv6()
+	}
+
+	return DefaultNetworkInterface()
+}
+
+func (osServerNetwork) NetworkInterfaceIPs(ifcName string) ([]net.IP, error) {
+	return NetworkInterfaceIPs(ifcName)
+}
+
+func (osServerNetwork) ForwardingValue(ipv6 bool) (string, error) {
+	if ipv6 {
+		return GetIPv6ForwardingValue()
+	}
+
+	return GetIPv4ForwardingValue()
+}
+
+func (osServerNetwork) SetForwardingValue(ipv6 bool, val string) error {
+	if ipv6 {
+		return SetIPv6ForwardingValue(val)
+	}
+
+	return SetIPv4ForwardingValue(val)
+}
+
+func (osServerNetwork) ForwardPolicy(ipv6 bool) (string, error) {
+	if ipv6 {
+		return GetIP6TablesForwardPolicy()
+	}
+
+	return GetIPTablesForwardPolicy()
+}
+
+func (osServerNetwork) SetForwardPolicy(ipv6 bool, policy string) error {
+	if ipv6 {
+		return SetIP6TablesForwardPolicy(policy)
+	}
+
+	return SetIPTablesForwardPolicy(policy)
+}
+
+func (osServerNetwork) EnableMasquerading(ifcName string, ipv6 bool) error {
+	if ipv6 {
+		return EnableIPv6Masquerading(ifcName)
+	}
+
+	return EnableIPMasquerading(ifcName)
+}
+
+func (osServerNetwork) DisableMasquerading(ifcName string, ipv6 bool) error {
+	if ipv6 {
+		return DisableIPv6Masquerading(ifcName)
+	}
+
+	return DisableIPMasquerading(ifcName)
+}
+
+func (osServerNetwork) AllowForwarding(ifcName string, ipv6 bool) error {
+	return AllowForwarding(ifcName, ipv6)
+}
+
+func (osServerNetwork) DisallowForwarding(ifcName string, ipv6 bool) error {
+	return DisallowForwarding(ifcName, ipv6)
+}
+
+func (osServerNetwork) BlockIPToLocalNetwork(src, dst net.IP) error {
+	return BlockIPToLocalNetwork(src, dst)
+}
+
+func (osServerNetwork) AllowIPToLocalNetwork(src, dst net.IP) error {
+	return AllowIPToLocalNetwork(src, dst)
+}
+
+func (osServerNetwork) SetupTUN(ifcName string, mtu int) error {
+	return SetupServerTUN(ifcName, mtu)
+}
+
+func (osServerNetwork) AddTUNAddress(ifcName, ipCIDR string) error {
+	return AddTUNAddress(ifcName, ipCIDR)
+}
+
+func (osServerNetwork) DeleteTUNAddress(ifcName, ipCIDR string) error {
+	return DeleteTUNAddress(ifcName, ipCIDR)
+}
+
+// netRollback keeps track of changes made to the OS network configuration,
+// so that all of them are reverted on server shutdown.
+type netRollback struct {
+	mx      sync.Mutex
+	reverts []netRevert
+}
+
+type netRevert struct {
+	desc   string
+	revert func() error
+}
+
+// add registers `revert` undoing the change described by `desc`.
+func (r *netRollback) add(desc string, revert func() error) {
+	r.mx.Lock()
+	defer r.mx.Unlock()
+
+	r.reverts = append(r.reverts, netRevert{desc: desc, revert: revert})
+}
+
+// run reverts all the registered changes, latest first.
+func (r *netRollback) run(log logrus.FieldLogger) {
+	r.mx.Lock()
+	reverts := r.reverts
+	r.reverts = nil
+	r.mx.Unlock()
+
+	for i := len(reverts) - 1; i >= 0; i-- {
+		if err := reverts[i].revert(); err != nil {
+			log.WithError(err).Errorf("Error reverting: %s", reverts[i].desc)
+		} else {
+			log.Infof("Reverted: %s", reverts[i].desc)
+		}
+	}
+}
@@ -0,0 +1,328 @@
+package vpn
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+)
+
+var errTestNetwork = errors.New("test network error")
+
+// testNetworkState is the OS network configuration kept by testServerNetwork.
+type testNetworkState struct {
+	forwarding   map[bool]string
+	policy       map[bool]string
+	masquerading map[string]bool
+	forwarded    map[string]bool
+	blocked      map[string]bool
+	tunAddrs     map[string]bool
+}
+
+// testServerNetwork keeps network configuration in memory. Method named by `fail` returns error,
+// SetForwardingValue and SetForwardPolicy are named along with the family, e.g. SetForwardPolicy/true for IPv6.
+type testServerNetwork struct {
+	mx    sync.Mutex
+	state testNetworkState
+	fail  string
+}
+
+func newTestServerNetwork() *testServerNetwork {
+	return &testServerNetwork{
+		state: testNetworkState{
+			forwarding:   map[bool]string{false: "0", true: "0"},
+			policy:       map[bool]string{false: forwardPolicyDrop, true: forwardPolicyDrop},
+			masquerading: make(map[string]bool),
+			forwarded:    make(map[string]bool),
+			blocked:      make(map[string]bool),
+			tunAddrs:     make(map[string]bool),
+		},
+	}
+}
+
+// snapshot copies current state, leaving out false entries.
+func (n *testServerNetwork) snapshot() testNetworkState {
+	n.mx.Lock()
+	defer n.mx.Unlock()
+
+	cp := func(m map[string]bool) map[string]bool {
+		res := make(map[string]bool)
+		for k, v := range m {
+			if v {
+				res[k] = v
+			}
+		}
+
+		return res
+	}
+
+	return testNetworkState{
+		forwarding:   map[bool]string{false: n.state.forwarding[false], true: n.state.forwarding[true]},
+		policy:       map[bool]string{false: n.state.policy[false], true: n.state.policy[true]},
+		masquerading: cp(n.state.masquerading),
+		forwarded:    cp(n.state.forwarded),
+		blocked:      cp(n.state.blocked),
+		tunAddrs:     cp(n.state.tunAddrs),
+	}
+}
+
+func (n *testServerNetwork) call(method string) error {
+	if n.fail == method {
+		return errTestNetwork
+	}
+
+	return nil
+}
+
+// set sets `m[key]` to `val`, failing if it's already set, the way the OS refuses
+// to add existing rule or delete missing one.
+func (n *testServerNetwork) set(method string, m map[string]bool, key string, val bool) error {
+	n.mx.Lock()
+	defer n.mx.Unlock()
+
+	if err := n.call(method); err != nil {
+		return err
+	}
+
+	if m[key] == val {
+		return fmt.Errorf("%s: %s is already %v", method, key, val)
+	}
+
+	m[key] = val
+
+	return nil
+}
+
+func (n *testServerNetwork) DefaultNetworkInterface(ipv6 bool) (string, error) {
+	if ipv6 {
+		return "eth6", n.call("DefaultNetworkInterface")
+	}
+
+	return "eth0", n.call("DefaultNetworkInterface")
+}
+
+func (n *testServerNetwork) NetworkInterfaceIPs(_ string) ([]net.IP, error) {
+	return []net.IP{net.IPv4(10, 0, 0, 2)}, n.call("NetworkInterfaceIPs")
+}
+
+func (n *testServerNetwork) ForwardingValue(ipv6 bool) (string, error) {
+	n.mx.Lock()
+	defer n.mx.Unlock()
+
+	return n.state.forwarding[ipv6], n.call("ForwardingValue")
+}
+
+func (n *testServerNetwork) SetForwardingValue(ipv6 bool, val string) error {
+	n.mx.Lock()
+	defer n.mx.Unlock()
+
+	if err := n.call(fmt.Sprintf("SetForwardingValue/%v", ipv6)); err != nil {
+		return err
+	}
+
+	n.state.forwarding[ipv6] = val
+
+	return nil
+}
+
+func (n *testServerNetwork) ForwardPolicy(ipv6 bool) (string, error) {
+	n.mx.Lock()
+	defer n.mx.Unlock()
+
+	return n.state.policy[ipv6], n.call("ForwardPolicy")
+}
+
+func (n *testServerNetwork) SetForwardPolicy(ipv6 bool, policy string) error {
+	n.mx.Lock()
+	defer n.mx.Unlock()
+
+	if err := n.call(fmt.Sprintf("SetForwardPolicy/%v", ipv6)); err != nil {
+		return err
+	}
+
+	n.state.policy[ipv6] = policy
+
+	return nil
+}
+
+func (n *testServerNetwork) EnableMasquerading(ifcName string, ipv6 bool) error {
+	return n.set("EnableMasquerading", n.state.masquerading, fmt.Sprintf("%s/%v", ifcName, ipv6), true)
+}
+
+func (n *testServerNetwork) DisableMasquerading(ifcName string, ipv6 bool) error {
+	return n.set("DisableMasquerading", n.state.masquerading, fmt.Sprintf("%s/%v", ifcName, ipv6), false)
+}
+
+func (n *testServerNetwork) AllowForwarding(ifcName string, ipv6 bool) error {
+	return n.set("AllowForwarding", n.state.forwarded, fmt.Sprintf("%s/%v", ifcName, ipv6), true)
+}
+
+func (n *testServerNetwork) DisallowForwarding(ifcName string, ipv6 bool) error {
+	return n.set("DisallowForwarding", n.state.forwarded, fmt.Sprintf("%s/%v", ifcName, ipv6), false)
+}
+
+func (n *testServerNetwork) BlockIPToLocalNetwork(src, _ net.IP) error {
+	return n.set("BlockIPToLocalNetwork", n.state.blocked, src.String(), true)
+}
+
+func (n *testServerNetwork) AllowIPToLocalNetwork(src, _ net.IP) error {
+	return n.set("AllowIPToLocalNetwork", n.state.blocked, src.String(), false)
+}
+
+func (n *testServerNetwork) SetupTUN(_ string, _ int) error {
+	return n.call("SetupTUN")
+}
+
+func (n *testServerNetwork) AddTUNAddress(_, ipCIDR string) error {
+	return n.set("AddTUNAddress", n.state.tunAddrs, ipCIDR, true)
+}
+
+func (n *testServerNetwork) DeleteTUNAddress(_, ipCIDR string) error {
+	return n.set("DeleteTUNAddress", n.state.tunAddrs, ipCIDR, false)
+}
+
+func TestServer_setupNetwork(t *testing.T) {
+	t.Run("rollback", func(t *testing.T) {
+		osNet := newTestServerNetwork()
+		initial := osNet.snapshot()
+
+		s, err := newServer(ServerConfig{}, logrus.New(), osNet)
+		require.NoError(t, err)
+		require.True(t, s.ipv6)
+
+		require.NoError(t, s.setupNetwork())
+		require.True(t, s.ipv6)
+
+		state := osNet.snapshot()
+		require.Equal(t, map[bool]string{false: "1", true: "1"}, state.forwarding)
+		require.Equal(t, map[bool]string{false: forwardPolicyAccept, true: forwardPolicyAccept}, state.policy)
+		require.Equal(t, map[string]bool{"eth0/false": true, "eth6/true": true}, state.masquerading)
+
+		s.rollback.run(s.log)
+		require.Equal(t, initial, osNet.snapshot())
+	})
+
+	t.Run("failure reverts previous changes", func(t *testing.T) {
+		osNet := newTestServerNetwork()
+		initial := osNet.snapshot()
+
+		s, err := newServer(ServerConfig{}, logrus.New(), osNet)
+		require.NoError(t, err)
+
+		osNet.fail = "SetForwardPolicy/false"
+		require.Error(t, s.setupNetwork())
+
+		osNet.fail = ""
+		s.rollback.run(s.log)
+		require.Equal(t, initial, osNet.snapshot())
+	})
+
+	t.Run("IPv6 failure keeps IPv4", func(t *testing.T) {
+		osNet := newTestServerNetwork()
+		initial := osNet.snapshot()
+
+		s, err := newServer(ServerConfig{}, logrus.New(), osNet)
+		require.NoError(t, err)
+
+		osNet.fail = "SetForwardPolicy/true"
+		require.NoError(t, s.setupNetwork())
+		require.False(t, s.ipv6)
+
+		state := osNet.snapshot()
+		require.Equal(t, map[string]bool{"eth0/false": true}, state.masquerading)
+		require.Equal(t, forwardPolicyDrop, state.policy[true])
+
+		osNet.fail = ""
+		s.rollback.run(s.log)
+		require.Equal(t, initial, osNet.snapshot())
+	})
+
+	t.Run("IPv6 forwarding failure keeps IPv4", func(t *testing.T) {
+		osNet := newTestServerNetwork()
+		initial := osNet.snapshot()
+
+		s, err := newServer(ServerConfig{}, logrus.New(), osNet)
+		require.NoError(t, err)
+
+		osNet.fail = "SetForwardingValue/true"
+		require.NoError(t, s.setupNetwork())
+		require.False(t, s.ipv6)
+
+		state := osNet.snapshot()
+		require.Equal(t, map[bool]string{false: "1", true: "0"}, state.forwarding)
+		require.Equal(t, map[string]bool{"eth0/false": true}, state.masquerading)
+
+		osNet.fail = ""
+		s.rollback.run(s.log)
+		require.Equal(t, initial, osNet.snapshot())
+	})
+}
+
+func TestServer_allowForwarding(t *testing.T) {
+	osNet := newTestServerNetwork()
+	initial := osNet.snapshot()
+
+	s, err := newServer(ServerConfig{}, logrus.New(), osNet)
+	require.NoError(t, err)
+
+	s.allowForwarding("tun0")
+	require.Equal(t, map[string]bool{"tun0/false": true, "tun0/true": true}, osNet.snapshot().forwarded)
+
+	s.rollback.run(s.log)
+	require.Equal(t, initial, osNet.snapshot())
+
+	// failure isn't fatal and leaves nothing to revert
+	osNet.fail = "AllowForwarding"
+	s.allowForwarding("tun0")
+	require.Empty(t, osNet.snapshot().forwarded)
+
+	osNet.fail = ""
+	s.rollback.run(s.log)
+	require.Equal(t, initial, osNet.snapshot())
+}
+
+func TestServer_closeConns(t *testing.T) {
+	osNet := newTestServerNetwork()
+	initial := osNet.snapshot()
+
+	s, err := newServer(ServerConfig{Secure: true}, logrus.New(), osNet)
+	require.NoError(t, err)
+
+	tun := newTestTUN()
+	s.tun = tun
+
+	clientConn, srvConn := net.Pipe()
+
+	s.conns[srvConn] = struct{}{}
+	s.connsWG.Add(1)
+	go func() {
+		defer s.connsWG.Done()
+		s.serveConn(srvConn)
+	}()
+
+	require.NoError(t, WriteJSON(clientConn, &ClientHello{}))
+
+	var sHello ServerHello
+	require.NoError(t, ReadJSON(clientConn, &sHello))
+	require.Equal(t, HandshakeStatusOK, sHello.Status)
+
+	require.Eventually(t, func() bool {
+		_, ok := s.sessions.get(sHello.TUNIP)
+		return ok
+	}, time.Second, 10*time.Millisecond)
+
+	state := osNet.snapshot()
+	require.Len(t, state.tunAddrs, 2)
+	require.Equal(t, map[string]bool{sHello.TUNIP.String(): true, sHello.TUNIPv6.String(): true}, state.blocked)
+
+	s.closeConns()
+
+	require.Equal(t, initial, osNet.snapshot())
+	_, ok := s.sessions.get(sHello.TUNIP)
+	require.False(t, ok)
+}
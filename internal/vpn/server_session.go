@@ -0,0 +1,127 @@
+package vpn
+
+import (
+	"net"
+	"sync"
+)
+
+// sessionPacketsBufSize is the number of packets from TUN buffered for each client.
+// Packets for a client which doesn't keep up are dropped, so it can't hold back others.
+const sessionPacketsBufSize = 256
+
+// clientSession is a client connected to the server. All clients share the server TUN,
+// packets read from it are passed to the client by their destination address.
+type clientSession struct {
+	conn net.Conn
+	// subnet is handed out by IPGenerator, all the addresses below are within it
+	subnet     net.IP
+	tunIP      net.IP // server-side TUN IP, added to the shared TUN
+	tunIPv6    net.IP
+	clientIP   net.IP
+	clientIPv6 net.IP
+	// dnsIPs are addresses the DNS forwarder answers the client on, if enabled
+	dnsIPs []net.IP
+
+	tunAddrs    []string // addresses added to the shared TUN
+	unsecureVPN func()
+
+	packets chan []byte
+	done    chan struct{}
+}
+
+func newClientSession(conn net.Conn) *clientSession {
+	return &clientSession{
+		conn:        conn,
+		unsecureVPN: func() {},
+		packets:     make(chan []byte, sessionPacketsBufSize),
+		done:        make(chan struct{}),
+	}
+}
+
+// clientIPs returns addresses the client sends packets from.
+func (sess *clientSession) clientIPs() []net.IP {
+	if sess.clientIPv6 == nil {
+		return []net.IP{sess.clientIP}
+	}
+
+	return []net.IP{sess.clientIP, sess.clientIPv6}
+}
+
+// ownsIP checks whether `ip` is one of the client addresses.
+func (sess *clientSession) ownsIP(ip net.IP) bool {
+	for _, clientIP := range sess.clientIPs() {
+		if clientIP.Equal(ip) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// isDNSIP checks whether `ip` is one the DNS forwarder answers the client on.
+func (sess *clientSession) isDNSIP(ip net.IP) bool {
+	for _, dnsIP := range sess.dnsIPs {
+		if dnsIP.Equal(ip) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// push queues `packet` to be sent to the client, dropping it if the queue is full.
+func (sess *clientSession) push(packet []byte) {
+	select {
+	case sess.packets <- packet:
+	default:
+	}
+}
+
+// sessionTable maps client addresses to their sessions.
+type sessionTable struct {
+	mx       sync.RWMutex
+	sessions map[[net.IPv6len]byte]*clientSession
+}
+
+func newSessionTable() *sessionTable {
+	return &sessionTable{
+		sessions: make(map[[net.IPv6len]byte]*clientSession),
+	}
+}
+
+func sessionKey(ip net.IP) [net.IPv6len]byte {
+	var key [net.IPv6len]byte
+	copy(key[:], ip.To16())
+
+	return key
+}
+
+func (t *sessionTable) add(sess *clientSession) {
+	t.mx.Lock()
+	defer t.mx.Unlock()
+
+	for _, ip := range sess.clientIPs() {
+		t.sessions[sessionKey(ip)] = sess
+	}
+}
+
+func (t *sessionTable) remove(sess *clientSession) {
+	t.mx.Lock()
+	defer t.mx.Unlock()
+
+	for _, ip := range sess.clientIPs() {
+		key := sessionKey(ip)
+		if t.sessions[key] == sess {
+			delete(t.sessions, key)
+		}
+	}
+}
+
+func (t *sessionTable) get(ip net.IP) (*clientSession, bool) {
+	t.mx.RLock()
+	defer t.mx.RUnlock()
+
+	sess, ok := t.sessions[sessionKey(ip)]
+
+	return sess, ok
+}
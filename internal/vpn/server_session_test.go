@@ -0,0 +1,131 @@
+package vpn
+
+import (
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+)
+
+// testTUN is a TUN device fed with packets through `in`, packets written to it go to `out`.
+type testTUN struct {
+	in  chan []byte
+	out chan []byte
+}
+
+func newTestTUN() *testTUN {
+	return &testTUN{
+		in:  make(chan []byte),
+		out: make(chan []byte, 10),
+	}
+}
+
+func (t *testTUN) Read(p []byte) (int, error) {
+	packet, ok := <-t.in
+	if !ok {
+		return 0, io.EOF
+	}
+
+	return copy(p, packet), nil
+}
+
+func (t *testTUN) Write(p []byte) (int, error) {
+	packet := make([]byte, len(p))
+	copy(packet, p)
+	t.out <- packet
+
+	return len(p), nil
+}
+
+func (t *testTUN) Close() error { return nil }
+
+func (t *testTUN) Name() string { return "tun-test" }
+
+// ipv4Packet makes a minimal IPv4 packet from `src` to `dst`.
+func ipv4Packet(src, dst net.IP) []byte {
+	p := make([]byte, ipv4HeaderLen)
+	p[0] = 4 << 4
+	copy(p[12:16], src.To4())
+	copy(p[16:20], dst.To4())
+
+	return p
+}
+
+func TestServer_serveTUN(t *testing.T) {
+	tun := newTestTUN()
+	s := &Server{log: logrus.New(), tun: tun, sessions: newSessionTable()}
+
+	sess1 := newClientSession(nil)
+	sess1.clientIP = net.IPv4(192, 168, 2, 12)
+	sess1.clientIPv6 = net.ParseIP("fd01:203:405::c0a8:20c")
+
+	sess2 := newClientSession(nil)
+	sess2.clientIP = net.IPv4(192, 168, 2, 20)
+
+	s.sessions.add(sess1)
+	s.sessions.add(sess2)
+
+	go s.serveTUN()
+	defer close(tun.in)
+
+	remote := net.IPv4(1, 1, 1, 1)
+
+	tun.in <- ipv4Packet(remote, sess2.clientIP)
+	tun.in <- ipv4Packet(remote, sess1.clientIP)
+	tun.in <- ipv4Packet(remote, net.IPv4(192, 168, 2, 28)) // unknown client, dropped
+
+	v6Packet := make([]byte, ipv6HeaderLen)
+	v6Packet[0] = 6 << 4
+	copy(v6Packet[24:40], sess1.clientIPv6)
+	tun.in <- v6Packet
+
+	require.Equal(t, ipv4Packet(remote, sess2.clientIP), <-sess2.packets)
+	require.Equal(t, ipv4Packet(remote, sess1.clientIP), <-sess1.packets)
+	require.Equal(t, v6Packet, <-sess1.packets)
+
+	// after the client is gone, its packets are dropped
+	s.sessions.remove(sess2)
+	tun.in <- ipv4Packet(remote, sess2.clientIP)
+	tun.in <- ipv4Packet(remote, sess1.clientIP)
+
+	require.Equal(t, ipv4Packet(remote, sess1.clientIP), <-sess1.packets)
+	require.Len(t, sess2.packets, 0)
+}
+
+func TestServer_copyConnToTUN(t *testing.T) {
+	tun := newTestTUN()
+	s := &Server{log: logrus.New(), tun: tun, sessions: newSessionTable()}
+
+	clientConn, srvConn := net.Pipe()
+
+	sess := newClientSession(srvConn)
+	sess.clientIP = net.IPv4(192, 168, 2, 12)
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- s.copyConnToTUN(sess)
+	}()
+
+	remote := net.IPv4(1, 1, 1, 1)
+
+	// packet from another client's address is dropped
+	_, err := clientConn.Write(ipv4Packet(net.IPv4(192, 168, 2, 20), remote))
+	require.NoError(t, err)
+
+	_, err = clientConn.Write(ipv4Packet(sess.clientIP, remote))
+	require.NoError(t, err)
+
+	select {
+	case packet := <-tun.out:
+		require.Equal(t, ipv4Packet(sess.clientIP, remote), packet)
+	case <-time.After(time.Second):
+		t.Fatal("packet wasn't written to TUN")
+	}
+
+	require.NoError(t, clientConn.Close())
+	require.NoError(t, <-errCh)
+	require.Len(t, tun.out, 0)
+}
@@ -0,0 +1,189 @@
+package vpn
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+)
+
+// routeResolveTimeout is the time given to resolve all hostnames of the split tunneling routes.
+const routeResolveTimeout = 10 * time.Second
+
+// ipLookupFunc resolves IPv4 and IPv6 addresses of `host`.
+type ipLookupFunc func(ctx context.Context, host string) ([]net.IP, error)
+
+func lookupIP(ctx context.Context, host string) ([]net.IP, error) {
+	return net.DefaultResolver.LookupIP(ctx, "ip", host)
+}
+
+// ParseRoutes parses comma-separated list of split tunneling routes.
+// Each route is a CIDR, an IP address or a hostname.
+func ParseRoutes(s string) ([]string, error) {
+	if strings.TrimSpace(s) == "" {
+		return nil, nil
+	}
+
+	routes := strings.Split(s, ",")
+	for i := range routes {
+		routes[i] = strings.TrimSpace(routes[i])
+
+		if err := ValidateRoute(routes[i]); err != nil {
+			return nil, fmt.Errorf("invalid route %q: %w", routes[i], err)
+		}
+	}
+
+	return routes, nil
+}
+
+// ValidateRoute checks that `route` is a CIDR, an IP address or a hostname.
+func ValidateRoute(route string) error {
+	if route == "" {
+		return errEmptyRoute
+	}
+
+	if strings.Contains(route, "/") {
+		_, _, err := net.ParseCIDR(route)
+		return err
+	}
+
+	if net.ParseIP(route) != nil {
+		return nil
+	}
+
+	if !isHostname(route) {
+		return errInvalidRoute
+	}
+
+	return nil
+}
+
+func isHostname(s string) bool {
+	if len(s) > 253 {
+		return false
+	}
+
+	for _, label := range strings.Split(strings.TrimSuffix(s, "."), ".") {
+		if label == "" || len(label) > 63 || label[0] == '-' || label[len(label)-1] == '-' {
+			return false
+		}
+
+		for _, c := range label {
+			isAlnum := (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+			if !isAlnum && c != '-' {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+// resolveRoutes turns `routes` into CIDRs. Hostnames are resolved to all of their addresses,
+// hostnames which fail to resolve are skipped.
+func resolveRoutes(lookup ipLookupFunc, routes []string) []string {
+	ctx, cancel := context.WithTimeout(context.Background(), routeResolveTimeout)
+	defer cancel()
+
+	cidrs := make([]string, 0, len(routes))
+	for _, route := range routes {
+		if _, ipNet, err := net.ParseCIDR(route); err == nil {
+			cidrs = append(cidrs, ipNet.String())
+			continue
+		}
+
+		if ip := net.ParseIP(route); ip != nil {
+			cidrs = append(cidrs, hostRoute(ip))
+			continue
+		}
+
+		ips, err := lookup(ctx, route)
+		if err != nil {
+			fmt.Printf("Failed to resolve %s, skipping route: %v\n", route, err)
+			continue
+		}
+
+		for _, ip := range ips {
+			cidrs = append(cidrs, hostRoute(ip))
+		}
+	}
+
+	return filterOutEqualStrings(cidrs)
+}
+
+// filterOutExcludedRoutes drops included CIDRs which are equal to or contained in any of the
+// excluded CIDRs. Such routes would otherwise win over the exclusion by the longest prefix match,
+// or clash with it for the same prefix. Excluded CIDRs narrower than an included one don't need
+// this, they win on their own.
+func filterOutExcludedRoutes(included, excluded []string) []string {
+	excludedNets := make([]*net.IPNet, 0, len(excluded))
+	for _, route := range excluded {
+		if _, ipNet, err := net.ParseCIDR(route); err == nil {
+			excludedNets = append(excludedNets, ipNet)
+		}
+	}
+
+	filtered := make([]string, 0, len(included))
+	for _, route := range included {
+		_, inNet, err := net.ParseCIDR(route)
+		if err != nil {
+			filtered = append(filtered, route)
+			continue
+		}
+
+		if exNet := coveringNet(excludedNets, inNet); exNet != nil {
+			fmt.Printf("Route %s is excluded by %s, not routing it through VPN\n", route, exNet.String())
+			continue
+		}
+
+		filtered = append(filtered, route)
+	}
+
+	return filtered
+}
+
+// coveringNet returns the first of `nets` which is equal to or broader than `ipNet`.
+func coveringNet(nets []*net.IPNet, ipNet *net.IPNet) *net.IPNet {
+	ones, bits := ipNet.Mask.Size()
+	for _, n := range nets {
+		nOnes, nBits := n.Mask.Size()
+		if nBits == bits && nOnes <= ones && n.Contains(ipNet.IP) {
+			return n
+		}
+	}
+
+	return nil
+}
+
+// hostRoute makes route to the single `ip`.
+func hostRoute(ip net.IP) string {
+	if ip4 := ip.To4(); ip4 != nil {
+		return ip4.String() + directRouteNetmaskCIDR
+	}
+
+	return ip.String() + directRouteNetmaskCIDRv6
+}
+
+func filterOutEqualStrings(strs []string) []string {
+	set := make(map[string]struct{}, len(strs))
+	filtered := make([]string, 0, len(strs))
+	for _, s := range strs {
+		if _, ok := set[s]; !ok {
+			filtered = append(filtered, s)
+			set[s] = struct{}{}
+		}
+	}
+
+	return filtered
+}
+
+func containsString(strs []string, s string) bool {
+	for _, str := range strs {
+		if str == s {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,88 @@
+package vpn
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRoutes(t *testing.T) {
+	tests := []struct {
+		name    string
+		s       string
+		want    []string
+		wantErr bool
+	}{
+		{
+			name: "empty",
+			s:    " ",
+		},
+		{
+			name: "CIDR, IP and hostname",
+			s:    "10.0.0.0/8, 192.168.1.1,corp.example.com",
+			want: []string{"10.0.0.0/8", "192.168.1.1", "corp.example.com"},
+		},
+		{
+			name: "IPv6 CIDR and IP",
+			s:    "fd00::/8,2001:db8::1",
+			want: []string{"fd00::/8", "2001:db8::1"},
+		},
+		{
+			name:    "empty entry",
+			s:       "10.0.0.0/8,,",
+			wantErr: true,
+		},
+		{
+			name:    "invalid hostname",
+			s:       "corp example.com",
+			wantErr: true,
+		},
+		{
+			name:    "invalid CIDR",
+			s:       "10.0.0.0/33",
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			routes, err := ParseRoutes(tc.s)
+			if tc.wantErr {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tc.want, routes)
+		})
+	}
+}
+
+func TestResolveRoutes(t *testing.T) {
+	lookup := func(_ context.Context, host string) ([]net.IP, error) {
+		switch host {
+		case "corp.example.com":
+			return []net.IP{net.IPv4(10, 1, 2, 3), net.IPv4(10, 1, 2, 4), net.ParseIP("2001:db8::3")}, nil
+		case "dup.example.com":
+			return []net.IP{net.IPv4(192, 168, 1, 1)}, nil
+		default:
+			return nil, errors.New("no such host")
+		}
+	}
+
+	routes := []string{"10.0.0.1/8", "192.168.1.1", "2001:db8::1", "corp.example.com", "dup.example.com", "unknown.example.com"}
+	want := []string{"10.0.0.0/8", "192.168.1.1/32", "2001:db8::1/128", "10.1.2.3/32", "10.1.2.4/32", "2001:db8::3/128"}
+
+	require.Equal(t, want, resolveRoutes(lookup, routes))
+}
+
+func TestFilterOutExcludedRoutes(t *testing.T) {
+	included := []string{"10.0.0.0/8", "192.168.1.0/24", "192.168.2.5/32", "172.16.0.0/12", "::/1", "2001:db8::1/128"}
+	excluded := []string{"10.0.0.0/8", "192.168.0.0/16", "172.16.1.0/24", "2001:db8::/32"}
+	want := []string{"172.16.0.0/12", "::/1"}
+
+	require.Equal(t, want, filterOutExcludedRoutes(included, excluded))
+}
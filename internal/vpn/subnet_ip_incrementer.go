@@ -14,7 +14,9 @@ type subnetIPIncrementer struct {
 	octetLowerBorders [4]uint8
 	octetBorders      [4]uint8
 	step              uint8
-	reserved          map[[4]uint8]struct{}
+	// reserved are IPs which are never generated, allocated are subnets handed out by next.
+	reserved  map[[4]uint8]struct{}
+	allocated map[[4]uint8]struct{}
 }
 
 func newSubnetIPIncrementer(octetLowerBorders, octetBorders [4]uint8, step uint8) *subnetIPIncrementer {
@@ -25,6 +27,7 @@ func newSubnetIPIncrementer(octetLowerBorders, octetBorders [4]uint8, step uint8
 		octetBorders:      octetBorders,
 		step:              step,
 		reserved:          make(map[[4]uint8]struct{}),
+		allocated:         make(map[[4]uint8]struct{}),
 	}
 }
 
@@ -63,12 +66,17 @@ func (inc *subnetIPIncrementer) next() (net.IP, error) {
 							isReserved = true
 							break
 						}
+
+						if _, ok := inc.allocated[generatedIP]; ok {
+							isReserved = true
+							break
+						}
 					}
 
 					if !isReserved {
 						generatedIP[3] = o4
 						inc.octets[3] = o4
-						inc.reserved[generatedIP] = struct{}{}
+						inc.allocated[generatedIP] = struct{}{}
 
 						return net.IPv4(generatedIP[0], generatedIP[1], generatedIP[2], generatedIP[3]), nil
 					}
@@ -121,3 +129,11 @@ func (inc *subnetIPIncrementer) reserve(octets [4]uint8) {
 
 	inc.reserved[octets] = struct{}{}
 }
+
+// release makes subnet `octets` handed out by next available again. Reserved IPs stay reserved.
+func (inc *subnetIPIncrementer) release(octets [4]uint8) {
+	inc.mx.Lock()
+	defer inc.mx.Unlock()
+
+	delete(inc.allocated, octets)
+}
@@ -0,0 +1,327 @@
+// Package appbundle implements signed bundles of third-party skywire apps.
+//
+// A bundle is a gzipped tar archive of three files:
+//   - manifest.json describing the app and the SHA256 checksum of its binary;
+//   - manifest.json.sig with a hex-encoded signature of the manifest;
+//   - the app binary named after the app.
+package appbundle
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"regexp"
+	"runtime"
+	"strings"
+
+	"github.com/skycoin/dmsg/cipher"
+
+	"github.com/skycoin/skywire/pkg/app/appcommon"
+	"github.com/skycoin/skywire/pkg/routing"
+	"github.com/skycoin/skywire/pkg/util/updater"
+)
+
+const (
+	// ManifestFilename is the name of the manifest file within a bundle.
+	ManifestFilename = "manifest.json"
+	// SignatureFilename is the name of the manifest signature file within a bundle.
+	SignatureFilename = ManifestFilename + ".sig"
+
+	// maxBundleFileSize limits size of a single bundle file.
+	maxBundleFileSize = 256 << 20
+)
+
+// Permissions an app may require. They have to be granted explicitly when the app is installed,
+// granted permissions are kept in the app config and define the app sandbox, see Sandbox.
+const (
+	// PermNetAdmin is required by apps which configure network interfaces, routes or firewall.
+	// Such apps can't be run under a separate user.
+	PermNetAdmin = "net_admin"
+	// PermFilesystem is required by apps which access files outside of their working directory.
+	PermFilesystem = "filesystem"
+)
+
+// AppUID is the unprivileged user (nobody) bundled apps are run as unless they are granted
+// net_admin or filesystem permission.
+const AppUID = 65534
+
+var knownPermissions = map[string]struct{}{
+	PermNetAdmin:   {},
+	PermFilesystem: {},
+}
+
+var (
+	// ErrNoBundleKeys is returned when no bundle public keys are configured, so no bundle can be verified.
+	ErrNoBundleKeys = errors.New("no app bundle public keys configured")
+	// ErrInvalidSignature is returned when the manifest is not signed by any of bundle public keys.
+	ErrInvalidSignature = errors.New("app bundle signature is not valid")
+	// ErrChecksumMismatch is returned when the app binary doesn't match the manifest checksum.
+	ErrChecksumMismatch = errors.New("app binary checksum mismatch")
+	// ErrPlatformMismatch is returned when the bundle is built for a different platform.
+	ErrPlatformMismatch = errors.New("app bundle is built for a different platform")
+	// ErrPermissionNotGranted is returned when a permission required by the app is not granted.
+	ErrPermissionNotGranted = errors.New("app permission is not granted")
+)
+
+var appNameRe = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9._-]*$`)
+
+// Manifest describes an app shipped in a bundle.
+type Manifest struct {
+	Name        string       `json:"name"`
+	Version     string       `json:"version"`
+	Port        routing.Port `json:"port"`
+	Args        []string     `json:"args,omitempty"`
+	Permissions []string     `json:"permissions,omitempty"`
+	OS          string       `json:"os"`
+	Arch        string       `json:"arch"`
+	SHA256      string       `json:"sha256"` // hex-encoded checksum of the binary
+}
+
+// Validate checks that the manifest is well-formed.
+func (m *Manifest) Validate() error {
+	if !appNameRe.MatchString(m.Name) {
+		return fmt.Errorf("invalid app name %q", m.Name)
+	}
+
+	if _, err := updater.VersionFromString(m.Version); err != nil {
+		return fmt.Errorf("invalid app version %q: %w", m.Version, err)
+	}
+
+	for _, perm := range m.Permissions {
+		if _, ok := knownPermissions[perm]; !ok {
+			return fmt.Errorf("unknown app permission %q", perm)
+		}
+	}
+
+	if _, err := hex.DecodeString(m.SHA256); err != nil || len(m.SHA256) != sha256.Size*2 {
+		return fmt.Errorf("invalid app binary checksum %q", m.SHA256)
+	}
+
+	return nil
+}
+
+// CheckPermissions checks that all permissions required by the app are in `granted`.
+func (m *Manifest) CheckPermissions(granted []string) error {
+	grantedSet := make(map[string]struct{}, len(granted))
+	for _, perm := range granted {
+		grantedSet[perm] = struct{}{}
+	}
+
+	for _, perm := range m.Permissions {
+		if _, ok := grantedSet[perm]; !ok {
+			return fmt.Errorf("%w: %s", ErrPermissionNotGranted, perm)
+		}
+	}
+
+	return nil
+}
+
+// Sandbox returns the sandbox a bundled app with `granted` permissions is run in, based on `conf`
+// set by the visor operator, which may be nil. Resource limits of `conf` are kept as is.
+// Apps granted neither net_admin nor filesystem are run as AppUID (unless `conf` sets another user)
+// in a private working directory. Apps granted any of them run as the visor user, as they need its privileges.
+// Confining apps requires a linux visor running as root, apps fail to start otherwise.
+func Sandbox(granted []string, conf *appcommon.SandboxConfig) *appcommon.SandboxConfig {
+	var sb appcommon.SandboxConfig
+	if conf != nil {
+		sb = *conf
+	}
+
+	for _, perm := range granted {
+		if perm == PermNetAdmin || perm == PermFilesystem {
+			return &sb
+		}
+	}
+
+	if sb.UID == nil {
+		uid := uint32(AppUID)
+		sb.UID = &uid
+	}
+
+	sb.PrivateDir = true
+
+	return &sb
+}
+
+// NewerThan returns whether the manifest version is newer than `version`.
+func (m *Manifest) NewerThan(version string) bool {
+	v1, err1 := updater.VersionFromString(m.Version)
+	v2, err2 := updater.VersionFromString(version)
+
+	if err1 != nil || err2 != nil {
+		return false
+	}
+
+	return v1.Cmp(v2) > 0
+}
+
+// Bundle is a verified app bundle.
+type Bundle struct {
+	Manifest Manifest
+	Binary   []byte
+}
+
+// Create creates a bundle of `binary` described by `m` and signed with `sk`.
+// Checksum of the binary is filled in the manifest.
+func Create(m Manifest, binary []byte, sk cipher.SecKey) ([]byte, error) {
+	sum := sha256.Sum256(binary)
+	m.SHA256 = hex.EncodeToString(sum[:])
+
+	if err := m.Validate(); err != nil {
+		return nil, err
+	}
+
+	manifest, err := json.MarshalIndent(m, "", "\t")
+	if err != nil {
+		return nil, err
+	}
+
+	sig, err := cipher.SignPayload(manifest, sk)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	gw := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gw)
+
+	files := []struct {
+		name string
+		mode int64
+		data []byte
+	}{
+		{ManifestFilename, 0644, manifest},
+		{SignatureFilename, 0644, []byte(sig.Hex())},
+		{m.Name, 0755, binary},
+	}
+
+	for _, f := range files {
+		hdr := &tar.Header{Name: f.name, Mode: f.mode, Size: int64(len(f.data))}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return nil, err
+		}
+
+		if _, err := tw.Write(f.data); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return nil, err
+	}
+
+	if err := gw.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// Read reads a bundle from `r` and verifies that it's signed by one of `keys`
+// and built for the current platform.
+func Read(r io.Reader, keys []cipher.PubKey) (*Bundle, error) {
+	if len(keys) == 0 {
+		return nil, ErrNoBundleKeys
+	}
+
+	files, err := readFiles(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read app bundle: %w", err)
+	}
+
+	manifest, ok := files[ManifestFilename]
+	if !ok {
+		return nil, fmt.Errorf("app bundle has no %s", ManifestFilename)
+	}
+
+	if err := verify(manifest, string(files[SignatureFilename]), keys); err != nil {
+		return nil, err
+	}
+
+	var b Bundle
+	if err := json.Unmarshal(manifest, &b.Manifest); err != nil {
+		return nil, fmt.Errorf("failed to decode manifest: %w", err)
+	}
+
+	if err := b.Manifest.Validate(); err != nil {
+		return nil, err
+	}
+
+	if b.Manifest.OS != runtime.GOOS || b.Manifest.Arch != runtime.GOARCH {
+		return nil, fmt.Errorf("%w: %s/%s", ErrPlatformMismatch, b.Manifest.OS, b.Manifest.Arch)
+	}
+
+	b.Binary, ok = files[b.Manifest.Name]
+	if !ok {
+		return nil, fmt.Errorf("app bundle has no binary %s", b.Manifest.Name)
+	}
+
+	sum := sha256.Sum256(b.Binary)
+	if hex.EncodeToString(sum[:]) != strings.ToLower(b.Manifest.SHA256) {
+		return nil, ErrChecksumMismatch
+	}
+
+	return &b, nil
+}
+
+func readFiles(r io.Reader) (map[string][]byte, error) {
+	gr, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		_ = gr.Close() // nolint:errcheck
+	}()
+
+	files := make(map[string][]byte)
+	tr := tar.NewReader(gr)
+
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return files, nil
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		if hdr.Size > maxBundleFileSize {
+			return nil, fmt.Errorf("file %s is too large", hdr.Name)
+		}
+
+		data, err := ioutil.ReadAll(io.LimitReader(tr, maxBundleFileSize))
+		if err != nil {
+			return nil, err
+		}
+
+		files[hdr.Name] = data
+	}
+}
+
+func verify(manifest []byte, signature string, keys []cipher.PubKey) error {
+	var sig cipher.Sig
+	if err := sig.UnmarshalText([]byte(strings.TrimSpace(signature))); err != nil {
+		return ErrInvalidSignature
+	}
+
+	for _, pk := range keys {
+		if err := cipher.VerifyPubKeySignedPayload(pk, sig, manifest); err == nil {
+			return nil
+		}
+	}
+
+	return ErrInvalidSignature
+}
@@ -0,0 +1,154 @@
+package appbundle
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"runtime"
+	"testing"
+
+	"github.com/skycoin/dmsg/cipher"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/skycoin/skywire/pkg/app/appcommon"
+)
+
+func testManifest() Manifest {
+	return Manifest{
+		Name:        "hello",
+		Version:     "v1.0.0",
+		Port:        100,
+		Args:        []string{"-v"},
+		Permissions: []string{PermFilesystem},
+		OS:          runtime.GOOS,
+		Arch:        runtime.GOARCH,
+	}
+}
+
+// replaceFile returns `bundle` with file `name` replaced by `data`.
+func replaceFile(t *testing.T, bundle []byte, name string, data []byte) []byte {
+	files, err := readFiles(bytes.NewReader(bundle))
+	require.NoError(t, err)
+
+	files[name] = data
+
+	var buf bytes.Buffer
+	gw := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gw)
+
+	for name, data := range files {
+		require.NoError(t, tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(data))}))
+		_, err := tw.Write(data)
+		require.NoError(t, err)
+	}
+
+	require.NoError(t, tw.Close())
+	require.NoError(t, gw.Close())
+
+	return buf.Bytes()
+}
+
+func TestBundle(t *testing.T) {
+	pk, sk := cipher.GenerateKeyPair()
+	otherPK, _ := cipher.GenerateKeyPair()
+
+	binary := []byte("binary")
+
+	bundle, err := Create(testManifest(), binary, sk)
+	require.NoError(t, err)
+
+	t.Run("valid", func(t *testing.T) {
+		b, err := Read(bytes.NewReader(bundle), []cipher.PubKey{otherPK, pk})
+		require.NoError(t, err)
+		assert.Equal(t, binary, b.Binary)
+		assert.Equal(t, "hello", b.Manifest.Name)
+		assert.Len(t, b.Manifest.SHA256, 64)
+	})
+
+	t.Run("no keys", func(t *testing.T) {
+		_, err := Read(bytes.NewReader(bundle), nil)
+		assert.Equal(t, ErrNoBundleKeys, err)
+	})
+
+	t.Run("wrong key", func(t *testing.T) {
+		_, err := Read(bytes.NewReader(bundle), []cipher.PubKey{otherPK})
+		assert.Equal(t, ErrInvalidSignature, err)
+	})
+
+	t.Run("tampered binary", func(t *testing.T) {
+		tampered := replaceFile(t, bundle, "hello", []byte("malware"))
+
+		_, err := Read(bytes.NewReader(tampered), []cipher.PubKey{pk})
+		assert.Equal(t, ErrChecksumMismatch, err)
+	})
+
+	t.Run("tampered manifest", func(t *testing.T) {
+		tampered := replaceFile(t, bundle, ManifestFilename, []byte(`{"name":"hello"}`))
+
+		_, err := Read(bytes.NewReader(tampered), []cipher.PubKey{pk})
+		assert.Equal(t, ErrInvalidSignature, err)
+	})
+
+	t.Run("other platform", func(t *testing.T) {
+		m := testManifest()
+		m.OS = "plan9"
+
+		other, err := Create(m, binary, sk)
+		require.NoError(t, err)
+
+		_, err = Read(bytes.NewReader(other), []cipher.PubKey{pk})
+		assert.True(t, errors.Is(err, ErrPlatformMismatch))
+	})
+}
+
+func TestManifest(t *testing.T) {
+	_, sk := cipher.GenerateKeyPair()
+
+	t.Run("invalid", func(t *testing.T) {
+		for name, modify := range map[string]func(m *Manifest){
+			"name":       func(m *Manifest) { m.Name = "../hello" },
+			"version":    func(m *Manifest) { m.Version = "latest" },
+			"permission": func(m *Manifest) { m.Permissions = []string{"root"} },
+		} {
+			m := testManifest()
+			modify(&m)
+
+			_, err := Create(m, []byte("binary"), sk)
+			assert.Error(t, err, name)
+		}
+	})
+
+	t.Run("permissions", func(t *testing.T) {
+		m := testManifest()
+
+		assert.True(t, errors.Is(m.CheckPermissions(nil), ErrPermissionNotGranted))
+		assert.NoError(t, m.CheckPermissions([]string{PermFilesystem}))
+	})
+
+	t.Run("sandbox", func(t *testing.T) {
+		sb := Sandbox(nil, &appcommon.SandboxConfig{MemoryMax: 1 << 20})
+		require.NotNil(t, sb.UID)
+		assert.Equal(t, uint32(AppUID), *sb.UID)
+		assert.True(t, sb.PrivateDir)
+		assert.Equal(t, uint64(1<<20), sb.MemoryMax)
+
+		sb = Sandbox([]string{PermNetAdmin}, nil)
+		assert.Nil(t, sb.UID)
+		assert.False(t, sb.PrivateDir)
+
+		uid := uint32(1000)
+		sb = Sandbox(nil, &appcommon.SandboxConfig{UID: &uid})
+		assert.Equal(t, uid, *sb.UID)
+		assert.True(t, sb.PrivateDir)
+	})
+
+	t.Run("newer", func(t *testing.T) {
+		m := testManifest()
+
+		assert.True(t, m.NewerThan("v0.9.0"))
+		assert.False(t, m.NewerThan("v1.0.0"))
+		assert.False(t, m.NewerThan("v1.1.0"))
+	})
+}
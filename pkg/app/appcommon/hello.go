@@ -7,12 +7,29 @@ import (
 	"io"
 )
 
+// Protocols an app may use to talk to the app server after the hello.
+const (
+	// ProtocolRPC is Go net/rpc with gob encoding. It is used if no protocol is requested.
+	ProtocolRPC = "rpc"
+	// ProtocolJSON is length-prefixed JSON messages, described in docs/app-protocol.md.
+	ProtocolJSON = "json"
+)
+
 // Hello represents the first JSON object that an app sends the visor.
 type Hello struct {
 	ProcKey    ProcKey         `json:"proc_key"`              // proc key
 	EgressNet  string          `json:"egress_net,omitempty"`  // network which hosts the appevent.RPCGateway of the app
 	EgressAddr string          `json:"egress_addr,omitempty"` // address which hosts the appevent.RPCGateway of the app
 	EventSubs  map[string]bool `json:"event_subs,omitempty"`  // event subscriptions
+	Protocol   string          `json:"protocol,omitempty"`    // protocol used after the hello, ProtocolRPC if empty
+}
+
+// ProtocolOrDefault returns the requested protocol, defaulting to ProtocolRPC.
+func (h *Hello) ProtocolOrDefault() string {
+	if h.Protocol == "" {
+		return ProtocolRPC
+	}
+	return h.Protocol
 }
 
 // String implements fmt.Stringer
@@ -0,0 +1,43 @@
+package appcommon
+
+import "errors"
+
+var (
+	// ErrSandboxUnsupported is returned when an app sandbox is configured on a platform which doesn't support it.
+	ErrSandboxUnsupported = errors.New("app sandbox is only supported on linux")
+	// ErrSandboxUIDWithoutPrivateDir is returned when an app is configured to run as another user
+	// without the private working directory, the user wouldn't be able to write to it.
+	ErrSandboxUIDWithoutPrivateDir = errors.New("sandbox uid requires private_dir")
+)
+
+// DefaultCgroupRoot is the default cgroup v2 directory app cgroups are created in.
+// The directory should be delegated to the visor user if the visor doesn't run as root.
+const DefaultCgroupRoot = "/sys/fs/cgroup/skywire"
+
+// SandboxConfig restricts resources and privileges of an app process.
+// Sandboxing is only supported on Linux and requires cgroup v2 and Linux 5.7 or newer for memory, CPU and pids limits.
+// Rlimits are set by the visor binary re-executed as the app user, so they can't exceed hard limits of the visor.
+type SandboxConfig struct {
+	MemoryMax  uint64            `json:"memory_max,omitempty"`  // memory limit in bytes
+	CPUMax     float64           `json:"cpu_max,omitempty"`     // CPU limit in CPUs, e.g. 0.5
+	PidsMax    uint64            `json:"pids_max,omitempty"`    // limit of processes and threads
+	CgroupRoot string            `json:"cgroup_root,omitempty"` // cgroup v2 directory for app cgroups, /sys/fs/cgroup/skywire by default
+	Rlimits    map[string]uint64 `json:"rlimits,omitempty"`     // rlimits by name: as, core, cpu, data, fsize, memlock, nofile, nproc, stack
+	UID        *uint32           `json:"uid,omitempty"`         // user to run the app as, requires private_dir
+	GID        *uint32           `json:"gid,omitempty"`         // group to run the app as, same as uid if not set
+	PrivateDir bool              `json:"private_dir,omitempty"` // make the working directory owned by and accessible only to the app user
+}
+
+// Validate checks that the sandbox config is consistent.
+func (c *SandboxConfig) Validate() error {
+	if c.UID != nil && !c.PrivateDir {
+		return ErrSandboxUIDWithoutPrivateDir
+	}
+
+	return nil
+}
+
+// NeedsCgroup returns whether the sandbox requires an app cgroup.
+func (c *SandboxConfig) NeedsCgroup() bool {
+	return c.MemoryMax > 0 || c.CPUMax > 0 || c.PidsMax > 0
+}
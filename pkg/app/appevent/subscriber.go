@@ -31,31 +31,104 @@ func NewSubscriber() *Subscriber {
 	}
 }
 
-// OnTCPDial subscribes to the OnTCPDial event channel (if not already).
+// OnTCPDial subscribes to the TCPDial event channel (if not already).
 // And triggers the contained action func on each subsequent event.
 func (s *Subscriber) OnTCPDial(action func(data TCPDialData)) {
-	evCh := s.ensureEventChan(TCPDial)
-
-	go func() {
-		for ev := range evCh {
-			var data TCPDialData
-			ev.Unmarshal(&data)
-			action(data)
-			ev.Done()
-		}
-	}()
+	s.onEvent(TCPDial, func(ev *Event) {
+		var data TCPDialData
+		ev.Unmarshal(&data)
+		action(data)
+	})
 }
 
-// OnTCPClose subscribes to the OnTCPClose event channel (if not already).
+// OnTCPClose subscribes to the TCPClose event channel (if not already).
 // And triggers the contained action func on each subsequent event.
 func (s *Subscriber) OnTCPClose(action func(data TCPCloseData)) {
-	evCh := s.ensureEventChan(TCPClose)
+	s.onEvent(TCPClose, func(ev *Event) {
+		var data TCPCloseData
+		ev.Unmarshal(&data)
+		action(data)
+	})
+}
+
+// OnTransportUp subscribes to the TransportUp event channel (if not already).
+// And triggers the contained action func on each subsequent event.
+func (s *Subscriber) OnTransportUp(action func(data TransportUpData)) {
+	s.onEvent(TransportUp, func(ev *Event) {
+		var data TransportUpData
+		ev.Unmarshal(&data)
+		action(data)
+	})
+}
+
+// OnTransportDown subscribes to the TransportDown event channel (if not already).
+// And triggers the contained action func on each subsequent event.
+func (s *Subscriber) OnTransportDown(action func(data TransportDownData)) {
+	s.onEvent(TransportDown, func(ev *Event) {
+		var data TransportDownData
+		ev.Unmarshal(&data)
+		action(data)
+	})
+}
+
+// OnRouteGroupUp subscribes to the RouteGroupUp event channel (if not already).
+// And triggers the contained action func on each subsequent event.
+func (s *Subscriber) OnRouteGroupUp(action func(data RouteGroupUpData)) {
+	s.onEvent(RouteGroupUp, func(ev *Event) {
+		var data RouteGroupUpData
+		ev.Unmarshal(&data)
+		action(data)
+	})
+}
+
+// OnRouteGroupClose subscribes to the RouteGroupClose event channel (if not already).
+// And triggers the contained action func on each subsequent event.
+func (s *Subscriber) OnRouteGroupClose(action func(data RouteGroupCloseData)) {
+	s.onEvent(RouteGroupClose, func(ev *Event) {
+		var data RouteGroupCloseData
+		ev.Unmarshal(&data)
+		action(data)
+	})
+}
+
+// OnVisorShutdown subscribes to the VisorShutdown event channel (if not already).
+// And triggers the contained action func on each subsequent event.
+func (s *Subscriber) OnVisorShutdown(action func(data VisorShutdownData)) {
+	s.onEvent(VisorShutdown, func(ev *Event) {
+		var data VisorShutdownData
+		ev.Unmarshal(&data)
+		action(data)
+	})
+}
+
+// OnConfigChanged subscribes to the ConfigChanged event channel (if not already).
+// And triggers the contained action func on each subsequent event.
+func (s *Subscriber) OnConfigChanged(action func(data ConfigChangedData)) {
+	s.onEvent(ConfigChanged, func(ev *Event) {
+		var data ConfigChangedData
+		ev.Unmarshal(&data)
+		action(data)
+	})
+}
+
+// OnNetworkReady subscribes to the NetworkReady event channel (if not already).
+// And triggers the contained action func on each subsequent event.
+func (s *Subscriber) OnNetworkReady(action func(data NetworkReadyData)) {
+	s.onEvent(NetworkReady, func(ev *Event) {
+		var data NetworkReadyData
+		ev.Unmarshal(&data)
+		action(data)
+	})
+}
+
+// onEvent subscribes to the event channel of `eventType` (if not already).
+// And triggers `handle` on each subsequent event.
+func (s *Subscriber) onEvent(eventType string, handle func(ev *Event)) {
+	evCh := s.ensureEventChan(eventType)
 
 	go func() {
 		for ev := range evCh {
-			var data TCPCloseData
-			ev.Unmarshal(&data)
-			action(data)
+			handle(ev)
 			ev.Done()
 		}
 	}()
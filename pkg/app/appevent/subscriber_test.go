@@ -0,0 +1,49 @@
+package appevent
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/skycoin/dmsg/cipher"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSubscriber_PushEvent(t *testing.T) {
+	pk, _ := cipher.GenerateKeyPair()
+
+	t.Run("transport_up", func(t *testing.T) {
+		subs := NewSubscriber()
+		defer func() { require.NoError(t, subs.Close()) }()
+
+		want := TransportUpData{TpID: uuid.New(), TpType: "stcpr", RemotePK: pk}
+
+		var got TransportUpData
+		subs.OnTransportUp(func(data TransportUpData) { got = data })
+
+		// PushEvent returns once the handler is done.
+		require.NoError(t, PushEvent(subs, NewEvent(TransportUp, want)))
+		assert.Equal(t, want, got)
+	})
+
+	t.Run("visor_shutdown", func(t *testing.T) {
+		subs := NewSubscriber()
+		defer func() { require.NoError(t, subs.Close()) }()
+
+		var calls int
+		subs.OnVisorShutdown(func(VisorShutdownData) { calls++ })
+
+		require.NoError(t, PushEvent(subs, NewEvent(VisorShutdown, VisorShutdownData{})))
+		assert.Equal(t, 1, calls)
+	})
+
+	t.Run("not_subscribed", func(t *testing.T) {
+		subs := NewSubscriber()
+		defer func() { require.NoError(t, subs.Close()) }()
+
+		subs.OnNetworkReady(func(NetworkReadyData) { t.Error("unexpected network_ready event") })
+
+		require.NoError(t, PushEvent(subs, NewEvent(ConfigChanged, ConfigChangedData{App: "vpn-client"})))
+		assert.Equal(t, map[string]bool{NetworkReady: true}, subs.Subscriptions())
+	})
+}
@@ -1,10 +1,24 @@
 package appevent
 
+import (
+	"github.com/google/uuid"
+	"github.com/skycoin/dmsg/cipher"
+
+	"github.com/skycoin/skywire/pkg/routing"
+)
+
 // AllTypes returns all event types.
 func AllTypes() map[string]bool {
 	return map[string]bool{
-		TCPDial:  true,
-		TCPClose: true,
+		TCPDial:         true,
+		TCPClose:        true,
+		TransportUp:     true,
+		TransportDown:   true,
+		RouteGroupUp:    true,
+		RouteGroupClose: true,
+		VisorShutdown:   true,
+		ConfigChanged:   true,
+		NetworkReady:    true,
 	}
 }
 
@@ -31,3 +45,86 @@ type TCPCloseData struct {
 
 // Type returns the TCPClose type.
 func (TCPCloseData) Type() string { return TCPClose }
+
+// TransportUp represents a transport up event.
+const TransportUp = "transport_up"
+
+// TransportUpData contains transport up event data.
+type TransportUpData struct {
+	TpID     uuid.UUID     `json:"tp_id"`
+	TpType   string        `json:"tp_type"`
+	RemotePK cipher.PubKey `json:"remote_pk"`
+}
+
+// Type returns the TransportUp type.
+func (TransportUpData) Type() string { return TransportUp }
+
+// TransportDown represents a transport down event.
+const TransportDown = "transport_down"
+
+// TransportDownData contains transport down event data.
+type TransportDownData struct {
+	TpID     uuid.UUID     `json:"tp_id"`
+	TpType   string        `json:"tp_type"`
+	RemotePK cipher.PubKey `json:"remote_pk"`
+}
+
+// Type returns the TransportDown type.
+func (TransportDownData) Type() string { return TransportDown }
+
+// RouteGroupUp represents a route group established event.
+const RouteGroupUp = "route_group_up"
+
+// RouteGroupUpData contains route group established event data.
+type RouteGroupUpData struct {
+	RemotePK   cipher.PubKey `json:"remote_pk"`
+	LocalPort  routing.Port  `json:"local_port"`
+	RemotePort routing.Port  `json:"remote_port"`
+}
+
+// Type returns the RouteGroupUp type.
+func (RouteGroupUpData) Type() string { return RouteGroupUp }
+
+// RouteGroupClose represents a route group closed event.
+const RouteGroupClose = "route_group_close"
+
+// RouteGroupCloseData contains route group closed event data.
+type RouteGroupCloseData struct {
+	RemotePK   cipher.PubKey `json:"remote_pk"`
+	LocalPort  routing.Port  `json:"local_port"`
+	RemotePort routing.Port  `json:"remote_port"`
+}
+
+// Type returns the RouteGroupClose type.
+func (RouteGroupCloseData) Type() string { return RouteGroupClose }
+
+// VisorShutdown represents a visor shutdown event. It's sent before apps are stopped.
+const VisorShutdown = "visor_shutdown"
+
+// VisorShutdownData contains visor shutdown event data.
+type VisorShutdownData struct{}
+
+// Type returns the VisorShutdown type.
+func (VisorShutdownData) Type() string { return VisorShutdown }
+
+// ConfigChanged represents a visor config changed event.
+const ConfigChanged = "config_changed"
+
+// ConfigChangedData contains visor config changed event data.
+type ConfigChangedData struct {
+	App string `json:"app,omitempty"` // app whose config changed, if any
+}
+
+// Type returns the ConfigChanged type.
+func (ConfigChangedData) Type() string { return ConfigChanged }
+
+// NetworkReady represents a network ready event.
+const NetworkReady = "network_ready"
+
+// NetworkReadyData contains network ready event data.
+type NetworkReadyData struct {
+	Network string `json:"network"` // dmsg, stcpr, etc
+}
+
+// Type returns the NetworkReady type.
+func (NetworkReadyData) Type() string { return NetworkReady }
@@ -54,6 +54,21 @@ func TestConvertAddr(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "ok - app addr",
+			addr: Addr{
+				Net:    TypeSkynet,
+				PubKey: pk,
+				Port:   routing.Port(port),
+			},
+			want: want{
+				addr: Addr{
+					Net:    TypeSkynet,
+					PubKey: pk,
+					Port:   routing.Port(port),
+				},
+			},
+		},
 	}
 
 	for _, tc := range tt {
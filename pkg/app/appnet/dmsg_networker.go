@@ -43,3 +43,23 @@ func (n *DmsgNetworker) Listen(addr Addr) (net.Listener, error) {
 func (n *DmsgNetworker) ListenContext(_ context.Context, addr Addr) (net.Listener, error) {
 	return n.dmsgC.Listen(uint16(addr.Port))
 }
+
+// DialPacket is not supported by dmsg network.
+func (n *DmsgNetworker) DialPacket(addr Addr) (net.Conn, error) {
+	return n.DialPacketContext(context.Background(), addr)
+}
+
+// DialPacketContext is not supported by dmsg network.
+func (n *DmsgNetworker) DialPacketContext(context.Context, Addr) (net.Conn, error) {
+	return nil, ErrDatagramUnsupported
+}
+
+// ListenPacket is not supported by dmsg network.
+func (n *DmsgNetworker) ListenPacket(addr Addr) (net.PacketConn, error) {
+	return n.ListenPacketContext(context.Background(), addr)
+}
+
+// ListenPacketContext is not supported by dmsg network.
+func (n *DmsgNetworker) ListenPacketContext(context.Context, Addr) (net.PacketConn, error) {
+	return nil, ErrDatagramUnsupported
+}
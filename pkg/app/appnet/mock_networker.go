@@ -60,6 +60,52 @@ func (_m *MockNetworker) DialContext(ctx context.Context, addr Addr) (net.Conn,
 	return r0, r1
 }
 
+// DialPacket provides a mock function with given fields: addr
+func (_m *MockNetworker) DialPacket(addr Addr) (net.Conn, error) {
+	ret := _m.Called(addr)
+
+	var r0 net.Conn
+	if rf, ok := ret.Get(0).(func(Addr) net.Conn); ok {
+		r0 = rf(addr)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(net.Conn)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(Addr) error); ok {
+		r1 = rf(addr)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// DialPacketContext provides a mock function with given fields: ctx, addr
+func (_m *MockNetworker) DialPacketContext(ctx context.Context, addr Addr) (net.Conn, error) {
+	ret := _m.Called(ctx, addr)
+
+	var r0 net.Conn
+	if rf, ok := ret.Get(0).(func(context.Context, Addr) net.Conn); ok {
+		r0 = rf(ctx, addr)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(net.Conn)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, Addr) error); ok {
+		r1 = rf(ctx, addr)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // Listen provides a mock function with given fields: addr
 func (_m *MockNetworker) Listen(addr Addr) (net.Listener, error) {
 	ret := _m.Called(addr)
@@ -105,3 +151,49 @@ func (_m *MockNetworker) ListenContext(ctx context.Context, addr Addr) (net.List
 
 	return r0, r1
 }
+
+// ListenPacket provides a mock function with given fields: addr
+func (_m *MockNetworker) ListenPacket(addr Addr) (net.PacketConn, error) {
+	ret := _m.Called(addr)
+
+	var r0 net.PacketConn
+	if rf, ok := ret.Get(0).(func(Addr) net.PacketConn); ok {
+		r0 = rf(addr)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(net.PacketConn)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(Addr) error); ok {
+		r1 = rf(addr)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListenPacketContext provides a mock function with given fields: ctx, addr
+func (_m *MockNetworker) ListenPacketContext(ctx context.Context, addr Addr) (net.PacketConn, error) {
+	ret := _m.Called(ctx, addr)
+
+	var r0 net.PacketConn
+	if rf, ok := ret.Get(0).(func(context.Context, Addr) net.PacketConn); ok {
+		r0 = rf(ctx, addr)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(net.PacketConn)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, Addr) error); ok {
+		r1 = rf(ctx, addr)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
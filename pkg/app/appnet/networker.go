@@ -14,6 +14,8 @@ var (
 	ErrNoSuchNetworker = errors.New("no such networker")
 	// ErrNetworkerAlreadyExists is being returned when there's already one with such Network type.
 	ErrNetworkerAlreadyExists = errors.New("networker already exists")
+	// ErrDatagramUnsupported is being returned when the network doesn't support datagrams.
+	ErrDatagramUnsupported = errors.New("network doesn't support datagrams")
 )
 
 // nolint: gochecknoglobals
@@ -60,11 +62,17 @@ func ClearNetworkers() {
 }
 
 // Networker defines basic network operations, such as Dial/Listen.
+// DialPacket/ListenPacket are the datagram counterparts of Dial/Listen: each write
+// is delivered as a single datagram or not at all, and reads return a single datagram.
 type Networker interface {
 	Dial(addr Addr) (net.Conn, error)
 	DialContext(ctx context.Context, addr Addr) (net.Conn, error)
 	Listen(addr Addr) (net.Listener, error)
 	ListenContext(ctx context.Context, addr Addr) (net.Listener, error)
+	DialPacket(addr Addr) (net.Conn, error)
+	DialPacketContext(ctx context.Context, addr Addr) (net.Conn, error)
+	ListenPacket(addr Addr) (net.PacketConn, error)
+	ListenPacketContext(ctx context.Context, addr Addr) (net.PacketConn, error)
 }
 
 // Dial dials the remote `addr`.
@@ -96,3 +104,33 @@ func ListenContext(ctx context.Context, addr Addr) (net.Listener, error) {
 
 	return networker.ListenContext(ctx, addr)
 }
+
+// DialPacket dials the remote `addr` for exchanging datagrams.
+func DialPacket(addr Addr) (net.Conn, error) {
+	return DialPacketContext(context.Background(), addr)
+}
+
+// DialPacketContext dials the remote `addr` for exchanging datagrams with the context.
+func DialPacketContext(ctx context.Context, addr Addr) (net.Conn, error) {
+	n, err := ResolveNetworker(addr.Net)
+	if err != nil {
+		return nil, err
+	}
+
+	return n.DialPacketContext(ctx, addr)
+}
+
+// ListenPacket starts listening for datagrams on the local `addr`.
+func ListenPacket(addr Addr) (net.PacketConn, error) {
+	return ListenPacketContext(context.Background(), addr)
+}
+
+// ListenPacketContext starts listening for datagrams on the local `addr` with the context.
+func ListenPacketContext(ctx context.Context, addr Addr) (net.PacketConn, error) {
+	networker, err := ResolveNetworker(addr.Net)
+	if err != nil {
+		return nil, err
+	}
+
+	return networker.ListenPacketContext(ctx, addr)
+}
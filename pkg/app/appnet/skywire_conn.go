@@ -37,6 +37,12 @@ func (c *SkywireConn) BandwidthSent() uint64 {
 	return c.nrg.BandwidthSent()
 }
 
+// IsDatagram checks whether connection carries datagrams.
+func (c *SkywireConn) IsDatagram() bool {
+	_, ok := c.Conn.(*datagramConn)
+	return ok
+}
+
 // Close closes connection.
 func (c *SkywireConn) Close() error {
 	var err error
@@ -57,7 +57,7 @@ func (r *SkywireNetworker) DialContext(ctx context.Context, addr Addr) (conn net
 		}
 	}()
 
-	conn, err = r.r.DialRoutes(ctx, addr.PubKey, routing.Port(localPort), addr.Port, router.DefaultDialOptions())
+	conn, err = r.r.DialRoutes(ctx, addr.PubKey, routing.Port(localPort), addr.Port, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -93,6 +93,70 @@ func (r *SkywireNetworker) ListenContext(ctx context.Context, addr Addr) (net.Li
 	lis.freePort = freePort
 	lis.freePortMx.Unlock()
 
+	r.startServing(ctx)
+
+	return lis, nil
+}
+
+// DialPacket dials remote `addr` via `skynet` for exchanging datagrams.
+func (r *SkywireNetworker) DialPacket(addr Addr) (net.Conn, error) {
+	return r.DialPacketContext(context.Background(), addr)
+}
+
+// DialPacketContext dials remote `addr` via `skynet` for exchanging datagrams with context.
+// Remote should be listening with ListenPacket.
+func (r *SkywireNetworker) DialPacketContext(ctx context.Context, addr Addr) (conn net.Conn, err error) {
+	localPort, freePort, err := r.porter.ReserveEphemeral(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	// ensure ports are freed on error.
+	defer func() {
+		if err != nil {
+			freePort()
+		}
+	}()
+
+	conn, err = r.r.DialRoutes(ctx, addr.PubKey, routing.Port(localPort), addr.Port, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	nrg := conn.(*router.NoiseRouteGroup)
+
+	return &SkywireConn{
+		Conn:     newDatagramConn(nrg),
+		nrg:      nrg,
+		freePort: freePort,
+	}, nil
+}
+
+// ListenPacket starts listening for datagrams on local `addr` in the skynet.
+func (r *SkywireNetworker) ListenPacket(addr Addr) (net.PacketConn, error) {
+	return r.ListenPacketContext(context.Background(), addr)
+}
+
+// ListenPacketContext starts listening for datagrams on local `addr` in the skynet with context.
+func (r *SkywireNetworker) ListenPacketContext(ctx context.Context, addr Addr) (net.PacketConn, error) {
+	pc := newSkywirePacketConn(r.log, r.r, addr)
+
+	ok, freePort := r.porter.Reserve(uint16(addr.Port), pc)
+	if !ok {
+		return nil, ErrPortAlreadyBound
+	}
+
+	pc.freePortMx.Lock()
+	pc.freePort = freePort
+	pc.freePortMx.Unlock()
+
+	r.startServing(ctx)
+
+	return pc, nil
+}
+
+// startServing starts accepting route groups once the first listener is created.
+func (r *SkywireNetworker) startServing(ctx context.Context) {
 	if atomic.CompareAndSwapInt32(&r.isServing, 0, 1) {
 		go func() {
 			if err := r.serveRouteGroup(ctx); err != nil && !strings.Contains(err.Error(), "use of closed network connection") {
@@ -100,8 +164,6 @@ func (r *SkywireNetworker) ListenContext(ctx context.Context, addr Addr) (net.Li
 			}
 		}()
 	}
-
-	return lis, nil
 }
 
 // serveRouteGroup accepts and serves routes.
@@ -144,15 +206,15 @@ func (r *SkywireNetworker) serve(conn net.Conn) {
 		return
 	}
 
-	lis, ok := lisIfc.(*skywireListener)
-	if !ok {
+	switch lis := lisIfc.(type) {
+	case *skywireListener:
+		lis.putConn(conn)
+	case *skywirePacketConn:
+		lis.putConn(conn.(*router.NoiseRouteGroup))
+	default:
 		r.close(conn)
 		r.log.Errorf("wrong type of listener on port %d", localAddr.Port)
-
-		return
 	}
-
-	lis.putConn(conn)
 }
 
 // closeRG closes router group and logs error if any.
@@ -0,0 +1,356 @@
+package appnet
+
+import (
+	"context"
+	"io"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/skycoin/skywire/pkg/router"
+	"github.com/skycoin/skywire/pkg/util/deadline"
+)
+
+// packetConnBufSize is the number of received datagrams buffered by a packet conn.
+// Datagrams received while the buffer is full are dropped.
+const packetConnBufSize = 1024
+
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
+
+// datagramConn is a route group carrying datagrams. Each Write is sent as a single datagram
+// and each Read returns a single datagram, truncated if it doesn't fit into the buffer.
+type datagramConn struct {
+	net.Conn
+	maxSize int
+
+	rMx sync.Mutex
+	buf []byte
+}
+
+func newDatagramConn(nrg *router.NoiseRouteGroup) *datagramConn {
+	nrg.SetDatagram()
+
+	return &datagramConn{
+		Conn:    nrg,
+		maxSize: nrg.MaxDatagramSize(),
+	}
+}
+
+// Read reads a single datagram.
+func (c *datagramConn) Read(p []byte) (int, error) {
+	if len(p) >= c.maxSize {
+		return c.Conn.Read(p)
+	}
+
+	c.rMx.Lock()
+	defer c.rMx.Unlock()
+
+	if c.buf == nil {
+		c.buf = make([]byte, c.maxSize)
+	}
+
+	n, err := c.Conn.Read(c.buf)
+
+	return copy(p, c.buf[:n]), err
+}
+
+// Write writes `p` as a single datagram.
+func (c *datagramConn) Write(p []byte) (int, error) {
+	if len(p) > c.maxSize {
+		return 0, router.ErrDatagramTooLarge
+	}
+
+	return c.Conn.Write(p)
+}
+
+type datagram struct {
+	data []byte
+	from Addr
+}
+
+// packetPeer is a remote a packet conn exchanges datagrams with.
+type packetPeer struct {
+	ready chan struct{} // closed once conn is set or dialing fails
+	conn  *datagramConn
+	err   error
+}
+
+// skywirePacketConn receives datagrams of all route groups dialed to its port.
+// Datagrams to remotes without a route group are sent through a newly dialed one.
+// Implements net.PacketConn.
+type skywirePacketConn struct {
+	log  logrus.FieldLogger
+	r    router.Router
+	addr Addr
+
+	packets       chan datagram
+	readDeadline  deadline.PipeDeadline
+	writeDeadline time.Time
+
+	mu    sync.Mutex
+	peers map[Addr]*packetPeer
+
+	freePort   func()
+	freePortMx sync.RWMutex
+	closed     chan struct{}
+	once       sync.Once
+}
+
+func newSkywirePacketConn(log logrus.FieldLogger, r router.Router, addr Addr) *skywirePacketConn {
+	return &skywirePacketConn{
+		log:          log,
+		r:            r,
+		addr:         addr,
+		packets:      make(chan datagram, packetConnBufSize),
+		readDeadline: deadline.MakePipeDeadline(),
+		peers:        make(map[Addr]*packetPeer),
+		closed:       make(chan struct{}),
+	}
+}
+
+// ReadFrom reads a single datagram, truncated if it doesn't fit into `p`.
+func (c *skywirePacketConn) ReadFrom(p []byte) (int, net.Addr, error) {
+	select {
+	case <-c.closed:
+		return 0, nil, io.ErrClosedPipe
+	case <-c.readDeadline.Wait():
+		return 0, nil, timeoutError{}
+	case d := <-c.packets:
+		return copy(p, d.data), d.from, nil
+	}
+}
+
+// WriteTo writes `p` as a single datagram to `addr`. The first datagram to a remote
+// which didn't send anything yet sets up routes to it, which may take a while.
+func (c *skywirePacketConn) WriteTo(p []byte, addr net.Addr) (int, error) {
+	remote, err := ConvertAddr(addr)
+	if err != nil {
+		return 0, err
+	}
+
+	remote.Net = TypeSkynet
+
+	peer, err := c.peer(remote)
+	if err != nil {
+		return 0, err
+	}
+
+	c.mu.Lock()
+	wd := c.writeDeadline
+	c.mu.Unlock()
+
+	if err := peer.conn.SetWriteDeadline(wd); err != nil {
+		return 0, err
+	}
+
+	return peer.conn.Write(p)
+}
+
+// peer returns the remote with a route group, dialing one if there's none yet.
+func (c *skywirePacketConn) peer(remote Addr) (*packetPeer, error) {
+	c.mu.Lock()
+
+	if c.isClosed() {
+		c.mu.Unlock()
+		return nil, io.ErrClosedPipe
+	}
+
+	peer, ok := c.peers[remote]
+	if !ok {
+		peer = &packetPeer{ready: make(chan struct{})}
+		c.peers[remote] = peer
+		wd := c.writeDeadline
+		c.mu.Unlock()
+
+		c.dial(remote, peer, wd)
+	} else {
+		c.mu.Unlock()
+	}
+
+	select {
+	case <-c.closed:
+		return nil, io.ErrClosedPipe
+	case <-peer.ready:
+	}
+
+	if peer.err != nil {
+		return nil, peer.err
+	}
+
+	return peer, nil
+}
+
+func (c *skywirePacketConn) dial(remote Addr, peer *packetPeer, wd time.Time) {
+	ctx := context.Background()
+	if !wd.IsZero() {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithDeadline(ctx, wd)
+		defer cancel()
+	}
+
+	conn, err := c.r.DialRoutes(ctx, remote.PubKey, c.addr.Port, remote.Port, nil)
+	if err != nil {
+		c.mu.Lock()
+		if c.peers[remote] == peer {
+			// next write retries
+			delete(c.peers, remote)
+		}
+		c.mu.Unlock()
+
+		peer.err = err
+		close(peer.ready)
+
+		return
+	}
+
+	c.setPeer(remote, peer, conn.(*router.NoiseRouteGroup))
+}
+
+// putConn adds a route group dialed to the packet conn.
+func (c *skywirePacketConn) putConn(nrg *router.NoiseRouteGroup) {
+	remote, err := ConvertAddr(nrg.RemoteAddr())
+	if err != nil {
+		c.log.WithError(err).Error("Wrong type of addr in accepted route group.")
+		c.closeConn(nrg)
+
+		return
+	}
+
+	c.setPeer(remote, &packetPeer{ready: make(chan struct{})}, nrg)
+}
+
+// setPeer sets route group of `peer` and starts reading datagrams from it.
+func (c *skywirePacketConn) setPeer(remote Addr, peer *packetPeer, nrg *router.NoiseRouteGroup) {
+	conn := newDatagramConn(nrg)
+
+	c.mu.Lock()
+	if c.isClosed() {
+		c.mu.Unlock()
+		c.closeConn(conn)
+
+		return
+	}
+
+	// new routes to the remote replace the old ones
+	old, ok := c.peers[remote]
+	peer.conn = conn
+	c.peers[remote] = peer
+	c.mu.Unlock()
+
+	if ok && old != peer && old.conn != nil {
+		c.closeConn(old.conn)
+	}
+
+	select {
+	case <-peer.ready:
+	default:
+		close(peer.ready)
+	}
+
+	go c.readLoop(remote, peer)
+}
+
+func (c *skywirePacketConn) readLoop(remote Addr, peer *packetPeer) {
+	buf := make([]byte, peer.conn.maxSize)
+
+	for {
+		n, err := peer.conn.Read(buf)
+		if err != nil {
+			c.mu.Lock()
+			if c.peers[remote] == peer {
+				delete(c.peers, remote)
+			}
+			c.mu.Unlock()
+
+			c.closeConn(peer.conn)
+
+			return
+		}
+
+		data := make([]byte, n)
+		copy(data, buf[:n])
+
+		select {
+		case c.packets <- datagram{data: data, from: remote}:
+		case <-c.closed:
+			return
+		default:
+			// reader doesn't keep up, datagram is dropped
+		}
+	}
+}
+
+func (c *skywirePacketConn) closeConn(conn io.Closer) {
+	if err := conn.Close(); err != nil && err != io.ErrClosedPipe {
+		c.log.WithError(err).Debug("Failed to close datagram route group.")
+	}
+}
+
+// Close closes the packet conn along with all of its route groups.
+func (c *skywirePacketConn) Close() error {
+	c.once.Do(func() {
+		c.mu.Lock()
+		close(c.closed)
+		peers := c.peers
+		c.peers = make(map[Addr]*packetPeer)
+		c.mu.Unlock()
+
+		for _, peer := range peers {
+			if peer.conn != nil {
+				c.closeConn(peer.conn)
+			}
+		}
+
+		c.freePortMx.RLock()
+		defer c.freePortMx.RUnlock()
+		if c.freePort != nil {
+			c.freePort()
+		}
+	})
+
+	return nil
+}
+
+func (c *skywirePacketConn) isClosed() bool {
+	select {
+	case <-c.closed:
+		return true
+	default:
+		return false
+	}
+}
+
+// LocalAddr returns local address.
+func (c *skywirePacketConn) LocalAddr() net.Addr {
+	return c.addr
+}
+
+// SetDeadline sets read and write deadlines.
+func (c *skywirePacketConn) SetDeadline(t time.Time) error {
+	if err := c.SetReadDeadline(t); err != nil {
+		return err
+	}
+
+	return c.SetWriteDeadline(t)
+}
+
+// SetReadDeadline sets read deadline.
+func (c *skywirePacketConn) SetReadDeadline(t time.Time) error {
+	c.readDeadline.Set(t)
+	return nil
+}
+
+// SetWriteDeadline sets write deadline.
+func (c *skywirePacketConn) SetWriteDeadline(t time.Time) error {
+	c.mu.Lock()
+	c.writeDeadline = t
+	c.mu.Unlock()
+
+	return nil
+}
@@ -0,0 +1,487 @@
+package appserver
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/skycoin/dmsg/cipher"
+	"github.com/skycoin/skycoin/src/util/logging"
+
+	"github.com/skycoin/skywire/pkg/app/appcommon"
+	"github.com/skycoin/skywire/pkg/app/appevent"
+	"github.com/skycoin/skywire/pkg/app/appnet"
+	"github.com/skycoin/skywire/pkg/routing"
+)
+
+const (
+	// maxJSONFrameSize limits size of a single JSON frame (both directions).
+	maxJSONFrameSize = 4 << 20
+	// maxJSONReadLen limits the amount of data returned by a single Read call.
+	maxJSONReadLen = 1 << 20
+)
+
+var (
+	// ErrJSONFrameTooLarge is returned when a JSON frame exceeds the size limit.
+	ErrJSONFrameTooLarge = errors.New("json frame is too large")
+	// ErrJSONUnknownMethod is returned when a JSON request has unknown method.
+	ErrJSONUnknownMethod = errors.New("unknown method")
+)
+
+// JSON protocol methods, named after the RPCIngressGateway methods.
+const (
+	jsonMethodDial             = "Dial"
+	jsonMethodListen           = "Listen"
+	jsonMethodAccept           = "Accept"
+	jsonMethodRead             = "Read"
+	jsonMethodWrite            = "Write"
+	jsonMethodCloseConn        = "CloseConn"
+	jsonMethodCloseListener    = "CloseListener"
+	jsonMethodSetDeadline      = "SetDeadline"
+	jsonMethodSetReadDeadline  = "SetReadDeadline"
+	jsonMethodSetWriteDeadline = "SetWriteDeadline"
+	jsonMethodPassthrough      = "Passthrough"
+)
+
+// jsonRequest is a request frame sent by the app.
+type jsonRequest struct {
+	ID     uint64          `json:"id"`
+	Method string          `json:"method"`
+	Params json.RawMessage `json:"params,omitempty"`
+}
+
+// jsonResponse is a response frame sent by the app server. It has the ID of the request.
+type jsonResponse struct {
+	ID     uint64      `json:"id"`
+	Result interface{} `json:"result,omitempty"`
+	Error  *jsonError  `json:"error,omitempty"`
+}
+
+// jsonEventFrame is an event frame pushed by the app server. Event frames have no ID.
+type jsonEventFrame struct {
+	Event jsonEvent `json:"event"`
+}
+
+type jsonEvent struct {
+	Type string          `json:"type"`
+	Data json.RawMessage `json:"data"`
+}
+
+// jsonError is an error returned by a method. Flags allow apps to tell network errors apart.
+type jsonError struct {
+	Message   string `json:"message"`
+	EOF       bool   `json:"eof,omitempty"`
+	Timeout   bool   `json:"timeout,omitempty"`
+	Temporary bool   `json:"temporary,omitempty"`
+}
+
+func toJSONError(err error) *jsonError {
+	rpcErr := ioErrToRPCIOErr(err)
+	if rpcErr == nil {
+		return nil
+	}
+
+	return &jsonError{
+		Message:   rpcErr.Text,
+		EOF:       !rpcErr.IsNetErr && errors.Is(rpcErr.ToError(), io.EOF),
+		Timeout:   rpcErr.IsTimeoutErr,
+		Temporary: rpcErr.IsTemporaryErr,
+	}
+}
+
+type jsonAddr struct {
+	Net  appnet.Type   `json:"net"`
+	PK   cipher.PubKey `json:"pk"`
+	Port routing.Port  `json:"port"`
+}
+
+type jsonConnParams struct {
+	ConnID uint16 `json:"conn_id"`
+}
+
+type jsonLisParams struct {
+	LisID uint16 `json:"lis_id"`
+}
+
+type jsonDialResult struct {
+	ConnID    uint16       `json:"conn_id"`
+	LocalPort routing.Port `json:"local_port"`
+}
+
+type jsonAcceptResult struct {
+	ConnID uint16   `json:"conn_id"`
+	Remote jsonAddr `json:"remote"`
+}
+
+type jsonReadParams struct {
+	ConnID uint16 `json:"conn_id"`
+	Max    int    `json:"max"`
+}
+
+type jsonReadResult struct {
+	Data []byte `json:"data"`
+}
+
+type jsonWriteParams struct {
+	ConnID uint16 `json:"conn_id"`
+	Data   []byte `json:"data"`
+}
+
+type jsonWriteResult struct {
+	N int `json:"n"`
+}
+
+type jsonPassthroughResult struct {
+	Path  string `json:"path"`
+	Token []byte `json:"token"`
+}
+
+type jsonDeadlineParams struct {
+	ConnID   uint16     `json:"conn_id"`
+	Deadline *time.Time `json:"deadline"` // null removes the deadline
+}
+
+// jsonIngressServer serves RPCIngressGateway over the JSON protocol.
+// Requests are handled concurrently, responses are sent in the order they complete.
+// Reads of the same conn are handled one by one in the order they are received, and so are writes,
+// so that data isn't reordered.
+type jsonIngressServer struct {
+	gw   *RPCIngressGateway
+	conn net.Conn
+	log  *logging.Logger
+	wMx  sync.Mutex
+
+	queuesMx sync.Mutex
+	queues   map[jsonQueueKey]chan struct{} // done channel of the last queued request
+}
+
+// jsonQueueKey identifies requests which are handled one by one.
+type jsonQueueKey struct {
+	connID uint16
+	method string
+}
+
+func newJSONIngressServer(log *logging.Logger, gw *RPCIngressGateway, conn net.Conn) *jsonIngressServer {
+	return &jsonIngressServer{
+		gw:     gw,
+		conn:   conn,
+		log:    log,
+		queues: make(map[jsonQueueKey]chan struct{}),
+	}
+}
+
+// serve reads and handles requests until the connection fails.
+func (s *jsonIngressServer) serve() {
+	for {
+		frame, err := readJSONFrame(s.conn)
+		if err != nil {
+			if !errors.Is(err, io.EOF) {
+				s.log.WithError(err).Warn("Failed to read JSON frame, closing proc conn.")
+				_ = s.conn.Close() // nolint:errcheck
+			}
+			return
+		}
+
+		var req jsonRequest
+		if err := json.Unmarshal(frame, &req); err != nil {
+			s.respond(jsonResponse{Error: &jsonError{Message: fmt.Sprintf("invalid request: %v", err)}})
+			continue
+		}
+
+		key, prev, done := s.enqueue(req)
+
+		go func() {
+			if done != nil {
+				defer s.dequeue(key, done)
+			}
+
+			if prev != nil {
+				<-prev
+			}
+
+			result, err := s.call(req.Method, req.Params)
+			resp := jsonResponse{ID: req.ID, Error: toJSONError(err)}
+
+			// Read may return both data and error.
+			if err == nil || req.Method == jsonMethodRead {
+				resp.Result = result
+			}
+
+			s.respond(resp)
+		}()
+	}
+}
+
+// enqueue queues Read and Write requests after the previous ones of the same kind on the same conn.
+// It returns done channel of the previous request to wait for, if any, and done channel of `req`,
+// which is nil if `req` is not queued.
+func (s *jsonIngressServer) enqueue(req jsonRequest) (key jsonQueueKey, prev, done chan struct{}) {
+	if req.Method != jsonMethodRead && req.Method != jsonMethodWrite {
+		return key, nil, nil
+	}
+
+	var in jsonConnParams
+	if err := decodeJSONParams(req.Params, &in); err != nil {
+		// Request fails anyway.
+		return key, nil, nil
+	}
+
+	key = jsonQueueKey{connID: in.ConnID, method: req.Method}
+	done = make(chan struct{})
+
+	s.queuesMx.Lock()
+	defer s.queuesMx.Unlock()
+
+	prev = s.queues[key]
+	s.queues[key] = done
+
+	return key, prev, done
+}
+
+// dequeue lets the next request queued after the one with `done` channel run.
+func (s *jsonIngressServer) dequeue(key jsonQueueKey, done chan struct{}) {
+	s.queuesMx.Lock()
+	if s.queues[key] == done {
+		delete(s.queues, key)
+	}
+	s.queuesMx.Unlock()
+
+	close(done)
+}
+
+func (s *jsonIngressServer) respond(resp jsonResponse) {
+	if err := s.writeFrame(resp); err != nil {
+		s.log.WithError(err).WithField("id", resp.ID).Warn("Failed to send JSON response.")
+	}
+}
+
+func (s *jsonIngressServer) writeFrame(v interface{}) error {
+	s.wMx.Lock()
+	defer s.wMx.Unlock()
+
+	return writeJSONFrame(s.conn, v)
+}
+
+// call calls gateway method `method` with JSON-encoded `params`.
+func (s *jsonIngressServer) call(method string, params json.RawMessage) (interface{}, error) {
+	switch method {
+	case jsonMethodDial:
+		var in jsonAddr
+		if err := decodeJSONParams(params, &in); err != nil {
+			return nil, err
+		}
+
+		var resp DialResp
+		if err := s.gw.Dial(&appnet.Addr{Net: in.Net, PubKey: in.PK, Port: in.Port}, &resp); err != nil {
+			return nil, err
+		}
+
+		return jsonDialResult{ConnID: resp.ConnID, LocalPort: resp.LocalPort}, nil
+
+	case jsonMethodListen:
+		var in jsonAddr
+		if err := decodeJSONParams(params, &in); err != nil {
+			return nil, err
+		}
+
+		var lisID uint16
+		if err := s.gw.Listen(&appnet.Addr{Net: in.Net, PubKey: in.PK, Port: in.Port}, &lisID); err != nil {
+			return nil, err
+		}
+
+		return jsonLisParams{LisID: lisID}, nil
+
+	case jsonMethodAccept:
+		var in jsonLisParams
+		if err := decodeJSONParams(params, &in); err != nil {
+			return nil, err
+		}
+
+		var resp AcceptResp
+		if err := s.gw.Accept(&in.LisID, &resp); err != nil {
+			return nil, err
+		}
+
+		remote := jsonAddr{Net: resp.Remote.Net, PK: resp.Remote.PubKey, Port: resp.Remote.Port}
+
+		return jsonAcceptResult{ConnID: resp.ConnID, Remote: remote}, nil
+
+	case jsonMethodRead:
+		var in jsonReadParams
+		if err := decodeJSONParams(params, &in); err != nil {
+			return nil, err
+		}
+
+		if in.Max <= 0 || in.Max > maxJSONReadLen {
+			in.Max = maxJSONReadLen
+		}
+
+		var resp ReadResp
+		if err := s.gw.Read(&ReadReq{ConnID: in.ConnID, BufLen: in.Max}, &resp); err != nil {
+			return nil, err
+		}
+
+		return jsonReadResult{Data: resp.B}, resp.Err.ToError()
+
+	case jsonMethodWrite:
+		var in jsonWriteParams
+		if err := decodeJSONParams(params, &in); err != nil {
+			return nil, err
+		}
+
+		var resp WriteResp
+		if err := s.gw.Write(&WriteReq{ConnID: in.ConnID, B: in.Data}, &resp); err != nil {
+			return nil, err
+		}
+
+		if err := resp.Err.ToError(); err != nil {
+			return nil, err
+		}
+
+		return jsonWriteResult{N: resp.N}, nil
+
+	case jsonMethodCloseConn:
+		var in jsonConnParams
+		if err := decodeJSONParams(params, &in); err != nil {
+			return nil, err
+		}
+
+		return struct{}{}, s.gw.CloseConn(&in.ConnID, nil)
+
+	case jsonMethodCloseListener:
+		var in jsonLisParams
+		if err := decodeJSONParams(params, &in); err != nil {
+			return nil, err
+		}
+
+		return struct{}{}, s.gw.CloseListener(&in.LisID, nil)
+
+	case jsonMethodSetDeadline, jsonMethodSetReadDeadline, jsonMethodSetWriteDeadline:
+		var in jsonDeadlineParams
+		if err := decodeJSONParams(params, &in); err != nil {
+			return nil, err
+		}
+
+		req := DeadlineReq{ConnID: in.ConnID}
+		if in.Deadline != nil {
+			req.Deadline = *in.Deadline
+		}
+
+		setDeadline := map[string]func(*DeadlineReq, *struct{}) error{
+			jsonMethodSetDeadline:      s.gw.SetDeadline,
+			jsonMethodSetReadDeadline:  s.gw.SetReadDeadline,
+			jsonMethodSetWriteDeadline: s.gw.SetWriteDeadline,
+		}[method]
+
+		return struct{}{}, setDeadline(&req, nil)
+
+	case jsonMethodPassthrough:
+		var in jsonConnParams
+		if err := decodeJSONParams(params, &in); err != nil {
+			return nil, err
+		}
+
+		var resp PassthroughResp
+		if err := s.gw.Passthrough(&in.ConnID, &resp); err != nil {
+			return nil, err
+		}
+
+		return jsonPassthroughResult{Path: resp.Path, Token: resp.Token}, nil
+
+	default:
+		return nil, fmt.Errorf("%w %q", ErrJSONUnknownMethod, method)
+	}
+}
+
+func decodeJSONParams(params json.RawMessage, v interface{}) error {
+	if len(params) == 0 {
+		return errors.New("missing params")
+	}
+
+	if err := json.Unmarshal(params, v); err != nil {
+		return fmt.Errorf("invalid params: %w", err)
+	}
+
+	return nil
+}
+
+// eventClient returns an appevent.RPCClient which pushes events subscribed to in `hello` as event frames.
+func (s *jsonIngressServer) eventClient(hello *appcommon.Hello) appevent.RPCClient {
+	return &jsonEventClient{s: s, hello: hello}
+}
+
+type jsonEventClient struct {
+	s     *jsonIngressServer
+	hello *appcommon.Hello
+}
+
+// Notify implements appevent.RPCClient
+func (c *jsonEventClient) Notify(ctx context.Context, e *appevent.Event) error {
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- c.s.writeFrame(jsonEventFrame{Event: jsonEvent{Type: e.Type, Data: e.Data}})
+	}()
+
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Hello implements appevent.RPCClient
+func (c *jsonEventClient) Hello() *appcommon.Hello {
+	return c.hello
+}
+
+// Close implements io.Closer. The proc conn is owned by the proc, so it's not closed here.
+func (c *jsonEventClient) Close() error {
+	return nil
+}
+
+// readJSONFrame reads a frame prefixed with its 4-byte big-endian length.
+func readJSONFrame(r io.Reader) ([]byte, error) {
+	sizeRaw := make([]byte, 4)
+	if _, err := io.ReadFull(r, sizeRaw); err != nil {
+		return nil, err
+	}
+
+	size := binary.BigEndian.Uint32(sizeRaw)
+	if size > maxJSONFrameSize {
+		return nil, fmt.Errorf("%w: %d bytes", ErrJSONFrameTooLarge, size)
+	}
+
+	frame := make([]byte, size)
+	if _, err := io.ReadFull(r, frame); err != nil {
+		return nil, fmt.Errorf("failed to read json frame: %w", err)
+	}
+
+	return frame, nil
+}
+
+// writeJSONFrame writes `v` encoded as JSON and prefixed with its 4-byte big-endian length.
+func writeJSONFrame(w io.Writer, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	if len(data) > maxJSONFrameSize {
+		return fmt.Errorf("%w: %d bytes", ErrJSONFrameTooLarge, len(data))
+	}
+
+	raw := make([]byte, 4+len(data))
+	binary.BigEndian.PutUint32(raw[:4], uint32(len(data)))
+	copy(raw[4:], data)
+
+	_, err = w.Write(raw)
+
+	return err
+}
@@ -0,0 +1,244 @@
+package appserver
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/skycoin/dmsg"
+	"github.com/skycoin/skycoin/src/util/logging"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/skycoin/skywire/pkg/app/appcommon"
+	"github.com/skycoin/skywire/pkg/app/appevent"
+	"github.com/skycoin/skywire/pkg/app/appnet"
+)
+
+// jsonTestApp speaks the JSON protocol the way a non-Go app would: frames are built by hand.
+type jsonTestApp struct {
+	t    *testing.T
+	conn net.Conn
+	id   uint64
+}
+
+func (a *jsonTestApp) send(frame string) {
+	raw := make([]byte, 4+len(frame))
+	binary.BigEndian.PutUint32(raw, uint32(len(frame)))
+	copy(raw[4:], frame)
+
+	_, err := a.conn.Write(raw)
+	require.NoError(a.t, err)
+}
+
+func (a *jsonTestApp) recv() map[string]json.RawMessage {
+	sizeRaw := make([]byte, 4)
+	_, err := io.ReadFull(a.conn, sizeRaw)
+	require.NoError(a.t, err)
+
+	frame := make([]byte, binary.BigEndian.Uint32(sizeRaw))
+	_, err = io.ReadFull(a.conn, frame)
+	require.NoError(a.t, err)
+
+	var msg map[string]json.RawMessage
+	require.NoError(a.t, json.Unmarshal(frame, &msg))
+
+	return msg
+}
+
+// call sends a request and returns raw result and error of the response.
+func (a *jsonTestApp) call(method, params string) (json.RawMessage, json.RawMessage) {
+	a.id++
+	a.send(fmt.Sprintf(`{"id":%d,"method":%q,"params":%s}`, a.id, method, params))
+
+	resp := a.recv()
+	require.Equal(a.t, fmt.Sprint(a.id), string(resp["id"]))
+
+	return resp["result"], resp["error"]
+}
+
+func newJSONTestApp(t *testing.T) (*jsonTestApp, *RPCIngressGateway, *jsonIngressServer) {
+	appConn, srvConn := net.Pipe()
+	t.Cleanup(func() {
+		require.NoError(t, appConn.Close())
+	})
+
+	gw := NewRPCGateway(logging.MustGetLogger("json_ingress_server"))
+	s := newJSONIngressServer(gw.log, gw, srvConn)
+	go s.serve()
+
+	return &jsonTestApp{t: t, conn: appConn}, gw, s
+}
+
+func TestJSONIngressServer(t *testing.T) {
+	t.Run("dial", func(t *testing.T) {
+		app, _, _ := newJSONTestApp(t)
+		appnet.ClearNetworkers()
+
+		dialAddr := prepAddr(appnet.TypeDmsg)
+
+		dialConn := &appcommon.MockConn{}
+		dialConn.On("LocalAddr").Return(dmsg.Addr{Port: 100})
+		dialConn.On("RemoteAddr").Return(dmsg.Addr{})
+
+		n := &appnet.MockNetworker{}
+		n.On("DialContext", context.Background(), dialAddr).Return(dialConn, nil)
+		require.NoError(t, appnet.AddNetworker(appnet.TypeDmsg, n))
+
+		result, rErr := app.call("Dial", fmt.Sprintf(`{"net":"dmsg","pk":%q,"port":100}`, dialAddr.PubKey))
+		require.Nil(t, rErr)
+		assert.JSONEq(t, `{"conn_id":1,"local_port":100}`, string(result))
+	})
+
+	t.Run("write and read", func(t *testing.T) {
+		app, gw, _ := newJSONTestApp(t)
+
+		local, remote := net.Pipe()
+		connID := addConn(t, gw, local)
+
+		go func() {
+			buf := make([]byte, 5)
+			_, _ = io.ReadFull(remote, buf)      // nolint:errcheck
+			_, _ = remote.Write([]byte("world")) // nolint:errcheck
+		}()
+
+		result, rErr := app.call("Write", fmt.Sprintf(`{"conn_id":%d,"data":"aGVsbG8="}`, connID))
+		require.Nil(t, rErr)
+		assert.JSONEq(t, `{"n":5}`, string(result))
+
+		result, rErr = app.call("Read", fmt.Sprintf(`{"conn_id":%d,"max":16}`, connID))
+		require.Nil(t, rErr)
+		assert.JSONEq(t, `{"data":"d29ybGQ="}`, string(result))
+
+		require.NoError(t, remote.Close())
+
+		_, rErr = app.call("Read", fmt.Sprintf(`{"conn_id":%d,"max":16}`, connID))
+		assert.JSONEq(t, `{"message":"EOF","eof":true}`, string(rErr))
+
+		result, rErr = app.call("CloseConn", fmt.Sprintf(`{"conn_id":%d}`, connID))
+		require.Nil(t, rErr)
+		assert.JSONEq(t, `{}`, string(result))
+
+		_, rErr = app.call("CloseConn", fmt.Sprintf(`{"conn_id":%d}`, connID))
+		assert.NotNil(t, rErr)
+	})
+
+	t.Run("pipelined writes", func(t *testing.T) {
+		app, gw, _ := newJSONTestApp(t)
+
+		local, remote := net.Pipe()
+		defer func() {
+			require.NoError(t, remote.Close())
+		}()
+		connID := addConn(t, gw, local)
+
+		const n = 200
+
+		// Writes are sent without waiting for replies, they have to reach the conn in order.
+		want := make([]byte, n)
+		for i := 0; i < n; i++ {
+			want[i] = byte(i)
+			data, err := json.Marshal([]byte{want[i]})
+			require.NoError(t, err)
+
+			app.send(fmt.Sprintf(`{"id":%d,"method":"Write","params":{"conn_id":%d,"data":%s}}`, i+1, connID, data))
+		}
+
+		got := make([]byte, n)
+		readErr := make(chan error, 1)
+		go func() {
+			_, err := io.ReadFull(remote, got)
+			readErr <- err
+		}()
+
+		for i := 0; i < n; i++ {
+			resp := app.recv()
+			assert.Nil(t, resp["error"])
+		}
+
+		require.NoError(t, <-readErr)
+		assert.Equal(t, want, got)
+	})
+
+	t.Run("deadline", func(t *testing.T) {
+		app, gw, _ := newJSONTestApp(t)
+
+		local, remote := net.Pipe()
+		defer func() {
+			require.NoError(t, remote.Close())
+		}()
+		connID := addConn(t, gw, local)
+
+		deadline, err := time.Now().Add(-time.Second).MarshalJSON()
+		require.NoError(t, err)
+
+		_, rErr := app.call("SetReadDeadline", fmt.Sprintf(`{"conn_id":%d,"deadline":%s}`, connID, deadline))
+		require.Nil(t, rErr)
+
+		_, rErr = app.call("Read", fmt.Sprintf(`{"conn_id":%d,"max":16}`, connID))
+
+		var jErr jsonError
+		require.NoError(t, json.Unmarshal(rErr, &jErr))
+		assert.True(t, jErr.Timeout)
+		assert.False(t, jErr.EOF)
+
+		_, rErr = app.call("SetDeadline", fmt.Sprintf(`{"conn_id":%d,"deadline":null}`, connID))
+		require.Nil(t, rErr)
+	})
+
+	t.Run("invalid requests", func(t *testing.T) {
+		app, _, _ := newJSONTestApp(t)
+
+		_, rErr := app.call("Reboot", `{}`)
+		assert.Contains(t, string(rErr), "unknown method")
+
+		_, rErr = app.call("Read", `{"conn_id":"one"}`)
+		assert.Contains(t, string(rErr), "invalid params")
+
+		_, rErr = app.call("Write", `{"conn_id":1,"data":""}`)
+		assert.Contains(t, string(rErr), "no conn")
+
+		app.send(`not json`)
+		resp := app.recv()
+		assert.Equal(t, "0", string(resp["id"]))
+		assert.NotNil(t, resp["error"])
+	})
+
+	t.Run("events", func(t *testing.T) {
+		app, _, s := newJSONTestApp(t)
+
+		hello := &appcommon.Hello{Protocol: appcommon.ProtocolJSON, EventSubs: map[string]bool{appevent.TCPDial: true}}
+		eb := appevent.NewBroadcaster(nil, time.Second)
+		eb.AddClient(s.eventClient(hello))
+
+		errCh := make(chan error, 1)
+		go func() {
+			errCh <- eb.Broadcast(context.Background(), appevent.NewEvent(appevent.TCPDial, appevent.TCPDialData{
+				RemoteNet:  "tcp",
+				RemoteAddr: "1.1.1.1:80",
+			}))
+		}()
+
+		msg := app.recv()
+		assert.JSONEq(t, `{"type":"tcp_dial","data":{"remote_net":"tcp","remote_addr":"1.1.1.1:80"}}`, string(msg["event"]))
+		require.NoError(t, <-errCh)
+	})
+
+	t.Run("oversized frame", func(t *testing.T) {
+		app, _, _ := newJSONTestApp(t)
+
+		raw := make([]byte, 4)
+		binary.BigEndian.PutUint32(raw, maxJSONFrameSize+1)
+		_, err := app.conn.Write(raw)
+		require.NoError(t, err)
+
+		// Framing can't be recovered, so the proc conn is closed.
+		_, err = app.conn.Read(make([]byte, 1))
+		assert.Equal(t, io.EOF, err)
+	})
+}
@@ -96,6 +96,11 @@ func (_m *MockProcManager) Range(next func(string, *Proc) bool) {
 	_m.Called(next)
 }
 
+// SetExitHandler provides a mock function with given fields: h
+func (_m *MockProcManager) SetExitHandler(h func(ProcExit)) {
+	_m.Called(h)
+}
+
 // Start provides a mock function with given fields: conf
 func (_m *MockProcManager) Start(conf appcommon.ProcConfig) (appcommon.ProcID, error) {
 	ret := _m.Called(conf)
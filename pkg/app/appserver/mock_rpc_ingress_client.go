@@ -72,6 +72,20 @@ func (_m *MockRPCIngressClient) CloseListener(id uint16) error {
 	return r0
 }
 
+// ClosePacketConn provides a mock function with given fields: id
+func (_m *MockRPCIngressClient) ClosePacketConn(id uint16) error {
+	ret := _m.Called(id)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(uint16) error); ok {
+		r0 = rf(id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // Dial provides a mock function with given fields: remote
 func (_m *MockRPCIngressClient) Dial(remote appnet.Addr) (uint16, routing.Port, error) {
 	ret := _m.Called(remote)
@@ -100,6 +114,34 @@ func (_m *MockRPCIngressClient) Dial(remote appnet.Addr) (uint16, routing.Port,
 	return r0, r1, r2
 }
 
+// DialPacket provides a mock function with given fields: remote
+func (_m *MockRPCIngressClient) DialPacket(remote appnet.Addr) (uint16, routing.Port, error) {
+	ret := _m.Called(remote)
+
+	var r0 uint16
+	if rf, ok := ret.Get(0).(func(appnet.Addr) uint16); ok {
+		r0 = rf(remote)
+	} else {
+		r0 = ret.Get(0).(uint16)
+	}
+
+	var r1 routing.Port
+	if rf, ok := ret.Get(1).(func(appnet.Addr) routing.Port); ok {
+		r1 = rf(remote)
+	} else {
+		r1 = ret.Get(1).(routing.Port)
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(appnet.Addr) error); ok {
+		r2 = rf(remote)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
 // Listen provides a mock function with given fields: local
 func (_m *MockRPCIngressClient) Listen(local appnet.Addr) (uint16, error) {
 	ret := _m.Called(local)
@@ -121,6 +163,57 @@ func (_m *MockRPCIngressClient) Listen(local appnet.Addr) (uint16, error) {
 	return r0, r1
 }
 
+// ListenPacket provides a mock function with given fields: local
+func (_m *MockRPCIngressClient) ListenPacket(local appnet.Addr) (uint16, error) {
+	ret := _m.Called(local)
+
+	var r0 uint16
+	if rf, ok := ret.Get(0).(func(appnet.Addr) uint16); ok {
+		r0 = rf(local)
+	} else {
+		r0 = ret.Get(0).(uint16)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(appnet.Addr) error); ok {
+		r1 = rf(local)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Passthrough provides a mock function with given fields: connID
+func (_m *MockRPCIngressClient) Passthrough(connID uint16) (string, []byte, error) {
+	ret := _m.Called(connID)
+
+	var r0 string
+	if rf, ok := ret.Get(0).(func(uint16) string); ok {
+		r0 = rf(connID)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	var r1 []byte
+	if rf, ok := ret.Get(1).(func(uint16) []byte); ok {
+		r1 = rf(connID)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).([]byte)
+		}
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(uint16) error); ok {
+		r2 = rf(connID)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
 // Read provides a mock function with given fields: connID, b
 func (_m *MockRPCIngressClient) Read(connID uint16, b []byte) (int, error) {
 	ret := _m.Called(connID, b)
@@ -142,6 +235,34 @@ func (_m *MockRPCIngressClient) Read(connID uint16, b []byte) (int, error) {
 	return r0, r1
 }
 
+// ReadFrom provides a mock function with given fields: pcID, b
+func (_m *MockRPCIngressClient) ReadFrom(pcID uint16, b []byte) (int, appnet.Addr, error) {
+	ret := _m.Called(pcID, b)
+
+	var r0 int
+	if rf, ok := ret.Get(0).(func(uint16, []byte) int); ok {
+		r0 = rf(pcID, b)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	var r1 appnet.Addr
+	if rf, ok := ret.Get(1).(func(uint16, []byte) appnet.Addr); ok {
+		r1 = rf(pcID, b)
+	} else {
+		r1 = ret.Get(1).(appnet.Addr)
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(uint16, []byte) error); ok {
+		r2 = rf(pcID, b)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
 // SetDeadline provides a mock function with given fields: connID, d
 func (_m *MockRPCIngressClient) SetDeadline(connID uint16, d time.Time) error {
 	ret := _m.Called(connID, d)
@@ -156,6 +277,48 @@ func (_m *MockRPCIngressClient) SetDeadline(connID uint16, d time.Time) error {
 	return r0
 }
 
+// SetPacketDeadline provides a mock function with given fields: pcID, d
+func (_m *MockRPCIngressClient) SetPacketDeadline(pcID uint16, d time.Time) error {
+	ret := _m.Called(pcID, d)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(uint16, time.Time) error); ok {
+		r0 = rf(pcID, d)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SetPacketReadDeadline provides a mock function with given fields: pcID, d
+func (_m *MockRPCIngressClient) SetPacketReadDeadline(pcID uint16, d time.Time) error {
+	ret := _m.Called(pcID, d)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(uint16, time.Time) error); ok {
+		r0 = rf(pcID, d)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SetPacketWriteDeadline provides a mock function with given fields: pcID, d
+func (_m *MockRPCIngressClient) SetPacketWriteDeadline(pcID uint16, d time.Time) error {
+	ret := _m.Called(pcID, d)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(uint16, time.Time) error); ok {
+		r0 = rf(pcID, d)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // SetReadDeadline provides a mock function with given fields: connID, d
 func (_m *MockRPCIngressClient) SetReadDeadline(connID uint16, d time.Time) error {
 	ret := _m.Called(connID, d)
@@ -204,3 +367,24 @@ func (_m *MockRPCIngressClient) Write(connID uint16, b []byte) (int, error) {
 
 	return r0, r1
 }
+
+// WriteTo provides a mock function with given fields: pcID, b, remote
+func (_m *MockRPCIngressClient) WriteTo(pcID uint16, b []byte, remote appnet.Addr) (int, error) {
+	ret := _m.Called(pcID, b, remote)
+
+	var r0 int
+	if rf, ok := ret.Get(0).(func(uint16, []byte, appnet.Addr) int); ok {
+		r0 = rf(pcID, b, remote)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(uint16, []byte, appnet.Addr) error); ok {
+		r1 = rf(pcID, b, remote)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
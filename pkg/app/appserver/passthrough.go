@@ -0,0 +1,176 @@
+package appserver
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/skycoin/skywire/pkg/app/appnet"
+)
+
+const (
+	// passthroughTimeout is how long the app has to connect to the passthrough socket
+	// and to send the token.
+	passthroughTimeout = 10 * time.Second
+
+	passthroughTokenLen = 16
+)
+
+var (
+	// ErrPassthroughDatagram is returned when passthrough is requested for a datagram conn.
+	// Unix stream socket doesn't keep datagram boundaries.
+	ErrPassthroughDatagram = errors.New("passthrough is not supported for datagram conns")
+)
+
+// passthrough relays data between an app conn and a unix socket the app connects to,
+// so app reads and writes don't go through RPC.
+type passthrough struct {
+	log   logrus.FieldLogger
+	conn  net.Conn
+	lis   *net.UnixListener
+	path  string
+	token []byte
+	done  func() // called once relaying is over
+}
+
+func newPassthrough(log logrus.FieldLogger, conn net.Conn, done func()) (*passthrough, error) {
+	if isDatagramConn(conn) {
+		return nil, ErrPassthroughDatagram
+	}
+
+	// first half names the socket, second half is the token
+	random := make([]byte, 8+passthroughTokenLen)
+	if _, err := rand.Read(random); err != nil {
+		return nil, fmt.Errorf("failed to generate passthrough token: %w", err)
+	}
+
+	path := filepath.Join(os.TempDir(), fmt.Sprintf("skywire-app-%x.sock", random[:8]))
+
+	lis, err := net.ListenUnix("unix", &net.UnixAddr{Name: path, Net: "unix"})
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on passthrough socket: %w", err)
+	}
+
+	// app may run as another user, it's authenticated by the token
+	if err := os.Chmod(path, 0666); err != nil { // nolint:gosec
+		if cErr := lis.Close(); cErr != nil {
+			log.WithError(cErr).Error("Failed to close passthrough socket.")
+		}
+
+		return nil, fmt.Errorf("failed to set passthrough socket permissions: %w", err)
+	}
+
+	return &passthrough{
+		log:   log,
+		conn:  conn,
+		lis:   lis,
+		path:  path,
+		token: random[8:],
+		done:  done,
+	}, nil
+}
+
+// serve waits for the app to connect and relays data until either side is closed.
+// If the app doesn't connect in time, the conn stays usable via RPC.
+func (p *passthrough) serve() {
+	appConn, err := p.accept()
+
+	if cErr := p.lis.Close(); cErr != nil {
+		p.log.WithError(cErr).Error("Failed to close passthrough socket.")
+	}
+
+	if err != nil {
+		p.log.WithError(err).Warn("App didn't connect to passthrough socket, conn stays on RPC.")
+		return
+	}
+
+	p.relay(appConn)
+}
+
+func (p *passthrough) accept() (net.Conn, error) {
+	if err := p.lis.SetDeadline(time.Now().Add(passthroughTimeout)); err != nil {
+		return nil, err
+	}
+
+	for {
+		conn, err := p.lis.Accept()
+		if err != nil {
+			return nil, err
+		}
+
+		if p.auth(conn) {
+			return conn, nil
+		}
+
+		p.log.Warn("Rejected passthrough socket conn with invalid token.")
+
+		if err := conn.Close(); err != nil {
+			p.log.WithError(err).Error("Failed to close rejected passthrough conn.")
+		}
+	}
+}
+
+func (p *passthrough) auth(conn net.Conn) bool {
+	if err := conn.SetReadDeadline(time.Now().Add(passthroughTimeout)); err != nil {
+		return false
+	}
+
+	token := make([]byte, len(p.token))
+	if _, err := io.ReadFull(conn, token); err != nil {
+		return false
+	}
+
+	if err := conn.SetReadDeadline(time.Time{}); err != nil {
+		return false
+	}
+
+	return subtle.ConstantTimeCompare(token, p.token) == 1
+}
+
+func (p *passthrough) relay(appConn net.Conn) {
+	errCh := make(chan error, 2)
+
+	go func() {
+		_, err := io.Copy(p.conn, appConn)
+		errCh <- err
+	}()
+
+	go func() {
+		_, err := io.Copy(appConn, p.conn)
+		errCh <- err
+	}()
+
+	if err := <-errCh; err != nil {
+		p.log.WithError(err).Debug("Passthrough relay stopped.")
+	}
+
+	if err := appConn.Close(); err != nil {
+		p.log.WithError(err).Debug("Failed to close passthrough conn.")
+	}
+
+	if err := p.conn.Close(); err != nil {
+		p.log.WithError(err).Debug("Failed to close app conn.")
+	}
+
+	<-errCh
+
+	p.done()
+}
+
+func isDatagramConn(conn net.Conn) bool {
+	if wc, ok := conn.(*appnet.WrappedConn); ok {
+		conn = wc.Conn
+	}
+
+	sc, ok := conn.(*appnet.SkywireConn)
+
+	return ok && sc.IsDatagram()
+}
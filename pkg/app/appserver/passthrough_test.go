@@ -0,0 +1,178 @@
+package appserver
+
+import (
+	"io"
+	"net"
+	"net/rpc"
+	"testing"
+	"time"
+
+	"github.com/skycoin/skycoin/src/util/logging"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/nettest"
+)
+
+func TestRPCGateway_Passthrough(t *testing.T) {
+	l := logging.MustGetLogger("rpc_gateway")
+
+	t.Run("ok", func(t *testing.T) {
+		rpc := NewRPCGateway(l)
+
+		local, remote := net.Pipe()
+		connID := addConn(t, rpc, local)
+
+		var resp PassthroughResp
+		err := rpc.Passthrough(&connID, &resp)
+		require.NoError(t, err)
+		require.Len(t, resp.Token, passthroughTokenLen)
+
+		pt, err := net.Dial("unix", resp.Path)
+		require.NoError(t, err)
+
+		_, err = pt.Write(resp.Token)
+		require.NoError(t, err)
+
+		_, err = pt.Write([]byte("hello"))
+		require.NoError(t, err)
+
+		buf := make([]byte, 5)
+		_, err = io.ReadFull(remote, buf)
+		require.NoError(t, err)
+		require.Equal(t, "hello", string(buf))
+
+		_, err = remote.Write([]byte("world"))
+		require.NoError(t, err)
+
+		_, err = io.ReadFull(pt, buf)
+		require.NoError(t, err)
+		require.Equal(t, "world", string(buf))
+
+		// closing the socket closes the conn
+		require.NoError(t, pt.Close())
+
+		_, err = remote.Read(buf)
+		require.Equal(t, io.EOF, err)
+
+		require.Eventually(t, func() bool {
+			_, ok := rpc.cm.Get(connID)
+			return !ok
+		}, time.Second, 10*time.Millisecond)
+	})
+
+	t.Run("invalid token", func(t *testing.T) {
+		rpc := NewRPCGateway(l)
+
+		local, remote := net.Pipe()
+		defer func() {
+			require.NoError(t, remote.Close())
+		}()
+		connID := addConn(t, rpc, local)
+
+		var resp PassthroughResp
+		err := rpc.Passthrough(&connID, &resp)
+		require.NoError(t, err)
+
+		pt, err := net.Dial("unix", resp.Path)
+		require.NoError(t, err)
+		defer func() {
+			require.NoError(t, pt.Close())
+		}()
+
+		_, err = pt.Write(make([]byte, passthroughTokenLen))
+		require.NoError(t, err)
+
+		_, err = pt.Read(make([]byte, 1))
+		require.Equal(t, io.EOF, err)
+
+		_, ok := rpc.cm.Get(connID)
+		require.True(t, ok)
+	})
+
+	t.Run("no such conn", func(t *testing.T) {
+		rpc := NewRPCGateway(l)
+
+		connID := uint16(1)
+
+		var resp PassthroughResp
+		err := rpc.Passthrough(&connID, &resp)
+		require.Error(t, err)
+	})
+}
+
+// BenchmarkDataPath compares writing app data over RPC and over the passthrough socket.
+func BenchmarkDataPath(b *testing.B) {
+	const chunkSize = 32 * 1024
+
+	l := logging.MustGetLogger("rpc_gateway")
+
+	prep := func(b *testing.B) (*RPCIngressGateway, RPCIngressClient, uint16) {
+		gw := NewRPCGateway(l)
+
+		local, remote := net.Pipe()
+		go func() {
+			_, _ = io.Copy(io.Discard, remote) // nolint:errcheck
+		}()
+
+		connID, _, err := gw.cm.ReserveNextID()
+		require.NoError(b, err)
+		require.NoError(b, gw.cm.Set(*connID, local))
+
+		s := rpc.NewServer()
+		require.NoError(b, s.RegisterName(rpcProcKey.String(), gw))
+
+		lis, err := nettest.NewLocalListener("tcp")
+		require.NoError(b, err)
+		go s.Accept(lis)
+
+		rpcCl, err := rpc.Dial(lis.Addr().Network(), lis.Addr().String())
+		require.NoError(b, err)
+
+		b.Cleanup(func() {
+			_ = rpcCl.Close()  // nolint:errcheck
+			_ = lis.Close()    // nolint:errcheck
+			_ = remote.Close() // nolint:errcheck
+		})
+
+		return gw, NewRPCIngressClient(rpcCl, rpcProcKey), *connID
+	}
+
+	chunk := make([]byte, chunkSize)
+
+	b.Run("rpc", func(b *testing.B) {
+		_, cl, connID := prep(b)
+
+		b.SetBytes(chunkSize)
+		b.ResetTimer()
+
+		for i := 0; i < b.N; i++ {
+			if _, err := cl.Write(connID, chunk); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("passthrough", func(b *testing.B) {
+		_, cl, connID := prep(b)
+
+		path, token, err := cl.Passthrough(connID)
+		require.NoError(b, err)
+
+		pt, err := net.Dial("unix", path)
+		require.NoError(b, err)
+		b.Cleanup(func() {
+			_ = pt.Close() // nolint:errcheck
+		})
+
+		_, err = pt.Write(token)
+		require.NoError(b, err)
+
+		b.SetBytes(chunkSize)
+		b.ResetTimer()
+
+		for i := 0; i < b.N; i++ {
+			if _, err := pt.Write(chunk); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
@@ -17,6 +17,7 @@ import (
 
 	"github.com/skycoin/skywire/pkg/app/appcommon"
 	"github.com/skycoin/skywire/pkg/app/appdisc"
+	"github.com/skycoin/skywire/pkg/app/appevent"
 	"github.com/skycoin/skywire/pkg/app/appnet"
 )
 
@@ -37,11 +38,14 @@ type Proc struct {
 
 	cmd       *exec.Cmd
 	isRunning int32
+	stopping  int32 // set when proc is stopped by Stop
 	waitMx    sync.Mutex
 	waitErr   error
+	onExit    func(ProcExit)
 
 	rpcGWMu  sync.Mutex
 	rpcGW    *RPCIngressGateway // gateway shared over 'conn' - introduced AFTER proc is started
+	jsonS    *jsonIngressServer // serves 'rpcGW' if proc uses the JSON protocol - introduced AFTER proc is started
 	conn     net.Conn           // connection to proc - introduced AFTER proc is started
 	connCh   chan struct{}      // push here when conn is received - protected by 'connOnce'
 	connOnce sync.Once          // ensures we only push to 'connCh' once
@@ -90,8 +94,8 @@ func (p *Proc) Cmd() *exec.Cmd {
 
 // InjectConn introduces the connection to the Proc after it is started.
 // Only the first call will return true.
-// It also prepares the RPC gateway.
-func (p *Proc) InjectConn(conn net.Conn) bool {
+// It also prepares the RPC gateway to be served over `protocol` (one of appcommon protocols).
+func (p *Proc) InjectConn(conn net.Conn, protocol string) bool {
 	ok := false
 
 	p.connOnce.Do(func() {
@@ -99,6 +103,9 @@ func (p *Proc) InjectConn(conn net.Conn) bool {
 		p.conn = conn
 		p.rpcGWMu.Lock()
 		p.rpcGW = NewRPCGateway(p.log)
+		if protocol == appcommon.ProtocolJSON {
+			p.jsonS = newJSONIngressServer(p.log, p.rpcGW, conn)
+		}
 		p.rpcGWMu.Unlock()
 
 		// Send ready signal.
@@ -109,12 +116,20 @@ func (p *Proc) InjectConn(conn net.Conn) bool {
 	return ok
 }
 
-func (p *Proc) awaitConn() bool {
-	rpcS := rpc.NewServer()
-	if err := rpcS.RegisterName(p.conf.ProcKey.String(), p.rpcGW); err != nil {
-		panic(err)
+// eventClient returns a client which pushes events to the proc over its conn.
+// It's only available if the proc uses the JSON protocol.
+func (p *Proc) eventClient(hello *appcommon.Hello) (appevent.RPCClient, bool) {
+	p.rpcGWMu.Lock()
+	defer p.rpcGWMu.Unlock()
+
+	if p.jsonS == nil {
+		return nil, false
 	}
 
+	return p.jsonS.eventClient(hello), true
+}
+
+func (p *Proc) awaitConn() bool {
 	connDelta := p.rpcGW.cm.AddDeltaInformer()
 	go func() {
 		for n := range connDelta.Chan() {
@@ -135,7 +150,16 @@ func (p *Proc) awaitConn() bool {
 		}
 	}()
 
-	go rpcS.ServeConn(p.conn)
+	if p.jsonS != nil {
+		go p.jsonS.serve()
+	} else {
+		rpcS := rpc.NewServer()
+		if err := rpcS.RegisterName(p.conf.ProcKey.String(), p.rpcGW); err != nil {
+			panic(err)
+		}
+
+		go rpcS.ServeConn(p.conn)
+	}
 
 	p.log.Info("Associated and serving proc conn.")
 	return true
@@ -150,23 +174,42 @@ func (p *Proc) Start() error {
 	// Acquire lock immediately.
 	p.waitMx.Lock()
 
+	sb, err := prepareSandbox(p.cmd, p.conf)
+	if err != nil {
+		p.waitMx.Unlock()
+		return fmt.Errorf("failed to prepare sandbox: %w", err)
+	}
+
 	if err := p.cmd.Start(); err != nil {
+		sb.cleanup()
 		p.waitMx.Unlock()
 		return err
 	}
 
+	sb.started()
+
 	go func() {
+		// Sandbox is released last, after the process has exited.
+		defer sb.cleanup()
+
 		waitErrCh := make(chan error)
 		go func() {
 			waitErrCh <- p.cmd.Wait()
 			close(waitErrCh)
 		}()
 
+		// exit is captured before proc is removed from the manager, as removal stops the proc.
+		var exit ProcExit
+
 		defer func() {
 			// here will definitely be an error notifying that the process
 			// is already stopped. We do this to remove proc from the manager,
 			// therefore giving the correct app status to hypervisor.
 			_ = p.m.Stop(p.appName) //nolint:errcheck
+
+			if p.onExit != nil {
+				p.onExit(exit)
+			}
 		}()
 
 		select {
@@ -175,6 +218,7 @@ func (p *Proc) Start() error {
 				// in this case app got stopped from the outer code before initializing the connection,
 				// just kill the process and exit.
 				_ = p.cmd.Process.Kill() //nolint:errcheck
+				exit = p.exit(<-waitErrCh)
 				p.waitMx.Unlock()
 
 				return
@@ -183,6 +227,7 @@ func (p *Proc) Start() error {
 			// in this case app process finished before initializing the connection. Happens if an
 			// error occurred during app startup.
 			p.waitErr = waitErr
+			exit = p.exit(waitErr)
 			p.waitMx.Unlock()
 
 			// channel won't get closed outside, close it now.
@@ -196,6 +241,7 @@ func (p *Proc) Start() error {
 
 		if ok := p.awaitConn(); !ok {
 			_ = p.cmd.Process.Kill() //nolint:errcheck
+			exit = p.exit(<-waitErrCh)
 			p.waitMx.Unlock()
 			return
 		}
@@ -206,6 +252,7 @@ func (p *Proc) Start() error {
 
 		// Wait for proc to exit.
 		p.waitErr = <-waitErrCh
+		exit = p.exit(p.waitErr)
 
 		// Close proc conn and associated listeners and connections.
 		if err := p.conn.Close(); err != nil && !strings.Contains(err.Error(), "use of closed network connection") {
@@ -213,6 +260,7 @@ func (p *Proc) Start() error {
 		}
 		p.rpcGW.cm.CloseAll()
 		p.rpcGW.lm.CloseAll()
+		p.rpcGW.pm.CloseAll()
 
 		// Unlock.
 		p.waitMx.Unlock()
@@ -227,6 +275,8 @@ func (p *Proc) Stop() error {
 		return errProcNotStarted
 	}
 
+	atomic.StoreInt32(&p.stopping, 1)
+
 	if p.cmd.Process != nil {
 		err := p.cmd.Process.Signal(os.Interrupt)
 		if err != nil {
@@ -244,6 +294,34 @@ func (p *Proc) Stop() error {
 	return nil
 }
 
+// ProcExit describes how a proc exited.
+type ProcExit struct {
+	AppName  string
+	ExitCode int   // -1 if the proc was killed by a signal or failed to run
+	Err      error // error returned by waiting for the proc
+	Stopped  bool  // whether the proc was stopped with Stop rather than exited by itself
+}
+
+// Failed returns whether the proc exited by itself with a failure.
+func (e ProcExit) Failed() bool {
+	return !e.Stopped && (e.ExitCode != 0 || e.Err != nil)
+}
+
+func (p *Proc) exit(waitErr error) ProcExit {
+	exit := ProcExit{
+		AppName:  p.appName,
+		ExitCode: -1,
+		Err:      waitErr,
+		Stopped:  atomic.LoadInt32(&p.stopping) == 1,
+	}
+
+	if p.cmd.ProcessState != nil {
+		exit.ExitCode = p.cmd.ProcessState.ExitCode()
+	}
+
+	return exit
+}
+
 // Wait waits for the application cmd to exit.
 func (p *Proc) Wait() error {
 	if atomic.LoadInt32(&p.isRunning) != 1 {
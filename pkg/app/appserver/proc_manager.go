@@ -43,6 +43,7 @@ type ProcManager interface {
 	Range(next func(appName string, proc *Proc) bool)
 	ConnectionsSummary(appName string) ([]ConnectionSummary, error)
 	Addr() net.Addr
+	SetExitHandler(h func(ProcExit))
 }
 
 // procManager manages skywire applications. It implements `ProcManager`.
@@ -61,6 +62,9 @@ type procManager struct {
 	// event broadcaster: broadcasts events to apps
 	eb *appevent.Broadcaster
 
+	// exit handler: called when a proc exits
+	onExit func(ProcExit)
+
 	mx   sync.RWMutex
 	done chan struct{}
 }
@@ -156,10 +160,22 @@ func (m *procManager) handleConn(conn net.Conn) bool {
 		log.Error("Failed to find proc of given key.")
 		return false
 	}
-	if ok := proc.InjectConn(conn); !ok {
+	switch hello.ProtocolOrDefault() {
+	case appcommon.ProtocolRPC, appcommon.ProtocolJSON:
+	default:
+		log.Error("Proc requested unknown protocol.")
+		return false
+	}
+	if ok := proc.InjectConn(conn, hello.ProtocolOrDefault()); !ok {
 		log.Error("Failed to associate conn with proc.")
 		return false
 	}
+	// Apps using the JSON protocol receive events over the proc conn.
+	if len(hello.EventSubs) > 0 {
+		if c, ok := proc.eventClient(hello); ok {
+			m.eb.AddClient(c)
+		}
+	}
 	log.Info("Accepted proc conn.")
 	return true
 }
@@ -197,6 +213,7 @@ func (m *procManager) Start(conf appcommon.ProcConfig) (appcommon.ProcID, error)
 	}
 
 	proc := NewProc(m.mLog, conf, disc, m, conf.AppName)
+	proc.onExit = m.onExit
 	m.procs[conf.AppName] = proc
 	m.procsByKey[conf.ProcKey] = proc
 
@@ -290,6 +307,14 @@ func (m *procManager) stopAll() {
 	m.procs = make(map[string]*Proc)
 }
 
+// SetExitHandler sets a handler which is called every time a proc exits.
+// Only procs started after the call are affected.
+func (m *procManager) SetExitHandler(h func(ProcExit)) {
+	m.mx.Lock()
+	m.onExit = h
+	m.mx.Unlock()
+}
+
 // Addr returns the underlying listener's listening address.
 func (m *procManager) Addr() net.Addr {
 	return m.lis.Addr()
@@ -1,10 +1,15 @@
 package appserver
 
 import (
+	"path/filepath"
+	"runtime"
 	"sort"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
+
+	"github.com/skycoin/skywire/pkg/app/appcommon"
 )
 
 func TestProcManager_ProcByName(t *testing.T) {
@@ -78,3 +83,41 @@ func TestProcManager_Pop(t *testing.T) {
 	_, ok = m.procs[appName]
 	require.False(t, ok)
 }
+
+func TestProcManager_SetExitHandler(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("requires sh")
+	}
+
+	mI, err := NewProcManager(nil, nil, nil, ":0")
+	require.NoError(t, err)
+
+	defer func() { require.NoError(t, mI.Close()) }()
+
+	exitCh := make(chan ProcExit, 1)
+	mI.SetExitHandler(func(exit ProcExit) { exitCh <- exit })
+
+	dir := t.TempDir()
+	_, err = mI.Start(appcommon.ProcConfig{
+		AppName:     "app",
+		ProcKey:     appcommon.RandProcKey(),
+		ProcArgs:    []string{"-c", "exit 3"},
+		ProcWorkDir: dir,
+		BinaryLoc:   "/bin/sh",
+		LogDBLoc:    filepath.Join(dir, "app_log.db"),
+	})
+	require.NoError(t, err)
+
+	select {
+	case exit := <-exitCh:
+		require.Equal(t, "app", exit.AppName)
+		require.Equal(t, 3, exit.ExitCode)
+		require.False(t, exit.Stopped)
+		require.True(t, exit.Failed())
+	case <-time.After(10 * time.Second):
+		t.Fatal("exit handler was not called")
+	}
+
+	_, ok := mI.ProcByName("app")
+	require.False(t, ok)
+}
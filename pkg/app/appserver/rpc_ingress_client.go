@@ -24,6 +24,15 @@ type RPCIngressClient interface {
 	SetDeadline(connID uint16, d time.Time) error
 	SetReadDeadline(connID uint16, d time.Time) error
 	SetWriteDeadline(connID uint16, d time.Time) error
+	DialPacket(remote appnet.Addr) (connID uint16, localPort routing.Port, err error)
+	ListenPacket(local appnet.Addr) (uint16, error)
+	ReadFrom(pcID uint16, b []byte) (int, appnet.Addr, error)
+	WriteTo(pcID uint16, b []byte, remote appnet.Addr) (int, error)
+	ClosePacketConn(id uint16) error
+	SetPacketDeadline(pcID uint16, d time.Time) error
+	SetPacketReadDeadline(pcID uint16, d time.Time) error
+	SetPacketWriteDeadline(pcID uint16, d time.Time) error
+	Passthrough(connID uint16) (path string, token []byte, err error)
 }
 
 // rpcIngressClient implements `RPCIngressClient`.
@@ -144,6 +153,106 @@ func (c *rpcIngressClient) SetWriteDeadline(id uint16, t time.Time) error {
 	return c.rpc.Call(c.formatMethod("SetWriteDeadline"), &req, nil)
 }
 
+// DialPacket sends `DialPacket` command to the server.
+func (c *rpcIngressClient) DialPacket(remote appnet.Addr) (connID uint16, localPort routing.Port, err error) {
+	var resp DialResp
+	if err := c.rpc.Call(c.formatMethod("DialPacket"), &remote, &resp); err != nil {
+		return 0, 0, err
+	}
+
+	return resp.ConnID, resp.LocalPort, nil
+}
+
+// ListenPacket sends `ListenPacket` command to the server.
+func (c *rpcIngressClient) ListenPacket(local appnet.Addr) (uint16, error) {
+	var pcID uint16
+	if err := c.rpc.Call(c.formatMethod("ListenPacket"), &local, &pcID); err != nil {
+		return 0, err
+	}
+
+	return pcID, nil
+}
+
+// ReadFrom sends `ReadFrom` command to the server.
+func (c *rpcIngressClient) ReadFrom(pcID uint16, b []byte) (int, appnet.Addr, error) {
+	req := ReadFromReq{
+		PacketConnID: pcID,
+		BufLen:       len(b),
+	}
+
+	var resp ReadFromResp
+	if err := c.rpc.Call(c.formatMethod("ReadFrom"), &req, &resp); err != nil {
+		return 0, appnet.Addr{}, err
+	}
+
+	if resp.N != 0 {
+		copy(b[:resp.N], resp.B[:resp.N])
+	}
+
+	return resp.N, resp.Remote, resp.Err.ToError()
+}
+
+// WriteTo sends `WriteTo` command to the server.
+func (c *rpcIngressClient) WriteTo(pcID uint16, b []byte, remote appnet.Addr) (int, error) {
+	req := WriteToReq{
+		PacketConnID: pcID,
+		B:            b,
+		Remote:       remote,
+	}
+
+	var resp WriteResp
+	if err := c.rpc.Call(c.formatMethod("WriteTo"), &req, &resp); err != nil {
+		return 0, err
+	}
+
+	return resp.N, resp.Err.ToError()
+}
+
+// ClosePacketConn sends `ClosePacketConn` command to the server.
+func (c *rpcIngressClient) ClosePacketConn(id uint16) error {
+	return c.rpc.Call(c.formatMethod("ClosePacketConn"), &id, nil)
+}
+
+// SetPacketDeadline sends `SetPacketDeadline` command to the server.
+func (c *rpcIngressClient) SetPacketDeadline(pcID uint16, t time.Time) error {
+	req := PacketDeadlineReq{
+		PacketConnID: pcID,
+		Deadline:     t,
+	}
+
+	return c.rpc.Call(c.formatMethod("SetPacketDeadline"), &req, nil)
+}
+
+// SetPacketReadDeadline sends `SetPacketReadDeadline` command to the server.
+func (c *rpcIngressClient) SetPacketReadDeadline(pcID uint16, t time.Time) error {
+	req := PacketDeadlineReq{
+		PacketConnID: pcID,
+		Deadline:     t,
+	}
+
+	return c.rpc.Call(c.formatMethod("SetPacketReadDeadline"), &req, nil)
+}
+
+// SetPacketWriteDeadline sends `SetPacketWriteDeadline` command to the server.
+func (c *rpcIngressClient) SetPacketWriteDeadline(pcID uint16, t time.Time) error {
+	req := PacketDeadlineReq{
+		PacketConnID: pcID,
+		Deadline:     t,
+	}
+
+	return c.rpc.Call(c.formatMethod("SetPacketWriteDeadline"), &req, nil)
+}
+
+// Passthrough sends `Passthrough` command to the server.
+func (c *rpcIngressClient) Passthrough(connID uint16) (path string, token []byte, err error) {
+	var resp PassthroughResp
+	if err := c.rpc.Call(c.formatMethod("Passthrough"), &connID, &resp); err != nil {
+		return "", nil, err
+	}
+
+	return resp.Path, resp.Token, nil
+}
+
 // formatMethod formats complete RPC method signature.
 func (c *rpcIngressClient) formatMethod(method string) string {
 	const methodFmt = "%s.%s"
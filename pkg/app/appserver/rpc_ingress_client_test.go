@@ -657,6 +657,55 @@ func TestRPCClient_SetWriteDeadline(t *testing.T) {
 	})
 }
 
+func TestRPCClient_PacketConn(t *testing.T) {
+	gateway := NewRPCGateway(nil)
+	s := prepRPCServer(t, gateway)
+	rpcL, lisCleanup := prepListener(t)
+	defer lisCleanup()
+	go s.Accept(rpcL)
+
+	cl := prepRPCClient(t, rpcL.Addr().Network(), rpcL.Addr().String())
+
+	_, _, local, remote := prepAddrs()
+	local.Net, remote.Net = appnet.TypeSkynet, appnet.TypeSkynet
+
+	pc := newTestPacketConn(local)
+
+	var noErr error
+
+	n := &appnet.MockNetworker{}
+	n.On("ListenPacketContext", mock.Anything, local).Return(pc, noErr)
+
+	appnet.ClearNetworkers()
+	err := appnet.AddNetworker(appnet.TypeSkynet, n)
+	require.NoError(t, err)
+
+	pcID, err := cl.ListenPacket(local)
+	require.NoError(t, err)
+	require.Equal(t, uint16(1), pcID)
+
+	n2, err := cl.WriteTo(pcID, []byte("data"), remote)
+	require.NoError(t, err)
+	require.Equal(t, 4, n2)
+	require.Equal(t, remote, <-pc.out)
+
+	pc.in <- remote
+
+	buf := make([]byte, 10)
+	n2, from, err := cl.ReadFrom(pcID, buf)
+	require.NoError(t, err)
+	require.Equal(t, "data", string(buf[:n2]))
+	require.Equal(t, remote, from)
+
+	require.NoError(t, cl.SetPacketDeadline(pcID, time.Now()))
+
+	require.NoError(t, cl.ClosePacketConn(pcID))
+	require.True(t, pc.closed)
+
+	err = cl.ClosePacketConn(pcID)
+	require.Error(t, err)
+}
+
 func prepNetworkerWithListener(t *testing.T, lis *appcommon.MockListener, local appnet.Addr) {
 	var noErr error
 
@@ -59,6 +59,7 @@ func (e *RPCIOErr) ToError() error {
 type RPCIngressGateway struct {
 	lm  *idmanager.Manager // contains listeners associated with their IDs
 	cm  *idmanager.Manager // contains connections associated with their IDs
+	pm  *idmanager.Manager // contains packet conns associated with their IDs
 	log *logging.Logger
 }
 
@@ -70,6 +71,7 @@ func NewRPCGateway(log *logging.Logger) *RPCIngressGateway {
 	return &RPCIngressGateway{
 		lm:  idmanager.New(),
 		cm:  idmanager.New(),
+		pm:  idmanager.New(),
 		log: log,
 	}
 }
@@ -84,12 +86,24 @@ type DialResp struct {
 func (r *RPCIngressGateway) Dial(remote *appnet.Addr, resp *DialResp) (err error) {
 	defer rpcutil.LogCall(r.log, "Dial", remote)(resp, &err)
 
+	return r.dial(*remote, resp, appnet.Dial)
+}
+
+// DialPacket dials to the remote for exchanging datagrams. Resulting conn is used with the conn methods,
+// each Write sends a single datagram and each Read returns a single datagram.
+func (r *RPCIngressGateway) DialPacket(remote *appnet.Addr, resp *DialResp) (err error) {
+	defer rpcutil.LogCall(r.log, "DialPacket", remote)(resp, &err)
+
+	return r.dial(*remote, resp, appnet.DialPacket)
+}
+
+func (r *RPCIngressGateway) dial(remote appnet.Addr, resp *DialResp, dial func(appnet.Addr) (net.Conn, error)) error {
 	reservedConnID, free, err := r.cm.ReserveNextID()
 	if err != nil {
 		return err
 	}
 
-	conn, err := appnet.Dial(*remote)
+	conn, err := dial(remote)
 	if err != nil {
 		free()
 		return err
@@ -273,6 +287,46 @@ func (r *RPCIngressGateway) CloseConn(connID *uint16, _ *struct{}) (err error) {
 	return conn.Close()
 }
 
+// PassthroughResp contains response parameters for `Passthrough`.
+type PassthroughResp struct {
+	Path  string
+	Token []byte
+}
+
+// Passthrough creates a unix socket at `Path` relaying data of the conn specified by `connID`.
+// The app connects to it and sends `Token` to read and write the conn directly, bypassing RPC.
+// The conn is closed once the socket is closed. If the app doesn't connect in time,
+// the conn is left as is.
+func (r *RPCIngressGateway) Passthrough(connID *uint16, resp *PassthroughResp) (err error) {
+	defer rpcutil.LogCall(r.log, "Passthrough", connID)(nil, &err)
+
+	id := *connID
+
+	conn, err := r.getConn(id)
+	if err != nil {
+		return err
+	}
+
+	pt, err := newPassthrough(r.log.WithField("conn_id", id), conn, func() {
+		// conn may have been closed with `CloseConn` already
+		if v, ok := r.cm.Get(id); ok && v == conn {
+			if _, err := r.cm.Pop(id); err != nil {
+				r.log.WithError(err).Debug("Failed to remove passthrough conn.")
+			}
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	resp.Path = pt.path
+	resp.Token = pt.token
+
+	go pt.serve()
+
+	return nil
+}
+
 // CloseListener closes listener specified by `lisID`.
 func (r *RPCIngressGateway) CloseListener(lisID *uint16, _ *struct{}) (err error) {
 	defer rpcutil.LogCall(r.log, "CloseConn", lisID)(nil, &err)
@@ -321,6 +375,150 @@ func (r *RPCIngressGateway) SetWriteDeadline(req *DeadlineReq, _ *struct{}) erro
 	return conn.SetWriteDeadline(req.Deadline)
 }
 
+// ListenPacket starts listening for datagrams.
+func (r *RPCIngressGateway) ListenPacket(local *appnet.Addr, pcID *uint16) (err error) {
+	defer rpcutil.LogCall(r.log, "ListenPacket", local)(pcID, &err)
+
+	nextPCID, free, err := r.pm.ReserveNextID()
+	if err != nil {
+		return err
+	}
+
+	pc, err := appnet.ListenPacket(*local)
+	if err != nil {
+		free()
+		return err
+	}
+
+	if err := r.pm.Set(*nextPCID, pc); err != nil {
+		if cErr := pc.Close(); cErr != nil {
+			r.log.WithError(cErr).Error("Error closing packet conn.")
+		}
+		free()
+		return err
+	}
+
+	*pcID = *nextPCID
+	return nil
+}
+
+// ReadFromReq contains arguments for `ReadFrom`.
+type ReadFromReq struct {
+	PacketConnID uint16
+	BufLen       int
+}
+
+// ReadFromResp contains response parameters for `ReadFrom`.
+type ReadFromResp struct {
+	B      []byte
+	N      int
+	Remote appnet.Addr
+	Err    *RPCIOErr
+}
+
+// ReadFrom reads a single datagram from packet conn specified by `PacketConnID`.
+func (r *RPCIngressGateway) ReadFrom(req *ReadFromReq, resp *ReadFromResp) error {
+	pc, err := r.getPacketConn(req.PacketConnID)
+	if err != nil {
+		return err
+	}
+
+	buf := make([]byte, req.BufLen)
+
+	var addr net.Addr
+	resp.N, addr, err = pc.ReadFrom(buf)
+	if resp.N != 0 {
+		resp.B = make([]byte, resp.N)
+		copy(resp.B, buf[:resp.N])
+	}
+	if addr != nil {
+		remote, cErr := appnet.ConvertAddr(addr)
+		if cErr != nil {
+			return cErr
+		}
+		resp.Remote = remote
+	}
+
+	resp.Err = ioErrToRPCIOErr(err)
+
+	// avoid error in RPC pipeline, error is included in response body
+	return nil
+}
+
+// WriteToReq contains arguments for `WriteTo`.
+type WriteToReq struct {
+	PacketConnID uint16
+	B            []byte
+	Remote       appnet.Addr
+}
+
+// WriteTo writes a single datagram to `Remote` via packet conn specified by `PacketConnID`.
+func (r *RPCIngressGateway) WriteTo(req *WriteToReq, resp *WriteResp) error {
+	pc, err := r.getPacketConn(req.PacketConnID)
+	if err != nil {
+		return err
+	}
+
+	resp.N, err = pc.WriteTo(req.B, req.Remote)
+	resp.Err = ioErrToRPCIOErr(err)
+
+	// avoid error in RPC pipeline, error is included in response body
+	return nil
+}
+
+// ClosePacketConn closes packet conn specified by `pcID`.
+func (r *RPCIngressGateway) ClosePacketConn(pcID *uint16, _ *struct{}) (err error) {
+	defer rpcutil.LogCall(r.log, "ClosePacketConn", pcID)(nil, &err)
+
+	pcIfc, err := r.pm.Pop(*pcID)
+	if err != nil {
+		return fmt.Errorf("no packet conn: %w", err)
+	}
+
+	pc, err := idmanager.AssertPacketConn(pcIfc)
+	if err != nil {
+		return err
+	}
+
+	return pc.Close()
+}
+
+// PacketDeadlineReq contains arguments for packet conn deadline methods.
+type PacketDeadlineReq struct {
+	PacketConnID uint16
+	Deadline     time.Time
+}
+
+// SetPacketDeadline sets deadline for packet conn specified by `PacketConnID`.
+func (r *RPCIngressGateway) SetPacketDeadline(req *PacketDeadlineReq, _ *struct{}) error {
+	pc, err := r.getPacketConn(req.PacketConnID)
+	if err != nil {
+		return err
+	}
+
+	return pc.SetDeadline(req.Deadline)
+}
+
+// SetPacketReadDeadline sets read deadline for packet conn specified by `PacketConnID`.
+func (r *RPCIngressGateway) SetPacketReadDeadline(req *PacketDeadlineReq, _ *struct{}) error {
+	pc, err := r.getPacketConn(req.PacketConnID)
+	if err != nil {
+		return err
+	}
+
+	return pc.SetReadDeadline(req.Deadline)
+}
+
+// SetPacketWriteDeadline sets write deadline for packet conn specified by `PacketConnID`.
+func (r *RPCIngressGateway) SetPacketWriteDeadline(req *PacketDeadlineReq, _ *struct{}) error {
+	pc, err := r.getPacketConn(req.PacketConnID)
+	if err != nil {
+		return err
+	}
+
+	return pc.SetWriteDeadline(req.Deadline)
+}
+
 // popListener gets listener from the manager by `lisID` and removes it.
 // Handles type assertion.
 func (r *RPCIngressGateway) popListener(lisID uint16) (net.Listener, error) {
@@ -363,6 +561,16 @@ func (r *RPCIngressGateway) getConn(connID uint16) (net.Conn, error) {
 	return idmanager.AssertConn(connIfc)
 }
 
+// getPacketConn gets packet conn from the manager by `pcID`. Handles type assertion.
+func (r *RPCIngressGateway) getPacketConn(pcID uint16) (net.PacketConn, error) {
+	pcIfc, ok := r.pm.Get(pcID)
+	if !ok {
+		return nil, fmt.Errorf("no packet conn with key %d", pcID)
+	}
+
+	return idmanager.AssertPacketConn(pcIfc)
+}
+
 func ioErrToRPCIOErr(err error) *RPCIOErr {
 	if err == nil {
 		return nil
@@ -1045,3 +1045,184 @@ func addListener(t *testing.T, rpc *RPCIngressGateway, lis net.Listener) uint16
 
 	return *lisID
 }
+
+func TestRPCGateway_DialPacket(t *testing.T) {
+	l := logging.MustGetLogger("rpc_gateway")
+
+	t.Run("ok", func(t *testing.T) {
+		appnet.ClearNetworkers()
+
+		dialAddr := prepAddr(appnet.TypeSkynet)
+
+		dialConn := &appcommon.MockConn{}
+		dialConn.On("LocalAddr").Return(routing.Addr{Port: 100})
+		dialConn.On("RemoteAddr").Return(routing.Addr{})
+
+		n := &appnet.MockNetworker{}
+		n.On("DialPacketContext", context.Background(), dialAddr).Return(dialConn, testhelpers.NoErr)
+
+		require.NoError(t, appnet.AddNetworker(appnet.TypeSkynet, n))
+
+		rpc := NewRPCGateway(l)
+
+		var resp DialResp
+		err := rpc.DialPacket(&dialAddr, &resp)
+		require.NoError(t, err)
+		require.Equal(t, DialResp{ConnID: 1, LocalPort: 100}, resp)
+	})
+
+	t.Run("unsupported", func(t *testing.T) {
+		appnet.ClearNetworkers()
+
+		dialAddr := prepAddr(appnet.TypeDmsg)
+
+		n := &appnet.MockNetworker{}
+		n.On("DialPacketContext", context.Background(), dialAddr).Return(nil, appnet.ErrDatagramUnsupported)
+
+		require.NoError(t, appnet.AddNetworker(appnet.TypeDmsg, n))
+
+		rpc := NewRPCGateway(l)
+
+		var resp DialResp
+		err := rpc.DialPacket(&dialAddr, &resp)
+		require.Equal(t, appnet.ErrDatagramUnsupported, err)
+
+		_, ok := rpc.cm.Get(1)
+		require.False(t, ok)
+	})
+}
+
+func TestRPCGateway_ListenPacket(t *testing.T) {
+	l := logging.MustGetLogger("rpc_gateway")
+
+	t.Run("ok", func(t *testing.T) {
+		appnet.ClearNetworkers()
+
+		listenAddr := prepAddr(appnet.TypeSkynet)
+
+		n := &appnet.MockNetworker{}
+		n.On("ListenPacketContext", context.Background(), listenAddr).Return(newTestPacketConn(listenAddr), testhelpers.NoErr)
+
+		require.NoError(t, appnet.AddNetworker(appnet.TypeSkynet, n))
+
+		rpc := NewRPCGateway(l)
+
+		var pcID uint16
+		err := rpc.ListenPacket(&listenAddr, &pcID)
+		require.NoError(t, err)
+		require.Equal(t, uint16(1), pcID)
+	})
+
+	t.Run("unsupported", func(t *testing.T) {
+		appnet.ClearNetworkers()
+
+		listenAddr := prepAddr(appnet.TypeDmsg)
+
+		n := &appnet.MockNetworker{}
+		n.On("ListenPacketContext", context.Background(), listenAddr).Return(nil, appnet.ErrDatagramUnsupported)
+
+		require.NoError(t, appnet.AddNetworker(appnet.TypeDmsg, n))
+
+		rpc := NewRPCGateway(l)
+
+		var pcID uint16
+		err := rpc.ListenPacket(&listenAddr, &pcID)
+		require.Equal(t, appnet.ErrDatagramUnsupported, err)
+
+		_, ok := rpc.pm.Get(1)
+		require.False(t, ok)
+	})
+}
+
+func TestRPCGateway_PacketConn(t *testing.T) {
+	l := logging.MustGetLogger("rpc_gateway")
+
+	local, remote := prepAddr(appnet.TypeSkynet), prepAddr(appnet.TypeSkynet)
+
+	t.Run("read from", func(t *testing.T) {
+		rpc := NewRPCGateway(l)
+
+		pc := newTestPacketConn(local)
+		pc.in <- appnet.Addr{Net: remote.Net, PubKey: remote.PubKey, Port: remote.Port}
+
+		pcID := addPacketConn(t, rpc, pc)
+
+		var resp ReadFromResp
+		err := rpc.ReadFrom(&ReadFromReq{PacketConnID: pcID, BufLen: 3}, &resp)
+		require.NoError(t, err)
+		require.Equal(t, ReadFromResp{B: []byte("dat"), N: 3, Remote: remote}, resp)
+	})
+
+	t.Run("write to", func(t *testing.T) {
+		rpc := NewRPCGateway(l)
+
+		pc := newTestPacketConn(local)
+		pcID := addPacketConn(t, rpc, pc)
+
+		var resp WriteResp
+		err := rpc.WriteTo(&WriteToReq{PacketConnID: pcID, B: []byte("data"), Remote: remote}, &resp)
+		require.NoError(t, err)
+		require.Equal(t, WriteResp{N: 4}, resp)
+		require.Equal(t, remote, <-pc.out)
+	})
+
+	t.Run("close", func(t *testing.T) {
+		rpc := NewRPCGateway(l)
+
+		pc := newTestPacketConn(local)
+		pcID := addPacketConn(t, rpc, pc)
+
+		err := rpc.ClosePacketConn(&pcID, nil)
+		require.NoError(t, err)
+		require.True(t, pc.closed)
+
+		var resp ReadFromResp
+		err = rpc.ReadFrom(&ReadFromReq{PacketConnID: pcID, BufLen: 3}, &resp)
+		require.Equal(t, fmt.Errorf("no packet conn with key %d", pcID), err)
+	})
+}
+
+func addPacketConn(t *testing.T, rpc *RPCIngressGateway, pc net.PacketConn) uint16 {
+	pcID, _, err := rpc.pm.ReserveNextID()
+	require.NoError(t, err)
+
+	err = rpc.pm.Set(*pcID, pc)
+	require.NoError(t, err)
+
+	return *pcID
+}
+
+// testPacketConn reads `data` from each addr sent to `in` and sends the addr of each write to `out`.
+type testPacketConn struct {
+	addr   appnet.Addr
+	in     chan net.Addr
+	out    chan net.Addr
+	closed bool
+}
+
+func newTestPacketConn(addr appnet.Addr) *testPacketConn {
+	return &testPacketConn{
+		addr: addr,
+		in:   make(chan net.Addr, 1),
+		out:  make(chan net.Addr, 1),
+	}
+}
+
+func (pc *testPacketConn) ReadFrom(p []byte) (int, net.Addr, error) {
+	return copy(p, "data"), <-pc.in, nil
+}
+
+func (pc *testPacketConn) WriteTo(p []byte, addr net.Addr) (int, error) {
+	pc.out <- addr
+	return len(p), nil
+}
+
+func (pc *testPacketConn) Close() error {
+	pc.closed = true
+	return nil
+}
+
+func (pc *testPacketConn) LocalAddr() net.Addr                { return pc.addr }
+func (pc *testPacketConn) SetDeadline(_ time.Time) error      { return nil }
+func (pc *testPacketConn) SetReadDeadline(_ time.Time) error  { return nil }
+func (pc *testPacketConn) SetWriteDeadline(_ time.Time) error { return nil }
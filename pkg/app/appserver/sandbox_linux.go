@@ -0,0 +1,340 @@
+// +build linux
+
+package appserver
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+
+	"github.com/skycoin/skywire/pkg/app/appcommon"
+)
+
+const (
+	// cpuMaxPeriod is the period of cgroup v2 cpu.max in microseconds.
+	cpuMaxPeriod = 100000
+
+	// sandboxExecArg0 is argv[0] of the visor binary re-executed to set rlimits of an app before executing it.
+	sandboxExecArg0 = "skywire-sandbox-exec"
+	// sandboxRlimitsEnv passes rlimits to the re-executed visor binary.
+	sandboxRlimitsEnv = "SKYWIRE_SANDBOX_RLIMITS"
+)
+
+var rlimitResources = map[string]int{
+	"as":      unix.RLIMIT_AS,
+	"core":    unix.RLIMIT_CORE,
+	"cpu":     unix.RLIMIT_CPU,
+	"data":    unix.RLIMIT_DATA,
+	"fsize":   unix.RLIMIT_FSIZE,
+	"memlock": unix.RLIMIT_MEMLOCK,
+	"nofile":  unix.RLIMIT_NOFILE,
+	"nproc":   unix.RLIMIT_NPROC,
+	"stack":   unix.RLIMIT_STACK,
+}
+
+func init() {
+	if len(os.Args) > 1 && os.Args[0] == sandboxExecArg0 {
+		sandboxExec()
+	}
+}
+
+// sandboxExec sets rlimits passed by the visor and executes the app in place of the current process,
+// so that the limits apply before the app runs. It never returns.
+// Args are the app binary path followed by the app argv.
+func sandboxExec() {
+	if err := setRlimits(os.Getenv(sandboxRlimitsEnv)); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to set app rlimits: %v\n", err)
+		os.Exit(1)
+	}
+
+	env := make([]string, 0, len(os.Environ()))
+	for _, kv := range os.Environ() {
+		if !strings.HasPrefix(kv, sandboxRlimitsEnv+"=") {
+			env = append(env, kv)
+		}
+	}
+
+	err := syscall.Exec(os.Args[1], os.Args[2:], env) // nolint:gosec
+	fmt.Fprintf(os.Stderr, "failed to execute app %s: %v\n", os.Args[1], err)
+	os.Exit(1)
+}
+
+// setRlimits sets rlimits of the current process from `limits` formatted as name=value,...
+func setRlimits(limits string) error {
+	for _, kv := range strings.Split(limits, ",") {
+		if kv == "" {
+			continue
+		}
+
+		name, value := kv, ""
+		if i := strings.IndexByte(kv, '='); i >= 0 {
+			name, value = kv[:i], kv[i+1:]
+		}
+
+		resource, ok := rlimitResources[name]
+		if !ok {
+			return fmt.Errorf("unknown rlimit %q", name)
+		}
+
+		v, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid rlimit %s: %w", name, err)
+		}
+
+		limit := syscall.Rlimit{Cur: v, Max: v}
+		if err := syscall.Setrlimit(resource, &limit); err != nil {
+			return fmt.Errorf("rlimit %s: %w", name, err)
+		}
+	}
+
+	return nil
+}
+
+// sandbox holds resources of an app sandbox. Methods of a nil sandbox are no-op.
+type sandbox struct {
+	conf      *appcommon.SandboxConfig
+	cgroupDir string
+	cgroupFD  *os.File
+}
+
+// prepareSandbox prepares `cmd` to run in a sandbox described by `conf`.
+// App cgroup is created and `cmd` is set up to be started in it with rlimits already applied.
+func prepareSandbox(cmd *exec.Cmd, conf appcommon.ProcConfig) (_ *sandbox, err error) {
+	if conf.Sandbox == nil {
+		return nil, nil
+	}
+
+	sb := &sandbox{conf: conf.Sandbox}
+	defer func() {
+		if err != nil {
+			sb.cleanup()
+		}
+	}()
+
+	for name := range sb.conf.Rlimits {
+		if _, ok := rlimitResources[name]; !ok {
+			return nil, fmt.Errorf("unknown rlimit %q", name)
+		}
+	}
+
+	if err := sb.conf.Validate(); err != nil {
+		return nil, err
+	}
+
+	attr := cmd.SysProcAttr
+	if attr == nil {
+		attr = &syscall.SysProcAttr{}
+	}
+
+	if sb.conf.UID != nil {
+		gid := *sb.conf.UID
+		if sb.conf.GID != nil {
+			gid = *sb.conf.GID
+		}
+
+		// Supplementary groups of the visor are dropped.
+		attr.Credential = &syscall.Credential{Uid: *sb.conf.UID, Gid: gid}
+	}
+
+	if sb.conf.PrivateDir {
+		if err := makePrivateDir(conf.ProcWorkDir, attr.Credential); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(sb.conf.Rlimits) > 0 {
+		if err := execWithRlimits(cmd, sb.conf.Rlimits); err != nil {
+			return nil, err
+		}
+	}
+
+	if sb.conf.NeedsCgroup() {
+		if err := checkCgroupFDSupport(); err != nil {
+			return nil, err
+		}
+
+		if err := sb.makeCgroup(conf.AppName + "-" + conf.ProcKey.String()); err != nil {
+			return nil, fmt.Errorf("failed to create app cgroup: %w", err)
+		}
+
+		// Process is started in the cgroup right away, so limits apply before the app runs.
+		attr.UseCgroupFD = true
+		attr.CgroupFD = int(sb.cgroupFD.Fd())
+	}
+
+	cmd.SysProcAttr = attr
+
+	return sb, nil
+}
+
+// execWithRlimits makes `cmd` execute the visor binary which sets `rlimits` and then executes the app,
+// there's no way to set rlimits of a child process between fork and exec otherwise.
+// The visor binary has to be executable by the app user.
+func execWithRlimits(cmd *exec.Cmd, rlimits map[string]uint64) error {
+	self, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("failed to locate visor binary: %w", err)
+	}
+
+	limits := make([]string, 0, len(rlimits))
+	for name, value := range rlimits {
+		limits = append(limits, name+"="+strconv.FormatUint(value, 10))
+	}
+
+	if cmd.Env == nil {
+		cmd.Env = os.Environ()
+	}
+
+	cmd.Env = append(cmd.Env, sandboxRlimitsEnv+"="+strings.Join(limits, ","))
+	cmd.Args = append([]string{sandboxExecArg0, cmd.Path}, cmd.Args...)
+	cmd.Path = self
+
+	return nil
+}
+
+// checkCgroupFDSupport checks that the kernel is able to start processes in a cgroup (CLONE_INTO_CGROUP),
+// which is supported since Linux 5.7.
+func checkCgroupFDSupport() error {
+	var uts unix.Utsname
+	if err := unix.Uname(&uts); err != nil {
+		return fmt.Errorf("failed to get kernel version: %w", err)
+	}
+
+	release := unix.ByteSliceToString(uts.Release[:])
+
+	var major, minor int
+	if _, err := fmt.Sscanf(release, "%d.%d", &major, &minor); err != nil {
+		return fmt.Errorf("failed to parse kernel version %q: %w", release, err)
+	}
+
+	if major < 5 || major == 5 && minor < 7 {
+		return fmt.Errorf("app cgroup limits require linux 5.7 or newer, running %s", release)
+	}
+
+	return nil
+}
+
+func makePrivateDir(dir string, cred *syscall.Credential) error {
+	if err := os.Chmod(dir, 0700); err != nil {
+		return fmt.Errorf("failed to make working directory private: %w", err)
+	}
+
+	if cred != nil {
+		if err := os.Chown(dir, int(cred.Uid), int(cred.Gid)); err != nil {
+			return fmt.Errorf("failed to change owner of working directory: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func (sb *sandbox) makeCgroup(name string) error {
+	root := sb.conf.CgroupRoot
+	if root == "" {
+		root = appcommon.DefaultCgroupRoot
+	}
+
+	if err := os.MkdirAll(root, 0755); err != nil {
+		return err
+	}
+
+	var controllers []string
+	if sb.conf.MemoryMax > 0 {
+		controllers = append(controllers, "+memory")
+	}
+	if sb.conf.CPUMax > 0 {
+		controllers = append(controllers, "+cpu")
+	}
+	if sb.conf.PidsMax > 0 {
+		controllers = append(controllers, "+pids")
+	}
+
+	if err := writeCgroupFile(root, "cgroup.subtree_control", strings.Join(controllers, " ")); err != nil {
+		return fmt.Errorf("failed to enable controllers: %w", err)
+	}
+
+	sb.cgroupDir = filepath.Join(root, name)
+	if err := os.Mkdir(sb.cgroupDir, 0755); err != nil {
+		sb.cgroupDir = ""
+		return err
+	}
+
+	if sb.conf.MemoryMax > 0 {
+		if err := writeCgroupFile(sb.cgroupDir, "memory.max", strconv.FormatUint(sb.conf.MemoryMax, 10)); err != nil {
+			return err
+		}
+	}
+
+	if sb.conf.CPUMax > 0 {
+		quota := int64(sb.conf.CPUMax * cpuMaxPeriod)
+		if quota < 1000 {
+			quota = 1000
+		}
+
+		if err := writeCgroupFile(sb.cgroupDir, "cpu.max", fmt.Sprintf("%d %d", quota, cpuMaxPeriod)); err != nil {
+			return err
+		}
+	}
+
+	if sb.conf.PidsMax > 0 {
+		if err := writeCgroupFile(sb.cgroupDir, "pids.max", strconv.FormatUint(sb.conf.PidsMax, 10)); err != nil {
+			return err
+		}
+	}
+
+	fd, err := os.Open(sb.cgroupDir)
+	if err != nil {
+		return err
+	}
+	sb.cgroupFD = fd
+
+	return nil
+}
+
+func writeCgroupFile(dir, name, value string) error {
+	if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(value), 0644); err != nil { // nolint:gosec
+		return fmt.Errorf("failed to write %s: %w", name, err)
+	}
+
+	return nil
+}
+
+// started releases resources only needed to start the app process.
+func (sb *sandbox) started() {
+	if sb == nil {
+		return
+	}
+
+	if sb.cgroupFD != nil {
+		_ = sb.cgroupFD.Close() // nolint:errcheck
+		sb.cgroupFD = nil
+	}
+}
+
+// cleanup releases sandbox resources once the app process exits.
+func (sb *sandbox) cleanup() {
+	if sb == nil {
+		return
+	}
+
+	if sb.cgroupFD != nil {
+		_ = sb.cgroupFD.Close() // nolint:errcheck
+		sb.cgroupFD = nil
+	}
+
+	// cgroup may only be removed when it has no processes left, if the app left children behind
+	// the cgroup is kept along with its limits.
+	if sb.cgroupDir != "" {
+		if err := os.Remove(sb.cgroupDir); err == nil || errors.Is(err, os.ErrNotExist) {
+			sb.cgroupDir = ""
+		}
+	}
+}
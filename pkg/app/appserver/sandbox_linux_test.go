@@ -0,0 +1,82 @@
+// +build linux
+
+package appserver
+
+import (
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/skycoin/skywire/pkg/app/appcommon"
+)
+
+func TestPrepareSandbox(t *testing.T) {
+	t.Run("no sandbox", func(t *testing.T) {
+		cmd := exec.Command("sleep", "1")
+
+		sb, err := prepareSandbox(cmd, appcommon.ProcConfig{})
+		require.NoError(t, err)
+		assert.Nil(t, sb)
+		assert.Nil(t, cmd.SysProcAttr)
+	})
+
+	t.Run("unknown rlimit", func(t *testing.T) {
+		conf := appcommon.ProcConfig{Sandbox: &appcommon.SandboxConfig{Rlimits: map[string]uint64{"foo": 1}}}
+
+		_, err := prepareSandbox(exec.Command("sleep", "1"), conf)
+		assert.Error(t, err)
+	})
+
+	t.Run("uid without private dir", func(t *testing.T) {
+		uid := uint32(65534)
+		conf := appcommon.ProcConfig{Sandbox: &appcommon.SandboxConfig{UID: &uid}}
+
+		_, err := prepareSandbox(exec.Command("sleep", "1"), conf)
+		assert.Equal(t, appcommon.ErrSandboxUIDWithoutPrivateDir, err)
+	})
+
+	t.Run("rlimits", func(t *testing.T) {
+		conf := appcommon.ProcConfig{
+			ProcWorkDir: t.TempDir(),
+			Sandbox: &appcommon.SandboxConfig{
+				Rlimits:    map[string]uint64{"nofile": 64, "core": 0},
+				PrivateDir: true,
+			},
+		}
+
+		// Test binary is re-executed to set rlimits, the app sees them right from the start.
+		cmd := exec.Command("cat", "/proc/self/limits")
+
+		sb, err := prepareSandbox(cmd, conf)
+		require.NoError(t, err)
+		defer sb.cleanup()
+
+		limits, err := cmd.Output()
+		require.NoError(t, err)
+		assert.Equal(t, "64", limitOf(string(limits), "Max open files"))
+		assert.Equal(t, "0", limitOf(string(limits), "Max core file size"))
+
+		for _, kv := range cmd.Env {
+			if strings.HasPrefix(kv, sandboxRlimitsEnv+"=") {
+				assert.ElementsMatch(t, []string{"nofile=64", "core=0"}, strings.Split(strings.TrimPrefix(kv, sandboxRlimitsEnv+"="), ","))
+			}
+		}
+	})
+}
+
+// limitOf returns soft limit of `name` from /proc/<pid>/limits.
+func limitOf(limits, name string) string {
+	for _, line := range strings.Split(limits, "\n") {
+		if strings.HasPrefix(line, name) {
+			fields := strings.Fields(strings.TrimPrefix(line, name))
+			if len(fields) > 0 {
+				return fields[0]
+			}
+		}
+	}
+
+	return ""
+}
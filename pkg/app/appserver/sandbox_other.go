@@ -0,0 +1,24 @@
+// +build !linux
+
+package appserver
+
+import (
+	"os/exec"
+
+	"github.com/skycoin/skywire/pkg/app/appcommon"
+)
+
+// sandbox is not supported on this platform. Methods of a nil sandbox are no-op.
+type sandbox struct{}
+
+func prepareSandbox(_ *exec.Cmd, conf appcommon.ProcConfig) (*sandbox, error) {
+	if conf.Sandbox != nil {
+		return nil, appcommon.ErrSandboxUnsupported
+	}
+
+	return nil, nil
+}
+
+func (*sandbox) started() {}
+
+func (*sandbox) cleanup() {}
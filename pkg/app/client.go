@@ -23,7 +23,10 @@ type Client struct {
 	rpcC    appserver.RPCIngressClient
 	lm      *idmanager.Manager // contains listeners associated with their IDs
 	cm      *idmanager.Manager // contains connections associated with their IDs
+	pm      *idmanager.Manager // contains packet conns associated with their IDs
 	closers []io.Closer        // additional things to close on close
+
+	passthrough bool
 }
 
 // NewClient creates a new Client, panicking on any error.
@@ -54,6 +57,7 @@ func NewClientFromConfig(log logrus.FieldLogger, conf appcommon.ProcConfig, subs
 		rpcC:    appserver.NewRPCIngressClient(rpc.NewClient(conn), conf.ProcKey),
 		lm:      idmanager.New(),
 		cm:      idmanager.New(),
+		pm:      idmanager.New(),
 		closers: closers,
 	}, nil
 }
@@ -63,6 +67,13 @@ func (c *Client) Config() appcommon.ProcConfig {
 	return c.conf
 }
 
+// EnablePassthrough makes conns of subsequent `Dial` and `Accept` calls exchange data
+// with the visor via dedicated unix sockets instead of per-call RPC, which is considerably
+// faster. Conns fall back to RPC if the visor can't provide a socket.
+func (c *Client) EnablePassthrough() {
+	c.passthrough = true
+}
+
 // Dial dials the remote visor using `remote`.
 func (c *Client) Dial(remote appnet.Addr) (net.Conn, error) {
 	connID, localPort, err := c.rpcC.Dial(remote)
@@ -99,6 +110,10 @@ func (c *Client) Dial(remote appnet.Addr) (net.Conn, error) {
 
 	conn.freeConnMx.Unlock()
 
+	if c.passthrough {
+		conn.usePassthrough(c.log)
+	}
+
 	return conn, nil
 }
 
@@ -116,11 +131,12 @@ func (c *Client) Listen(n appnet.Type, port routing.Port) (net.Listener, error)
 	}
 
 	listener := &Listener{
-		log:  c.log,
-		id:   lisID,
-		rpc:  c.rpcC,
-		addr: local,
-		cm:   idmanager.New(),
+		log:         c.log,
+		id:          lisID,
+		rpc:         c.rpcC,
+		addr:        local,
+		cm:          idmanager.New(),
+		passthrough: c.passthrough,
 	}
 
 	listener.freeLisMx.Lock()
@@ -143,12 +159,93 @@ func (c *Client) Listen(n appnet.Type, port routing.Port) (net.Listener, error)
 	return listener, nil
 }
 
+// DialPacket dials the remote visor using `remote`. Unlike `Dial`, the returned conn
+// carries datagrams: each write is sent as a single packet, packets may be lost
+// or reordered, and each read returns a single packet. The remote should
+// use `ListenPacket` or `DialPacket` as well.
+func (c *Client) DialPacket(remote appnet.Addr) (net.Conn, error) {
+	connID, localPort, err := c.rpcC.DialPacket(remote)
+	if err != nil {
+		return nil, err
+	}
+
+	conn := &Conn{
+		id:  connID,
+		rpc: c.rpcC,
+		local: appnet.Addr{
+			Net:    remote.Net,
+			PubKey: c.conf.VisorPK,
+			Port:   localPort,
+		},
+		remote: remote,
+	}
+
+	conn.freeConnMx.Lock()
+
+	free, err := c.cm.Add(connID, conn)
+	if err != nil {
+		conn.freeConnMx.Unlock()
+
+		if err := conn.Close(); err != nil && !strings.Contains(err.Error(), "use of closed network connection") {
+			c.log.WithError(err).Error("Received unexpected error when closing conn.")
+		}
+
+		return nil, err
+	}
+
+	conn.freeConn = free
+
+	conn.freeConnMx.Unlock()
+
+	return conn, nil
+}
+
+// ListenPacket listens on the specified `port` for the incoming datagrams.
+func (c *Client) ListenPacket(n appnet.Type, port routing.Port) (net.PacketConn, error) {
+	local := appnet.Addr{
+		Net:    n,
+		PubKey: c.conf.VisorPK,
+		Port:   port,
+	}
+
+	pcID, err := c.rpcC.ListenPacket(local)
+	if err != nil {
+		return nil, err
+	}
+
+	pc := &PacketConn{
+		id:   pcID,
+		rpc:  c.rpcC,
+		addr: local,
+	}
+
+	pc.freePCMx.Lock()
+
+	freePC, err := c.pm.Add(pcID, pc)
+	if err != nil {
+		pc.freePCMx.Unlock()
+
+		if err := pc.Close(); err != nil {
+			c.log.WithError(err).Error("Unexpected error while closing packet conn.")
+		}
+
+		return nil, err
+	}
+
+	pc.freePC = freePC
+
+	pc.freePCMx.Unlock()
+
+	return pc, nil
+}
+
 // Close closes client/server communication entirely. It closes all open
-// listeners and connections.
+// listeners, connections and packet conns.
 func (c *Client) Close() {
 	var (
-		listeners []net.Listener
-		conns     []net.Conn
+		listeners   []net.Listener
+		conns       []net.Conn
+		packetConns []net.PacketConn
 	)
 
 	// Fill listeners and connections.
@@ -170,6 +267,15 @@ func (c *Client) Close() {
 		conns = append(conns, conn)
 		return true
 	})
+	c.pm.DoRange(func(_ uint16, v interface{}) bool {
+		pc, err := idmanager.AssertPacketConn(v)
+		if err != nil {
+			c.log.Error(err)
+			return true
+		}
+		packetConns = append(packetConns, pc)
+		return true
+	})
 
 	// Close everything.
 	for _, lis := range listeners {
@@ -182,6 +288,11 @@ func (c *Client) Close() {
 			c.log.WithError(err).Error("Error closing conn.")
 		}
 	}
+	for _, pc := range packetConns {
+		if err := pc.Close(); err != nil && !strings.Contains(err.Error(), "use of closed network connection") {
+			c.log.WithError(err).Error("Error closing packet conn.")
+		}
+	}
 	for _, v := range c.closers {
 		if err := v.Close(); err != nil && !strings.Contains(err.Error(), "use of closed network connection") {
 			c.log.WithError(err).Error("Error closing closer.")
@@ -217,6 +217,119 @@ func TestClient_Listen(t *testing.T) {
 	})
 }
 
+func TestClient_DialPacket(t *testing.T) {
+	l := logging.MustGetLogger("app2_client")
+	visorPK, _ := cipher.GenerateKeyPair()
+
+	remotePK, _ := cipher.GenerateKeyPair()
+	remote := appnet.Addr{
+		Net:    appnet.TypeSkynet,
+		PubKey: remotePK,
+		Port:   routing.Port(120),
+	}
+
+	t.Run("ok", func(t *testing.T) {
+		dialConnID := uint16(1)
+		dialLocalPort := routing.Port(1)
+		var dialErr error
+
+		rpc := &appserver.MockRPCIngressClient{}
+		rpc.On("DialPacket", remote).Return(dialConnID, dialLocalPort, dialErr)
+
+		cl := prepClient(l, visorPK, rpc)
+
+		conn, err := cl.DialPacket(remote)
+		require.NoError(t, err)
+
+		appConn, ok := conn.(*Conn)
+		require.True(t, ok)
+
+		require.Equal(t, dialConnID, appConn.id)
+		require.Equal(t, appnet.Addr{Net: remote.Net, PubKey: visorPK, Port: dialLocalPort}, appConn.local)
+		require.Equal(t, remote, appConn.remote)
+		require.NotNil(t, appConn.freeConn)
+
+		_, ok = cl.cm.Get(appConn.id)
+		require.True(t, ok)
+	})
+
+	t.Run("dial error", func(t *testing.T) {
+		rpc := &appserver.MockRPCIngressClient{}
+		rpc.On("DialPacket", remote).Return(uint16(0), routing.Port(0), appnet.ErrDatagramUnsupported)
+
+		cl := prepClient(l, visorPK, rpc)
+
+		conn, err := cl.DialPacket(remote)
+		require.Equal(t, appnet.ErrDatagramUnsupported, err)
+		require.Nil(t, conn)
+	})
+}
+
+func TestClient_ListenPacket(t *testing.T) {
+	l := logging.MustGetLogger("app2_client")
+	visorPK, _ := cipher.GenerateKeyPair()
+
+	port := routing.Port(1)
+	local := appnet.Addr{
+		Net:    appnet.TypeSkynet,
+		PubKey: visorPK,
+		Port:   port,
+	}
+
+	t.Run("ok", func(t *testing.T) {
+		pcID := uint16(1)
+		var listenErr error
+
+		rpc := &appserver.MockRPCIngressClient{}
+		rpc.On("ListenPacket", local).Return(pcID, listenErr)
+
+		cl := prepClient(l, visorPK, rpc)
+
+		pc, err := cl.ListenPacket(appnet.TypeSkynet, port)
+		require.NoError(t, err)
+
+		appPC, ok := pc.(*PacketConn)
+		require.True(t, ok)
+
+		require.Equal(t, pcID, appPC.id)
+		require.Equal(t, local, appPC.addr)
+		require.NotNil(t, appPC.freePC)
+
+		_, ok = cl.pm.Get(pcID)
+		require.True(t, ok)
+	})
+
+	t.Run("packet conn already exists", func(t *testing.T) {
+		pcID := uint16(1)
+		var listenErr error
+
+		rpc := &appserver.MockRPCIngressClient{}
+		rpc.On("ListenPacket", local).Return(pcID, listenErr)
+
+		cl := prepClient(l, visorPK, rpc)
+
+		_, err := cl.pm.Add(pcID, nil)
+		require.NoError(t, err)
+
+		pc, err := cl.ListenPacket(appnet.TypeSkynet, port)
+		require.Equal(t, err, idmanager.ErrValueAlreadyExists)
+		require.Nil(t, pc)
+	})
+
+	t.Run("listen error", func(t *testing.T) {
+		listenErr := errors.New("listen error")
+
+		rpc := &appserver.MockRPCIngressClient{}
+		rpc.On("ListenPacket", local).Return(uint16(0), listenErr)
+
+		cl := prepClient(l, visorPK, rpc)
+
+		pc, err := cl.ListenPacket(appnet.TypeSkynet, port)
+		require.Equal(t, listenErr, err)
+		require.Nil(t, pc)
+	})
+}
+
 func TestClient_Close(t *testing.T) {
 	l := logging.MustGetLogger("app2_client")
 	visorPK, _ := cipher.GenerateKeyPair()
@@ -268,9 +381,22 @@ func TestClient_Close(t *testing.T) {
 
 	conn2.freeConn = freeConn2
 
+	pcID := uint16(1)
+
+	rpc.On("ClosePacketConn", pcID).Return(closeNoErr)
+
+	pm := idmanager.New()
+
+	pc := &PacketConn{id: pcID, rpc: rpc}
+	freePC, err := pm.Add(pcID, pc)
+	require.NoError(t, err)
+
+	pc.freePC = freePC
+
 	cl := prepClient(l, visorPK, rpc)
 	cl.cm = cm
 	cl.lm = lm
+	cl.pm = pm
 
 	cl.Close()
 
@@ -283,6 +409,9 @@ func TestClient_Close(t *testing.T) {
 	require.False(t, ok)
 	_, ok = cm.Get(connID2)
 	require.False(t, ok)
+
+	_, ok = pm.Get(pcID)
+	require.False(t, ok)
 }
 
 func prepClient(l *logging.Logger, visorPK cipher.PubKey, rpc appserver.RPCIngressClient) *Client {
@@ -304,5 +433,6 @@ func prepClient(l *logging.Logger, visorPK cipher.PubKey, rpc appserver.RPCIngre
 		rpcC: rpc,
 		lm:   idmanager.New(),
 		cm:   idmanager.New(),
+		pm:   idmanager.New(),
 	}
 }
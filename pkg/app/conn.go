@@ -8,6 +8,8 @@ import (
 	"sync"
 	"time"
 
+	"github.com/sirupsen/logrus"
+
 	"github.com/skycoin/skywire/pkg/app/appnet"
 	"github.com/skycoin/skywire/pkg/app/appserver"
 )
@@ -19,12 +21,17 @@ type Conn struct {
 	rpc        appserver.RPCIngressClient
 	local      appnet.Addr
 	remote     appnet.Addr
+	pt         net.Conn // unix socket relaying conn data, if passthrough is used
 	freeConn   func() bool
 	freeConnMx sync.RWMutex
 }
 
 // Read reads from connection.
 func (c *Conn) Read(b []byte) (int, error) {
+	if c.pt != nil {
+		return c.pt.Read(b)
+	}
+
 	n, err := c.rpc.Read(c.id, b)
 
 	return n, err
@@ -32,6 +39,10 @@ func (c *Conn) Read(b []byte) (int, error) {
 
 // Write writes to connection.
 func (c *Conn) Write(b []byte) (int, error) {
+	if c.pt != nil {
+		return c.pt.Write(b)
+	}
+
 	n, err := c.rpc.Write(c.id, b)
 	if err != nil {
 		if err == io.EOF {
@@ -54,6 +65,11 @@ func (c *Conn) Close() error {
 			return errors.New("conn is already closed")
 		}
 
+		// visor closes the conn once the socket is closed
+		if c.pt != nil {
+			return c.pt.Close()
+		}
+
 		return c.rpc.CloseConn(c.id)
 	}
 
@@ -72,15 +88,55 @@ func (c *Conn) RemoteAddr() net.Addr {
 
 // SetDeadline sets read and write deadlines for connection.
 func (c *Conn) SetDeadline(t time.Time) error {
+	if c.pt != nil {
+		return c.pt.SetDeadline(t)
+	}
+
 	return c.rpc.SetDeadline(c.id, t)
 }
 
 // SetReadDeadline sets read deadline for connection.
 func (c *Conn) SetReadDeadline(t time.Time) error {
+	if c.pt != nil {
+		return c.pt.SetReadDeadline(t)
+	}
+
 	return c.rpc.SetReadDeadline(c.id, t)
 }
 
 // SetWriteDeadline sets write deadline for connection.
 func (c *Conn) SetWriteDeadline(t time.Time) error {
+	if c.pt != nil {
+		return c.pt.SetWriteDeadline(t)
+	}
+
 	return c.rpc.SetWriteDeadline(c.id, t)
 }
+
+// usePassthrough switches the conn to a unix socket relaying its data, so reads and writes
+// bypass RPC. The conn stays on RPC if the visor can't provide the socket.
+func (c *Conn) usePassthrough(log logrus.FieldLogger) {
+	path, token, err := c.rpc.Passthrough(c.id)
+	if err != nil {
+		log.WithError(err).Debug("Passthrough is unavailable, conn stays on RPC.")
+		return
+	}
+
+	pt, err := net.Dial("unix", path)
+	if err != nil {
+		log.WithError(err).Warn("Failed to connect to passthrough socket, conn stays on RPC.")
+		return
+	}
+
+	if _, err := pt.Write(token); err != nil {
+		log.WithError(err).Warn("Failed to send passthrough token, conn stays on RPC.")
+
+		if err := pt.Close(); err != nil {
+			log.WithError(err).Error("Failed to close passthrough socket.")
+		}
+
+		return
+	}
+
+	c.pt = pt
+}
@@ -3,8 +3,10 @@ package app
 import (
 	"errors"
 	"fmt"
+	"io"
 	"net"
 	"net/rpc"
+	"path/filepath"
 	"testing"
 
 	"github.com/skycoin/skycoin/src/util/logging"
@@ -150,6 +152,69 @@ func TestConn_Close(t *testing.T) {
 	})
 }
 
+func TestConn_Passthrough(t *testing.T) {
+	connID := uint16(1)
+	l := logging.MustGetLogger("app_conn")
+
+	t.Run("ok", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "pt.sock")
+		token := []byte("token")
+
+		lis, err := net.Listen("unix", path)
+		require.NoError(t, err)
+		defer func() {
+			require.NoError(t, lis.Close())
+		}()
+
+		rpc := &appserver.MockRPCIngressClient{}
+		rpc.On("Passthrough", connID).Return(path, token, testhelpers.NoErr)
+
+		conn := &Conn{
+			id:       connID,
+			rpc:      rpc,
+			freeConn: func() bool { return true },
+		}
+
+		conn.usePassthrough(l)
+		require.NotNil(t, conn.pt)
+
+		visorConn, err := lis.Accept()
+		require.NoError(t, err)
+
+		buf := make([]byte, len(token))
+		_, err = io.ReadFull(visorConn, buf)
+		require.NoError(t, err)
+		require.Equal(t, token, buf)
+
+		// data goes through the socket, not RPC
+		_, err = conn.Write([]byte("data"))
+		require.NoError(t, err)
+
+		buf = make([]byte, 4)
+		_, err = io.ReadFull(visorConn, buf)
+		require.NoError(t, err)
+		require.Equal(t, "data", string(buf))
+
+		require.NoError(t, conn.Close())
+
+		_, err = visorConn.Read(buf)
+		require.Equal(t, io.EOF, err)
+	})
+
+	t.Run("unavailable", func(t *testing.T) {
+		rpc := &appserver.MockRPCIngressClient{}
+		rpc.On("Passthrough", connID).Return("", nil, errors.New("passthrough error"))
+
+		conn := &Conn{
+			id:  connID,
+			rpc: rpc,
+		}
+
+		conn.usePassthrough(l)
+		require.Nil(t, conn.pt)
+	})
+}
+
 type wrappedConn struct {
 	net.Conn
 	local  routing.Addr
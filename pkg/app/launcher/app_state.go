@@ -14,5 +14,8 @@ const (
 // AppState defines state parameters for a registered App.
 type AppState struct {
 	AppConfig
-	Status AppStatus `json:"status"`
+	Status       AppStatus `json:"status"`
+	Restarts     int       `json:"restarts"`                 // number of automatic restarts
+	LastExitCode *int      `json:"last_exit_code,omitempty"` // exit code of the last run, -1 if it was killed
+	CrashLooping bool      `json:"crash_looping"`            // app keeps failing or restarts are exhausted
 }
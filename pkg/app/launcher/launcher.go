@@ -11,11 +11,13 @@ import (
 	"strings"
 	"sync"
 	"syscall"
+	"time"
 
 	"github.com/sirupsen/logrus"
 	"github.com/skycoin/dmsg"
 	"github.com/skycoin/dmsg/cipher"
 
+	"github.com/skycoin/skywire/pkg/app/appbundle"
 	"github.com/skycoin/skywire/pkg/app/appcommon"
 	"github.com/skycoin/skywire/pkg/app/appnet"
 	"github.com/skycoin/skywire/pkg/app/appserver"
@@ -36,10 +38,16 @@ var (
 
 // AppConfig defines app startup parameters.
 type AppConfig struct {
-	Name      string       `json:"name"`
-	Args      []string     `json:"args,omitempty"`
-	AutoStart bool         `json:"auto_start"`
-	Port      routing.Port `json:"port"`
+	Name      string                   `json:"name"`
+	Args      []string                 `json:"args,omitempty"`
+	AutoStart bool                     `json:"auto_start"`
+	Port      routing.Port             `json:"port"`
+	Restart   *RestartPolicy           `json:"restart,omitempty"`
+	Sandbox   *appcommon.SandboxConfig `json:"sandbox,omitempty"` // linux only
+
+	// Apps installed from bundles are sandboxed according to permissions granted on install.
+	Bundled     bool     `json:"bundled,omitempty"`
+	Permissions []string `json:"permissions,omitempty"`
 }
 
 // Config configures the launcher.
@@ -58,6 +66,8 @@ type Launcher struct {
 	r     router.Router
 	procM appserver.ProcManager
 	apps  map[string]AppConfig
+	runs  map[string]*appRun
+	done  bool // launcher is closed, apps are not restarted anymore
 	mx    sync.Mutex
 }
 
@@ -68,6 +78,20 @@ func NewLauncher(log logrus.FieldLogger, conf Config, dmsgC *dmsg.Client, r rout
 		log:   log,
 		r:     r,
 		procM: procM,
+		runs:  make(map[string]*appRun),
+	}
+
+	for _, ac := range conf.Apps {
+		if ac.Restart != nil {
+			if err := ac.Restart.validate(); err != nil {
+				return nil, fmt.Errorf("app %s: %w", ac.Name, err)
+			}
+		}
+		if ac.Sandbox != nil {
+			if err := ac.Sandbox.Validate(); err != nil {
+				return nil, fmt.Errorf("app %s: %w", ac.Name, err)
+			}
+		}
 	}
 
 	// Ensure the existence of directories.
@@ -100,6 +124,8 @@ func NewLauncher(log logrus.FieldLogger, conf Config, dmsgC *dmsg.Client, r rout
 	}
 	launcher.apps = apps
 
+	procM.SetExitHandler(launcher.handleExit)
+
 	return launcher, nil
 }
 
@@ -169,6 +195,7 @@ func (l *Launcher) AppState(name string) (*AppState, bool) {
 	if _, ok := l.procM.ProcByName(ac.Name); ok {
 		state.Status = AppStatusRunning
 	}
+	l.fillRunState(state)
 	return state, true
 }
 
@@ -186,11 +213,24 @@ func (l *Launcher) AppStates() []*AppState {
 				state.Status = AppStatusRunning
 			}
 		}
+		l.fillRunState(state)
 		states = append(states, state)
 	}
 	return states
 }
 
+// fillRunState fills restart stats of an app state.
+func (l *Launcher) fillRunState(state *AppState) {
+	run, ok := l.runs[state.Name]
+	if !ok {
+		return
+	}
+
+	state.Restarts = run.restarts
+	state.LastExitCode = run.lastExitCode
+	state.CrashLooping = run.gaveUp || run.failures >= crashLoopFailures
+}
+
 // StartApp starts cmd with given args and env.
 // If 'args' is nil, default args will be used.
 func (l *Launcher) StartApp(cmd string, args, envs []string) error {
@@ -213,6 +253,10 @@ func (l *Launcher) startApp(cmd string, args, envs []string) error {
 		ac.Args = args
 	}
 
+	if ac.Bundled {
+		ac.Sandbox = appbundle.Sandbox(ac.Permissions, ac.Sandbox)
+	}
+
 	// Make proc config.
 	procConf, err := makeProcConfig(l.conf, ac, envs)
 	if err != nil {
@@ -224,6 +268,15 @@ func (l *Launcher) startApp(cmd string, args, envs []string) error {
 	if err != nil {
 		return err
 	}
+
+	// Keep restart stats of previous runs, a new run cancels any scheduled restart.
+	run := &appRun{args: args, envs: envs, startedAt: time.Now()}
+	if prev, ok := l.runs[cmd]; ok {
+		prev.cancelRestart()
+		run.restarts = prev.restarts
+		run.lastExitCode = prev.lastExitCode
+	}
+	l.runs[cmd] = run
 	if err := l.persistPID(cmd, pid); err != nil {
 		log.WithError(err).Warn("Failed to persist pid.")
 	}
@@ -235,6 +288,12 @@ func (l *Launcher) startApp(cmd string, args, envs []string) error {
 func (l *Launcher) StopApp(name string) (*appserver.Proc, error) {
 	log := l.log.WithField("func", "StopApp").WithField("app_name", name)
 
+	l.mx.Lock()
+	if run, ok := l.runs[name]; ok {
+		run.cancelRestart()
+	}
+	l.mx.Unlock()
+
 	proc, ok := l.procM.ProcByName(name)
 	if !ok {
 		return nil, ErrAppNotRunning
@@ -280,6 +339,7 @@ func makeProcConfig(lc Config, ac AppConfig, envs []string) (appcommon.ProcConfi
 		RoutingPort: ac.Port,
 		BinaryLoc:   filepath.Join(lc.BinPath, ac.Name),
 		LogDBLoc:    filepath.Join(lc.LocalPath, ac.Name+"_log.db"),
+		Sandbox:     ac.Sandbox,
 	}
 	err := ensureDir(&procConf.ProcWorkDir)
 	return procConf, err
@@ -0,0 +1,251 @@
+package launcher
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/skycoin/skywire/pkg/app/appserver"
+)
+
+// Restart policies of apps.
+const (
+	// RestartNever never restarts an exited app.
+	RestartNever = "never"
+	// RestartOnFailure restarts an app which exited with an error.
+	RestartOnFailure = "on-failure"
+	// RestartAlways restarts an app whenever it exits by itself.
+	RestartAlways = "always"
+)
+
+const (
+	defaultRestartBackoff    = time.Second
+	defaultRestartMaxBackoff = time.Minute
+
+	// restartStableTime is the time an app should run to reset its consecutive restarts and failures.
+	restartStableTime = time.Minute
+
+	// crashLoopFailures is the number of consecutive failures after which an app is considered crash-looping.
+	crashLoopFailures = 3
+)
+
+// ErrUnknownRestartPolicy is returned when app restart policy is unknown.
+var ErrUnknownRestartPolicy = errors.New("unknown restart policy")
+
+// RestartPolicy defines whether and how an app is restarted when it exits.
+// Apps stopped through the launcher are never restarted.
+type RestartPolicy struct {
+	Policy     string `json:"policy"`                // never (default), on-failure or always
+	MaxRetries int    `json:"max_retries,omitempty"` // consecutive restarts before giving up, clean exits included, unlimited if 0
+	Backoff    string `json:"backoff,omitempty"`     // delay before the first restart, doubled on every retry, 1s by default
+	MaxBackoff string `json:"max_backoff,omitempty"` // upper limit of the delay, 1m by default
+}
+
+// backoffs returns the initial and maximum restart delays.
+func (p *RestartPolicy) backoffs() (time.Duration, time.Duration, error) {
+	backoff, maxBackoff := defaultRestartBackoff, defaultRestartMaxBackoff
+
+	if p.Backoff != "" {
+		d, err := time.ParseDuration(p.Backoff)
+		if err != nil {
+			return 0, 0, fmt.Errorf("invalid backoff: %w", err)
+		}
+		backoff = d
+	}
+
+	if p.MaxBackoff != "" {
+		d, err := time.ParseDuration(p.MaxBackoff)
+		if err != nil {
+			return 0, 0, fmt.Errorf("invalid max backoff: %w", err)
+		}
+		maxBackoff = d
+	}
+
+	if maxBackoff < backoff {
+		maxBackoff = backoff
+	}
+
+	return backoff, maxBackoff, nil
+}
+
+// validate checks that the policy is valid.
+func (p *RestartPolicy) validate() error {
+	switch p.Policy {
+	case RestartNever, RestartOnFailure, RestartAlways, "":
+	default:
+		return fmt.Errorf("%w: %q", ErrUnknownRestartPolicy, p.Policy)
+	}
+
+	if p.MaxRetries < 0 {
+		return fmt.Errorf("invalid max retries: %d", p.MaxRetries)
+	}
+
+	_, _, err := p.backoffs()
+
+	return err
+}
+
+// shouldRestart returns whether an app should be restarted after `exit`.
+func (p *RestartPolicy) shouldRestart(exit appserver.ProcExit) bool {
+	if p == nil || exit.Stopped {
+		return false
+	}
+
+	switch p.Policy {
+	case RestartAlways:
+		return true
+	case RestartOnFailure:
+		return exit.Failed()
+	default:
+		return false
+	}
+}
+
+// delay returns the delay before restart number `retry` (starting from 1).
+func (p *RestartPolicy) delay(retry int) time.Duration {
+	backoff, maxBackoff, err := p.backoffs()
+	if err != nil {
+		backoff, maxBackoff = defaultRestartBackoff, defaultRestartMaxBackoff
+	}
+
+	for i := 1; i < retry && backoff < maxBackoff; i++ {
+		backoff *= 2
+	}
+
+	if backoff > maxBackoff {
+		backoff = maxBackoff
+	}
+
+	return backoff
+}
+
+// appRun tracks runs and restarts of an app.
+type appRun struct {
+	args      []string
+	envs      []string
+	startedAt time.Time
+
+	restarts     int  // total number of automatic restarts
+	retries      int  // consecutive restarts, whatever the exit
+	failures     int  // consecutive failures
+	lastExitCode *int // exit code of the last run
+	gaveUp       bool // restarts are exhausted
+	timer        *time.Timer
+}
+
+// cancelRestart cancels a scheduled restart.
+func (r *appRun) cancelRestart() {
+	if r.timer != nil {
+		r.timer.Stop()
+		r.timer = nil
+	}
+}
+
+// Close cancels scheduled restarts of apps, no apps are restarted afterwards.
+// It should be called before closing the proc manager.
+func (l *Launcher) Close() error {
+	l.mx.Lock()
+	defer l.mx.Unlock()
+
+	l.done = true
+
+	for _, run := range l.runs {
+		run.cancelRestart()
+	}
+
+	return nil
+}
+
+// handleExit is called by the proc manager whenever an app exits.
+func (l *Launcher) handleExit(exit appserver.ProcExit) {
+	l.mx.Lock()
+	defer l.mx.Unlock()
+
+	run, ok := l.runs[exit.AppName]
+	if !ok || l.done {
+		return
+	}
+
+	code := exit.ExitCode
+	run.lastExitCode = &code
+
+	if time.Since(run.startedAt) >= restartStableTime {
+		run.retries = 0
+		run.failures = 0
+	}
+
+	if exit.Failed() {
+		run.failures++
+	}
+
+	ac, ok := l.apps[exit.AppName]
+	if !ok || !ac.Restart.shouldRestart(exit) {
+		return
+	}
+
+	l.log.WithField("app_name", exit.AppName).WithField("exit_code", exit.ExitCode).WithError(exit.Err).
+		Info("App exited.")
+
+	l.scheduleRestart(exit.AppName, run, ac.Restart)
+}
+
+// scheduleRestart schedules a restart of app `name` with a backoff according to `policy`.
+func (l *Launcher) scheduleRestart(name string, run *appRun, policy *RestartPolicy) {
+	log := l.log.WithField("func", "scheduleRestart").WithField("app_name", name)
+
+	if policy.MaxRetries > 0 && run.retries >= policy.MaxRetries {
+		run.gaveUp = true
+		log.Warnf("App was restarted %d times in a row, giving up restarting it.", run.retries)
+
+		return
+	}
+
+	run.retries++
+
+	delay := policy.delay(run.retries)
+	log.Infof("Restarting app in %s.", delay)
+
+	run.cancelRestart()
+	run.timer = time.AfterFunc(delay, func() {
+		l.restart(name, run)
+	})
+}
+
+// restart starts app `name` again after a scheduled delay.
+func (l *Launcher) restart(name string, run *appRun) {
+	log := l.log.WithField("func", "restart").WithField("app_name", name)
+
+	l.mx.Lock()
+	defer l.mx.Unlock()
+
+	// Restart was cancelled or the app was started by other means meanwhile.
+	if l.done || l.runs[name] != run || run.timer == nil {
+		return
+	}
+	run.timer = nil
+
+	run.restarts++
+
+	if err := l.startApp(name, run.args, run.envs); err != nil {
+		// Apps can't be started anymore once the proc manager is closed.
+		if errors.Is(err, appserver.ErrClosed) {
+			run.gaveUp = true
+			log.WithError(err).Info("Proc manager is closed, not restarting app.")
+
+			return
+		}
+
+		log.WithError(err).Warn("Failed to restart app.")
+
+		if ac, ok := l.apps[name]; ok && ac.Restart != nil {
+			run.failures++
+			l.scheduleRestart(name, run, ac.Restart)
+		}
+
+		return
+	}
+
+	// Consecutive restarts and failures are only reset once the app runs long enough.
+	l.runs[name].retries = run.retries
+	l.runs[name].failures = run.failures
+}
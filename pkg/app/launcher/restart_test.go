@@ -0,0 +1,174 @@
+package launcher
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/skycoin/skycoin/src/util/logging"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/skycoin/skywire/pkg/app/appcommon"
+	"github.com/skycoin/skywire/pkg/app/appserver"
+)
+
+func TestRestartPolicy_shouldRestart(t *testing.T) {
+	var (
+		failed  = appserver.ProcExit{ExitCode: 1}
+		exited  = appserver.ProcExit{ExitCode: 0}
+		stopped = appserver.ProcExit{ExitCode: -1, Err: errors.New("signal: interrupt"), Stopped: true}
+	)
+
+	tests := []struct {
+		name   string
+		policy *RestartPolicy
+		exit   appserver.ProcExit
+		want   bool
+	}{
+		{name: "no policy", policy: nil, exit: failed, want: false},
+		{name: "never", policy: &RestartPolicy{Policy: RestartNever}, exit: failed, want: false},
+		{name: "on-failure failed", policy: &RestartPolicy{Policy: RestartOnFailure}, exit: failed, want: true},
+		{name: "on-failure exited", policy: &RestartPolicy{Policy: RestartOnFailure}, exit: exited, want: false},
+		{name: "always exited", policy: &RestartPolicy{Policy: RestartAlways}, exit: exited, want: true},
+		{name: "always stopped", policy: &RestartPolicy{Policy: RestartAlways}, exit: stopped, want: false},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.want, tc.policy.shouldRestart(tc.exit))
+		})
+	}
+}
+
+func TestRestartPolicy_delay(t *testing.T) {
+	p := &RestartPolicy{Policy: RestartAlways, Backoff: "100ms", MaxBackoff: "1s"}
+
+	assert.Equal(t, 100*time.Millisecond, p.delay(1))
+	assert.Equal(t, 200*time.Millisecond, p.delay(2))
+	assert.Equal(t, 800*time.Millisecond, p.delay(4))
+	assert.Equal(t, time.Second, p.delay(5))
+	assert.Equal(t, time.Second, p.delay(100))
+
+	assert.Equal(t, defaultRestartBackoff, (&RestartPolicy{}).delay(1))
+}
+
+func TestRestartPolicy_validate(t *testing.T) {
+	assert.NoError(t, (&RestartPolicy{Policy: RestartOnFailure, MaxRetries: 3, Backoff: "1s"}).validate())
+	assert.True(t, errors.Is((&RestartPolicy{Policy: "sometimes"}).validate(), ErrUnknownRestartPolicy))
+	assert.Error(t, (&RestartPolicy{Backoff: "soon"}).validate())
+	assert.Error(t, (&RestartPolicy{MaxRetries: -1}).validate())
+}
+
+func TestLauncher_restart(t *testing.T) {
+	const appName = "app"
+
+	newLauncher := func(t *testing.T, procM appserver.ProcManager) *Launcher {
+		return &Launcher{
+			conf:  Config{LocalPath: t.TempDir(), BinPath: t.TempDir()},
+			log:   logging.MustGetLogger("launcher"),
+			procM: procM,
+			apps: map[string]AppConfig{
+				appName: {Name: appName, Restart: &RestartPolicy{Policy: RestartAlways, Backoff: "10ms"}},
+			},
+			runs: map[string]*appRun{appName: {startedAt: time.Now()}},
+		}
+	}
+
+	t.Run("close cancels scheduled restart", func(t *testing.T) {
+		procM := &appserver.MockProcManager{}
+		l := newLauncher(t, procM)
+
+		l.handleExit(appserver.ProcExit{AppName: appName, ExitCode: 1})
+		require.NotNil(t, l.runs[appName].timer)
+
+		require.NoError(t, l.Close())
+		assert.Nil(t, l.runs[appName].timer)
+
+		// Exits after closing don't schedule restarts.
+		l.handleExit(appserver.ProcExit{AppName: appName, ExitCode: 1})
+		assert.Nil(t, l.runs[appName].timer)
+
+		time.Sleep(50 * time.Millisecond)
+		procM.AssertNotCalled(t, "Start", mock.Anything)
+	})
+
+	t.Run("closed proc manager stops restarts", func(t *testing.T) {
+		procM := &appserver.MockProcManager{}
+		procM.On("Start", mock.Anything).Return(appcommon.ProcID(0), appserver.ErrClosed)
+		l := newLauncher(t, procM)
+
+		l.handleExit(appserver.ProcExit{AppName: appName, ExitCode: 1})
+
+		require.Eventually(t, func() bool {
+			l.mx.Lock()
+			defer l.mx.Unlock()
+
+			return l.runs[appName].gaveUp
+		}, time.Second, 5*time.Millisecond)
+
+		l.mx.Lock()
+		assert.Nil(t, l.runs[appName].timer)
+		l.mx.Unlock()
+
+		procM.AssertNumberOfCalls(t, "Start", 1)
+	})
+
+	t.Run("max retries", func(t *testing.T) {
+		var (
+			failed = appserver.ProcExit{AppName: appName, ExitCode: 1}
+			exited = appserver.ProcExit{AppName: appName, ExitCode: 0}
+		)
+
+		tests := []struct {
+			name      string
+			policy    string
+			exit      appserver.ProcExit
+			restarted bool // the first exits are restarted
+		}{
+			{name: "never", policy: RestartNever, exit: failed, restarted: false},
+			{name: "on-failure failed", policy: RestartOnFailure, exit: failed, restarted: true},
+			{name: "on-failure exited", policy: RestartOnFailure, exit: exited, restarted: false},
+			{name: "always failed", policy: RestartAlways, exit: failed, restarted: true},
+			{name: "always exited", policy: RestartAlways, exit: exited, restarted: true},
+		}
+
+		for _, tc := range tests {
+			tc := tc
+			t.Run(tc.name, func(t *testing.T) {
+				l := newLauncher(t, &appserver.MockProcManager{})
+				l.apps[appName] = AppConfig{
+					Name:    appName,
+					Restart: &RestartPolicy{Policy: tc.policy, MaxRetries: 2, Backoff: "1h"},
+				}
+				run := l.runs[appName]
+
+				// exit simulates a quick exit of the app, returns whether a restart was scheduled.
+				exit := func() bool {
+					l.handleExit(tc.exit)
+					scheduled := run.timer != nil
+					run.cancelRestart()
+
+					return scheduled
+				}
+
+				assert.Equal(t, tc.restarted, exit())
+				assert.Equal(t, tc.restarted, exit())
+				assert.False(t, exit())
+				assert.Equal(t, tc.restarted, run.gaveUp)
+
+				if !tc.restarted {
+					return
+				}
+
+				// Running long enough resets consecutive restarts.
+				run.gaveUp = false
+				run.startedAt = time.Now().Add(-restartStableTime)
+				assert.True(t, exit())
+				assert.Equal(t, 1, run.retries)
+			})
+		}
+	})
+}
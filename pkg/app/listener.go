@@ -23,6 +23,8 @@ type Listener struct {
 	cm        *idmanager.Manager // contains conns associated with their IDs
 	freeLis   func() bool
 	freeLisMx sync.RWMutex
+
+	passthrough bool
 }
 
 // Accept accepts a connection from listener.
@@ -64,6 +66,10 @@ func (l *Listener) Accept() (net.Conn, error) {
 	conn.freeConn = free
 	conn.freeConnMx.Unlock()
 
+	if l.passthrough {
+		conn.usePassthrough(l.log)
+	}
+
 	return conn, nil
 }
 
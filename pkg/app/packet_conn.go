@@ -0,0 +1,77 @@
+package app
+
+import (
+	"errors"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/skycoin/skywire/pkg/app/appnet"
+	"github.com/skycoin/skywire/pkg/app/appserver"
+)
+
+// PacketConn is a datagram connection from app client to the server.
+// Implements `net.PacketConn`.
+type PacketConn struct {
+	id       uint16
+	rpc      appserver.RPCIngressClient
+	addr     appnet.Addr
+	freePC   func() bool
+	freePCMx sync.RWMutex
+}
+
+// ReadFrom reads a single datagram from the packet conn.
+func (pc *PacketConn) ReadFrom(b []byte) (int, net.Addr, error) {
+	n, remote, err := pc.rpc.ReadFrom(pc.id, b)
+	if err != nil {
+		return n, nil, err
+	}
+
+	return n, remote, nil
+}
+
+// WriteTo writes `b` as a single datagram to `addr`.
+func (pc *PacketConn) WriteTo(b []byte, addr net.Addr) (int, error) {
+	remote, err := appnet.ConvertAddr(addr)
+	if err != nil {
+		return 0, err
+	}
+
+	return pc.rpc.WriteTo(pc.id, b, remote)
+}
+
+// Close closes the packet conn.
+func (pc *PacketConn) Close() error {
+	pc.freePCMx.RLock()
+	defer pc.freePCMx.RUnlock()
+
+	if pc.freePC != nil {
+		if freed := pc.freePC(); !freed {
+			return errors.New("packet conn is already closed")
+		}
+
+		return pc.rpc.ClosePacketConn(pc.id)
+	}
+
+	return nil
+}
+
+// LocalAddr returns local address of the packet conn.
+func (pc *PacketConn) LocalAddr() net.Addr {
+	return pc.addr
+}
+
+// SetDeadline sets read and write deadlines for the packet conn.
+func (pc *PacketConn) SetDeadline(t time.Time) error {
+	return pc.rpc.SetPacketDeadline(pc.id, t)
+}
+
+// SetReadDeadline sets read deadline for the packet conn.
+func (pc *PacketConn) SetReadDeadline(t time.Time) error {
+	return pc.rpc.SetPacketReadDeadline(pc.id, t)
+}
+
+// SetWriteDeadline sets write deadline for the packet conn.
+func (pc *PacketConn) SetWriteDeadline(t time.Time) error {
+	return pc.rpc.SetPacketWriteDeadline(pc.id, t)
+}
@@ -0,0 +1,119 @@
+package app
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/skycoin/dmsg/cipher"
+	"github.com/stretchr/testify/require"
+
+	"github.com/skycoin/skywire/pkg/app/appnet"
+	"github.com/skycoin/skywire/pkg/app/appserver"
+	"github.com/skycoin/skywire/pkg/routing"
+)
+
+func TestPacketConn_ReadFrom(t *testing.T) {
+	pcID := uint16(1)
+
+	remotePK, _ := cipher.GenerateKeyPair()
+	remote := appnet.Addr{
+		Net:    appnet.TypeSkynet,
+		PubKey: remotePK,
+		Port:   routing.Port(10),
+	}
+
+	t.Run("ok", func(t *testing.T) {
+		buf := make([]byte, 10)
+		var readErr error
+
+		rpc := &appserver.MockRPCIngressClient{}
+		rpc.On("ReadFrom", pcID, buf).Return(2, remote, readErr)
+
+		pc := &PacketConn{id: pcID, rpc: rpc}
+
+		n, addr, err := pc.ReadFrom(buf)
+		require.NoError(t, err)
+		require.Equal(t, 2, n)
+		require.Equal(t, remote, addr)
+	})
+
+	t.Run("read error", func(t *testing.T) {
+		buf := make([]byte, 10)
+		readErr := errors.New("read error")
+
+		rpc := &appserver.MockRPCIngressClient{}
+		rpc.On("ReadFrom", pcID, buf).Return(0, appnet.Addr{}, readErr)
+
+		pc := &PacketConn{id: pcID, rpc: rpc}
+
+		n, addr, err := pc.ReadFrom(buf)
+		require.Equal(t, readErr, err)
+		require.Equal(t, 0, n)
+		require.Nil(t, addr)
+	})
+}
+
+func TestPacketConn_WriteTo(t *testing.T) {
+	pcID := uint16(1)
+
+	remotePK, _ := cipher.GenerateKeyPair()
+	remote := appnet.Addr{
+		Net:    appnet.TypeSkynet,
+		PubKey: remotePK,
+		Port:   routing.Port(10),
+	}
+
+	t.Run("ok", func(t *testing.T) {
+		buf := []byte("data")
+		var writeErr error
+
+		rpc := &appserver.MockRPCIngressClient{}
+		rpc.On("WriteTo", pcID, buf, remote).Return(len(buf), writeErr)
+
+		pc := &PacketConn{id: pcID, rpc: rpc}
+
+		n, err := pc.WriteTo(buf, routing.Addr{PubKey: remote.PubKey, Port: remote.Port})
+		require.NoError(t, err)
+		require.Equal(t, len(buf), n)
+	})
+
+	t.Run("unknown addr type", func(t *testing.T) {
+		pc := &PacketConn{id: pcID, rpc: &appserver.MockRPCIngressClient{}}
+
+		n, err := pc.WriteTo([]byte("data"), nil)
+		require.Equal(t, appnet.ErrUnknownAddrType, err)
+		require.Equal(t, 0, n)
+	})
+}
+
+func TestPacketConn_Close(t *testing.T) {
+	pcID := uint16(1)
+
+	var noErr error
+
+	t.Run("ok", func(t *testing.T) {
+		rpc := &appserver.MockRPCIngressClient{}
+		rpc.On("ClosePacketConn", pcID).Return(noErr)
+
+		pc := &PacketConn{
+			id:     pcID,
+			rpc:    rpc,
+			freePC: func() bool { return true },
+		}
+
+		err := pc.Close()
+		require.NoError(t, err)
+	})
+
+	t.Run("already closed", func(t *testing.T) {
+		pc := &PacketConn{
+			id:     pcID,
+			rpc:    &appserver.MockRPCIngressClient{},
+			freePC: func() bool { return false },
+		}
+
+		err := pc.Close()
+		require.Error(t, err)
+		require.Equal(t, "packet conn is already closed", err.Error())
+	})
+}
@@ -29,6 +29,9 @@ var ErrTransportNotFound = errors.New("transport not found")
 type RouteOptions struct {
 	MinHops uint16
 	MaxHops uint16
+	// Count above 1 requests that many paths not sharing transports,
+	// otherwise shortest paths are returned, possibly overlapping.
+	Count uint16
 }
 
 // FindRoutesRequest parses json body for /routes endpoint request
@@ -0,0 +1,157 @@
+// Package rfserver implements route finder server.
+package rfserver
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi"
+	"github.com/go-chi/chi/middleware"
+	"github.com/sirupsen/logrus"
+	"github.com/skycoin/dmsg/buildinfo"
+	"github.com/skycoin/dmsg/httputil"
+
+	"github.com/skycoin/skywire/pkg/routefinder/rfclient"
+	"github.com/skycoin/skywire/pkg/routing"
+	"github.com/skycoin/skywire/pkg/transport"
+)
+
+const httpTimeout = 30 * time.Second
+
+var (
+	// ErrNoEdges is returned when route request contains no edges.
+	ErrNoEdges = errors.New("no edges requested")
+	// ErrBadHops is returned when minimum number of hops is bigger than the maximum one.
+	ErrBadHops = errors.New("min hops exceed max hops")
+	// ErrNoRoutes is returned when no routes were found for some of the requested edges.
+	ErrNoRoutes = errors.New("no routes found")
+)
+
+// API serves route finder HTTP API, finding routes with transports
+// registered in the transport discovery.
+type API struct {
+	http.Handler
+
+	log       logrus.FieldLogger
+	tpd       transport.DiscoveryClient
+	maxHops   int
+	maxRoutes int
+}
+
+// New constructs a new route finder API.
+func New(log logrus.FieldLogger, tpd transport.DiscoveryClient, conf Config) *API {
+	conf.SetDefaults()
+
+	api := &API{
+		log:       log,
+		tpd:       tpd,
+		maxHops:   int(conf.MaxHops),
+		maxRoutes: conf.MaxRoutes,
+	}
+
+	r := chi.NewRouter()
+
+	r.Use(middleware.RequestID)
+	r.Use(middleware.RealIP)
+	r.Use(middleware.Logger)
+	r.Use(middleware.Recoverer)
+	r.Use(middleware.Timeout(httpTimeout))
+	r.Use(httputil.SetLoggerMiddleware(log))
+
+	r.Post("/routes", api.getRoutes)
+	r.Get("/health", api.health)
+
+	api.Handler = r
+
+	return api
+}
+
+func (api *API) getRoutes(w http.ResponseWriter, r *http.Request) {
+	var req rfclient.FindRoutesRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		api.writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if len(req.Edges) == 0 {
+		api.writeError(w, http.StatusBadRequest, ErrNoEdges)
+		return
+	}
+
+	minHops, maxHops, count := 0, api.maxHops, 0
+	if req.Opts != nil {
+		minHops, count = int(req.Opts.MinHops), int(req.Opts.Count)
+
+		if req.Opts.MaxHops != 0 && int(req.Opts.MaxHops) < maxHops {
+			maxHops = int(req.Opts.MaxHops)
+		}
+	}
+
+	if minHops > maxHops {
+		api.writeError(w, http.StatusBadRequest, ErrBadHops)
+		return
+	}
+
+	g := newGraph(api.tpd)
+	routes := make(map[routing.PathEdges][][]routing.Hop, len(req.Edges))
+
+	for _, edges := range req.Edges {
+		var (
+			paths [][]routing.Hop
+			err   error
+		)
+
+		if count > 1 {
+			if count > api.maxRoutes {
+				count = api.maxRoutes
+			}
+
+			paths, err = g.FindDisjointPaths(r.Context(), edges[0], edges[1], minHops, maxHops, count)
+		} else {
+			paths, err = g.FindPaths(r.Context(), edges[0], edges[1], minHops, maxHops, api.maxRoutes)
+		}
+
+		if err != nil {
+			httputil.GetLogger(r).WithError(err).Warn("Failed to find routes.")
+			api.writeError(w, http.StatusInternalServerError, err)
+
+			return
+		}
+
+		if len(paths) == 0 {
+			api.writeError(w, http.StatusNotFound, ErrNoRoutes)
+			return
+		}
+
+		routes[edges] = paths
+	}
+
+	api.writeJSON(w, http.StatusOK, routes)
+}
+
+func (api *API) health(w http.ResponseWriter, _ *http.Request) {
+	api.writeJSON(w, http.StatusOK, buildinfo.Get())
+}
+
+// writeError writes error in the format expected by rfclient.
+func (api *API) writeError(w http.ResponseWriter, code int, err error) {
+	api.writeJSON(w, code, rfclient.HTTPResponse{
+		Error: &rfclient.HTTPError{
+			Message: err.Error(),
+			Code:    code,
+		},
+	})
+}
+
+// writeJSON writes `v` with encoding/json, which (unlike httputil.WriteJSON)
+// encodes map keys implementing encoding.TextMarshaler, like routing.PathEdges.
+func (api *API) writeJSON(w http.ResponseWriter, code int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		api.log.WithError(err).Warn("Failed to write response.")
+	}
+}
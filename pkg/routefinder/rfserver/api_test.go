@@ -0,0 +1,140 @@
+package rfserver
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/skycoin/dmsg/cipher"
+	"github.com/skycoin/skycoin/src/util/logging"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/skycoin/skywire/pkg/routefinder/rfclient"
+	"github.com/skycoin/skywire/pkg/routing"
+	"github.com/skycoin/skywire/pkg/snet/directtp/tptypes"
+	"github.com/skycoin/skywire/pkg/transport"
+)
+
+func TestAPI_FindRoutes(t *testing.T) {
+	ctx := context.Background()
+
+	pks := make([]cipher.PubKey, 5)
+	for i := range pks {
+		pks[i], _ = cipher.GenerateKeyPair()
+	}
+
+	a, b, c, d, e := pks[0], pks[1], pks[2], pks[3], pks[4]
+
+	tpd := transport.NewDiscoveryMock()
+	tps := map[[2]cipher.PubKey]*transport.Entry{}
+
+	// a - b - d, a - c - e - d, a - d (down)
+	for _, edges := range [][2]cipher.PubKey{{a, b}, {b, d}, {a, c}, {c, e}, {e, d}, {a, d}} {
+		entry := transport.NewEntry(edges[0], edges[1], tptypes.STCPR, true)
+		require.NoError(t, tpd.RegisterTransports(ctx, &transport.SignedEntry{Entry: entry}))
+		tps[edges] = entry
+	}
+
+	_, err := tpd.UpdateStatuses(ctx, &transport.Status{ID: tps[[2]cipher.PubKey{a, d}].ID, IsUp: false})
+	require.NoError(t, err)
+
+	srv := httptest.NewServer(New(logging.MustGetLogger("route_finder"), tpd, Config{}))
+	defer srv.Close()
+
+	rf := rfclient.NewHTTP(srv.URL, 0)
+
+	code, err := rf.Health(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, code)
+
+	forward := routing.PathEdges{a, d}
+	backward := routing.PathEdges{d, a}
+
+	t.Run("shortest first", func(t *testing.T) {
+		routes, err := rf.FindRoutes(ctx, []routing.PathEdges{forward, backward}, nil)
+		require.NoError(t, err)
+
+		require.Len(t, routes[forward], 2)
+		assert.Equal(t, []routing.Hop{
+			{TpID: tps[[2]cipher.PubKey{a, b}].ID, From: a, To: b},
+			{TpID: tps[[2]cipher.PubKey{b, d}].ID, From: b, To: d},
+		}, routes[forward][0])
+		assert.Len(t, routes[forward][1], 3)
+
+		require.Len(t, routes[backward], 2)
+		assert.Equal(t, []routing.Hop{
+			{TpID: tps[[2]cipher.PubKey{b, d}].ID, From: d, To: b},
+			{TpID: tps[[2]cipher.PubKey{a, b}].ID, From: b, To: a},
+		}, routes[backward][0])
+	})
+
+	t.Run("min hops", func(t *testing.T) {
+		routes, err := rf.FindRoutes(ctx, []routing.PathEdges{forward}, &rfclient.RouteOptions{MinHops: 3})
+		require.NoError(t, err)
+		require.Len(t, routes[forward], 1)
+		assert.Len(t, routes[forward][0], 3)
+	})
+
+	t.Run("max hops", func(t *testing.T) {
+		routes, err := rf.FindRoutes(ctx, []routing.PathEdges{forward}, &rfclient.RouteOptions{MaxHops: 2})
+		require.NoError(t, err)
+		require.Len(t, routes[forward], 1)
+		assert.Len(t, routes[forward][0], 2)
+	})
+
+	t.Run("no routes", func(t *testing.T) {
+		_, err := rf.FindRoutes(ctx, []routing.PathEdges{forward}, &rfclient.RouteOptions{MaxHops: 1})
+		assert.Equal(t, rfclient.ErrTransportNotFound, err)
+	})
+
+	t.Run("bad hops", func(t *testing.T) {
+		_, err := rf.FindRoutes(ctx, []routing.PathEdges{forward}, &rfclient.RouteOptions{MinHops: 3, MaxHops: 2})
+		require.Error(t, err)
+		assert.Equal(t, ErrBadHops.Error(), err.Error())
+	})
+}
+
+func TestAPI_FindDisjointRoutes(t *testing.T) {
+	ctx := context.Background()
+
+	pks := make([]cipher.PubKey, 7)
+	for i := range pks {
+		pks[i], _ = cipher.GenerateKeyPair()
+	}
+
+	a, b, c, d, e, f, g := pks[0], pks[1], pks[2], pks[3], pks[4], pks[5], pks[6]
+
+	tpd := transport.NewDiscoveryMock()
+
+	// a - b - d, a - b - f - d, a - c - e - g - d
+	for _, edges := range [][2]cipher.PubKey{{a, b}, {b, d}, {b, f}, {f, d}, {a, c}, {c, e}, {e, g}, {g, d}} {
+		entry := transport.NewEntry(edges[0], edges[1], tptypes.STCPR, true)
+		require.NoError(t, tpd.RegisterTransports(ctx, &transport.SignedEntry{Entry: entry}))
+	}
+
+	srv := httptest.NewServer(New(logging.MustGetLogger("route_finder"), tpd, Config{MaxRoutes: 2}))
+	defer srv.Close()
+
+	rf := rfclient.NewHTTP(srv.URL, 0)
+	forward := routing.PathEdges{a, d}
+
+	// both shortest paths go through a - b
+	routes, err := rf.FindRoutes(ctx, []routing.PathEdges{forward}, nil)
+	require.NoError(t, err)
+	require.Len(t, routes[forward], 2)
+	assert.Equal(t, routes[forward][0][0], routes[forward][1][0])
+
+	routes, err = rf.FindRoutes(ctx, []routing.PathEdges{forward}, &rfclient.RouteOptions{Count: 2})
+	require.NoError(t, err)
+	require.Len(t, routes[forward], 2)
+	assert.Len(t, routes[forward][0], 2)
+	assert.Len(t, routes[forward][1], 4)
+
+	for _, hop := range routes[forward][1] {
+		for _, other := range routes[forward][0] {
+			assert.NotEqual(t, other.TpID, hop.TpID)
+		}
+	}
+}
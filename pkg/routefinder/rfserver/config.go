@@ -0,0 +1,40 @@
+package rfserver
+
+import (
+	"github.com/skycoin/dmsg/cipher"
+)
+
+//go:generate readmegen -n Config -o ./README.md ./config.go
+
+// Default route finder parameters.
+const (
+	DefaultAddr      = ":9092"
+	DefaultMaxHops   = 10
+	DefaultMaxRoutes = 5
+)
+
+// Config defines configuration parameters for route finder.
+type Config struct {
+	PK                 cipher.PubKey `json:"public_key"`
+	SK                 cipher.SecKey `json:"secret_key"`
+	Addr               string        `json:"address"`
+	TransportDiscovery string        `json:"transport_discovery"`
+	MaxHops            uint16        `json:"max_hops"`   // MaxHops caps the requested maximum number of hops.
+	MaxRoutes          int           `json:"max_routes"` // MaxRoutes is the number of routes returned per edges pair.
+	LogLevel           string        `json:"log_level"`
+}
+
+// SetDefaults sets default values for unset fields.
+func (c *Config) SetDefaults() {
+	if c.Addr == "" {
+		c.Addr = DefaultAddr
+	}
+
+	if c.MaxHops == 0 {
+		c.MaxHops = DefaultMaxHops
+	}
+
+	if c.MaxRoutes <= 0 {
+		c.MaxRoutes = DefaultMaxRoutes
+	}
+}
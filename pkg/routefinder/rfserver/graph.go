@@ -0,0 +1,174 @@
+package rfserver
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sort"
+
+	"github.com/google/uuid"
+	"github.com/skycoin/dmsg/cipher"
+	"github.com/skycoin/dmsg/httputil"
+
+	"github.com/skycoin/skywire/pkg/routing"
+	"github.com/skycoin/skywire/pkg/transport"
+)
+
+// maxPartialPaths limits the number of paths explored within a single search,
+// so that dense networks don't make it run forever.
+const maxPartialPaths = 100000
+
+// graph is a view of the network built from the transport discovery.
+// Transports of each visor are fetched lazily and cached for the graph lifetime,
+// so graph is expected to live for a single request.
+type graph struct {
+	tpd   transport.DiscoveryClient
+	edges map[cipher.PubKey][]routing.Hop
+}
+
+func newGraph(tpd transport.DiscoveryClient) *graph {
+	return &graph{
+		tpd:   tpd,
+		edges: make(map[cipher.PubKey][]routing.Hop),
+	}
+}
+
+// hopsFrom returns hops through all transports of `pk` which are up.
+func (g *graph) hopsFrom(ctx context.Context, pk cipher.PubKey) ([]routing.Hop, error) {
+	if hops, ok := g.edges[pk]; ok {
+		return hops, nil
+	}
+
+	entries, err := g.tpd.GetTransportsByEdge(ctx, pk)
+	if err != nil {
+		var httpErr *httputil.HTTPError
+		if !errors.As(err, &httpErr) || httpErr.Status != http.StatusNotFound {
+			return nil, err
+		}
+	}
+
+	hops := make([]routing.Hop, 0, len(entries))
+
+	for _, e := range entries {
+		if e == nil || e.Entry == nil || !e.IsUp {
+			continue
+		}
+
+		hops = append(hops, routing.Hop{
+			TpID: e.Entry.ID,
+			From: pk,
+			To:   e.Entry.RemoteEdge(pk),
+		})
+	}
+
+	// keep results stable regardless of the discovery ordering
+	sort.Slice(hops, func(i, j int) bool {
+		return hops[i].TpID.String() < hops[j].TpID.String()
+	})
+
+	g.edges[pk] = hops
+
+	return hops, nil
+}
+
+// FindPaths returns up to `limit` shortest paths from `src` to `dst` with the number
+// of hops between `minHops` and `maxHops`. Each visor appears in a path at most once.
+func (g *graph) FindPaths(ctx context.Context, src, dst cipher.PubKey, minHops, maxHops, limit int) ([][]routing.Hop, error) {
+	return g.findPaths(ctx, src, dst, minHops, maxHops, limit, nil)
+}
+
+// FindDisjointPaths returns up to `limit` paths from `src` to `dst` which don't share transports.
+// Each next path is the shortest one avoiding transports of the paths found before it, so
+// alternatives going around the shared first hop of the shortest paths aren't missed.
+func (g *graph) FindDisjointPaths(ctx context.Context, src, dst cipher.PubKey, minHops, maxHops, limit int) ([][]routing.Hop, error) {
+	paths := make([][]routing.Hop, 0, limit)
+	usedTps := make(map[uuid.UUID]struct{})
+
+	for len(paths) < limit {
+		found, err := g.findPaths(ctx, src, dst, minHops, maxHops, 1, usedTps)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(found) == 0 {
+			break
+		}
+
+		for _, hop := range found[0] {
+			usedTps[hop.TpID] = struct{}{}
+		}
+
+		paths = append(paths, found[0])
+	}
+
+	return paths, nil
+}
+
+// findPaths is FindPaths skipping transports from `avoid`.
+func (g *graph) findPaths(ctx context.Context, src, dst cipher.PubKey, minHops, maxHops, limit int,
+	avoid map[uuid.UUID]struct{}) ([][]routing.Hop, error) {
+	paths := make([][]routing.Hop, 0, limit)
+
+	if src == dst || maxHops < 1 || limit < 1 {
+		return paths, nil
+	}
+
+	// breadth-first search over paths, so shorter ones come first
+	queue := [][]routing.Hop{nil}
+	explored := 0
+
+	for len(queue) > 0 && explored < maxPartialPaths {
+		path := queue[0]
+		queue = queue[1:]
+		explored++
+
+		last := src
+		if len(path) > 0 {
+			last = path[len(path)-1].To
+		}
+
+		hops, err := g.hopsFrom(ctx, last)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, hop := range hops {
+			if _, ok := avoid[hop.TpID]; ok || hop.To == src || visits(path, hop.To) {
+				continue
+			}
+
+			next := make([]routing.Hop, len(path), len(path)+1)
+			copy(next, path)
+			next = append(next, hop)
+
+			if hop.To == dst {
+				if len(next) < minHops {
+					continue
+				}
+
+				paths = append(paths, next)
+				if len(paths) == limit {
+					return paths, nil
+				}
+
+				continue
+			}
+
+			if len(next) < maxHops {
+				queue = append(queue, next)
+			}
+		}
+	}
+
+	return paths, nil
+}
+
+func visits(path []routing.Hop, pk cipher.PubKey) bool {
+	for _, hop := range path {
+		if hop.To == pk {
+			return true
+		}
+	}
+
+	return false
+}
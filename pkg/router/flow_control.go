@@ -0,0 +1,118 @@
+package router
+
+import (
+	"sync"
+)
+
+// flowControl implements credit-based flow control of a route group.
+// Credits are counted in messages, each being either a data packet or a whole
+// fragmented write. Each edge advertises the total number of messages the remote
+// is allowed to send (consumed by the local app + window),
+// so that incoming packets always fit into the read buffer and handling them never
+// blocks the transport read loop shared with other route groups.
+// Window updates are only sent once both edges agree on flow control in the handshake.
+// Flow control of the sending side is only enabled once the remote sends its first
+// window update, remotes which don't support flow control are written to as before.
+type flowControl struct {
+	mu sync.Mutex
+
+	window uint64
+
+	// sending side
+	enabled bool
+	sent    uint64
+	limit   uint64
+	updated chan struct{} // closed and replaced on each limit increase
+
+	// receiving side
+	consumed uint64
+	granted  uint64
+}
+
+func newFlowControl(window int) *flowControl {
+	if window < 0 {
+		window = 0
+	}
+
+	return &flowControl{
+		window:  uint64(window),
+		updated: make(chan struct{}),
+	}
+}
+
+// reserve reserves credit for a single message. If no credit is left,
+// it returns false along with the channel which is closed once the remote
+// grants more.
+func (fc *flowControl) reserve() (bool, <-chan struct{}) {
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+
+	if fc.enabled && fc.sent >= fc.limit {
+		return false, fc.updated
+	}
+
+	fc.sent++
+
+	return true, nil
+}
+
+// release returns credit reserved for a message which failed to be sent.
+func (fc *flowControl) release() {
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+
+	if fc.sent > 0 {
+		fc.sent--
+	}
+}
+
+// setLimit sets the total number of messages the remote allows us to send.
+// Limits lower than the current one are ignored, since window updates
+// may get reordered.
+func (fc *flowControl) setLimit(limit uint64) {
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+
+	fc.enabled = true
+
+	if limit <= fc.limit {
+		return
+	}
+
+	fc.limit = limit
+	close(fc.updated)
+	fc.updated = make(chan struct{})
+}
+
+// consume registers a message read by the app. It returns the new limit
+// and true if the remote should be sent a window update, which happens once
+// a quarter of the window is freed.
+func (fc *flowControl) consume() (uint64, bool) {
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+
+	fc.consumed++
+
+	if fc.window == 0 {
+		return 0, false
+	}
+
+	limit := fc.consumed + fc.window
+	if limit-fc.granted < fc.window/4 {
+		return 0, false
+	}
+
+	fc.granted = limit
+
+	return limit, true
+}
+
+// grant returns the current limit for the remote.
+func (fc *flowControl) grant() uint64 {
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+
+	fc.granted = fc.consumed + fc.window
+
+	return fc.granted
+}
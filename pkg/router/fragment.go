@@ -0,0 +1,249 @@
+package router
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/skycoin/skywire/pkg/routing"
+)
+
+var (
+	// ErrUnexpectedFragment is returned when fragment doesn't continue the message being reassembled.
+	ErrUnexpectedFragment = errors.New("unexpected fragment")
+	// ErrSequenceWindow is returned when message is too far ahead of the one being waited for.
+	ErrSequenceWindow = errors.New("message out of sequence window")
+	// ErrMessageTooLarge is returned when message exceeds the max size or number of fragments.
+	ErrMessageTooLarge = errors.New("message is too large")
+	// ErrSequencerFull is returned when messages held by the sequencer exceed maxSequencerSize.
+	ErrSequencerFull = errors.New("sequencer is full")
+)
+
+const (
+	// maxMessageSize is the max size of a single message, larger writes are split into multiple messages.
+	maxMessageSize = 256 << 10
+	// maxMessageFragments is the max number of fragments of a single message.
+	maxMessageFragments = 1024
+	// maxSequencerSize is the max total size of messages held by the sequencer.
+	maxSequencerSize = 16 << 20
+)
+
+// makeDataPackets splits `data` into packets with payload of at most `mtu` bytes.
+// Data which fits into a single packet is sent as a DataPacket, otherwise it's
+// split into FragmentPackets of the message `seq`.
+func makeDataPackets(id routing.RouteID, seq uint32, data []byte, mtu int) ([]routing.Packet, error) {
+	if len(data) <= mtu {
+		packet, err := routing.MakeDataPacket(id, data)
+		if err != nil {
+			return nil, err
+		}
+
+		return []routing.Packet{packet}, nil
+	}
+
+	return makeFragmentPackets(id, seq, data, mtu)
+}
+
+// makeFragmentPackets splits `data` of the sequenced message `seq` into FragmentPackets
+// with payload of at most `mtu` bytes. Unlike makeDataPackets, data which fits into a single
+// packet is sent as a single fragment, so that the remote is able to put it in order.
+func makeFragmentPackets(id routing.RouteID, seq uint32, data []byte, mtu int) ([]routing.Packet, error) {
+	fragSize := mtu - routing.FragmentHeaderSize
+	count := (len(data) + fragSize - 1) / fragSize
+
+	packets := make([]routing.Packet, 0, count)
+
+	for i := 0; i < count; i++ {
+		end := (i + 1) * fragSize
+		if end > len(data) {
+			end = len(data)
+		}
+
+		packet, err := routing.MakeFragmentPacket(id, seq, uint32(i), uint32(count), data[i*fragSize:end])
+		if err != nil {
+			return nil, err
+		}
+
+		packets = append(packets, packet)
+	}
+
+	return packets, nil
+}
+
+// sequencer puts together fragments of incoming messages and hands messages out in the order
+// of their sequence numbers. Sequenced messages may be spread over multiple paths and get
+// reordered, so messages following a missing one are held until it arrives. Fragments of a
+// single message are sent via a single path one after another.
+// Sequence numbers start with 1. Remotes which don't sequence all of the messages only number
+// the fragmented ones, these are handed out as soon as they are reassembled.
+// Memory held is bounded by the window, which caps the number of messages in flight, and by
+// limits on the size and number of fragments of a single message and on the total size.
+type sequencer struct {
+	mu      sync.Mutex
+	window  uint32
+	next    uint32                 // sequence number of the message to be handed out next
+	msgs    map[uint32]*seqMessage // messages received ahead of `next`
+	size    int                    // total size of messages held
+	waiting time.Time              // when a complete message started waiting for the missing one
+}
+
+type seqMessage struct {
+	next  uint32
+	count uint32
+	buf   []byte
+	done  bool
+}
+
+// newSequencer creates a sequencer which holds up to `window` messages.
+func newSequencer(window int) *sequencer {
+	if window <= 0 {
+		window = defaultReadChBufSize
+	}
+
+	return &sequencer{
+		window: uint32(window),
+		next:   1,
+		msgs:   make(map[uint32]*seqMessage),
+	}
+}
+
+// push adds a fragment of the message `seq`. It returns messages which are ready to be
+// handed out in order. The first fragment of a message discards its incomplete copy,
+// which happens if the sender switched paths in the middle of it.
+func (s *sequencer) push(seq, idx, count uint32, data []byte) ([][]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// serial number arithmetic keeps working once sequence numbers wrap around
+	if ahead := seq - s.next; ahead >= 1<<31 {
+		return nil, fmt.Errorf("%w: message %d is already handed out", ErrUnexpectedFragment, seq)
+	} else if ahead >= s.window {
+		return nil, fmt.Errorf("%w: message %d is too far ahead of %d", ErrSequenceWindow, seq, s.next)
+	}
+
+	if count == 0 || count > maxMessageFragments {
+		return nil, fmt.Errorf("%w: message %d has %d fragments", ErrMessageTooLarge, seq, count)
+	}
+
+	msg := s.msgs[seq]
+	if idx == 0 && (msg == nil || !msg.done) {
+		s.remove(seq)
+
+		msg = &seqMessage{count: count, buf: make([]byte, 0, len(data))}
+		s.msgs[seq] = msg
+	}
+
+	if msg == nil || msg.done || idx != msg.next || count != msg.count {
+		if msg != nil && !msg.done {
+			s.remove(seq)
+		}
+
+		return nil, fmt.Errorf("%w: message %d, fragment %d/%d", ErrUnexpectedFragment, seq, idx+1, count)
+	}
+
+	if len(msg.buf)+len(data) > maxMessageSize {
+		s.remove(seq)
+		return nil, fmt.Errorf("%w: message %d exceeds %d bytes", ErrMessageTooLarge, seq, maxMessageSize)
+	}
+
+	// the message being waited for is always taken, it lets the rest of them out
+	if seq != s.next && s.size+len(data) > maxSequencerSize {
+		s.remove(seq)
+		return nil, fmt.Errorf("%w: dropping message %d", ErrSequencerFull, seq)
+	}
+
+	msg.buf = append(msg.buf, data...)
+	msg.next++
+	s.size += len(data)
+
+	if msg.next < msg.count {
+		return nil, nil
+	}
+
+	msg.done = true
+
+	out := s.release()
+	if len(out) == 0 && s.waiting.IsZero() {
+		s.waiting = time.Now()
+	}
+
+	return out, nil
+}
+
+// skip gives up on the missing message if complete messages following it waited for longer
+// than `timeout`. Incomplete messages preceding the first complete one are discarded too.
+// It returns messages which are ready to be handed out after that.
+func (s *sequencer) skip(timeout time.Duration) [][]byte {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.waiting.IsZero() || time.Since(s.waiting) < timeout {
+		return nil
+	}
+
+	first, found := uint32(0), false
+
+	for seq, msg := range s.msgs {
+		if msg.done && (!found || seq-s.next < first-s.next) {
+			first, found = seq, true
+		}
+	}
+
+	if !found {
+		s.waiting = time.Time{}
+		return nil
+	}
+
+	for seq := range s.msgs {
+		if seq-s.next < first-s.next {
+			s.remove(seq)
+		}
+	}
+
+	s.next = first
+
+	return s.release()
+}
+
+// release hands out complete messages starting with `next`.
+// Must be called with the mutex held.
+func (s *sequencer) release() [][]byte {
+	var out [][]byte
+
+	for {
+		msg, ok := s.msgs[s.next]
+		if !ok || !msg.done {
+			break
+		}
+
+		out = append(out, msg.buf)
+		s.remove(s.next)
+		s.next++
+	}
+
+	if len(out) == 0 {
+		return nil
+	}
+
+	// messages still waiting start their wait over
+	s.waiting = time.Time{}
+
+	for _, msg := range s.msgs {
+		if msg.done {
+			s.waiting = time.Now()
+			break
+		}
+	}
+
+	return out
+}
+
+// remove discards the message `seq`.
+// Must be called with the mutex held.
+func (s *sequencer) remove(seq uint32) {
+	if msg, ok := s.msgs[seq]; ok {
+		s.size -= len(msg.buf)
+		delete(s.msgs, seq)
+	}
+}
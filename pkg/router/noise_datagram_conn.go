@@ -0,0 +1,158 @@
+package router
+
+import (
+	"encoding/binary"
+	"net"
+	"sync"
+
+	"github.com/skycoin/dmsg/noise"
+)
+
+const (
+	// noisePrefixSize is the size of noise frame length prefix.
+	noisePrefixSize = 2
+	// noiseNonceSize is the size of the nonce which starts a noise frame.
+	noiseNonceSize = 8
+	// nonceWindowSize is the number of latest nonces tracked to drop replayed datagrams.
+	// Datagrams which fall behind the window are dropped as well.
+	nonceWindowSize = 1024
+)
+
+// noiseDatagramConn encrypts datagrams of a route group with noise. Each datagram is sent as
+// a single noise frame. Datagrams may be lost or arrive out of order, so unlike the noise stream,
+// frames are decrypted with a window of nonces rather than a strictly increasing nonce, and
+// frames which fail to decrypt are dropped instead of failing the connection.
+type noiseDatagramConn struct {
+	net.Conn
+	ns *noise.Noise
+
+	rMx    sync.Mutex
+	rBuf   []byte
+	window nonceWindow
+
+	wMx sync.Mutex
+}
+
+func newNoiseDatagramConn(conn net.Conn, ns *noise.Noise, mtu int) *noiseDatagramConn {
+	return &noiseDatagramConn{
+		Conn: conn,
+		ns:   ns,
+		rBuf: make([]byte, mtu),
+	}
+}
+
+// Read reads a single datagram, truncated if it doesn't fit into `p`.
+func (c *noiseDatagramConn) Read(p []byte) (int, error) {
+	c.rMx.Lock()
+	defer c.rMx.Unlock()
+
+	for {
+		n, err := c.Conn.Read(c.rBuf)
+		if err != nil {
+			return 0, err
+		}
+
+		plaintext, ok := c.decrypt(c.rBuf[:n])
+		if !ok || len(plaintext) == 0 {
+			continue
+		}
+
+		return copy(p, plaintext), nil
+	}
+}
+
+// decrypt decrypts noise frame `frame`, it returns false if the frame is malformed, forged or replayed.
+func (c *noiseDatagramConn) decrypt(frame []byte) ([]byte, bool) {
+	if len(frame) < noisePrefixSize+noiseNonceSize ||
+		int(binary.BigEndian.Uint16(frame)) != len(frame)-noisePrefixSize {
+		return nil, false
+	}
+
+	ciphertext := frame[noisePrefixSize:]
+
+	nonce := binary.BigEndian.Uint64(ciphertext)
+	if !c.window.fresh(nonce) {
+		return nil, false
+	}
+
+	// Nonce is only recorded once the frame is authenticated, so forged frames don't move the window.
+	plaintext, err := c.ns.DecryptWithNonceMap(nil, ciphertext)
+	if err != nil {
+		return nil, false
+	}
+
+	c.window.add(nonce)
+
+	return plaintext, true
+}
+
+// Write sends `p` as a single datagram.
+func (c *noiseDatagramConn) Write(p []byte) (int, error) {
+	if len(p) > noise.MaxWriteSize {
+		return 0, ErrDatagramTooLarge
+	}
+
+	c.wMx.Lock()
+	defer c.wMx.Unlock()
+
+	ciphertext := c.ns.EncryptUnsafe(p)
+
+	frame := make([]byte, noisePrefixSize+len(ciphertext))
+	binary.BigEndian.PutUint16(frame, uint16(len(ciphertext)))
+	copy(frame[noisePrefixSize:], ciphertext)
+
+	if _, err := c.Conn.Write(frame); err != nil {
+		return 0, err
+	}
+
+	return len(p), nil
+}
+
+// nonceWindow tracks nonces of the latest nonceWindowSize received datagrams.
+type nonceWindow struct {
+	max  uint64 // largest nonce received
+	bits [nonceWindowSize / 64]uint64
+}
+
+// fresh returns whether `nonce` wasn't received yet and is not too old to tell.
+func (w *nonceWindow) fresh(nonce uint64) bool {
+	switch {
+	case nonce == 0:
+		// noise nonces start from 1
+		return false
+	case nonce > w.max:
+		return true
+	case w.max-nonce >= nonceWindowSize:
+		return false
+	default:
+		return !w.has(nonce)
+	}
+}
+
+// add records `nonce` as received, sliding the window if it's the largest one.
+func (w *nonceWindow) add(nonce uint64) {
+	if nonce > w.max {
+		if nonce-w.max >= nonceWindowSize {
+			w.bits = [nonceWindowSize / 64]uint64{}
+		} else {
+			for n := w.max + 1; n < nonce; n++ {
+				w.clear(n)
+			}
+		}
+
+		w.max = nonce
+	}
+
+	i := nonce % nonceWindowSize
+	w.bits[i/64] |= 1 << (i % 64)
+}
+
+func (w *nonceWindow) has(nonce uint64) bool {
+	i := nonce % nonceWindowSize
+	return w.bits[i/64]&(1<<(i%64)) != 0
+}
+
+func (w *nonceWindow) clear(nonce uint64) {
+	i := nonce % nonceWindowSize
+	w.bits[i/64] &^= 1 << (i % 64)
+}
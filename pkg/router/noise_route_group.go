@@ -2,15 +2,22 @@ package router
 
 import (
 	"net"
+	"sync/atomic"
 	"time"
 
+	"github.com/skycoin/dmsg/noise"
+
 	"github.com/skycoin/skywire/pkg/routing"
 )
 
+// noiseFrameOverhead is the size of noise frame length prefix and auth data.
+const noiseFrameOverhead = 2 + 24
+
 // NoiseRouteGroup is a route group wrapped with noise.
 // Implements net.Conn.
 type NoiseRouteGroup struct {
 	rg *RouteGroup
+	ns *noise.Noise // nil if the route group is not encrypted
 	net.Conn
 }
 
@@ -44,6 +51,38 @@ func (nrg *NoiseRouteGroup) BandwidthSent() uint64 {
 	return nrg.rg.BandwidthSent()
 }
 
+// SetDatagram switches the route group to carry datagrams. Each write of at most MaxDatagramSize
+// bytes is sent as a single packet, writes don't wait for the remote and datagrams the reader
+// doesn't keep up with are dropped. It should be called before any data is read or written.
+func (nrg *NoiseRouteGroup) SetDatagram() {
+	nrg.rg.setDatagram()
+
+	if nrg.ns != nil {
+		nrg.Conn = newNoiseDatagramConn(nrg.rg, nrg.ns, nrg.rg.mtu())
+	}
+}
+
+// MaxDatagramSize returns the maximum size of a datagram which fits into a single packet.
+func (nrg *NoiseRouteGroup) MaxDatagramSize() int {
+	size := nrg.rg.mtu()
+	if nrg.Conn == net.Conn(nrg.rg) {
+		return size
+	}
+
+	// each write is encrypted into a single noise frame
+	size -= noiseFrameOverhead
+	if size > noise.MaxWriteSize {
+		size = noise.MaxWriteSize
+	}
+
+	return size
+}
+
+// DroppedDatagrams returns the number of incoming datagrams dropped because the reader didn't keep up.
+func (nrg *NoiseRouteGroup) DroppedDatagrams() uint64 {
+	return atomic.LoadUint64(&nrg.rg.dropped)
+}
+
 func (nrg *NoiseRouteGroup) isClosed() bool {
 	return nrg.rg.isClosed()
 }
@@ -7,11 +7,13 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"math"
 	"net"
 	"sync"
 	"sync/atomic"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/skycoin/dmsg/ioutil"
 	"github.com/skycoin/skycoin/src/util/logging"
 
@@ -23,7 +25,9 @@ import (
 const (
 	defaultRouteGroupKeepAliveInterval = DefaultRouteKeepAlive / 2
 	defaultNetworkProbeInterval        = 3 * time.Second
+	defaultPathTimeout                 = 4 * defaultNetworkProbeInterval
 	defaultReadChBufSize               = 1024
+	defaultMTU                         = math.MaxUint16
 	closeRoutineTimeout                = 2 * time.Second
 )
 
@@ -38,6 +42,8 @@ var (
 	ErrRuleTransportMismatch = errors.New("rule/transport mismatch")
 	// ErrNoSuitableTransport is returned when no suitable transport was found.
 	ErrNoSuitableTransport = errors.New("no suitable transport")
+	// ErrDatagramTooLarge is returned when a datagram doesn't fit into a single packet.
+	ErrDatagramTooLarge = errors.New("datagram is too large")
 )
 
 type timeoutError struct{}
@@ -48,11 +54,23 @@ func (timeoutError) Temporary() bool { return true }
 
 type sendServicePacketFn func(interval time.Duration)
 
+// pathDownFn is called when a path of the route group with forward rule `rtID` is considered broken.
+type pathDownFn func(rg *RouteGroup, rtID routing.RouteID)
+
 // RouteGroupConfig configures RouteGroup.
 type RouteGroupConfig struct {
 	ReadChBufSize        int
 	KeepAliveInterval    time.Duration
 	NetworkProbeInterval time.Duration
+	// PathTimeout is the time without any incoming packets via a path after which
+	// the path is considered broken.
+	PathTimeout time.Duration
+	// MTU is the maximum payload size of a single packet. Writes exceeding it
+	// are split into fragments and reassembled by the remote.
+	MTU int
+	// Scheduler picks paths for writes. Writes are only spread over multiple
+	// paths once both edges agree on sequencing them, failover is used otherwise.
+	Scheduler Scheduler
 }
 
 // DefaultRouteGroupConfig returns default RouteGroup config.
@@ -61,7 +79,9 @@ func DefaultRouteGroupConfig() *RouteGroupConfig {
 	return &RouteGroupConfig{
 		KeepAliveInterval:    defaultRouteGroupKeepAliveInterval,
 		NetworkProbeInterval: defaultNetworkProbeInterval,
+		PathTimeout:          defaultPathTimeout,
 		ReadChBufSize:        defaultReadChBufSize,
+		MTU:                  defaultMTU,
 	}
 }
 
@@ -69,7 +89,12 @@ func DefaultRouteGroupConfig() *RouteGroupConfig {
 // It implements 'net.Conn'.
 type RouteGroup struct {
 	// atomic requires 64-bit alignment for struct field access
-	lastSent int64
+	// number of datagrams dropped because the reader didn't keep up
+	dropped uint64
+	// sequence number of the last sequenced message
+	fragSeq uint32
+	// set if the route group carries datagrams rather than a stream
+	datagram int32
 
 	mu sync.Mutex
 
@@ -81,6 +106,10 @@ type RouteGroup struct {
 	handshakeProcessed     chan struct{}
 	handshakeProcessedOnce sync.Once
 	encrypt                bool
+	// 'caps' holds handshake flags both edges agreed on, 'peerFlags' holds the ones remote requested.
+	caps      byte
+	capsSet   bool
+	peerFlags byte
 
 	// 'tps' is transports used for writing/forward rules.
 	// It should have the same number of elements as 'fwd'
@@ -94,16 +123,31 @@ type RouteGroup struct {
 	fwd []routing.Rule // forward rules (for writing)
 	rvs []routing.Rule // reverse rules (for reading)
 
+	// 'active' is the index of the path (element of 'tps'/'fwd') writes currently go through
+	// unless they are spread over multiple paths.
+	active int
+	// 'paths' holds the state of each path.
+	paths []*pathState
+	// 'rrNext' is the index of the path the round-robin scheduler tries next.
+	rrNext int
+
+	// 'onPathDown' is called when a path breaks. Set by the router for route groups
+	// it is able to repair.
+	onPathDown pathDownFn
+
 	// 'readCh' reads in incoming packets of this route group.
 	// - Router should serve call '(*transport.Manager).ReadPacket' in a loop,
 	//      and push to the appropriate '(RouteGroup).readCh'.
 	readCh  chan []byte  // push reads from Router
 	readBuf bytes.Buffer // for read overflow
+	seq     *sequencer   // for fragmented and sequenced messages
 
 	readDeadline  deadline.PipeDeadline
 	writeDeadline deadline.PipeDeadline
 
 	networkStats *networkStats
+	// 'fc' keeps the peer from sending more than 'readCh' is able to hold.
+	fc *flowControl
 
 	// used as a bool to indicate if this particular route group initiated close loop
 	closeInitiated   int32
@@ -137,6 +181,8 @@ func NewRouteGroup(cfg *RouteGroupConfig, rt routing.Table, desc routing.RouteDe
 		writeDeadline:      deadline.MakePipeDeadline(),
 		handshakeProcessed: make(chan struct{}),
 		networkStats:       newNetworkStats(),
+		fc:                 newFlowControl(cfg.ReadChBufSize),
+		seq:                newSequencer(cfg.ReadChBufSize),
 	}
 
 	return rg
@@ -161,8 +207,12 @@ func (rg *RouteGroup) Read(p []byte) (n int, err error) {
 	return rg.read(p)
 }
 
-// Write writes payload to a RouteGroup
-// For the first version, only the first ForwardRule (fwd[0]) is used for writing.
+// Write writes payload to a RouteGroup.
+// Paths are picked for writes by the configured scheduler. Writes of a stream are only
+// spread over multiple paths if both edges agreed on sequencing them, otherwise they are
+// kept on the active path, since the stream on top of it relies on in-order delivery.
+// If writing fails, the rest of the paths are tried in order and the path is marked as broken.
+// In datagram mode each write is sent as a single packet and doesn't wait for the remote to catch up.
 func (rg *RouteGroup) Write(p []byte) (n int, err error) {
 	if rg.isClosed() {
 		return 0, io.ErrClosedPipe
@@ -176,22 +226,103 @@ func (rg *RouteGroup) Write(p []byte) (n int, err error) {
 		return 0, nil
 	}
 
+	datagram := rg.isDatagram()
+	if datagram {
+		if len(p) > rg.mtu() {
+			return 0, ErrDatagramTooLarge
+		}
+
+		return rg.writeMessage(p, true)
+	}
+
+	// large writes are split into multiple messages, so that remote doesn't have to buffer them whole
+	maxSize := rg.maxMessageSize()
+
+	for len(p) > 0 {
+		msg := p
+		if len(msg) > maxSize {
+			msg = msg[:maxSize]
+		}
+
+		written, err := rg.writeMessage(msg, false)
+		n += written
+
+		if err != nil {
+			return n, err
+		}
+
+		p = p[len(msg):]
+	}
+
+	return n, nil
+}
+
+// writeMessage writes `p` as a single message via one of the paths picked by the scheduler.
+func (rg *RouteGroup) writeMessage(p []byte, datagram bool) (n int, err error) {
 	rg.mu.Lock()
-	tp, err := rg.tp()
-	if err != nil {
+	if len(rg.fwd) == 0 {
 		rg.mu.Unlock()
-		return 0, err
+		return 0, ErrNoRules
 	}
 
-	rule, err := rg.rule()
-	if err != nil {
+	if len(rg.tps) == 0 {
 		rg.mu.Unlock()
-		return 0, err
+		return 0, ErrNoTransports
 	}
+
+	sequenced := !datagram && rg.caps&routing.HandshakeSequenced != 0
+	spread := (datagram || sequenced) && rg.cfg.Scheduler.spreads()
+	order := rg.schedule(spread, sequenced)
 	// we don't need to keep holding mutex from this point on
 	rg.mu.Unlock()
 
-	return rg.write(p, tp, rule)
+	if !datagram {
+		if err := rg.reserveCredit(); err != nil {
+			return 0, err
+		}
+	}
+
+	// retries via other paths reuse the sequence number, so that remote doesn't wait for the failed one
+	var seq uint32
+	if sequenced || len(p) > rg.mtu() {
+		seq = atomic.AddUint32(&rg.fragSeq, 1)
+	}
+
+	err = ErrBadTransport
+
+	for i, idx := range order {
+		tp, rule, state, pathErr := rg.path(idx)
+		if pathErr != nil {
+			continue
+		}
+
+		n, err = rg.write(p, seq, sequenced, tp, rule)
+		if err == nil {
+			state.markSent()
+
+			if i > 0 && !spread {
+				rg.setActivePath(idx)
+			}
+
+			return n, nil
+		}
+
+		if _, ok := err.(timeoutError); ok {
+			if !datagram {
+				rg.fc.release()
+			}
+			return 0, err
+		}
+
+		rg.logger.WithError(err).Warnf("Failed to write via path %d/%d", idx+1, len(order))
+		rg.setPathDown(idx)
+	}
+
+	if !datagram {
+		rg.fc.release()
+	}
+
+	return 0, err
 }
 
 // Close closes a RouteGroup.
@@ -292,6 +423,14 @@ func (rg *RouteGroup) read(p []byte) (int, error) {
 			return 0, io.EOF
 		}
 
+		if !rg.isDatagram() {
+			if limit, ok := rg.fc.consume(); ok && rg.flowControlled() {
+				if err := rg.sendWindowUpdate(limit); err != nil {
+					rg.logger.WithError(err).Warn("Failed to send window update")
+				}
+			}
+		}
+
 		rg.mu.Lock()
 		defer rg.mu.Unlock()
 
@@ -299,12 +438,53 @@ func (rg *RouteGroup) read(p []byte) (int, error) {
 	}
 }
 
-func (rg *RouteGroup) write(data []byte, tp *transport.ManagedTransport, rule routing.Rule) (int, error) {
-	packet, err := routing.MakeDataPacket(rule.NextRouteID(), data)
+// reserveCredit blocks until remote allows sending another data packet.
+func (rg *RouteGroup) reserveCredit() error {
+	for {
+		ok, updated := rg.fc.reserve()
+		if ok {
+			return nil
+		}
+
+		select {
+		case <-rg.writeDeadline.Wait():
+			return timeoutError{}
+		case <-rg.closed:
+			return io.ErrClosedPipe
+		case <-rg.remoteClosed:
+			return io.ErrClosedPipe
+		case <-updated:
+		}
+	}
+}
+
+func (rg *RouteGroup) write(data []byte, seq uint32, sequenced bool, tp *transport.ManagedTransport,
+	rule routing.Rule) (int, error) {
+	var (
+		packets []routing.Packet
+		err     error
+	)
+
+	if sequenced {
+		packets, err = makeFragmentPackets(rule.NextRouteID(), seq, data, rg.mtu())
+	} else {
+		packets, err = makeDataPackets(rule.NextRouteID(), seq, data, rg.mtu())
+	}
+
 	if err != nil {
 		return 0, err
 	}
 
+	for _, packet := range packets {
+		if err := rg.writeData(packet, tp, rule); err != nil {
+			return 0, err
+		}
+	}
+
+	return len(data), nil
+}
+
+func (rg *RouteGroup) writeData(packet routing.Packet, tp *transport.ManagedTransport, rule routing.Rule) error {
 	rg.logger.Debugf("Writing packet of type %s, route ID %d and next ID %d", packet.Type(),
 		rule.KeyRouteID(), rule.NextRouteID())
 
@@ -315,16 +495,30 @@ func (rg *RouteGroup) write(data []byte, tp *transport.ManagedTransport, rule ro
 
 	select {
 	case <-rg.writeDeadline.Wait():
-		return 0, timeoutError{}
+		return timeoutError{}
 	case err := <-errCh:
-		if err != nil {
-			return 0, err
-		}
+		return err
+	}
+}
+
+// mtu returns the maximum payload size of a single data packet.
+func (rg *RouteGroup) mtu() int {
+	if rg.cfg.MTU <= routing.FragmentHeaderSize || rg.cfg.MTU > math.MaxUint16 {
+		return defaultMTU
+	}
 
-		atomic.StoreInt64(&rg.lastSent, time.Now().UnixNano())
+	return rg.cfg.MTU
+}
 
-		return len(data), nil
+// maxMessageSize returns the max size of a single message, which fits into both
+// maxMessageSize and maxMessageFragments of the remote.
+func (rg *RouteGroup) maxMessageSize() int {
+	size := maxMessageFragments * (rg.mtu() - routing.FragmentHeaderSize)
+	if size > maxMessageSize {
+		return maxMessageSize
 	}
+
+	return size
 }
 
 func (rg *RouteGroup) writePacketAsync(ctx context.Context, tp *transport.ManagedTransport, packet routing.Packet,
@@ -349,7 +543,7 @@ func (rg *RouteGroup) writePacket(ctx context.Context, tp *transport.ManagedTran
 	err := tp.WritePacket(ctx, packet)
 	// note equality here. update activity only if there was NO error
 	if err == nil {
-		if packet.Type() == routing.DataPacket {
+		if t := packet.Type(); t == routing.DataPacket || t == routing.FragmentPacket {
 			rg.networkStats.AddBandwidthSent(uint64(packet.Size()))
 		}
 
@@ -361,72 +555,509 @@ func (rg *RouteGroup) writePacket(ctx context.Context, tp *transport.ManagedTran
 	return err
 }
 
-// rule fetches first available forward rule.
-// NOTE: not thread-safe.
-func (rg *RouteGroup) rule() (routing.Rule, error) {
-	if len(rg.fwd) == 0 {
-		return nil, ErrNoRules
+// path fetches transport, forward rule and state of the path with index `idx`.
+func (rg *RouteGroup) path(idx int) (*transport.ManagedTransport, routing.Rule, *pathState, error) {
+	rg.mu.Lock()
+	defer rg.mu.Unlock()
+
+	if idx < 0 || idx >= len(rg.fwd) {
+		return nil, nil, nil, ErrNoRules
 	}
 
-	rule := rg.fwd[0]
+	if idx >= len(rg.tps) {
+		return nil, nil, nil, ErrNoTransports
+	}
+
+	tp := rg.tps[idx]
+	if tp == nil {
+		return nil, nil, nil, ErrBadTransport
+	}
 
-	return rule, nil
+	return tp, rg.fwd[idx], rg.paths[idx], nil
 }
 
-// tp fetches first available transport.
-// NOTE: not thread-safe.
-func (rg *RouteGroup) tp() (*transport.ManagedTransport, error) {
-	if len(rg.tps) == 0 {
-		return nil, ErrNoTransports
+// pathIndex returns index of the path the packet with `rtID` came from, or -1 if there's none.
+// Must be called with the mutex held.
+func (rg *RouteGroup) pathIndex(rtID routing.RouteID) int {
+	for i, rule := range rg.rvs {
+		if rule != nil && rule.KeyRouteID() == rtID {
+			return i
+		}
 	}
 
-	tp := rg.tps[0]
+	return -1
+}
 
-	if tp == nil {
-		return nil, ErrBadTransport
+func (rg *RouteGroup) activePath() int {
+	rg.mu.Lock()
+	defer rg.mu.Unlock()
+
+	return rg.active
+}
+
+func (rg *RouteGroup) setActivePath(idx int) {
+	rg.mu.Lock()
+	rg.active = idx
+	rg.mu.Unlock()
+
+	rg.logger.Infof("Switched to path %d", idx+1)
+}
+
+func (rg *RouteGroup) isPathDown(idx int) bool {
+	rg.mu.Lock()
+	defer rg.mu.Unlock()
+
+	return idx < len(rg.paths) && rg.paths[idx].down
+}
+
+// setPathDown marks path with index `idx` as broken and reports it. Nothing but writes
+// which failed via the rest of the paths is sent via a broken path, so that the remote
+// notices it's broken even if it only got broken in one direction.
+func (rg *RouteGroup) setPathDown(idx int) {
+	rg.mu.Lock()
+	if idx < 0 || idx >= len(rg.paths) || rg.paths[idx].down {
+		rg.mu.Unlock()
+		return
+	}
+
+	rg.paths[idx].down = true
+	rtID := rg.fwd[idx].KeyRouteID()
+
+	if rg.active == idx {
+		for i, path := range rg.paths {
+			if !path.down {
+				rg.active = i
+				break
+			}
+		}
+	}
+
+	onPathDown := rg.onPathDown
+	rg.mu.Unlock()
+
+	rg.logger.Warnf("Path %d is down", idx+1)
+
+	if onPathDown != nil && !rg.isClosed() {
+		go onPathDown(rg, rtID)
+	}
+}
+
+// setTransportDown marks paths going through transport `tpID` as broken.
+func (rg *RouteGroup) setTransportDown(tpID uuid.UUID) {
+	rg.mu.Lock()
+	var broken []int
+	for i, tp := range rg.tps {
+		if tp != nil && tp.Entry.ID == tpID {
+			broken = append(broken, i)
+		}
+	}
+	rg.mu.Unlock()
+
+	// indexes are only shifted by removing paths, which is done in the descending order
+	for i := len(broken) - 1; i >= 0; i-- {
+		rg.setPathDown(broken[i])
+	}
+}
+
+// markRecv registers a packet received via the path with reverse rule `rtID`.
+func (rg *RouteGroup) markRecv(rtID routing.RouteID) {
+	rg.mu.Lock()
+	defer rg.mu.Unlock()
+
+	if idx := rg.pathIndex(rtID); idx >= 0 {
+		rg.paths[idx].lastRecv = time.Now()
+	}
+}
+
+// silentPaths returns indexes of healthy paths nothing was received through for `timeout`.
+func (rg *RouteGroup) silentPaths(timeout time.Duration) []int {
+	rg.mu.Lock()
+	defer rg.mu.Unlock()
+
+	var silent []int
+
+	for i, path := range rg.paths {
+		if !path.down && time.Since(path.lastRecv) >= timeout {
+			silent = append(silent, i)
+		}
+	}
+
+	return silent
+}
+
+// pathByRule returns index of the path with forward rule `rtID` and transports
+// of all the paths of the route group. Index is -1 if there's no such path.
+func (rg *RouteGroup) pathByRule(rtID routing.RouteID) (int, map[uuid.UUID]struct{}) {
+	rg.mu.Lock()
+	defer rg.mu.Unlock()
+
+	idx := -1
+	tps := make(map[uuid.UUID]struct{})
+
+	for i, path := range rg.paths {
+		if rg.fwd[i].KeyRouteID() == rtID {
+			idx = i
+		}
+
+		for _, hop := range path.hops {
+			tps[hop.TpID] = struct{}{}
+		}
+
+		if tp := rg.tps[i]; tp != nil {
+			tps[tp.Entry.ID] = struct{}{}
+		}
 	}
 
-	return tp, nil
+	return idx, tps
+}
+
+// replacePath puts a new path in place of the one with forward rule `rtID`, so that
+// the number of paths stays the same. It returns index of the path and keys of rules
+// of the replaced path, which are no longer used.
+func (rg *RouteGroup) replacePath(rtID routing.RouteID, forward, reverse routing.Rule,
+	tp *transport.ManagedTransport, hops []routing.Hop) (int, []routing.RouteID, bool) {
+	rg.mu.Lock()
+	defer rg.mu.Unlock()
+
+	for i, rule := range rg.fwd {
+		if rule.KeyRouteID() != rtID {
+			continue
+		}
+
+		old := []routing.RouteID{rule.KeyRouteID(), rg.rvs[i].KeyRouteID()}
+
+		rg.fwd[i], rg.rvs[i], rg.tps[i] = forward, reverse, tp
+		rg.paths[i] = newPathState()
+		rg.paths[i].hops = hops
+
+		return i, old, true
+	}
+
+	return -1, nil, false
+}
+
+// followPath switches writes to the path the packet with `rtID` came from,
+// so that both edges move to a new path once one of them switches.
+func (rg *RouteGroup) followPath(rtID routing.RouteID) {
+	rg.mu.Lock()
+	defer rg.mu.Unlock()
+
+	i := rg.pathIndex(rtID)
+	if i < 0 || i == rg.active || rg.paths[i].down {
+		return
+	}
+
+	rg.active = i
+	rg.logger.Infof("Remote switched to path %d, following", i+1)
+}
+
+// pathHops returns transports used by the path with index `idx`, if known.
+func (rg *RouteGroup) pathHops(idx int) []routing.Hop {
+	rg.mu.Lock()
+	defer rg.mu.Unlock()
+
+	if idx >= len(rg.paths) {
+		return nil
+	}
+
+	return rg.paths[idx].hops
+}
+
+func (rg *RouteGroup) setPathHops(idx int, hops []routing.Hop) {
+	rg.mu.Lock()
+	defer rg.mu.Unlock()
+
+	if idx < len(rg.paths) {
+		rg.paths[idx].hops = hops
+	}
+}
+
+// setPathCaps stores handshake flags verified for the path with index `idx`.
+// Flags of the first verified path are the ones both edges agreed on.
+func (rg *RouteGroup) setPathCaps(idx int, caps byte) {
+	rg.mu.Lock()
+	defer rg.mu.Unlock()
+
+	if idx < 0 || idx >= len(rg.paths) {
+		return
+	}
+
+	rg.paths[idx].caps = caps
+
+	if !rg.capsSet {
+		rg.caps, rg.capsSet = caps, true
+	}
+}
+
+func (rg *RouteGroup) setPathRTT(idx int, rtt time.Duration) {
+	rg.mu.Lock()
+	defer rg.mu.Unlock()
+
+	if idx >= 0 && idx < len(rg.paths) && rtt > 0 {
+		rg.paths[idx].addRTT(rtt)
+	}
+}
+
+// removePath removes the path which has a rule with `rtID` as its key.
+// It returns the key of the other rule of the path and the number of paths left.
+// The last path is never removed, route group should be closed instead.
+func (rg *RouteGroup) removePath(rtID routing.RouteID) (other routing.RouteID, left int, ok bool) {
+	rg.mu.Lock()
+	defer rg.mu.Unlock()
+
+	idx := -1
+
+	for i := range rg.fwd {
+		if fwd := rg.fwd[i]; fwd != nil && fwd.KeyRouteID() == rtID {
+			idx, other = i, rg.rvs[i].KeyRouteID()
+			break
+		}
+
+		if rvs := rg.rvs[i]; rvs != nil && rvs.KeyRouteID() == rtID {
+			idx, other = i, rg.fwd[i].KeyRouteID()
+			break
+		}
+	}
+
+	if idx < 0 {
+		return 0, len(rg.fwd), false
+	}
+
+	if len(rg.fwd) == 1 {
+		return other, 0, true
+	}
+
+	rg.fwd = append(rg.fwd[:idx], rg.fwd[idx+1:]...)
+	rg.rvs = append(rg.rvs[:idx], rg.rvs[idx+1:]...)
+	rg.tps = append(rg.tps[:idx], rg.tps[idx+1:]...)
+	rg.paths = append(rg.paths[:idx], rg.paths[idx+1:]...)
+
+	switch {
+	case rg.active > idx:
+		rg.active--
+	case rg.active == idx:
+		rg.active = 0
+
+		for i, path := range rg.paths {
+			if !path.down {
+				rg.active = i
+				break
+			}
+		}
+	}
+
+	rg.logger.Infof("Removed path %d, %d left", idx+1, len(rg.fwd))
+
+	return other, len(rg.fwd), true
+}
+
+// pathMonitorLoop marks paths nothing was received through for longer than the configured
+// path timeout as broken. Remote edge sends keep-alives via each healthy path which is idle,
+// so silence means a broken path, even if it's only broken in one direction.
+func (rg *RouteGroup) pathMonitorLoop() {
+	ticker := time.NewTicker(rg.cfg.NetworkProbeInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-rg.remoteClosed:
+			return
+		case <-rg.closed:
+			return
+		case <-ticker.C:
+			silent := rg.silentPaths(rg.cfg.PathTimeout)
+
+			for i := len(silent) - 1; i >= 0; i-- {
+				rg.setPathDown(silent[i])
+			}
+		}
+	}
 }
 
 func (rg *RouteGroup) startOffServiceLoops() {
-	go rg.servicePacketLoop("keep-alive", rg.cfg.KeepAliveInterval, rg.keepAliveServiceFn)
+	rg.grantWindow()
+
+	// keep-alives tell the remote a path works, so they are sent often enough for it to notice silence
+	keepAliveInterval := rg.cfg.KeepAliveInterval
+	if rg.cfg.PathTimeout > 0 && rg.cfg.NetworkProbeInterval < keepAliveInterval {
+		keepAliveInterval = rg.cfg.NetworkProbeInterval
+	}
+
+	go rg.servicePacketLoop("keep-alive", keepAliveInterval, rg.keepAliveServiceFn)
 	go rg.servicePacketLoop("network probe", rg.cfg.NetworkProbeInterval, rg.networkProbeServiceFn)
+
+	if rg.cfg.PathTimeout > 0 {
+		go rg.servicePacketLoop("sequencer", rg.cfg.NetworkProbeInterval, rg.sequencerServiceFn)
+		go rg.pathMonitorLoop()
+	}
+}
+
+// sendPathPacket sends a service packet made by `makePacket` via the path with index `idx`.
+func (rg *RouteGroup) sendPathPacket(idx int, makePacket func(id routing.RouteID) routing.Packet) error {
+	tp, rule, state, err := rg.path(idx)
+	if err != nil {
+		return err
+	}
+
+	if err := rg.writePacket(context.Background(), tp, makePacket(rule.NextRouteID()), rule.KeyRouteID()); err != nil {
+		return err
+	}
+
+	state.markSent()
+
+	return nil
 }
 
 func (rg *RouteGroup) sendNetworkProbe() error {
+	throughput := rg.networkStats.RemoteThroughput()
+	timestamp := time.Now().UnixNano() / int64(time.Millisecond)
+
+	err := rg.sendPathPacket(rg.activePath(), func(id routing.RouteID) routing.Packet {
+		return routing.MakeNetworkProbePacket(id, timestamp, throughput)
+	})
+
+	// if no transports, no rules, then no latency probe
+	if errors.Is(err, ErrNoRules) || errors.Is(err, ErrBadTransport) {
+		return nil
+	}
+
+	return err
+}
+
+// flowControlled tells whether both edges agreed on flow control during the handshake.
+// Intermediary visors which don't support it reject window updates, so it has to be
+// negotiated before any of them is sent.
+func (rg *RouteGroup) flowControlled() bool {
+	if rg.cfg.ReadChBufSize <= 0 || rg.isDatagram() {
+		return false
+	}
+
 	rg.mu.Lock()
+	defer rg.mu.Unlock()
 
-	if len(rg.tps) == 0 || len(rg.fwd) == 0 {
-		rg.mu.Unlock()
-		// if no transports, no rules, then no latency probe
+	return rg.caps&routing.HandshakeFlowControl != 0
+}
+
+// flowControlPath returns index of the path window updates are sent through. It's the
+// active path if it's verified to get them through, or else any other healthy path
+// which is. Returns -1 if there's no such path.
+func (rg *RouteGroup) flowControlPath() int {
+	rg.mu.Lock()
+	defer rg.mu.Unlock()
+
+	usable := func(idx int) bool {
+		return rg.paths[idx].caps&routing.HandshakeFlowControl != 0
+	}
+
+	if rg.active < len(rg.paths) && !rg.paths[rg.active].down && usable(rg.active) {
+		return rg.active
+	}
+
+	for idx, path := range rg.paths {
+		if !path.down && usable(idx) {
+			return idx
+		}
+	}
+
+	return -1
+}
+
+// grantWindow sends the current limit to the remote if flow control was agreed on.
+func (rg *RouteGroup) grantWindow() {
+	if !rg.flowControlled() {
+		return
+	}
+
+	if err := rg.sendWindowUpdate(rg.fc.grant()); err != nil {
+		rg.logger.WithError(err).Warn("Failed to send window update")
+	}
+}
+
+func (rg *RouteGroup) sendWindowUpdate(limit uint64) error {
+	idx := rg.flowControlPath()
+	if idx < 0 {
 		return nil
 	}
 
-	tp := rg.tps[0]
-	rule := rg.fwd[0]
-	rg.mu.Unlock()
+	err := rg.sendPathPacket(idx, func(id routing.RouteID) routing.Packet {
+		return routing.MakeWindowUpdatePacket(id, limit)
+	})
 
-	if tp == nil {
+	if errors.Is(err, ErrNoRules) || errors.Is(err, ErrBadTransport) {
 		return nil
 	}
 
-	throughput := rg.networkStats.RemoteThroughput()
-	timestamp := time.Now().UnixNano() / int64(time.Millisecond)
+	return err
+}
 
-	packet := routing.MakeNetworkProbePacket(rule.NextRouteID(), timestamp, throughput)
+// sendPathHandshakes pings paths verified to support handshake flags to measure their
+// round trip time, and asks the remote to verify the rest of them.
+func (rg *RouteGroup) sendPathHandshakes() {
+	rg.mu.Lock()
+	if rg.caps == 0 {
+		rg.mu.Unlock()
+		return
+	}
 
-	if err := rg.writePacket(context.Background(), tp, packet, rule.KeyRouteID()); err != nil {
-		return err
+	verified := make([]bool, len(rg.paths))
+	down := make([]bool, len(rg.paths))
+	for i, path := range rg.paths {
+		verified[i], down[i] = path.caps != 0, path.down
 	}
+	rg.mu.Unlock()
 
-	return nil
+	for idx := range verified {
+		var err error
+
+		if down[idx] {
+			continue
+		}
+
+		if verified[idx] {
+			err = rg.sendPathHandshake(idx, routing.HandshakePing, time.Now().UnixNano())
+		} else {
+			err = rg.sendPathHandshake(idx, supportedHandshakeFlags, 0)
+		}
+
+		if err != nil && !errors.Is(err, ErrBadTransport) {
+			rg.logger.WithError(err).Debugf("Failed to send handshake via path %d", idx+1)
+		}
+	}
+}
+
+func (rg *RouteGroup) sendPathHandshake(idx int, flags byte, timestamp int64) error {
+	return rg.sendPathPacket(idx, func(id routing.RouteID) routing.Packet {
+		return routing.MakeHandshakeFlagsPacket(id, true, flags, timestamp)
+	})
 }
 
 func (rg *RouteGroup) networkProbeServiceFn(_ time.Duration) {
 	if err := rg.sendNetworkProbe(); err != nil {
 		rg.logger.Warnf("Failed to send network probe: %v", err)
 	}
+
+	rg.sendPathHandshakes()
+
+	// window updates may get lost on a broken path, so the current one is resent
+	// periodically to keep remote from getting stuck
+	rg.grantWindow()
+}
+
+// sequencerServiceFn gives up on a message which didn't arrive in time
+// and passes the ones following it to the reader.
+func (rg *RouteGroup) sequencerServiceFn(_ time.Duration) {
+	msgs := rg.seq.skip(rg.cfg.PathTimeout)
+	if len(msgs) == 0 {
+		return
+	}
+
+	rg.logger.Warnf("Message got lost, skipping to the next one")
+
+	for _, msg := range msgs {
+		if err := rg.pushData(msg); err != nil {
+			return
+		}
+	}
 }
 
 func (rg *RouteGroup) servicePacketLoop(name string, interval time.Duration, f sendServicePacketFn) {
@@ -444,19 +1075,17 @@ func (rg *RouteGroup) servicePacketLoop(name string, interval time.Duration, f s
 	}
 }
 
+// keepAliveServiceFn keeps rules of each healthy path from expiring. Keep-alive is only sent
+// via paths nothing was sent through for half of the interval, so rules of standby
+// paths are refreshed as well as the ones of paths in use. Rules of broken paths expire.
 func (rg *RouteGroup) keepAliveServiceFn(interval time.Duration) {
-	lastSent := time.Unix(0, atomic.LoadInt64(&rg.lastSent))
-
-	if time.Since(lastSent) < interval {
-		return
-	}
-
-	if err := rg.sendKeepAlive(); err != nil {
+	if err := rg.sendKeepAlive(interval / 2); err != nil {
 		rg.logger.Warnf("Failed to send keepalive: %v", err)
 	}
 }
 
-func (rg *RouteGroup) sendKeepAlive() error {
+// sendKeepAlive sends keep-alive via each path which was idle for at least `idle`.
+func (rg *RouteGroup) sendKeepAlive(idle time.Duration) error {
 	rg.mu.Lock()
 	defer rg.mu.Unlock()
 
@@ -465,25 +1094,34 @@ func (rg *RouteGroup) sendKeepAlive() error {
 		return nil
 	}
 
+	var err error
+
 	for i := 0; i < len(rg.tps); i++ {
 		tp := rg.tps[i]
 		rule := rg.fwd[i]
+		path := rg.paths[i]
 
-		if tp == nil {
+		if tp == nil || path.down || time.Since(path.lastSentAt()) < idle {
 			continue
 		}
 
 		packet := routing.MakeKeepAlivePacket(rule.NextRouteID())
 
-		if err := rg.writePacket(context.Background(), tp, packet, rule.KeyRouteID()); err != nil {
-			return err
+		// failure of a single path shouldn't leave the rest of them without keep-alive
+		if pathErr := rg.writePacket(context.Background(), tp, packet, rule.KeyRouteID()); pathErr != nil {
+			err = fmt.Errorf("path %d: %w", i+1, pathErr)
+			continue
 		}
+
+		path.markSent()
 	}
 
-	return nil
+	return err
 }
 
-func (rg *RouteGroup) sendHandshake(encrypt bool) error {
+// sendHandshake sends the initial handshake via the first working path. Initiating edge
+// requests handshake flags it supports, the other one replies with the ones both edges support.
+func (rg *RouteGroup) sendHandshake(encrypt, reply bool) error {
 	rg.mu.Lock()
 	defer rg.mu.Unlock()
 
@@ -492,6 +1130,11 @@ func (rg *RouteGroup) sendHandshake(encrypt bool) error {
 		return nil
 	}
 
+	flags := supportedHandshakeFlags
+	if reply {
+		flags = routing.HandshakeReply | rg.peerFlags&supportedHandshakeFlags
+	}
+
 	for i := 0; i < len(rg.tps); i++ {
 		tp := rg.tps[i]
 
@@ -500,7 +1143,7 @@ func (rg *RouteGroup) sendHandshake(encrypt bool) error {
 		}
 
 		rule := rg.fwd[i]
-		packet := routing.MakeHandshakePacket(rule.NextRouteID(), encrypt)
+		packet := routing.MakeHandshakeFlagsPacket(rule.NextRouteID(), encrypt, flags, 0)
 
 		err := rg.writePacket(context.Background(), tp, packet, rule.KeyRouteID())
 		if err == nil {
@@ -565,6 +1208,14 @@ func (rg *RouteGroup) close(code routing.CloseCode) error {
 }
 
 func (rg *RouteGroup) handlePacket(packet routing.Packet) error {
+	rg.markRecv(packet.RouteID())
+
+	// remote sends network probes via its active path, sequenced messages
+	// and datagrams may be spread over all paths, so they aren't followed
+	if t := packet.Type(); t == routing.NetworkProbePacket || t == routing.DataPacket && !rg.isDatagram() {
+		rg.followPath(packet.RouteID())
+	}
+
 	switch packet.Type() {
 	case routing.ClosePacket:
 		rg.mu.Lock()
@@ -578,23 +1229,81 @@ func (rg *RouteGroup) handlePacket(packet routing.Packet) error {
 			close(rg.handshakeProcessed)
 		})
 		return rg.handleDataPacket(packet)
+	case routing.FragmentPacket:
+		return rg.handleFragmentPacket(packet)
 	case routing.NetworkProbePacket:
 		return rg.handleNetworkProbePacket(packet)
+	case routing.WindowUpdatePacket:
+		return rg.handleWindowUpdatePacket(packet)
 	case routing.HandshakePacket:
-		rg.handshakeProcessedOnce.Do(func() {
-			// first packet is handshake packet, so we're communicating with the new visor
-			rg.encrypt = true
-			if packet.Payload()[0] == 0 {
-				rg.encrypt = false
-			}
-
-			close(rg.handshakeProcessed)
-		})
+		return rg.handleHandshakePacket(packet)
 	}
 
 	return nil
 }
 
+// handleHandshakePacket handles handshake packets. The first one completes the route group
+// handshake, the rest of them are used to verify handshake flags get through each path
+// and to measure round trip time of the paths:
+// - request is answered with a reply via the same path,
+// - reply is answered with a confirmation, both mark the path as verified,
+// - ping is answered with a pong carrying the same timestamp.
+func (rg *RouteGroup) handleHandshakePacket(packet routing.Packet) error {
+	flags, timestamp := packet.HandshakeFlags()
+
+	rg.mu.Lock()
+	idx := rg.pathIndex(packet.RouteID())
+	rg.mu.Unlock()
+
+	var err error
+
+	switch {
+	case flags&routing.HandshakePing != 0:
+		return rg.sendPathHandshake(idx, routing.HandshakePong, timestamp)
+	case flags&routing.HandshakePong != 0:
+		rg.setPathRTT(idx, time.Since(time.Unix(0, timestamp)))
+		return nil
+	case flags&routing.HandshakeConfirm != 0:
+		rg.setPathCaps(idx, flags&supportedHandshakeFlags)
+		// remote doesn't limit writes until it gets the first window update
+		rg.grantWindow()
+
+		return nil
+	case flags&routing.HandshakeReply != 0:
+		// path is verified before the handshake is processed, so that the first
+		// write already knows whether it may be sequenced
+		if caps := flags & supportedHandshakeFlags; caps != 0 {
+			rg.setPathCaps(idx, caps)
+			err = rg.sendPathHandshake(idx, routing.HandshakeConfirm|caps, 0)
+			rg.grantWindow()
+		}
+	case flags != 0:
+		rg.mu.Lock()
+		initial := !rg.capsSet && rg.peerFlags == 0
+		if initial {
+			// the reply is sent once the route group is set up
+			rg.peerFlags = flags
+		}
+		rg.mu.Unlock()
+
+		if !initial {
+			err = rg.sendPathHandshake(idx, routing.HandshakeReply|flags&supportedHandshakeFlags, 0)
+		}
+	}
+
+	rg.handshakeProcessedOnce.Do(func() {
+		// first packet is handshake packet, so we're communicating with the new visor
+		rg.encrypt = true
+		if packet.Payload()[0] == 0 {
+			rg.encrypt = false
+		}
+
+		close(rg.handshakeProcessed)
+	})
+
+	return err
+}
+
 func (rg *RouteGroup) handleNetworkProbePacket(packet routing.Packet) error {
 	payload := packet.Payload()
 
@@ -610,9 +1319,67 @@ func (rg *RouteGroup) handleNetworkProbePacket(packet routing.Packet) error {
 	return nil
 }
 
+func (rg *RouteGroup) handleWindowUpdatePacket(packet routing.Packet) error {
+	if len(packet.Payload()) < 8 {
+		return errors.New("malformed window update packet")
+	}
+
+	rg.fc.setLimit(binary.BigEndian.Uint64(packet.Payload()))
+
+	return nil
+}
+
+func (rg *RouteGroup) handleFragmentPacket(packet routing.Packet) error {
+	rg.networkStats.AddBandwidthReceived(uint64(packet.Size()))
+
+	seq, idx, count, data, err := packet.Fragment()
+	if err != nil {
+		return err
+	}
+
+	msgs, err := rg.seq.push(seq, idx, count, data)
+	if err != nil {
+		return err
+	}
+
+	for _, msg := range msgs {
+		if err := rg.pushData(msg); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 func (rg *RouteGroup) handleDataPacket(packet routing.Packet) error {
 	rg.networkStats.AddBandwidthReceived(uint64(packet.Size()))
 
+	return rg.pushData(packet.Payload())
+}
+
+// pushData passes received data to the reader.
+func (rg *RouteGroup) pushData(data []byte) error {
+	// remote respecting our window never fills up 'readCh', so we don't block
+	// the transport read loop shared with other route groups
+	select {
+	case <-rg.remoteClosed:
+		return nil
+	case rg.readCh <- data:
+		return nil
+	default:
+	}
+
+	// datagrams are dropped rather than waited for the reader to catch up
+	if rg.isDatagram() {
+		atomic.AddUint64(&rg.dropped, 1)
+		return nil
+	}
+
+	if rg.flowControlled() {
+		rg.logger.Warn("Remote exceeded flow control window")
+	}
+
+	// remote doesn't support flow control, falling back to blocking
 	select {
 	case <-rg.closed:
 		return io.ErrClosedPipe
@@ -621,7 +1388,7 @@ func (rg *RouteGroup) handleDataPacket(packet routing.Packet) error {
 		// but some packets may still reach the rg causing panic on writing
 		// to `readCh`, so we simple omit such packets
 		return nil
-	case rg.readCh <- packet.Payload():
+	case rg.readCh <- data:
 	}
 
 	return nil
@@ -688,11 +1455,21 @@ func (rg *RouteGroup) isRemoteClosed() bool {
 	return chanClosed(rg.remoteClosed)
 }
 
+// setDatagram switches the route group to datagram mode. Flow control is not used in this mode:
+// writes never wait for the remote and incoming datagrams are dropped if the reader doesn't keep up.
+func (rg *RouteGroup) setDatagram() {
+	atomic.StoreInt32(&rg.datagram, 1)
+}
+
+func (rg *RouteGroup) isDatagram() bool {
+	return atomic.LoadInt32(&rg.datagram) == 1
+}
+
 func (rg *RouteGroup) isClosed() bool {
 	return chanClosed(rg.closed)
 }
 
-func (rg *RouteGroup) appendRules(forward, reverse routing.Rule, tp *transport.ManagedTransport) {
+func (rg *RouteGroup) appendRules(forward, reverse routing.Rule, tp *transport.ManagedTransport) int {
 	rg.mu.Lock()
 	defer rg.mu.Unlock()
 
@@ -700,6 +1477,9 @@ func (rg *RouteGroup) appendRules(forward, reverse routing.Rule, tp *transport.M
 	rg.rvs = append(rg.rvs, reverse)
 
 	rg.tps = append(rg.tps, tp)
+	rg.paths = append(rg.paths, newPathState())
+
+	return len(rg.tps) - 1
 }
 
 func chanClosed(ch chan struct{}) bool {
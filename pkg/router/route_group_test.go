@@ -2,15 +2,21 @@ package router
 
 import (
 	"context"
+	"crypto/rand"
+	"errors"
 	"fmt"
 	"io"
+	"net"
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/skycoin/dmsg/cipher"
+	"github.com/skycoin/dmsg/noise"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
@@ -518,6 +524,479 @@ func TestRouteGroup_RemoteAddr(t *testing.T) {
 	require.NoError(t, rg.Close())
 }
 
+func TestRouteGroup_Paths(t *testing.T) {
+	rg := createRouteGroup(DefaultRouteGroupConfig())
+
+	for i := 0; i < 2; i++ {
+		ids, err := rg.rt.ReserveKeys(2)
+		require.NoError(t, err)
+
+		fwd := routing.ForwardRule(ruleKeepAlive, ids[0], ids[1], uuid.New(), rg.desc.SrcPK(), rg.desc.DstPK(), 0, 0)
+		rvs := routing.ConsumeRule(ruleKeepAlive, ids[1], rg.desc.SrcPK(), rg.desc.DstPK(), 0, 0)
+		require.Equal(t, i, rg.appendRules(fwd, rvs, nil))
+	}
+
+	downCh := make(chan routing.RouteID, 1)
+	rg.onPathDown = func(_ *RouteGroup, rtID routing.RouteID) { downCh <- rtID }
+
+	// remote switched to the second path
+	rg.followPath(rg.rvs[1].KeyRouteID())
+	require.Equal(t, 1, rg.active)
+
+	// broken active path is switched from right away
+	rg.setPathDown(1)
+	require.True(t, rg.isPathDown(1))
+	require.False(t, rg.isPathDown(0))
+	require.Equal(t, 0, rg.active)
+
+	select {
+	case rtID := <-downCh:
+		require.Equal(t, rg.fwd[1].KeyRouteID(), rtID)
+	case <-time.After(time.Second):
+		t.Fatal("path down callback was not called")
+	}
+
+	// packets from the broken path shouldn't make us switch back to it
+	rg.followPath(rg.rvs[1].KeyRouteID())
+	require.Equal(t, 0, rg.active)
+
+	_, err := rg.Write([]byte("hello"))
+	require.Equal(t, ErrBadTransport, err)
+
+	require.NoError(t, rg.Close())
+}
+
+func TestRouteGroup_FlowControl(t *testing.T) {
+	cfg := DefaultRouteGroupConfig()
+	cfg.ReadChBufSize = 4
+
+	rg := createRouteGroup(cfg)
+
+	// remote granted us 2 packets
+	require.NoError(t, rg.handlePacket(routing.MakeWindowUpdatePacket(1, 2)))
+
+	require.NoError(t, rg.reserveCredit())
+	require.NoError(t, rg.reserveCredit())
+
+	require.NoError(t, rg.SetWriteDeadline(time.Now().Add(50*time.Millisecond)))
+	require.Equal(t, timeoutError{}, rg.reserveCredit())
+	require.NoError(t, rg.SetWriteDeadline(time.Time{}))
+
+	errCh := make(chan error, 1)
+	go func() { errCh <- rg.reserveCredit() }()
+
+	// stale window updates don't grant anything
+	require.NoError(t, rg.handlePacket(routing.MakeWindowUpdatePacket(1, 1)))
+	select {
+	case <-errCh:
+		t.Fatal("credit reserved without window update")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	require.NoError(t, rg.handlePacket(routing.MakeWindowUpdatePacket(1, 3)))
+	select {
+	case err := <-errCh:
+		require.NoError(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("credit was not reserved after window update")
+	}
+
+	// a full window is buffered without blocking the caller
+	for i := 0; i < cfg.ReadChBufSize; i++ {
+		packet, err := routing.MakeDataPacket(1, []byte{byte(i)})
+		require.NoError(t, err)
+		require.NoError(t, rg.handlePacket(packet))
+	}
+
+	buf := make([]byte, 1)
+	_, err := rg.Read(buf)
+	require.NoError(t, err)
+	require.Equal(t, uint64(cfg.ReadChBufSize+1), rg.fc.granted)
+
+	require.NoError(t, rg.Close())
+}
+
+func TestRouteGroup_Fragmentation(t *testing.T) {
+	cfg := DefaultRouteGroupConfig()
+	cfg.MTU = 100
+
+	rg := createRouteGroup(cfg)
+
+	packets, err := makeDataPackets(1, 0, []byte("small"), rg.mtu())
+	require.NoError(t, err)
+	require.Len(t, packets, 1)
+	require.Equal(t, routing.DataPacket, packets[0].Type())
+
+	msg := make([]byte, 250)
+	_, err = rand.Read(msg)
+	require.NoError(t, err)
+
+	packets, err = makeDataPackets(1, 1, msg, rg.mtu())
+	require.NoError(t, err)
+	require.Len(t, packets, 3)
+
+	for _, packet := range packets {
+		require.Equal(t, routing.FragmentPacket, packet.Type())
+		require.True(t, int(packet.Size()) <= cfg.MTU)
+	}
+
+	// fragment out of order
+	require.True(t, errors.Is(rg.handlePacket(packets[1]), ErrUnexpectedFragment))
+
+	for _, packet := range packets {
+		require.NoError(t, rg.handlePacket(packet))
+	}
+
+	buf := make([]byte, len(msg))
+	n, err := rg.Read(buf)
+	require.NoError(t, err)
+	require.Equal(t, len(msg), n)
+	require.Equal(t, msg, buf)
+
+	// whole message takes a single flow control credit
+	require.Equal(t, uint64(1), rg.fc.consumed)
+
+	require.NoError(t, rg.Close())
+}
+
+func TestRouteGroup_Datagram(t *testing.T) {
+	cfg := DefaultRouteGroupConfig()
+	cfg.MTU = 100
+	cfg.ReadChBufSize = 2
+
+	rg := createRouteGroup(cfg)
+	rg.setDatagram()
+
+	// datagrams are never fragmented
+	_, err := rg.Write(make([]byte, cfg.MTU+1))
+	require.Equal(t, ErrDatagramTooLarge, err)
+
+	// datagrams exceeding the buffer are dropped without blocking
+	for i := 0; i < cfg.ReadChBufSize+1; i++ {
+		packet, err := routing.MakeDataPacket(1, []byte{byte(i)})
+		require.NoError(t, err)
+		require.NoError(t, rg.handlePacket(packet))
+	}
+	require.Equal(t, uint64(1), atomic.LoadUint64(&rg.dropped))
+
+	buf := make([]byte, 10)
+	for i := 0; i < cfg.ReadChBufSize; i++ {
+		n, err := rg.Read(buf)
+		require.NoError(t, err)
+		require.Equal(t, []byte{byte(i)}, buf[:n])
+	}
+
+	// remote is not granted any window
+	require.Equal(t, uint64(0), rg.fc.granted)
+
+	require.NoError(t, rg.Close())
+}
+
+func TestRouteGroup_Schedulers(t *testing.T) {
+	cfg := DefaultRouteGroupConfig()
+	rg := createRouteGroup(cfg)
+	appendTestPaths(t, rg, 3)
+
+	rg.mu.Lock()
+	defer rg.mu.Unlock()
+
+	count := func(spread, sequenced bool, writes int) map[int]int {
+		picked := make(map[int]int)
+		for i := 0; i < writes; i++ {
+			picked[rg.schedule(spread, sequenced)[0]]++
+		}
+		return picked
+	}
+
+	// failover keeps writes on the active path and tries the broken ones last
+	rg.active = 1
+	rg.paths[2].down = true
+	require.Equal(t, []int{1, 0, 2}, rg.schedule(false, false))
+	require.Equal(t, map[int]int{1: 10}, count(false, false, 10))
+
+	cfg.Scheduler = SchedulerRoundRobin
+	require.Equal(t, map[int]int{0: 5, 1: 5}, count(true, false, 10))
+
+	// sequenced writes only go via paths verified to get them through
+	rg.paths[1].caps = routing.HandshakeSequenced
+	rg.paths[2].caps = routing.HandshakeSequenced
+	require.Equal(t, []int{1, 2}, rg.schedule(true, true))
+
+	cfg.Scheduler = SchedulerLatency
+	rg.paths[2].down = false
+
+	// paths get equal share until round trip times are measured
+	require.Equal(t, map[int]int{0: 10, 1: 10, 2: 10}, count(true, false, 30))
+
+	resetWeights := func() {
+		for _, path := range rg.paths {
+			path.weight = 0
+		}
+	}
+
+	rg.paths[0].rtt = 10 * time.Millisecond
+	rg.paths[1].rtt = 20 * time.Millisecond
+	rg.paths[2].rtt = 40 * time.Millisecond
+	resetWeights()
+	require.Equal(t, map[int]int{0: 20, 1: 10, 2: 5}, count(true, false, 35))
+
+	// path which wasn't measured yet is treated as the slowest one
+	rg.paths[2].rtt = 0
+	resetWeights()
+	require.Equal(t, map[int]int{0: 20, 1: 10, 2: 10}, count(true, false, 40))
+}
+
+func TestRouteGroup_RemovePath(t *testing.T) {
+	rg := createRouteGroup(DefaultRouteGroupConfig())
+	appendTestPaths(t, rg, 3)
+
+	rg.active = 2
+	fwd, rvs := rg.fwd[1].KeyRouteID(), rg.rvs[1].KeyRouteID()
+
+	// path is found by either of its rules
+	other, left, ok := rg.removePath(rvs)
+	require.True(t, ok)
+	require.Equal(t, fwd, other)
+	require.Equal(t, 2, left)
+	require.Len(t, rg.paths, 2)
+	require.Equal(t, 1, rg.active)
+
+	_, _, ok = rg.removePath(fwd)
+	require.False(t, ok)
+
+	other, left, ok = rg.removePath(rg.fwd[1].KeyRouteID())
+	require.True(t, ok)
+	require.Equal(t, 1, left)
+	require.Equal(t, 0, rg.active)
+
+	// the last path stays for the route group to be closed with it
+	other, left, ok = rg.removePath(rg.fwd[0].KeyRouteID())
+	require.True(t, ok)
+	require.Equal(t, rg.rvs[0].KeyRouteID(), other)
+	require.Equal(t, 0, left)
+	require.Len(t, rg.paths, 1)
+
+	require.NoError(t, rg.Close())
+}
+
+func TestRouteGroup_PathMonitor(t *testing.T) {
+	rg := createRouteGroup(DefaultRouteGroupConfig())
+	appendTestPaths(t, rg, 3)
+
+	past := time.Now().Add(-time.Minute)
+	for _, path := range rg.paths {
+		path.lastRecv = past
+	}
+
+	// keep-alives came via the second path only
+	rg.markRecv(rg.rvs[1].KeyRouteID())
+	require.Equal(t, []int{0, 2}, rg.silentPaths(time.Second))
+
+	rg.setPathDown(2)
+	require.Equal(t, []int{0}, rg.silentPaths(time.Second))
+
+	require.NoError(t, rg.Close())
+}
+
+func TestRouteGroup_ReplacePath(t *testing.T) {
+	rg := createRouteGroup(DefaultRouteGroupConfig())
+	appendTestPaths(t, rg, 2)
+
+	hops := []routing.Hop{{TpID: uuid.New()}}
+	rg.setPathHops(0, hops)
+	rg.setPathDown(1)
+
+	oldFwd, oldRvs := rg.fwd[1].KeyRouteID(), rg.rvs[1].KeyRouteID()
+
+	idx, avoid := rg.pathByRule(oldFwd)
+	require.Equal(t, 1, idx)
+	require.Contains(t, avoid, hops[0].TpID)
+
+	ids, err := rg.rt.ReserveKeys(2)
+	require.NoError(t, err)
+
+	fwd := routing.ForwardRule(ruleKeepAlive, ids[0], ids[1], uuid.New(), rg.desc.SrcPK(), rg.desc.DstPK(), 0, 0)
+	rvs := routing.ConsumeRule(ruleKeepAlive, ids[1], rg.desc.SrcPK(), rg.desc.DstPK(), 0, 0)
+
+	// new path takes the place of the broken one
+	idx, old, ok := rg.replacePath(oldFwd, fwd, rvs, nil, nil)
+	require.True(t, ok)
+	require.Equal(t, 1, idx)
+	require.Equal(t, []routing.RouteID{oldFwd, oldRvs}, old)
+	require.Len(t, rg.paths, 2)
+	require.False(t, rg.isPathDown(1))
+	require.Equal(t, ids[0], rg.fwd[1].KeyRouteID())
+
+	_, _, ok = rg.replacePath(oldFwd, fwd, rvs, nil, nil)
+	require.False(t, ok)
+
+	require.NoError(t, rg.Close())
+}
+
+func TestRouteGroup_Sequencing(t *testing.T) {
+	cfg := DefaultRouteGroupConfig()
+	cfg.MTU = 100
+
+	rg := createRouteGroup(cfg)
+
+	msgs := make([][]byte, 3)
+	packets := make([][]routing.Packet, 3)
+
+	for i := range msgs {
+		msgs[i] = make([]byte, 50+100*i)
+		_, err := rand.Read(msgs[i])
+		require.NoError(t, err)
+
+		packets[i], err = makeFragmentPackets(1, uint32(i+1), msgs[i], rg.mtu())
+		require.NoError(t, err)
+	}
+
+	// even small messages are sequenced
+	require.Len(t, packets[0], 1)
+	require.Equal(t, routing.FragmentPacket, packets[0][0].Type())
+
+	// messages spread over paths arrive out of order
+	for _, i := range []int{2, 1, 0} {
+		for _, packet := range packets[i] {
+			require.NoError(t, rg.handlePacket(packet))
+		}
+	}
+
+	buf := make([]byte, 1024)
+	for i := range msgs {
+		n, err := rg.Read(buf)
+		require.NoError(t, err)
+		require.Equal(t, msgs[i], buf[:n])
+	}
+
+	// already handed out message is dropped
+	require.True(t, errors.Is(rg.handlePacket(packets[0][0]), ErrUnexpectedFragment))
+
+	// message which got lost is skipped after a while
+	lost, err := makeFragmentPackets(1, 5, []byte("after lost"), rg.mtu())
+	require.NoError(t, err)
+	require.NoError(t, rg.handlePacket(lost[0]))
+	require.Nil(t, rg.seq.skip(time.Hour))
+
+	time.Sleep(10 * time.Millisecond)
+	require.Equal(t, [][]byte{[]byte("after lost")}, rg.seq.skip(5*time.Millisecond))
+
+	far, err := makeFragmentPackets(1, 6+uint32(cfg.ReadChBufSize), []byte("too far"), rg.mtu())
+	require.NoError(t, err)
+	require.True(t, errors.Is(rg.handlePacket(far[0]), ErrSequenceWindow))
+
+	require.NoError(t, rg.Close())
+}
+
+func TestSequencer_Limits(t *testing.T) {
+	s := newSequencer(4)
+
+	_, err := s.push(1, 0, maxMessageFragments+1, []byte("a"))
+	require.True(t, errors.Is(err, ErrMessageTooLarge))
+
+	_, err = s.push(1, 0, 0, []byte("a"))
+	require.True(t, errors.Is(err, ErrMessageTooLarge))
+
+	// message growing over the max size is discarded
+	chunk := make([]byte, maxMessageSize/2+1)
+	_, err = s.push(1, 0, 2, chunk)
+	require.NoError(t, err)
+	_, err = s.push(1, 1, 2, chunk)
+	require.True(t, errors.Is(err, ErrMessageTooLarge))
+	require.Empty(t, s.msgs)
+	require.Equal(t, 0, s.size)
+
+	// messages held ahead of the missing one are bounded in total
+	s = newSequencer(maxSequencerSize/maxMessageSize + 2)
+	chunk = make([]byte, maxMessageSize)
+
+	for seq := uint32(2); seq < maxSequencerSize/maxMessageSize+2; seq++ {
+		_, err = s.push(seq, 0, 1, chunk)
+		require.NoError(t, err)
+	}
+
+	_, err = s.push(maxSequencerSize/maxMessageSize+2, 0, 1, chunk)
+	require.True(t, errors.Is(err, ErrSequencerFull))
+
+	// handing messages out frees the space
+	msgs, err := s.push(1, 0, 1, []byte("first"))
+	require.NoError(t, err)
+	require.Len(t, msgs, maxSequencerSize/maxMessageSize+1)
+	require.Equal(t, 0, s.size)
+}
+
+func TestRouteGroup_MaxMessageSize(t *testing.T) {
+	cfg := DefaultRouteGroupConfig()
+	cfg.MTU = 100
+
+	rg := createRouteGroup(cfg)
+	require.Equal(t, maxMessageFragments*(100-routing.FragmentHeaderSize), rg.maxMessageSize())
+	require.NoError(t, rg.Close())
+
+	rg = createRouteGroup(DefaultRouteGroupConfig())
+	require.Equal(t, maxMessageSize, rg.maxMessageSize())
+	require.NoError(t, rg.Close())
+}
+
+func TestRouteGroup_HandshakeFlags(t *testing.T) {
+	rg := createRouteGroup(DefaultRouteGroupConfig())
+	appendTestPaths(t, rg, 2)
+
+	// the first request completes the handshake, the reply is sent by the router
+	request := routing.MakeHandshakeFlagsPacket(rg.rvs[0].KeyRouteID(), true, routing.HandshakeSequenced, 0)
+	require.NoError(t, rg.handlePacket(request))
+	require.True(t, chanClosed(rg.handshakeProcessed))
+	require.True(t, rg.encrypt)
+	require.Equal(t, routing.HandshakeSequenced, rg.peerFlags)
+	require.False(t, rg.capsSet)
+
+	// confirmation of the reply is the agreement
+	confirm := routing.MakeHandshakeFlagsPacket(rg.rvs[0].KeyRouteID(), true,
+		routing.HandshakeConfirm|routing.HandshakeSequenced, 0)
+	require.NoError(t, rg.handlePacket(confirm))
+	require.Equal(t, routing.HandshakeSequenced, rg.caps)
+	require.Equal(t, routing.HandshakeSequenced, rg.paths[0].caps)
+	require.Equal(t, byte(0), rg.paths[1].caps)
+
+	// handshake stripped of flags by an intermediary doesn't verify anything
+	require.NoError(t, rg.handlePacket(routing.MakeHandshakePacket(rg.rvs[1].KeyRouteID(), true)))
+	require.Equal(t, byte(0), rg.paths[1].caps)
+
+	// requests for additional paths are replied via the same path
+	request = routing.MakeHandshakeFlagsPacket(rg.rvs[1].KeyRouteID(), true, routing.HandshakeSequenced, 0)
+	require.Equal(t, ErrBadTransport, rg.handlePacket(request))
+
+	pong := routing.MakeHandshakeFlagsPacket(rg.rvs[1].KeyRouteID(), true, routing.HandshakePong,
+		time.Now().Add(-50*time.Millisecond).UnixNano())
+	require.NoError(t, rg.handlePacket(pong))
+	require.True(t, rg.paths[1].rtt >= 50*time.Millisecond)
+
+	require.NoError(t, rg.Close())
+}
+
+func TestRouteGroup_FlowControlNegotiation(t *testing.T) {
+	rg := createRouteGroup(DefaultRouteGroupConfig())
+	appendTestPaths(t, rg, 2)
+
+	// nothing is sent to remotes which didn't agree on flow control
+	require.False(t, rg.flowControlled())
+	require.Equal(t, -1, rg.flowControlPath())
+
+	confirm := routing.MakeHandshakeFlagsPacket(rg.rvs[1].KeyRouteID(), true,
+		routing.HandshakeConfirm|routing.HandshakeSequenced|routing.HandshakeFlowControl, 0)
+	require.NoError(t, rg.handlePacket(confirm))
+	require.True(t, rg.flowControlled())
+
+	// window updates only go via paths verified to get them through
+	rg.active = 0
+	require.Equal(t, 1, rg.flowControlPath())
+
+	rg.setPathDown(1)
+	require.Equal(t, -1, rg.flowControlPath())
+
+	require.NoError(t, rg.Close())
+}
+
 // TODO(darkrengarius): Uncomment and fix.
 /*
 func TestRouteGroup_TestConn(t *testing.T) {
@@ -577,8 +1056,12 @@ func pushPackets(ctx context.Context, from *transport.Manager, to *RouteGroup) {
 			if !safeSend(ctx, to, payload) {
 				return
 			}
-		case routing.HandshakePacket:
-			// error won't happen with the handshake packet
+		case routing.FragmentPacket:
+			if err := to.handlePacket(packet); err != nil {
+				panic(err)
+			}
+		case routing.HandshakePacket, routing.WindowUpdatePacket:
+			// error won't happen with the handshake and window update packets
 			_ = to.handlePacket(packet) //nolint:errcheck
 		default:
 			panic(fmt.Sprintf("wrong packet type %v", packet.Type()))
@@ -603,6 +1086,18 @@ func safeSend(ctx context.Context, to *RouteGroup, payload []byte) (keepSending
 	}
 }
 
+// appendTestPaths appends `n` paths without transports to `rg`.
+func appendTestPaths(t *testing.T, rg *RouteGroup, n int) {
+	for i := 0; i < n; i++ {
+		ids, err := rg.rt.ReserveKeys(2)
+		require.NoError(t, err)
+
+		fwd := routing.ForwardRule(ruleKeepAlive, ids[0], ids[1], uuid.New(), rg.desc.SrcPK(), rg.desc.DstPK(), 0, 0)
+		rvs := routing.ConsumeRule(ruleKeepAlive, ids[1], rg.desc.SrcPK(), rg.desc.DstPK(), 0, 0)
+		rg.appendRules(fwd, rvs, nil)
+	}
+}
+
 func createRouteGroup(cfg *RouteGroupConfig) *RouteGroup {
 	rt := routing.NewTable()
 
@@ -665,6 +1160,7 @@ func setupEnv(t *testing.T) (rg1, rg2 *RouteGroup, m1, m2 *transport.Manager, te
 	rg1.desc = r1FwdRtDesc.Invert()
 	rg1.tps = append(rg1.tps, tp1)
 	rg1.fwd = append(rg1.fwd, r1FwdRule)
+	rg1.paths = append(rg1.paths, newPathState())
 	rg1.mu.Unlock()
 
 	r2FwdRtDesc := r2FwdRule.RouteDescriptor()
@@ -672,6 +1168,7 @@ func setupEnv(t *testing.T) (rg1, rg2 *RouteGroup, m1, m2 *transport.Manager, te
 	rg2.desc = r2FwdRtDesc.Invert()
 	rg2.tps = append(rg2.tps, tp2)
 	rg2.fwd = append(rg2.fwd, r2FwdRule)
+	rg2.paths = append(rg2.paths, newPathState())
 	rg2.mu.Unlock()
 
 	teardown = func() {
@@ -680,3 +1177,111 @@ func setupEnv(t *testing.T) (rg1, rg2 *RouteGroup, m1, m2 *transport.Manager, te
 
 	return rg1, rg2, m1, m2, teardown
 }
+
+// datagramLink is a packet conn which hands datagrams to the test instead of delivering them,
+// so the test may lose, reorder or replay them.
+type datagramLink struct {
+	net.Conn
+	in  chan []byte
+	out chan []byte
+}
+
+func newDatagramLink() *datagramLink {
+	return &datagramLink{in: make(chan []byte, 100), out: make(chan []byte, 100)}
+}
+
+func (l *datagramLink) Read(p []byte) (int, error) {
+	return copy(p, <-l.in), nil
+}
+
+func (l *datagramLink) Write(p []byte) (int, error) {
+	l.out <- append([]byte(nil), p...)
+	return len(p), nil
+}
+
+func TestNoiseDatagramConn(t *testing.T) {
+	pk1, sk1 := cipher.GenerateKeyPair()
+	pk2, sk2 := cipher.GenerateKeyPair()
+
+	ns1, err := noise.New(noise.HandshakeKK, noise.Config{LocalPK: pk1, LocalSK: sk1, RemotePK: pk2, Initiator: true})
+	require.NoError(t, err)
+	ns2, err := noise.New(noise.HandshakeKK, noise.Config{LocalPK: pk2, LocalSK: sk2, RemotePK: pk1})
+	require.NoError(t, err)
+
+	msg, err := ns1.MakeHandshakeMessage()
+	require.NoError(t, err)
+	require.NoError(t, ns2.ProcessHandshakeMessage(msg))
+	msg, err = ns2.MakeHandshakeMessage()
+	require.NoError(t, err)
+	require.NoError(t, ns1.ProcessHandshakeMessage(msg))
+
+	link1, link2 := newDatagramLink(), newDatagramLink()
+	c1 := newNoiseDatagramConn(link1, ns1, defaultMTU)
+	c2 := newNoiseDatagramConn(link2, ns2, defaultMTU)
+
+	const n = 10
+
+	frames := make([][]byte, n)
+	for i := range frames {
+		_, err := c1.Write([]byte{byte(i)})
+		require.NoError(t, err)
+		frames[i] = <-link1.out
+	}
+
+	read := func() byte {
+		buf := make([]byte, 16)
+		n, err := c2.Read(buf)
+		require.NoError(t, err)
+		require.Equal(t, 1, n)
+
+		return buf[0]
+	}
+
+	// Frames are delivered in reverse order, every one of them is read.
+	for i := n - 1; i >= 0; i-- {
+		link2.in <- frames[i]
+		assert.Equal(t, byte(i), read())
+	}
+
+	// Replayed, malformed and forged frames are dropped without breaking the conn.
+	forged := append([]byte(nil), frames[0]...)
+	forged[len(forged)-1] ^= 0xFF
+	forged[noisePrefixSize+noiseNonceSize-1] = n + 1
+
+	link2.in <- frames[3]
+	link2.in <- []byte{1, 2, 3}
+	link2.in <- forged
+
+	_, err = c1.Write([]byte{n})
+	require.NoError(t, err)
+	link2.in <- <-link1.out
+
+	assert.Equal(t, byte(n), read())
+
+	// Frames behind the window are dropped.
+	for i := 0; i < nonceWindowSize; i++ {
+		_, err = c1.Write([]byte{0})
+		require.NoError(t, err)
+		<-link1.out
+	}
+
+	_, err = c1.Write([]byte{42})
+	require.NoError(t, err)
+	latest := <-link1.out
+
+	_, err = c1.Write([]byte{43})
+	require.NoError(t, err)
+
+	link2.in <- <-link1.out
+	link2.in <- latest
+	assert.Equal(t, byte(43), read())
+	assert.Equal(t, byte(42), read())
+
+	link2.in <- frames[n-1]
+	link2.in <- latest
+
+	_, err = c1.Write([]byte{44})
+	require.NoError(t, err)
+	link2.in <- <-link1.out
+	assert.Equal(t, byte(44), read())
+}
@@ -12,6 +12,8 @@ import (
 	"sync"
 	"time"
 
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
 	"github.com/skycoin/dmsg"
 	"github.com/skycoin/dmsg/cipher"
 	"github.com/skycoin/dmsg/noise"
@@ -41,6 +43,10 @@ const (
 	maxHops       = 50
 	retryDuration = 10 * time.Second
 	retryInterval = 500 * time.Millisecond
+
+	pathRepairRetryInterval = 5 * time.Second
+	pathRepairMaxInterval   = time.Minute
+	pathRepairAttempts      = 5
 )
 
 var (
@@ -49,8 +55,15 @@ var (
 
 	// ErrRemoteEmptyPK occurs when the specified remote public key is empty.
 	ErrRemoteEmptyPK = errors.New("empty remote public key")
+
+	// ErrNotEnoughRoutes is returned when less disjoint routes than requested
+	// by DialOptions could be found or established.
+	ErrNotEnoughRoutes = errors.New("not enough disjoint routes")
 )
 
+// RouteGroupCallback triggers after a route group is established and after it is closed.
+type RouteGroupCallback func(desc routing.RouteDescriptor, established bool)
+
 // Config configures Router.
 type Config struct {
 	Logger           *logging.Logger
@@ -61,6 +74,9 @@ type Config struct {
 	RouteGroupDialer setupclient.RouteGroupDialer
 	SetupNodes       []cipher.PubKey
 	RulesGCInterval  time.Duration
+	DialOptions      *DialOptions  // used by DialRoutes when called with nil options
+	RoutingTable     routing.Table // in-memory table is used if nil
+	OnRouteGroup     RouteGroupCallback
 }
 
 // SetDefaults sets default values for certain empty values.
@@ -76,14 +92,26 @@ func (c *Config) SetDefaults() {
 	if c.RulesGCInterval <= 0 {
 		c.RulesGCInterval = DefaultRulesGCInterval
 	}
+
+	if c.DialOptions == nil {
+		c.DialOptions = DefaultDialOptions()
+	}
+
+	if c.RoutingTable == nil {
+		c.RoutingTable = routing.NewTable()
+	}
 }
 
 // DialOptions describes dial options.
+// Forward and consume route counts bound the number of disjoint paths
+// a single route group is built of. Scheduler defines how writes are
+// distributed over these paths.
 type DialOptions struct {
 	MinForwardRts int
 	MaxForwardRts int
 	MinConsumeRts int
 	MaxConsumeRts int
+	Scheduler     Scheduler
 }
 
 // DefaultDialOptions returns default dial options.
@@ -104,10 +132,10 @@ type Router interface {
 
 	// DialRoutes dials to a given visor of 'rPK'.
 	// 'lPort'/'rPort' specifies the local/remote ports respectively.
-	// A nil 'opts' input results in a value of '1' for all DialOptions fields.
+	// A nil 'opts' input results in the DialOptions of the router Config being used.
 	// A single call to DialRoutes should perform the following:
 	// - Find routes via RouteFinder (in one call).
-	// - Setup routes via SetupNode (in one call).
+	// - Setup routes via SetupNode (one call per path).
 	// - Save to routing.Table and internal RouteGroup map.
 	// - Return RouteGroup if successful.
 	DialRoutes(ctx context.Context, rPK cipher.PubKey, lPort, rPort routing.Port, opts *DialOptions) (net.Conn, error)
@@ -172,7 +200,7 @@ func New(n *snet.Network, config *Config) (Router, error) {
 		logger:        config.Logger,
 		n:             n,
 		tm:            config.TransportManager,
-		rt:            routing.NewTable(),
+		rt:            config.RoutingTable,
 		sl:            sl,
 		rgsNs:         make(map[routing.RouteDescriptor]*NoiseRouteGroup),
 		rgsRaw:        make(map[routing.RouteDescriptor]*RouteGroup),
@@ -182,6 +210,10 @@ func New(n *snet.Network, config *Config) (Router, error) {
 		trustedVisors: trustedVisors,
 	}
 
+	if r.tm != nil {
+		r.tm.OnTPStatusChanged(r.handleTransportStatus)
+	}
+
 	go r.rulesGCLoop()
 
 	if err := r.rpcSrv.Register(NewRPCGateway(r)); err != nil {
@@ -193,10 +225,10 @@ func New(n *snet.Network, config *Config) (Router, error) {
 
 // DialRoutes dials to a given visor of 'rPK'.
 // 'lPort'/'rPort' specifies the local/remote ports respectively.
-// A nil 'opts' input results in a value of '1' for all DialOptions fields.
+// A nil 'opts' input results in the DialOptions of the router Config being used.
 // A single call to DialRoutes should perform the following:
 // - Find routes via RouteFinder (in one call).
-// - Setup routes via SetupNode (in one call).
+// - Setup routes via SetupNode (one call per path).
 // - Save to routing.Table and internal RouteGroup map.
 // - Return RouteGroup if successful.
 func (r *router) DialRoutes(
@@ -212,10 +244,14 @@ func (r *router) DialRoutes(
 		return nil, fmt.Errorf("failed to dial routes: %w", err)
 	}
 
+	if opts == nil {
+		opts = r.conf.DialOptions
+	}
+
 	lPK := r.conf.PubKey
 	forwardDesc := routing.NewRouteDescriptor(lPK, rPK, lPort, rPort)
 
-	forwardPath, reversePath, err := r.fetchBestRoutes(lPK, rPK, opts)
+	forwardPaths, reversePaths, err := r.fetchBestRoutes(lPK, rPK, opts)
 	if err != nil {
 		return nil, fmt.Errorf("route finder: %w", err)
 	}
@@ -223,8 +259,8 @@ func (r *router) DialRoutes(
 	req := routing.BidirectionalRoute{
 		Desc:      forwardDesc,
 		KeepAlive: DefaultRouteKeepAlive,
-		Forward:   forwardPath,
-		Reverse:   reversePath,
+		Forward:   forwardPaths[0],
+		Reverse:   reversePaths[0],
 	}
 
 	rules, err := r.conf.RouteGroupDialer.Dial(ctx, r.logger, r.n, r.conf.SetupNodes, req)
@@ -245,18 +281,241 @@ func (r *router) DialRoutes(
 		Initiator: true,
 	}
 
-	nrg, err := r.saveRouteGroupRules(rules, nsConf)
+	nrg, err := r.saveRouteGroupRules(rules, nsConf, opts.Scheduler)
 	if err != nil {
 		return nil, fmt.Errorf("saveRouteGroupRules: %w", err)
 	}
 
+	nrg.rg.setPathHops(0, joinHops(forwardPaths[0], reversePaths[0]))
+
+	paths := 1 + r.dialAdditionalPaths(ctx, nrg.rg, forwardDesc, forwardPaths, reversePaths)
+	if paths < opts.MinForwardRts || paths < opts.MinConsumeRts {
+		if err := nrg.Close(); err != nil {
+			r.logger.WithError(err).Warnf("Failed to close route group (%s)", &forwardDesc)
+		}
+
+		return nil, fmt.Errorf("established %d paths: %w", paths, ErrNotEnoughRoutes)
+	}
+
+	// only the initiating edge is able to set up new paths for the route group
+	nrg.rg.mu.Lock()
+	nrg.rg.onPathDown = r.repairPath
+	nrg.rg.mu.Unlock()
+
 	nrg.rg.startOffServiceLoops()
 
-	r.logger.Infof("Created new routes to %s on port %d", rPK, lPort)
+	r.logger.Infof("Created new routes to %s on port %d using %d paths", rPK, lPort, paths)
 
 	return nrg, nil
 }
 
+// dialAdditionalPaths sets up all the paths except for the first one and appends
+// them to `rg`. Returns the number of successfully established paths.
+// The first path always goes through the regular route group setup, additional
+// ones are marked with `Append` for the remote to attach them to its route group.
+func (r *router) dialAdditionalPaths(
+	ctx context.Context,
+	rg *RouteGroup,
+	desc routing.RouteDescriptor,
+	forwardPaths, reversePaths [][]routing.Hop,
+) int {
+	n := len(forwardPaths)
+	if len(reversePaths) > n {
+		n = len(reversePaths)
+	}
+
+	established := 0
+
+	for i := 1; i < n; i++ {
+		fwd, rev := forwardPaths[i%len(forwardPaths)], reversePaths[i%len(reversePaths)]
+
+		if _, err := r.dialPath(ctx, rg, desc, fwd, rev); err != nil {
+			r.logger.WithError(err).Warnf("Failed to dial additional path %d/%d", i+1, n)
+			continue
+		}
+
+		established++
+	}
+
+	return established
+}
+
+// dialPath sets up a single additional path for `rg` and appends it.
+// Returns index of the new path within the route group.
+func (r *router) dialPath(
+	ctx context.Context,
+	rg *RouteGroup,
+	desc routing.RouteDescriptor,
+	fwd, rev []routing.Hop,
+) (int, error) {
+	rules, err := r.setupPath(ctx, desc, fwd, rev)
+	if err != nil {
+		return 0, err
+	}
+
+	idx := rg.appendRules(rules.Forward, rules.Reverse, r.tm.Transport(rules.Forward.NextTransportID()))
+	rg.setPathHops(idx, joinHops(fwd, rev))
+	r.verifyPath(rg, idx)
+
+	return idx, nil
+}
+
+// setupPath sets up a single additional path of the route group `desc` and saves its rules.
+func (r *router) setupPath(
+	ctx context.Context,
+	desc routing.RouteDescriptor,
+	fwd, rev []routing.Hop,
+) (routing.EdgeRules, error) {
+	req := routing.BidirectionalRoute{
+		Desc:      desc,
+		KeepAlive: DefaultRouteKeepAlive,
+		Forward:   fwd,
+		Reverse:   rev,
+		Append:    true,
+	}
+
+	rules, err := r.conf.RouteGroupDialer.Dial(ctx, r.logger, r.n, r.conf.SetupNodes, req)
+	if err != nil {
+		return routing.EdgeRules{}, err
+	}
+
+	if err := r.SaveRoutingRules(rules.Forward, rules.Reverse); err != nil {
+		return routing.EdgeRules{}, err
+	}
+
+	return rules, nil
+}
+
+// verifyPath asks remote to verify the new path `idx` gets sequenced messages through,
+// until then writes aren't spread over it. It's retried along with network probes if it gets lost.
+func (r *router) verifyPath(rg *RouteGroup, idx int) {
+	rg.mu.Lock()
+	caps := rg.caps
+	rg.mu.Unlock()
+
+	if caps == 0 {
+		return
+	}
+
+	if err := rg.sendPathHandshake(idx, supportedHandshakeFlags, 0); err != nil {
+		r.logger.WithError(err).Warnf("Failed to send handshake via path %d", idx+1)
+	}
+}
+
+// repairPath replaces the broken path of `rg` with forward rule `rtID` with a new one.
+func (r *router) repairPath(rg *RouteGroup, rtID routing.RouteID) {
+	r.repairPathAttempt(rg, rtID, 1)
+}
+
+// repairPathAttempt makes attempt number `attempt` to replace the broken path of `rg`
+// with forward rule `rtID`. Failed attempts are retried with exponential backoff, the
+// broken path is removed once all of them fail.
+func (r *router) repairPathAttempt(rg *RouteGroup, rtID routing.RouteID, attempt int) {
+	log := r.logger.
+		WithField("func", "router.repairPath").
+		WithField("rt_desc", rg.desc.String()).
+		WithField("rule_keyRtID", rtID).
+		WithField("attempt", attempt)
+
+	if chanClosed(r.done) || !rg.IsAlive() {
+		return
+	}
+
+	idx, avoid := rg.pathByRule(rtID)
+	if idx < 0 {
+		log.Debug("Path is already removed. Nothing to be done.")
+		return
+	}
+
+	log.Info("Path is broken, requesting a new one...")
+
+	newIdx, err := r.replacePath(rg, rtID, avoid)
+	if err == nil {
+		log.WithField("new_path", newIdx+1).Info("Replaced broken path.")
+		return
+	}
+
+	if attempt >= pathRepairAttempts {
+		log.WithError(err).Warn("Failed to replace broken path, removing it.")
+		r.rt.DelRules([]routing.RouteID{rtID})
+		r.removeRouteGroupPath(rg.desc, rtID, log)
+
+		return
+	}
+
+	delay := pathRepairRetryInterval << (attempt - 1)
+	if delay > pathRepairMaxInterval {
+		delay = pathRepairMaxInterval
+	}
+
+	log.WithError(err).Warnf("Failed to replace broken path, retrying in %s...", delay)
+
+	time.AfterFunc(delay, func() { r.repairPathAttempt(rg, rtID, attempt+1) })
+}
+
+// replacePath sets up a new path avoiding transports `avoid` and puts it in place of
+// the path of `rg` with forward rule `rtID`. Rules of the broken path are removed, the
+// remote edge removes its side of the path once its rules expire.
+// Returns index of the new path within the route group.
+func (r *router) replacePath(rg *RouteGroup, rtID routing.RouteID, avoid map[uuid.UUID]struct{}) (int, error) {
+	// route group descriptor is inverted on the initiating edge
+	desc := rg.desc.Invert()
+	opts := &DialOptions{MinForwardRts: 1, MaxForwardRts: 1, MinConsumeRts: 1, MaxConsumeRts: 1}
+
+	fwd, rev, err := r.fetchRoutes(desc.SrcPK(), desc.DstPK(), opts, avoid)
+	if err != nil {
+		return 0, fmt.Errorf("route finder: %w", err)
+	}
+
+	rules, err := r.setupPath(context.Background(), desc, fwd[0], rev[0])
+	if err != nil {
+		return 0, fmt.Errorf("setup path: %w", err)
+	}
+
+	tp := r.tm.Transport(rules.Forward.NextTransportID())
+	hops := joinHops(fwd[0], rev[0])
+
+	idx, old, ok := rg.replacePath(rtID, rules.Forward, rules.Reverse, tp, hops)
+	if ok {
+		r.rt.DelRules(old)
+	} else {
+		// broken path got removed meanwhile, so the new one is just added
+		idx = rg.appendRules(rules.Forward, rules.Reverse, tp)
+		rg.setPathHops(idx, hops)
+	}
+
+	r.verifyPath(rg, idx)
+
+	return idx, nil
+}
+
+// handleTransportStatus marks paths going through the transport which went down as broken.
+func (r *router) handleTransportStatus(tp *transport.ManagedTransport, isUp bool) {
+	if isUp || tp == nil {
+		return
+	}
+
+	r.mx.Lock()
+	rgs := make([]*RouteGroup, 0, len(r.rgsNs))
+	for _, nrg := range r.rgsNs {
+		if nrg != nil {
+			rgs = append(rgs, nrg.rg)
+		}
+	}
+	r.mx.Unlock()
+
+	for _, rg := range rgs {
+		rg.setTransportDown(tp.Entry.ID)
+	}
+}
+
+func joinHops(fwd, rev []routing.Hop) []routing.Hop {
+	hops := make([]routing.Hop, 0, len(fwd)+len(rev))
+	hops = append(hops, fwd...)
+
+	return append(hops, rev...)
+}
+
 // AcceptsRoutes should block until we receive an AddRules packet from SetupNode
 // that contains ConsumeRule(s) or ForwardRule(s).
 // Then the following should happen:
@@ -296,7 +555,7 @@ func (r *router) AcceptRoutes(ctx context.Context) (net.Conn, error) {
 		Initiator: false,
 	}
 
-	nrg, err := r.saveRouteGroupRules(rules, nsConf)
+	nrg, err := r.saveRouteGroupRules(rules, nsConf, r.conf.DialOptions.Scheduler)
 	if err != nil {
 		return nil, fmt.Errorf("saveRouteGroupRules: %w", err)
 	}
@@ -372,7 +631,11 @@ func (r *router) serveSetup() {
 	}
 }
 
-func (r *router) saveRouteGroupRules(rules routing.EdgeRules, nsConf noise.Config) (*NoiseRouteGroup, error) {
+func (r *router) saveRouteGroupRules(
+	rules routing.EdgeRules,
+	nsConf noise.Config,
+	scheduler Scheduler,
+) (*NoiseRouteGroup, error) {
 	r.logger.Infof("Saving route group rules with desc: %s", &rules.Desc)
 
 	// When route group is wrapped with noise, it's put into `nrgs`. but before that,
@@ -393,14 +656,17 @@ func (r *router) saveRouteGroupRules(rules routing.EdgeRules, nsConf noise.Confi
 	nrg, ok := r.rgsNs[rules.Desc]
 
 	r.logger.Infof("Creating new route group rule with desc: %s", &rules.Desc)
-	rg := NewRouteGroup(DefaultRouteGroupConfig(), r.rt, rules.Desc)
+	rgConf := DefaultRouteGroupConfig()
+	rgConf.Scheduler = scheduler
+
+	rg := NewRouteGroup(rgConf, r.rt, rules.Desc)
 	rg.appendRules(rules.Forward, rules.Reverse, r.tm.Transport(rules.Forward.NextTransportID()))
 	// we put raw rg so it can be accessible to the router when handshake packets come in
 	r.rgsRaw[rules.Desc] = rg
 	r.mx.Unlock()
 
 	if nsConf.Initiator {
-		if err := rg.sendHandshake(true); err != nil {
+		if err := rg.sendHandshake(true, false); err != nil {
 			r.logger.WithError(err).Errorf("Failed to send handshake from route group (%s): %v, closing...",
 				&rules.Desc, err)
 			if err := rg.Close(); err != nil {
@@ -427,7 +693,7 @@ func (r *router) saveRouteGroupRules(rules routing.EdgeRules, nsConf noise.Confi
 	}
 
 	if !nsConf.Initiator {
-		if err := rg.sendHandshake(true); err != nil {
+		if err := rg.sendHandshake(true, true); err != nil {
 			r.logger.WithError(err).Errorf("Failed to send handshake from route group (%s): %v, closing...",
 				&rules.Desc, err)
 			if err := rg.Close(); err != nil {
@@ -451,7 +717,7 @@ func (r *router) saveRouteGroupRules(rules routing.EdgeRules, nsConf noise.Confi
 
 	if rg.encrypt {
 		// wrapping rg with noise
-		wrappedRG, err := noisewrapper.WrapConn(nsConf, rg)
+		wrappedRG, ns, err := noisewrapper.WrapConnNoise(nsConf, rg)
 		if err != nil {
 			r.logger.WithError(err).Errorf("Failed to wrap route group (%s): %v, closing...", &rules.Desc, err)
 			if err := rg.Close(); err != nil {
@@ -463,6 +729,7 @@ func (r *router) saveRouteGroupRules(rules routing.EdgeRules, nsConf noise.Confi
 
 		nrg = &NoiseRouteGroup{
 			rg:   rg,
+			ns:   ns,
 			Conn: wrappedRG,
 		}
 	} else {
@@ -478,12 +745,28 @@ func (r *router) saveRouteGroupRules(rules routing.EdgeRules, nsConf noise.Confi
 	delete(r.rgsRaw, rules.Desc)
 	r.mx.Unlock()
 
+	if r.conf.OnRouteGroup != nil {
+		go r.notifyRouteGroup(rules.Desc, rg)
+	}
+
 	return nrg, nil
 }
 
+// notifyRouteGroup reports the route group as established and then as closed once it's closed.
+func (r *router) notifyRouteGroup(desc routing.RouteDescriptor, rg *RouteGroup) {
+	r.conf.OnRouteGroup(desc, true)
+
+	select {
+	case <-rg.closed:
+	case <-rg.remoteClosed:
+	}
+
+	r.conf.OnRouteGroup(desc, false)
+}
+
 func (r *router) handleTransportPacket(ctx context.Context, packet routing.Packet) error {
 	switch packet.Type() {
-	case routing.DataPacket, routing.HandshakePacket:
+	case routing.DataPacket, routing.FragmentPacket, routing.HandshakePacket, routing.WindowUpdatePacket:
 		return r.handleDataHandshakePacket(ctx, packet)
 	case routing.ClosePacket:
 		return r.handleClosePacket(ctx, packet)
@@ -678,12 +961,19 @@ func (r *router) handleKeepAlivePacket(ctx context.Context, packet routing.Packe
 	}
 
 	// propagate packet only for intermediary rule. forward rule workflow doesn't get here,
-	// consume rules should be omitted, activity is already updated
+	// activity is already updated
 	if t := rule.Type(); t == routing.RuleIntermediary {
 		r.logger.Debugln("Handling intermediary keep-alive packet")
 		return r.forwardPacket(ctx, packet, rule)
 	}
 
+	// route group tracks keep-alives to tell broken paths
+	if rule.Type() == routing.RuleReverse {
+		if nrg, ok := r.noiseRouteGroup(rule.RouteDescriptor()); ok && nrg != nil {
+			nrg.rg.markRecv(routeID)
+		}
+	}
+
 	r.logger.Debugf("Route ID %v found, updated activity", routeID)
 
 	return nil
@@ -741,6 +1031,12 @@ func (r *router) Close() error {
 
 	r.wg.Wait()
 
+	if closer, ok := r.rt.(io.Closer); ok {
+		if err := closer.Close(); err != nil {
+			r.logger.WithError(err).Warn("Failed to close routing table")
+		}
+	}
+
 	return r.tm.Close()
 }
 
@@ -760,17 +1056,32 @@ func (r *router) forwardPacket(ctx context.Context, packet routing.Packet, rule
 		if err != nil {
 			return err
 		}
+	case routing.FragmentPacket:
+		seq, idx, count, data, err := packet.Fragment()
+		if err != nil {
+			return err
+		}
+
+		p, err = routing.MakeFragmentPacket(rule.NextRouteID(), seq, idx, count, data)
+		if err != nil {
+			return err
+		}
 	case routing.HandshakePacket:
 		b := int(packet[routing.PacketPayloadOffset])
 		supportEncryptionVal := true
 		if b == 0 {
 			supportEncryptionVal = false
 		}
-		p = routing.MakeHandshakePacket(rule.NextRouteID(), supportEncryptionVal)
+		// handshake flags are passed on as is for the edges to know they got through
+		flags, timestamp := packet.HandshakeFlags()
+		p = routing.MakeHandshakeFlagsPacket(rule.NextRouteID(), supportEncryptionVal, flags, timestamp)
 	case routing.NetworkProbePacket:
 		timestamp := int64(binary.BigEndian.Uint64(packet[routing.PacketPayloadOffset:]))
 		throughput := int64(binary.BigEndian.Uint64(packet[routing.PacketPayloadOffset+8:]))
 		p = routing.MakeNetworkProbePacket(rule.NextRouteID(), timestamp, throughput)
+	case routing.WindowUpdatePacket:
+		limit := binary.BigEndian.Uint64(packet[routing.PacketPayloadOffset:])
+		p = routing.MakeWindowUpdatePacket(rule.NextRouteID(), limit)
 	case routing.KeepAlivePacket:
 		p = routing.MakeKeepAlivePacket(rule.NextRouteID())
 	case routing.ClosePacket:
@@ -809,10 +1120,19 @@ func (r *router) RemoveRouteDescriptor(desc routing.RouteDescriptor) {
 	}
 }
 
-func (r *router) fetchBestRoutes(src, dst cipher.PubKey, opts *DialOptions) (fwd, rev []routing.Hop, err error) {
-	// TODO: use opts
+func (r *router) fetchBestRoutes(src, dst cipher.PubKey, opts *DialOptions) (fwd, rev [][]routing.Hop, err error) {
+	return r.fetchRoutes(src, dst, opts, nil)
+}
+
+// fetchRoutes requests routes from the route finder and selects disjoint paths
+// out of them, skipping ones going through transports from `avoid`.
+func (r *router) fetchRoutes(
+	src, dst cipher.PubKey,
+	opts *DialOptions,
+	avoid map[uuid.UUID]struct{},
+) (fwd, rev [][]routing.Hop, err error) {
 	if opts == nil {
-		opts = DefaultDialOptions() // nolint
+		opts = DefaultDialOptions()
 	}
 
 	r.logger.Infof("Requesting new routes from %s to %s", src, dst)
@@ -826,8 +1146,7 @@ func (r *router) fetchBestRoutes(src, dst cipher.PubKey, opts *DialOptions) (fwd
 fetchRoutesAgain:
 	ctx := context.Background()
 
-	paths, err := r.conf.RouteFinder.FindRoutes(ctx, []routing.PathEdges{forward, backward},
-		&rfclient.RouteOptions{MinHops: minHops, MaxHops: maxHops})
+	paths, err := r.conf.RouteFinder.FindRoutes(ctx, []routing.PathEdges{forward, backward}, routeOptions(opts))
 
 	if err == rfclient.ErrTransportNotFound {
 		return nil, nil, err
@@ -845,7 +1164,83 @@ fetchRoutesAgain:
 
 	r.logger.Infof("Found routes Forward: %s. Reverse %s", paths[forward], paths[backward])
 
-	return paths[forward][0], paths[backward][0], nil
+	if fwd, err = selectDisjointPaths(paths[forward], opts.MinForwardRts, opts.MaxForwardRts, avoid); err != nil {
+		return nil, nil, fmt.Errorf("forward: %w", err)
+	}
+
+	if rev, err = selectDisjointPaths(paths[backward], opts.MinConsumeRts, opts.MaxConsumeRts, avoid); err != nil {
+		return nil, nil, fmt.Errorf("reverse: %w", err)
+	}
+
+	return fwd, rev, nil
+}
+
+// routeOptions makes route finder options for `opts`. Disjoint paths are requested only if
+// more than one path is wanted, otherwise overlapping alternatives are kept to choose from.
+func routeOptions(opts *DialOptions) *rfclient.RouteOptions {
+	count := opts.MaxForwardRts
+	if opts.MaxConsumeRts > count {
+		count = opts.MaxConsumeRts
+	}
+
+	rOpts := &rfclient.RouteOptions{MinHops: minHops, MaxHops: maxHops}
+	if count > 1 {
+		rOpts.Count = uint16(count)
+	}
+
+	return rOpts
+}
+
+// selectDisjointPaths picks up to `max` paths out of `paths` so that no two of them
+// share a transport and none of them goes through transports from `avoid`.
+// Paths are considered in the order the route finder returned them.
+func selectDisjointPaths(paths [][]routing.Hop, min, max int, avoid map[uuid.UUID]struct{}) ([][]routing.Hop, error) {
+	if min < 1 {
+		min = 1
+	}
+
+	if max < min {
+		max = min
+	}
+
+	usedTps := make(map[uuid.UUID]struct{}, len(avoid))
+	for tpID := range avoid {
+		usedTps[tpID] = struct{}{}
+	}
+
+	selected := make([][]routing.Hop, 0, max)
+
+	for _, path := range paths {
+		if len(selected) == max {
+			break
+		}
+
+		if len(path) == 0 || sharesTransport(path, usedTps) {
+			continue
+		}
+
+		for _, hop := range path {
+			usedTps[hop.TpID] = struct{}{}
+		}
+
+		selected = append(selected, path)
+	}
+
+	if len(selected) < min {
+		return nil, fmt.Errorf("found %d of %d: %w", len(selected), min, ErrNotEnoughRoutes)
+	}
+
+	return selected, nil
+}
+
+func sharesTransport(path []routing.Hop, tps map[uuid.UUID]struct{}) bool {
+	for _, hop := range path {
+		if _, ok := tps[hop.TpID]; ok {
+			return true
+		}
+	}
+
+	return false
 }
 
 // SetupIsTrusted checks if setup node is trusted.
@@ -917,6 +1312,10 @@ func (r *router) removeNoiseRouteGroup(desc routing.RouteDescriptor) {
 }
 
 func (r *router) IntroduceRules(rules routing.EdgeRules) error {
+	if rules.Append {
+		return r.appendRouteGroupRules(rules)
+	}
+
 	select {
 	case <-r.done:
 		return io.ErrClosedPipe
@@ -933,6 +1332,31 @@ func (r *router) IntroduceRules(rules routing.EdgeRules) error {
 	}
 }
 
+// appendRouteGroupRules saves rules of an additional path and attaches them to
+// the existing route group with the same descriptor.
+func (r *router) appendRouteGroupRules(rules routing.EdgeRules) error {
+	r.mx.Lock()
+	rg, ok := r.rgsRaw[rules.Desc]
+	if nrg, nrgOK := r.rgsNs[rules.Desc]; nrgOK && nrg != nil {
+		rg, ok = nrg.rg, true
+	}
+	r.mx.Unlock()
+
+	if !ok || rg == nil || !rg.IsAlive() {
+		return fmt.Errorf("no route group with desc %s to append path to", &rules.Desc)
+	}
+
+	if err := r.SaveRoutingRules(rules.Forward, rules.Reverse); err != nil {
+		return err
+	}
+
+	rg.appendRules(rules.Forward, rules.Reverse, r.tm.Transport(rules.Forward.NextTransportID()))
+
+	r.logger.Infof("Appended path to route group with desc %s", &rules.Desc)
+
+	return nil
+}
+
 // RoutesCount returns count of the routes stored within the routing table.
 func (r *router) RoutesCount() int {
 	return r.rt.Count()
@@ -999,16 +1423,27 @@ func (r *router) rulesGC() {
 	}
 }
 
+// removeRouteGroupOfRule removes the path of the route group `rule` belongs to.
+// Route group is closed once its last path is removed.
 func (r *router) removeRouteGroupOfRule(rule routing.Rule) {
 	log := r.logger.
 		WithField("func", "router.removeRouteGroupOfRule").
 		WithField("rule_type", rule.Type().String()).
 		WithField("rule_keyRtID", rule.KeyRouteID())
 
-	// we need to process only consume rules, cause we don't
+	// we need to process only edge rules, cause we don't
 	// really care about the other ones, other rules removal
 	// doesn't affect our work here
-	if rule.Type() != routing.RuleReverse {
+	var rDesc routing.RouteDescriptor
+
+	switch rule.Type() {
+	case routing.RuleReverse:
+		rDesc = rule.RouteDescriptor()
+	case routing.RuleForward:
+		// forward rule describes the route from the local edge
+		desc := rule.RouteDescriptor()
+		rDesc = desc.Invert()
+	default:
 		log.
 			WithField("func", "removeRouteGroupOfRule").
 			WithField("rule", rule.Type().String()).
@@ -1017,15 +1452,35 @@ func (r *router) removeRouteGroupOfRule(rule routing.Rule) {
 		return
 	}
 
-	rDesc := rule.RouteDescriptor()
-	log.WithField("rt_desc", rDesc.String()).
-		Debug("Closing noise route group associated with rule...")
+	r.removeRouteGroupPath(rDesc, rule.KeyRouteID(), log.WithField("rt_desc", rDesc.String()))
+}
 
-	nrg, ok := r.popNoiseRouteGroup(rDesc)
+// removeRouteGroupPath removes the path with rule `rtID` of the route group `rDesc`
+// along with the other rule of the path. Route group is closed once its last path is removed.
+func (r *router) removeRouteGroupPath(rDesc routing.RouteDescriptor, rtID routing.RouteID, log logrus.FieldLogger) {
+	nrg, ok := r.noiseRouteGroup(rDesc)
 	if !ok {
-		log.Debug("No noise route group associated with expired rule. Nothing to be done.")
+		log.Debug("No noise route group associated with rule. Nothing to be done.")
+		return
+	}
+
+	other, left, found := nrg.rg.removePath(rtID)
+	if !found {
+		log.Debug("Rule doesn't belong to any path of the route group. Nothing to be done.")
+		return
+	}
+
+	if left > 0 {
+		r.rt.DelRules([]routing.RouteID{other})
+		log.WithField("paths_left", left).Info("Removed path of the route group associated with rule.")
+
 		return
 	}
+
+	log.Debug("Closing noise route group associated with rule...")
+
+	r.popNoiseRouteGroup(rDesc)
+
 	if nrg.isClosed() {
 		log.Debug("Noise route group already closed. Nothing to be done.")
 		return
@@ -2,6 +2,7 @@ package router
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"net"
@@ -748,6 +749,57 @@ func TestRouter_SetupIsTrusted(t *testing.T) {
 	assert.False(t, r0.SetupIsTrusted(keys[1].PK))
 }
 
+func TestSelectDisjointPaths(t *testing.T) {
+	keys := snettest.GenKeyPairs(3)
+	tp01, tp12, tp02 := uuid.New(), uuid.New(), uuid.New()
+
+	direct := []routing.Hop{{TpID: tp02, From: keys[0].PK, To: keys[2].PK}}
+	viaOne := []routing.Hop{
+		{TpID: tp01, From: keys[0].PK, To: keys[1].PK},
+		{TpID: tp12, From: keys[1].PK, To: keys[2].PK},
+	}
+	viaOneAgain := []routing.Hop{
+		{TpID: tp01, From: keys[0].PK, To: keys[1].PK},
+		{TpID: tp02, From: keys[1].PK, To: keys[2].PK},
+	}
+	paths := [][]routing.Hop{direct, viaOneAgain, viaOne}
+
+	t.Run("single path", func(t *testing.T) {
+		got, err := selectDisjointPaths(paths, 1, 1, nil)
+		require.NoError(t, err)
+		assert.Equal(t, [][]routing.Hop{direct}, got)
+	})
+
+	t.Run("skips paths sharing transports", func(t *testing.T) {
+		got, err := selectDisjointPaths(paths, 1, 3, nil)
+		require.NoError(t, err)
+		assert.Equal(t, [][]routing.Hop{direct, viaOne}, got)
+	})
+
+	t.Run("avoids transports", func(t *testing.T) {
+		got, err := selectDisjointPaths(paths, 1, 3, map[uuid.UUID]struct{}{tp02: {}})
+		require.NoError(t, err)
+		assert.Equal(t, [][]routing.Hop{viaOne}, got)
+	})
+
+	t.Run("not enough disjoint paths", func(t *testing.T) {
+		_, err := selectDisjointPaths(paths, 3, 3, nil)
+		require.True(t, errors.Is(err, ErrNotEnoughRoutes))
+	})
+
+	t.Run("no paths", func(t *testing.T) {
+		_, err := selectDisjointPaths(nil, 0, 0, nil)
+		require.True(t, errors.Is(err, ErrNotEnoughRoutes))
+	})
+}
+
+func TestRouteOptions(t *testing.T) {
+	assert.Equal(t, &rfclient.RouteOptions{MinHops: minHops, MaxHops: maxHops}, routeOptions(DefaultDialOptions()))
+
+	opts := &DialOptions{MinForwardRts: 1, MaxForwardRts: 2, MinConsumeRts: 1, MaxConsumeRts: 3}
+	assert.Equal(t, &rfclient.RouteOptions{MinHops: minHops, MaxHops: maxHops, Count: 3}, routeOptions(opts))
+}
+
 func clearRouteGroups(routers ...*router) {
 	for _, r := range routers {
 		r.rgsNs = make(map[routing.RouteDescriptor]*NoiseRouteGroup)
@@ -0,0 +1,175 @@
+package router
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/skycoin/skywire/pkg/routing"
+)
+
+// Scheduler defines how writes of a route group are distributed over its paths.
+type Scheduler string
+
+// Possible Scheduler values. Empty value is the same as SchedulerFailover.
+const (
+	// SchedulerFailover sends all writes via the active path, the rest of paths are standby
+	// and are only switched to once the active one breaks.
+	SchedulerFailover Scheduler = "failover"
+	// SchedulerRoundRobin spreads writes evenly over the healthy paths.
+	SchedulerRoundRobin Scheduler = "round_robin"
+	// SchedulerLatency spreads writes over the healthy paths in inverse proportion
+	// to their round trip time.
+	SchedulerLatency Scheduler = "latency"
+)
+
+// spreads tells whether scheduler spreads writes over multiple paths.
+func (s Scheduler) spreads() bool {
+	return s == SchedulerRoundRobin || s == SchedulerLatency
+}
+
+// supportedHandshakeFlags are the handshake flags of features this visor supports.
+const supportedHandshakeFlags = routing.HandshakeSequenced | routing.HandshakeFlowControl
+
+// pathState holds the state of a single path of the route group.
+type pathState struct {
+	// atomic requires 64-bit alignment for struct field access
+	lastSent int64
+
+	// the rest of the fields are guarded by the route group mutex
+	down     bool          // broken paths are only used if no other path is left
+	hops     []routing.Hop // transports of the path, only known to the initiating edge
+	caps     byte          // handshake flags verified to get through the path
+	rtt      time.Duration // smoothed round trip time, zero if not measured yet
+	weight   float64       // current weight of the path used by the latency scheduler
+	lastRecv time.Time     // when a packet was last received via the path
+}
+
+func newPathState() *pathState {
+	now := time.Now()
+
+	return &pathState{lastSent: now.UnixNano(), lastRecv: now}
+}
+
+func (p *pathState) markSent() {
+	atomic.StoreInt64(&p.lastSent, time.Now().UnixNano())
+}
+
+func (p *pathState) lastSentAt() time.Time {
+	return time.Unix(0, atomic.LoadInt64(&p.lastSent))
+}
+
+// addRTT adds a round trip time sample to the smoothed one.
+func (p *pathState) addRTT(rtt time.Duration) {
+	if p.rtt == 0 {
+		p.rtt = rtt
+		return
+	}
+
+	p.rtt = (7*p.rtt + rtt) / 8
+}
+
+// schedule returns indices of paths to try for the next write. The path picked by the
+// scheduler goes first if writes are spread, followed by the rest of the healthy paths
+// starting with the active one, and then by the broken ones. Sequenced writes only go
+// via paths verified to get them through.
+// Must be called with the mutex held.
+func (rg *RouteGroup) schedule(spread, sequenced bool) []int {
+	n := len(rg.paths)
+	if n == 0 {
+		return nil
+	}
+
+	usable := func(idx int) bool {
+		return !sequenced || rg.paths[idx].caps&routing.HandshakeSequenced != 0
+	}
+
+	order := make([]int, 0, n)
+
+	first := -1
+	if spread {
+		first = rg.pick(usable)
+	}
+
+	if first >= 0 {
+		order = append(order, first)
+	}
+
+	for _, down := range []bool{false, true} {
+		for i := 0; i < n; i++ {
+			idx := (rg.active + i) % n
+			if idx != first && rg.paths[idx].down == down && usable(idx) {
+				order = append(order, idx)
+			}
+		}
+	}
+
+	return order
+}
+
+// pick returns index of the healthy path picked by the scheduler, or -1 if there's none.
+func (rg *RouteGroup) pick(usable func(idx int) bool) int {
+	if rg.cfg.Scheduler == SchedulerLatency {
+		return rg.pickByLatency(usable)
+	}
+
+	return rg.pickRoundRobin(usable)
+}
+
+func (rg *RouteGroup) pickRoundRobin(usable func(idx int) bool) int {
+	n := len(rg.paths)
+
+	for i := 0; i < n; i++ {
+		idx := (rg.rrNext + i) % n
+		if !rg.paths[idx].down && usable(idx) {
+			rg.rrNext = idx + 1
+			return idx
+		}
+	}
+
+	return -1
+}
+
+// pickByLatency implements smooth weighted round-robin with path weights inversely
+// proportional to their round trip times. Paths which weren't measured yet get the
+// weight of the slowest measured one, all paths get equal weights until then.
+func (rg *RouteGroup) pickByLatency(usable func(idx int) bool) int {
+	var slowest time.Duration
+
+	for idx, path := range rg.paths {
+		if !path.down && usable(idx) && path.rtt > slowest {
+			slowest = path.rtt
+		}
+	}
+
+	best := -1
+	total := 0.0
+
+	for idx, path := range rg.paths {
+		if path.down || !usable(idx) {
+			continue
+		}
+
+		rtt := path.rtt
+		if rtt == 0 {
+			rtt = slowest
+		}
+
+		weight := 1.0
+		if rtt > 0 {
+			weight = float64(time.Second) / float64(rtt)
+		}
+
+		path.weight += weight
+		total += weight
+
+		if best < 0 || path.weight > rg.paths[best].weight {
+			best = idx
+		}
+	}
+
+	if best >= 0 {
+		rg.paths[best].weight -= total
+	}
+
+	return best
+}
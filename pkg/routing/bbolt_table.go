@@ -0,0 +1,225 @@
+package routing
+
+import (
+	"encoding/binary"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/skycoin/skycoin/src/util/logging"
+	"go.etcd.io/bbolt"
+)
+
+var (
+	tableLog = logging.MustGetLogger("routing_table")
+
+	rulesBucket = []byte("rules")
+	metaBucket  = []byte("meta")
+	nextIDKey   = []byte("next_id")
+)
+
+// activitySize is the size of the activity timestamp stored in front of each rule.
+const activitySize = 8
+
+// bboltTable is a routing table which keeps rules in memory and persists them
+// along with reserved route IDs to a bbolt database, so they survive restarts.
+// Rule activity is only updated in memory on the hot path and gets flushed to
+// the database during garbage collection.
+type bboltTable struct {
+	*memTable
+
+	db        *bbolt.DB
+	reserveMx sync.Mutex
+}
+
+// NewBBoltTable returns a routing table persisted to the bbolt database at `path`.
+// Rules stored in the database which did not time out are restored along with
+// the last reserved route ID.
+func NewBBoltTable(path string) (Table, error) {
+	db, err := bbolt.Open(path, 0600, &bbolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open routing table db: %w", err)
+	}
+
+	bt := &bboltTable{
+		memTable: &memTable{
+			rules:    map[RouteID]Rule{},
+			activity: make(map[RouteID]time.Time),
+		},
+		db: db,
+	}
+
+	if err := bt.restore(); err != nil {
+		if closeErr := db.Close(); closeErr != nil {
+			err = fmt.Errorf("%v (close: %v)", err, closeErr)
+		}
+
+		return nil, err
+	}
+
+	return bt, nil
+}
+
+// restore loads non-expired rules and next route ID from the database.
+// Expired rules are removed.
+func (bt *bboltTable) restore() error {
+	return bt.db.Update(func(tx *bbolt.Tx) error {
+		rules, err := tx.CreateBucketIfNotExists(rulesBucket)
+		if err != nil {
+			return fmt.Errorf("failed to create bucket: %w", err)
+		}
+
+		meta, err := tx.CreateBucketIfNotExists(metaBucket)
+		if err != nil {
+			return fmt.Errorf("failed to create bucket: %w", err)
+		}
+
+		if v := meta.Get(nextIDKey); len(v) == 4 {
+			bt.nextID = RouteID(binary.BigEndian.Uint32(v))
+		}
+
+		var expired [][]byte
+
+		err = rules.ForEach(func(k, v []byte) error {
+			if len(k) != 4 || len(v) < activitySize+RuleHeaderSize {
+				expired = append(expired, k)
+				return nil
+			}
+
+			key := RouteID(binary.BigEndian.Uint32(k))
+			activity := time.Unix(0, int64(binary.BigEndian.Uint64(v)))
+			rule := make(Rule, len(v)-activitySize)
+			copy(rule, v[activitySize:])
+
+			if time.Since(activity) > rule.KeepAlive() {
+				expired = append(expired, k)
+				return nil
+			}
+
+			bt.rules[key] = rule
+			bt.activity[key] = activity
+
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, k := range expired {
+			if err := rules.Delete(k); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+func (bt *bboltTable) ReserveKeys(n int) ([]RouteID, error) {
+	bt.reserveMx.Lock()
+	defer bt.reserveMx.Unlock()
+
+	ids, err := bt.memTable.ReserveKeys(n)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(ids) == 0 {
+		return ids, nil
+	}
+
+	err = bt.db.Update(func(tx *bbolt.Tx) error {
+		v := make([]byte, 4)
+		binary.BigEndian.PutUint32(v, uint32(ids[len(ids)-1]))
+
+		return tx.Bucket(metaBucket).Put(nextIDKey, v)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to persist reserved route IDs: %w", err)
+	}
+
+	return ids, nil
+}
+
+func (bt *bboltTable) SaveRule(rule Rule) error {
+	if err := bt.memTable.SaveRule(rule); err != nil {
+		return err
+	}
+
+	return bt.db.Update(func(tx *bbolt.Tx) error {
+		return tx.Bucket(rulesBucket).Put(routeIDKey(rule.KeyRouteID()), ruleValue(rule, time.Now()))
+	})
+}
+
+func (bt *bboltTable) DelRules(keys []RouteID) {
+	bt.memTable.DelRules(keys)
+
+	err := bt.db.Update(func(tx *bbolt.Tx) error {
+		b := tx.Bucket(rulesBucket)
+		for _, key := range keys {
+			if err := b.Delete(routeIDKey(key)); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		tableLog.WithError(err).Error("Failed to delete rules from routing table db")
+	}
+}
+
+// CollectGarbage removes timed out rules and stores activity of the remaining
+// ones, so that they are restored with the correct expiration time.
+func (bt *bboltTable) CollectGarbage() []Rule {
+	removed := bt.memTable.CollectGarbage()
+
+	bt.RLock()
+	live := make(map[RouteID][]byte, len(bt.rules))
+	for key, rule := range bt.rules {
+		live[key] = ruleValue(rule, bt.activity[key])
+	}
+	bt.RUnlock()
+
+	err := bt.db.Update(func(tx *bbolt.Tx) error {
+		b := tx.Bucket(rulesBucket)
+		for _, rule := range removed {
+			if err := b.Delete(routeIDKey(rule.KeyRouteID())); err != nil {
+				return err
+			}
+		}
+
+		for key, v := range live {
+			if err := b.Put(routeIDKey(key), v); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		tableLog.WithError(err).Error("Failed to flush routing table db")
+	}
+
+	return removed
+}
+
+// Close closes the underlying database.
+func (bt *bboltTable) Close() error {
+	return bt.db.Close()
+}
+
+func routeIDKey(id RouteID) []byte {
+	k := make([]byte, 4)
+	binary.BigEndian.PutUint32(k, uint32(id))
+
+	return k
+}
+
+func ruleValue(rule Rule, activity time.Time) []byte {
+	v := make([]byte, activitySize+len(rule))
+	binary.BigEndian.PutUint64(v, uint64(activity.UnixNano()))
+	copy(v[activitySize:], rule)
+
+	return v
+}
@@ -0,0 +1,68 @@
+package routing
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBBoltRoutingTable(t *testing.T) {
+	p, err := ioutil.TempFile("", "routing-db")
+	require.NoError(t, err)
+
+	defer os.Remove(p.Name()) // nolint
+
+	tbl, err := NewBBoltTable(p.Name())
+	require.NoError(t, err)
+
+	RoutingTableSuite(t, tbl)
+	require.NoError(t, tbl.(io.Closer).Close())
+}
+
+func TestBBoltRoutingTable_Restore(t *testing.T) {
+	p, err := ioutil.TempFile("", "routing-db")
+	require.NoError(t, err)
+
+	defer os.Remove(p.Name()) // nolint
+
+	tbl, err := NewBBoltTable(p.Name())
+	require.NoError(t, err)
+
+	ids, err := tbl.ReserveKeys(3)
+	require.NoError(t, err)
+
+	alive := IntermediaryForwardRule(15*time.Minute, ids[0], 2, uuid.New())
+	expiring := IntermediaryForwardRule(50*time.Millisecond, ids[1], 3, uuid.New())
+	deleted := IntermediaryForwardRule(15*time.Minute, ids[2], 4, uuid.New())
+
+	for _, rule := range []Rule{alive, expiring, deleted} {
+		require.NoError(t, tbl.SaveRule(rule))
+	}
+
+	tbl.DelRules([]RouteID{deleted.KeyRouteID()})
+	require.NoError(t, tbl.(io.Closer).Close())
+
+	time.Sleep(100 * time.Millisecond)
+
+	tbl, err = NewBBoltTable(p.Name())
+	require.NoError(t, err)
+
+	defer func() { require.NoError(t, tbl.(io.Closer).Close()) }()
+
+	assert.Equal(t, []Rule{alive}, tbl.AllRules())
+
+	r, err := tbl.Rule(alive.KeyRouteID())
+	require.NoError(t, err)
+	assert.Equal(t, alive, r)
+
+	// reserved route IDs are not handed out again
+	next, err := tbl.ReserveKeys(1)
+	require.NoError(t, err)
+	assert.Equal(t, ids[2]+1, next[0])
+}
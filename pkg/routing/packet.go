@@ -23,11 +23,47 @@ const (
 	PacketRouteIDOffset     = 1
 	PacketPayloadSizeOffset = 5
 	PacketPayloadOffset     = PacketHeaderSize
+
+	// FragmentHeaderSize is the size of the fragment header preceding data in the FragmentPacket payload.
+	// Fragment header format:
+	//     | message seq (uint32) | fragment index (uint32) | fragments count (uint32) |
+	FragmentHeaderSize = 12
+
+	// HandshakeFlagsOffset is the offset of the optional flags byte within HandshakePacket payload.
+	HandshakeFlagsOffset = 1
+	// HandshakeTimestampOffset is the offset of the optional timestamp within HandshakePacket payload.
+	HandshakeTimestampOffset = 2
+)
+
+// Handshake flags follow the encryption byte of the HandshakePacket payload. They let edges
+// of a route group agree on optional features. Visors which don't know about them, including
+// intermediary ones, drop everything but the encryption byte, so a flag only gets through
+// paths where every visor supports it.
+const (
+	// HandshakeSequenced is set if the edge reassembles sequenced messages spread over multiple paths.
+	HandshakeSequenced byte = 1 << iota
+	// HandshakeFlowControl is set if the edge supports credit-based flow control with WindowUpdatePacket.
+	HandshakeFlowControl
+)
+
+// Handshake flags describing the purpose of the handshake packet. Handshake without any of them
+// is a request, which the remote answers with a reply via the same path.
+const (
+	// HandshakePing requests the remote to echo the timestamp back as HandshakePong.
+	HandshakePing byte = 1 << (iota + 4)
+	// HandshakePong carries the timestamp of HandshakePing back.
+	HandshakePong
+	// HandshakeReply answers the request with features supported by both edges.
+	HandshakeReply
+	// HandshakeConfirm confirms features of the reply got through.
+	HandshakeConfirm
 )
 
 var (
 	// ErrPayloadTooBig is returned when passed payload is too big (more than math.MaxUint16).
 	ErrPayloadTooBig = errors.New("packet size exceeded")
+	// ErrMalformedFragment is returned when FragmentPacket payload is too short to hold the fragment header.
+	ErrMalformedFragment = errors.New("malformed fragment")
 )
 
 // PacketType represents packet purpose.
@@ -45,6 +81,10 @@ func (t PacketType) String() string {
 		return "NetworkProbe"
 	case HandshakePacket:
 		return "Handshake"
+	case WindowUpdatePacket:
+		return "WindowUpdate"
+	case FragmentPacket:
+		return "Fragment"
 	default:
 		return fmt.Sprintf("Unknown(%d)", t)
 	}
@@ -54,12 +94,20 @@ func (t PacketType) String() string {
 // - DataPacket      - Payload is just the underlying data.
 // - ClosePacket     - Payload is a type CloseCode byte.
 // - KeepAlivePacket - Payload is empty.
+// - HandshakePacket - Payload is the encryption byte optionally followed by handshake flags
+//   and a timestamp.
+// - WindowUpdatePacket - Payload is the total number of messages (uint64) the receiving
+//   edge is allowed to send. Message is either a DataPacket or all fragments of a single write.
+// - FragmentPacket  - Payload is the fragment header followed by a part of the data
+//   which doesn't fit into a single DataPacket.
 const (
 	DataPacket PacketType = iota
 	ClosePacket
 	KeepAlivePacket
 	HandshakePacket
 	NetworkProbePacket
+	WindowUpdatePacket
+	FragmentPacket
 )
 
 // CloseCode represents close code for ClosePacket.
@@ -152,6 +200,66 @@ func MakeHandshakePacket(id RouteID, supportEncryption bool) Packet {
 	return packet
 }
 
+// MakeHandshakeFlagsPacket constructs a new HandshakePacket carrying handshake `flags`.
+// Timestamp is only included for HandshakePing and HandshakePong.
+func MakeHandshakeFlagsPacket(id RouteID, supportEncryption bool, flags byte, timestamp int64) Packet {
+	size := HandshakeTimestampOffset
+	if flags&(HandshakePing|HandshakePong) != 0 {
+		size += 8
+	}
+
+	packet := make([]byte, PacketHeaderSize+size)
+
+	packet[PacketTypeOffset] = byte(HandshakePacket)
+	binary.BigEndian.PutUint32(packet[PacketRouteIDOffset:], uint32(id))
+	binary.BigEndian.PutUint16(packet[PacketPayloadSizeOffset:], uint16(size))
+
+	if supportEncryption {
+		packet[PacketPayloadOffset] = 1
+	}
+
+	packet[PacketPayloadOffset+HandshakeFlagsOffset] = flags
+
+	if size > HandshakeTimestampOffset {
+		binary.BigEndian.PutUint64(packet[PacketPayloadOffset+HandshakeTimestampOffset:], uint64(timestamp))
+	}
+
+	return packet
+}
+
+// MakeWindowUpdatePacket constructs a new WindowUpdatePacket.
+func MakeWindowUpdatePacket(id RouteID, limit uint64) Packet {
+	packet := make([]byte, PacketHeaderSize+8)
+
+	packet[PacketTypeOffset] = byte(WindowUpdatePacket)
+	binary.BigEndian.PutUint32(packet[PacketRouteIDOffset:], uint32(id))
+	binary.BigEndian.PutUint16(packet[PacketPayloadSizeOffset:], uint16(8))
+	binary.BigEndian.PutUint64(packet[PacketPayloadOffset:], limit)
+
+	return packet
+}
+
+// MakeFragmentPacket constructs a new FragmentPacket carrying fragment `idx` out of `count`
+// of the message with sequence number `seq`.
+// If resulting payload size is more than uint16, MakeFragmentPacket returns an error.
+func MakeFragmentPacket(id RouteID, seq, idx, count uint32, data []byte) (Packet, error) {
+	if FragmentHeaderSize+len(data) > math.MaxUint16 {
+		return Packet{}, ErrPayloadTooBig
+	}
+
+	packet := make([]byte, PacketHeaderSize+FragmentHeaderSize+len(data))
+
+	packet[PacketTypeOffset] = byte(FragmentPacket)
+	binary.BigEndian.PutUint32(packet[PacketRouteIDOffset:], uint32(id))
+	binary.BigEndian.PutUint16(packet[PacketPayloadSizeOffset:], uint16(FragmentHeaderSize+len(data)))
+	binary.BigEndian.PutUint32(packet[PacketPayloadOffset:], seq)
+	binary.BigEndian.PutUint32(packet[PacketPayloadOffset+4:], idx)
+	binary.BigEndian.PutUint32(packet[PacketPayloadOffset+8:], count)
+	copy(packet[PacketPayloadOffset+FragmentHeaderSize:], data)
+
+	return packet, nil
+}
+
 // Type returns Packet's type.
 func (p Packet) Type() PacketType {
 	return PacketType(p[PacketTypeOffset])
@@ -171,3 +279,35 @@ func (p Packet) RouteID() RouteID {
 func (p Packet) Payload() []byte {
 	return p[PacketPayloadOffset:]
 }
+
+// HandshakeFlags returns flags and timestamp from a HandshakePacket.
+// Both are zero if the remote didn't send them.
+func (p Packet) HandshakeFlags() (flags byte, timestamp int64) {
+	payload := p.Payload()
+	if len(payload) <= HandshakeFlagsOffset {
+		return 0, 0
+	}
+
+	flags = payload[HandshakeFlagsOffset]
+
+	if len(payload) >= HandshakeTimestampOffset+8 {
+		timestamp = int64(binary.BigEndian.Uint64(payload[HandshakeTimestampOffset:]))
+	}
+
+	return flags, timestamp
+}
+
+// Fragment returns message sequence number, fragment index, fragments count
+// and data from a FragmentPacket.
+func (p Packet) Fragment() (seq, idx, count uint32, data []byte, err error) {
+	payload := p.Payload()
+	if len(payload) < FragmentHeaderSize {
+		return 0, 0, 0, nil, ErrMalformedFragment
+	}
+
+	seq = binary.BigEndian.Uint32(payload)
+	idx = binary.BigEndian.Uint32(payload[4:])
+	count = binary.BigEndian.Uint32(payload[8:])
+
+	return seq, idx, count, payload[FragmentHeaderSize:], nil
+}
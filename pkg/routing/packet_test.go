@@ -1,6 +1,7 @@
 package routing
 
 import (
+	"math"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -38,3 +39,61 @@ func TestMakeKeepAlivePacket(t *testing.T) {
 	assert.Equal(t, RouteID(4), packet.RouteID())
 	assert.Equal(t, []byte{}, packet.Payload())
 }
+
+func TestMakeWindowUpdatePacket(t *testing.T) {
+	packet := MakeWindowUpdatePacket(5, 1024)
+	expected := []byte{0x5, 0x0, 0x0, 0x0, 0x5, 0x0, 0x8, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x4, 0x0}
+
+	assert.Equal(t, expected, []byte(packet))
+	assert.Equal(t, WindowUpdatePacket, packet.Type())
+	assert.Equal(t, uint16(8), packet.Size())
+	assert.Equal(t, RouteID(5), packet.RouteID())
+	assert.Equal(t, []byte{0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x4, 0x0}, packet.Payload())
+}
+
+func TestMakeFragmentPacket(t *testing.T) {
+	packet, err := MakeFragmentPacket(6, 1, 2, 3, []byte("foo"))
+	require.NoError(t, err)
+
+	expected := []byte{0x6, 0x0, 0x0, 0x0, 0x6, 0x0, 0xf, 0x0, 0x0, 0x0, 0x1, 0x0, 0x0, 0x0, 0x2,
+		0x0, 0x0, 0x0, 0x3, 0x66, 0x6f, 0x6f}
+
+	assert.Equal(t, expected, []byte(packet))
+	assert.Equal(t, FragmentPacket, packet.Type())
+	assert.Equal(t, uint16(15), packet.Size())
+	assert.Equal(t, RouteID(6), packet.RouteID())
+
+	seq, idx, count, data, err := packet.Fragment()
+	require.NoError(t, err)
+	assert.Equal(t, uint32(1), seq)
+	assert.Equal(t, uint32(2), idx)
+	assert.Equal(t, uint32(3), count)
+	assert.Equal(t, []byte("foo"), data)
+
+	_, err = MakeFragmentPacket(6, 1, 2, 3, make([]byte, math.MaxUint16-FragmentHeaderSize+1))
+	assert.Equal(t, ErrPayloadTooBig, err)
+}
+
+func TestMakeHandshakeFlagsPacket(t *testing.T) {
+	packet := MakeHandshakeFlagsPacket(7, true, HandshakeSequenced|HandshakeReply, 0)
+	expected := []byte{0x3, 0x0, 0x0, 0x0, 0x7, 0x0, 0x2, 0x1, 0x41}
+
+	assert.Equal(t, expected, []byte(packet))
+	assert.Equal(t, HandshakePacket, packet.Type())
+
+	flags, timestamp := packet.HandshakeFlags()
+	assert.Equal(t, HandshakeSequenced|HandshakeReply, flags)
+	assert.Equal(t, int64(0), timestamp)
+
+	packet = MakeHandshakeFlagsPacket(7, false, HandshakePing, 42)
+	assert.Equal(t, uint16(10), packet.Size())
+	assert.Equal(t, byte(0), packet.Payload()[0])
+
+	flags, timestamp = packet.HandshakeFlags()
+	assert.Equal(t, HandshakePing, flags)
+	assert.Equal(t, int64(42), timestamp)
+
+	// handshake of visors which don't support flags
+	flags, _ = MakeHandshakePacket(7, true).HandshakeFlags()
+	assert.Equal(t, byte(0), flags)
+}
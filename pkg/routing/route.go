@@ -42,6 +42,9 @@ type BidirectionalRoute struct {
 	KeepAlive time.Duration
 	Forward   []Hop
 	Reverse   []Hop
+	// Append is set when the route is an additional path of an already
+	// established route group with the same descriptor.
+	Append bool
 }
 
 // ForwardAndReverse generate forward and reverse routes for bidirectional route.
@@ -104,6 +107,9 @@ type EdgeRules struct {
 	Desc    RouteDescriptor
 	Forward Rule
 	Reverse Rule
+	// Append is set when the rules belong to an additional path of an already
+	// established route group and should be attached to it.
+	Append bool
 }
 
 // String implements fmt.Stringer
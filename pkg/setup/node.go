@@ -143,8 +143,18 @@ func CreateRouteGroup(ctx context.Context, dialer snet.Dialer, biRt routing.Bidi
 	if err != nil {
 		return routing.EdgeRules{}, err
 	}
-	initEdge := routing.EdgeRules{Desc: revRt.Desc, Forward: fwdRules[srcPK][0], Reverse: revRules[srcPK][0]}
-	respEdge := routing.EdgeRules{Desc: fwdRt.Desc, Forward: fwdRules[dstPK][0], Reverse: revRules[dstPK][0]}
+	initEdge := routing.EdgeRules{
+		Desc:    revRt.Desc,
+		Forward: fwdRules[srcPK][0],
+		Reverse: revRules[srcPK][0],
+		Append:  biRt.Append,
+	}
+	respEdge := routing.EdgeRules{
+		Desc:    fwdRt.Desc,
+		Forward: fwdRules[dstPK][0],
+		Reverse: revRules[dstPK][0],
+		Append:  biRt.Append,
+	}
 
 	log.Infof("Generated routing rules:\nInitiating edge: %v\nResponding edge: %v\nIntermediaries: %v",
 		initEdge.String(), respEdge.String(), interRules.String())
@@ -1,6 +1,8 @@
 package skyenv
 
 import (
+	"fmt"
+	"strings"
 	"time"
 
 	"github.com/skycoin/dmsg/cipher"
@@ -117,6 +119,43 @@ const (
 	PackageTLSCert      = PackageSkywirePath + "/ssl/cert.pem"
 )
 
+// releasePKs are comma-separated public keys of the keys releases are signed with.
+// They are set at build time with -ldflags "-X github.com/skycoin/skywire/pkg/skyenv.releasePKs=<pk>,<pk>".
+var releasePKs string
+
+// DefaultReleasePKs returns public keys of the keys releases are signed with built into the binary.
+// Malformed keys are skipped, release builds check them beforehand with TestReleasePKs.
+func DefaultReleasePKs() []cipher.PubKey {
+	pks, _ := parseReleasePKs(releasePKs)
+	return pks
+}
+
+// parseReleasePKs parses comma-separated public keys, returning the valid ones and the first error met.
+func parseReleasePKs(s string) ([]cipher.PubKey, error) {
+	var (
+		pks      []cipher.PubKey
+		firstErr error
+	)
+
+	for _, str := range strings.Split(s, ",") {
+		if str = strings.TrimSpace(str); str == "" {
+			continue
+		}
+
+		var pk cipher.PubKey
+		if err := pk.UnmarshalText([]byte(str)); err != nil {
+			if firstErr == nil {
+				firstErr = fmt.Errorf("invalid release public key %q: %w", str, err)
+			}
+			continue
+		}
+
+		pks = append(pks, pk)
+	}
+
+	return pks, firstErr
+}
+
 // MustPK unmarshals string PK to cipher.PubKey. It panics if unmarshaling fails.
 func MustPK(pk string) cipher.PubKey {
 	var sPK cipher.PubKey
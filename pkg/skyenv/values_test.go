@@ -0,0 +1,39 @@
+package skyenv
+
+import (
+	"testing"
+
+	"github.com/skycoin/dmsg/cipher"
+	"github.com/stretchr/testify/require"
+)
+
+// TestReleasePKs checks release public keys built in with -ldflags.
+// Release builds run it with the same flags so a malformed key fails the build.
+func TestReleasePKs(t *testing.T) {
+	pks, err := parseReleasePKs(releasePKs)
+	require.NoError(t, err)
+	require.Equal(t, pks, DefaultReleasePKs())
+}
+
+func TestParseReleasePKs(t *testing.T) {
+	pk1, _ := cipher.GenerateKeyPair()
+	pk2, _ := cipher.GenerateKeyPair()
+
+	t.Run("valid", func(t *testing.T) {
+		pks, err := parseReleasePKs(" " + pk1.Hex() + ", ," + pk2.Hex())
+		require.NoError(t, err)
+		require.Equal(t, []cipher.PubKey{pk1, pk2}, pks)
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		pks, err := parseReleasePKs("")
+		require.NoError(t, err)
+		require.Empty(t, pks)
+	})
+
+	t.Run("malformed", func(t *testing.T) {
+		pks, err := parseReleasePKs(pk1.Hex() + ",not-a-key")
+		require.Error(t, err)
+		require.Equal(t, []cipher.PubKey{pk1}, pks)
+	})
+}
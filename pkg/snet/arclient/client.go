@@ -32,6 +32,7 @@ const (
 	// sudphPriority is used to set an order how connection filters apply.
 	sudphPriority        = 1
 	stcprBindPath        = "/bind/stcpr"
+	quicBindPath         = "/bind/quic"
 	addrChSize           = 1024
 	udpKeepAliveInterval = 10 * time.Second
 	udpKeepAliveMessage  = "keepalive"
@@ -56,6 +57,7 @@ type Error struct {
 type APIClient interface {
 	io.Closer
 	BindSTCPR(ctx context.Context, port string) error
+	BindQUIC(ctx context.Context, port string) error
 	BindSUDPH(filter *pfilter.PacketFilter) (<-chan RemoteVisor, error)
 	Resolve(ctx context.Context, tType string, pk cipher.PubKey) (VisorData, error)
 	Health(ctx context.Context) (int, error)
@@ -194,6 +196,21 @@ func (c *httpClient) BindSTCPR(ctx context.Context, port string) error {
 		c.log.Infof("BindSTCPR: Address resolver became ready, binding")
 	}
 
+	return c.bind(ctx, stcprBindPath, port)
+}
+
+// BindQUIC binds client PK to IP:port of QUIC listener on address resolver.
+func (c *httpClient) BindQUIC(ctx context.Context, port string) error {
+	if !c.isReady() {
+		c.log.Infof("BindQUIC: Address resolver is not ready yet, waiting...")
+		<-c.ready
+		c.log.Infof("BindQUIC: Address resolver became ready, binding")
+	}
+
+	return c.bind(ctx, quicBindPath, port)
+}
+
+func (c *httpClient) bind(ctx context.Context, path, port string) error {
 	addresses, err := netutil.LocalAddresses()
 	if err != nil {
 		return err
@@ -204,7 +221,7 @@ func (c *httpClient) BindSTCPR(ctx context.Context, port string) error {
 		Port:      port,
 	}
 
-	resp, err := c.Post(ctx, stcprBindPath, localAddresses)
+	resp, err := c.Post(ctx, path, localAddresses)
 	if err != nil {
 		return err
 	}
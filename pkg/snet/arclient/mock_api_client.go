@@ -15,6 +15,20 @@ type MockAPIClient struct {
 	mock.Mock
 }
 
+// BindQUIC provides a mock function with given fields: ctx, port
+func (_m *MockAPIClient) BindQUIC(ctx context.Context, port string) error {
+	ret := _m.Called(ctx, port)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, port)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // BindSTCPR provides a mock function with given fields: ctx, port
 func (_m *MockAPIClient) BindSTCPR(ctx context.Context, port string) error {
 	ret := _m.Called(ctx, port)
@@ -0,0 +1,243 @@
+// Package arserver implements address resolver server.
+package arserver
+
+import (
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi"
+	"github.com/go-chi/chi/middleware"
+	"github.com/sirupsen/logrus"
+	"github.com/skycoin/dmsg/buildinfo"
+	"github.com/skycoin/dmsg/cipher"
+	"github.com/skycoin/dmsg/httputil"
+
+	"github.com/skycoin/skywire/internal/httpauth"
+	"github.com/skycoin/skywire/pkg/snet/arclient"
+	"github.com/skycoin/skywire/pkg/snet/directtp/tptypes"
+)
+
+const httpTimeout = 30 * time.Second
+
+var (
+	// ErrUnknownTransportType is returned when resolving an address for a transport type
+	// address resolver is not responsible for.
+	ErrUnknownTransportType = errors.New("unknown transport type")
+)
+
+// API serves address resolver HTTP API. Visors bind STCPR and QUIC addresses
+// via HTTP and SUDPH addresses via UDP, see ServeUDP.
+type API struct {
+	http.Handler
+
+	log   logrus.FieldLogger
+	store *store
+}
+
+// New constructs a new address resolver API keeping nonces of the visors in `nonces`.
+// Client addresses passed in headers are only trusted if requests come from `trustedProxies`.
+func New(log logrus.FieldLogger, nonces httpauth.NonceStore, trustedProxies []*net.IPNet) *API {
+	api := &API{
+		log:   log,
+		store: newStore(),
+	}
+
+	auth := httpauth.NewServer(nonces)
+
+	r := chi.NewRouter()
+
+	r.Use(middleware.RequestID)
+	r.Use(realIP(trustedProxies))
+	r.Use(middleware.Logger)
+	r.Use(middleware.Recoverer)
+	r.Use(middleware.Timeout(httpTimeout))
+	r.Use(httputil.SetLoggerMiddleware(log))
+
+	r.Get("/health", api.health)
+	r.Get("/security/nonces/{pk}", auth.NonceHandler)
+
+	r.Group(func(r chi.Router) {
+		r.Use(auth.Middleware)
+
+		r.Post("/bind/stcpr", api.bind(tptypes.STCPR))
+		r.Post("/bind/quic", api.bind(tptypes.QUIC))
+		r.Get("/resolve/{type}/{pk}", api.resolve)
+	})
+
+	api.Handler = r
+
+	return api
+}
+
+// bind returns handler binding visor addresses for transport type `tType`,
+// which is expected to be listened on the public IP of the visor.
+func (api *API) bind(tType string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		pk, _ := httpauth.PKFromContext(r.Context())
+
+		var localAddresses arclient.LocalAddresses
+		if err := json.NewDecoder(r.Body).Decode(&localAddresses); err != nil {
+			api.writeError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		if _, err := net.LookupPort("tcp", localAddresses.Port); err != nil {
+			api.writeError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		remoteIP := hostOf(r.RemoteAddr)
+
+		api.store.bind(tType, pk, &binding{
+			remoteIP: remoteIP,
+			data: arclient.VisorData{
+				RemoteAddr:     remoteIP,
+				LocalAddresses: localAddresses,
+			},
+		})
+
+		api.log.WithField("pk", pk).WithField("addr", net.JoinHostPort(remoteIP, localAddresses.Port)).
+			Infof("Bound %s address.", tType)
+
+		api.writeJSON(w, http.StatusOK, true)
+	}
+}
+
+func (api *API) resolve(w http.ResponseWriter, r *http.Request) {
+	srcPK, _ := httpauth.PKFromContext(r.Context())
+
+	tType := chi.URLParam(r, "type")
+	if tType != tptypes.STCPR && tType != tptypes.SUDPH && tType != tptypes.QUIC {
+		api.writeError(w, http.StatusBadRequest, ErrUnknownTransportType)
+		return
+	}
+
+	var pk cipher.PubKey
+	if err := pk.UnmarshalText([]byte(chi.URLParam(r, "pk"))); err != nil {
+		api.writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	dst, ok := api.store.binding(tType, pk)
+	if !ok {
+		api.writeError(w, http.StatusNotFound, arclient.ErrNoEntry)
+		return
+	}
+
+	srcIP := hostOf(r.RemoteAddr)
+
+	if tType == tptypes.SUDPH {
+		// Hole punching only works if the dialing visor is bound as well,
+		// so that the remote one knows where to send the punching packet to.
+		src, ok := api.store.binding(tType, srcPK)
+		if !ok {
+			api.writeError(w, http.StatusBadRequest, arclient.ErrNoEntry)
+			return
+		}
+
+		srcIP = src.remoteIP
+
+		if err := api.notify(dst, srcPK, src.data.RemoteAddr); err != nil {
+			api.log.WithError(err).WithField("pk", pk).Warn("Failed to notify visor about hole punching.")
+		}
+	}
+
+	data := dst.data
+	data.IsLocal = srcIP == dst.remoteIP
+
+	api.writeJSON(w, http.StatusOK, data)
+}
+
+// notify asks SUDPH visor bound with `b` to punch a hole to `addr` of `pk`.
+func (api *API) notify(b *binding, pk cipher.PubKey, addr string) error {
+	msg, err := json.Marshal(arclient.RemoteVisor{PK: pk, Addr: addr})
+	if err != nil {
+		return err
+	}
+
+	_, err = b.conn.Write(msg)
+
+	return err
+}
+
+func (api *API) health(w http.ResponseWriter, _ *http.Request) {
+	api.writeJSON(w, http.StatusOK, buildinfo.Get())
+}
+
+func (api *API) writeError(w http.ResponseWriter, code int, err error) {
+	api.writeJSON(w, code, arclient.Error{Error: err.Error()})
+}
+
+func (api *API) writeJSON(w http.ResponseWriter, code int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		api.log.WithError(err).Warn("Failed to write response.")
+	}
+}
+
+// realIP replaces remote address of requests coming from `trusted` proxies with the client
+// address passed in X-Forwarded-For or X-Real-IP header. Addresses in X-Forwarded-For are
+// appended by each proxy, so the last one not added by a trusted proxy is taken. Headers
+// of requests coming from anywhere else are ignored, since clients may set them to anything.
+func realIP(trusted []*net.IPNet) func(http.Handler) http.Handler {
+	isTrusted := func(addr string) bool {
+		ip := net.ParseIP(hostOf(addr))
+		if ip == nil {
+			return false
+		}
+
+		for _, ipNet := range trusted {
+			if ipNet.Contains(ip) {
+				return true
+			}
+		}
+
+		return false
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !isTrusted(r.RemoteAddr) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if xff := r.Header.Get("X-Forwarded-For"); xff != "" {
+				hops := strings.Split(xff, ",")
+
+				for i := len(hops) - 1; i >= 0; i-- {
+					hop := strings.TrimSpace(hops[i])
+					if net.ParseIP(hop) == nil {
+						break
+					}
+
+					r.RemoteAddr = hop
+
+					if !isTrusted(hop) {
+						break
+					}
+				}
+			} else if xrip := r.Header.Get("X-Real-IP"); net.ParseIP(xrip) != nil {
+				r.RemoteAddr = xrip
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// hostOf returns host of `addr`, which may come without port after realIP middleware.
+func hostOf(addr string) string {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return addr
+	}
+
+	return host
+}
@@ -0,0 +1,199 @@
+package arserver
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/AudriusButkevicius/pfilter"
+	"github.com/skycoin/dmsg/cipher"
+	"github.com/skycoin/skycoin/src/util/logging"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/skycoin/skywire/internal/httpauth"
+	"github.com/skycoin/skywire/pkg/snet/arclient"
+	"github.com/skycoin/skywire/pkg/snet/directtp/tptypes"
+)
+
+func TestMain(m *testing.M) {
+	loggingLevel, ok := os.LookupEnv("TEST_LOGGING_LEVEL")
+	if ok {
+		lvl, err := logging.LevelFromString(loggingLevel)
+		if err != nil {
+			panic(err)
+		}
+		logging.SetLevel(lvl)
+	} else {
+		logging.Disable()
+	}
+
+	os.Exit(m.Run())
+}
+
+// serve serves address resolver with HTTP and UDP on the same port, as clients expect.
+func serve(t *testing.T) string {
+	api := New(logging.MustGetLogger("address_resolver"), httpauth.NewMemoryNonceStore(), nil)
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	udpConn, err := net.ListenPacket("udp", l.Addr().String())
+	require.NoError(t, err)
+
+	srv := &http.Server{Handler: api}
+
+	go srv.Serve(l)          // nolint:errcheck
+	go api.ServeUDP(udpConn) // nolint:errcheck
+
+	t.Cleanup(func() {
+		assert.NoError(t, srv.Close())
+		assert.NoError(t, udpConn.Close())
+	})
+
+	return "http://" + l.Addr().String()
+}
+
+func newClient(t *testing.T, addr string) (arclient.APIClient, cipher.PubKey) {
+	pk, sk := cipher.GenerateKeyPair()
+
+	c, err := arclient.NewHTTP(addr, pk, sk)
+	require.NoError(t, err)
+
+	t.Cleanup(func() { assert.NoError(t, c.Close()) })
+
+	require.Eventually(t, func() bool {
+		code, err := c.Health(context.TODO())
+		return err == nil && code == http.StatusOK
+	}, 5*time.Second, 10*time.Millisecond)
+
+	return c, pk
+}
+
+func bindSUDPH(t *testing.T, c arclient.APIClient) (<-chan arclient.RemoteVisor, net.Addr) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	filter := pfilter.NewPacketFilter(conn)
+	filter.Start()
+
+	addrCh, err := c.BindSUDPH(filter)
+	require.NoError(t, err)
+
+	// client has to be closed before the underlying connection, so that
+	// filter isn't read and modified concurrently
+	t.Cleanup(func() {
+		assert.NoError(t, c.Close())
+		assert.NoError(t, conn.Close())
+	})
+
+	return addrCh, conn.LocalAddr()
+}
+
+func TestAPI_Bind(t *testing.T) {
+	addr := serve(t)
+
+	clientA, pkA := newClient(t, addr)
+	clientB, _ := newClient(t, addr)
+
+	binds := map[string]func(ctx context.Context, port string) error{
+		tptypes.STCPR: clientA.BindSTCPR,
+		tptypes.QUIC:  clientA.BindQUIC,
+	}
+
+	for tType, bind := range binds {
+		t.Run(tType, func(t *testing.T) {
+			_, err := clientB.Resolve(context.TODO(), tType, pkA)
+			assert.Equal(t, arclient.ErrNoEntry, err)
+
+			require.NoError(t, bind(context.TODO(), "1234"))
+
+			data, err := clientB.Resolve(context.TODO(), tType, pkA)
+			require.NoError(t, err)
+			assert.Equal(t, "127.0.0.1", data.RemoteAddr)
+			assert.Equal(t, "1234", data.Port)
+			assert.True(t, data.IsLocal)
+		})
+	}
+}
+
+func TestAPI_SUDPH(t *testing.T) {
+	addr := serve(t)
+
+	clientA, pkA := newClient(t, addr)
+	clientB, pkB := newClient(t, addr)
+
+	_, addrA := bindSUDPH(t, clientA)
+	addrChB, addrB := bindSUDPH(t, clientB)
+
+	var data arclient.VisorData
+
+	require.Eventually(t, func() bool {
+		var err error
+		data, err = clientA.Resolve(context.TODO(), tptypes.SUDPH, pkB)
+		return err == nil
+	}, 5*time.Second, 10*time.Millisecond)
+
+	assert.Equal(t, addrB.String(), data.RemoteAddr)
+	assert.True(t, data.IsLocal)
+
+	// the resolved visor is asked to punch a hole to the dialing one
+	select {
+	case remote := <-addrChB:
+		assert.Equal(t, pkA, remote.PK)
+		assert.Equal(t, addrA.String(), remote.Addr)
+	case <-time.After(5 * time.Second):
+		t.Fatal("no hole punching request")
+	}
+}
+
+func TestRealIP(t *testing.T) {
+	conf := Config{TrustedProxies: []string{"10.0.0.1", "192.168.0.0/16"}}
+	trusted, err := conf.ParseTrustedProxies()
+	require.NoError(t, err)
+
+	var remoteAddr string
+
+	handler := realIP(trusted)(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		remoteAddr = r.RemoteAddr
+	}))
+
+	cases := []struct {
+		name       string
+		remoteAddr string
+		xff        string
+		xrip       string
+		want       string
+	}{
+		{"untrusted client", "1.2.3.4:5000", "5.6.7.8", "5.6.7.8", "1.2.3.4:5000"},
+		{"trusted proxy", "10.0.0.1:5000", "5.6.7.8", "", "5.6.7.8"},
+		{"chain of proxies", "10.0.0.1:5000", "6.6.6.6, 5.6.7.8, 192.168.1.1", "", "5.6.7.8"},
+		{"real ip header", "192.168.1.1:5000", "", "5.6.7.8", "5.6.7.8"},
+		{"no headers", "10.0.0.1:5000", "", "", "10.0.0.1:5000"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodGet, "/", nil)
+			require.NoError(t, err)
+
+			req.RemoteAddr = tc.remoteAddr
+			if tc.xff != "" {
+				req.Header.Set("X-Forwarded-For", tc.xff)
+			}
+			if tc.xrip != "" {
+				req.Header.Set("X-Real-IP", tc.xrip)
+			}
+
+			handler.ServeHTTP(nil, req)
+			assert.Equal(t, tc.want, remoteAddr)
+		})
+	}
+
+	conf.TrustedProxies = []string{"not an ip"}
+	_, err = conf.ParseTrustedProxies()
+	require.Error(t, err)
+}
@@ -0,0 +1,70 @@
+package arserver
+
+import (
+	"fmt"
+	"net"
+	"strings"
+)
+
+//go:generate readmegen -n Config -o ./README.md ./config.go
+
+// Default address resolver parameters.
+const (
+	DefaultAddr    = ":9093"
+	DefaultUDPAddr = ":30178"
+)
+
+// Config defines configuration parameters for address resolver.
+// Note that visors expect UDP address to have the same port as HTTP one
+// if the address resolver URL contains a port, and port 30178 otherwise.
+type Config struct {
+	Addr     string `json:"address"`     // Addr is the address HTTP API is served on.
+	UDPAddr  string `json:"udp_address"` // UDPAddr is the address SUDPH visors bind to.
+	LogLevel string `json:"log_level"`
+	// TrustedProxies are IPs or CIDRs of reverse proxies whose X-Forwarded-For and X-Real-IP
+	// headers are trusted. Headers of the rest of requests are ignored.
+	TrustedProxies []string `json:"trusted_proxies,omitempty"`
+}
+
+// SetDefaults sets default values for unset fields.
+func (c *Config) SetDefaults() {
+	if c.Addr == "" {
+		c.Addr = DefaultAddr
+	}
+
+	if c.UDPAddr == "" {
+		c.UDPAddr = DefaultUDPAddr
+	}
+}
+
+// ParseTrustedProxies parses TrustedProxies into networks.
+func (c *Config) ParseTrustedProxies() ([]*net.IPNet, error) {
+	nets := make([]*net.IPNet, 0, len(c.TrustedProxies))
+
+	for _, proxy := range c.TrustedProxies {
+		if !strings.Contains(proxy, "/") {
+			ip := net.ParseIP(proxy)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid trusted proxy: %s", proxy)
+			}
+
+			bits := 8 * net.IPv6len
+			if ip4 := ip.To4(); ip4 != nil {
+				ip, bits = ip4, 8*net.IPv4len
+			}
+
+			nets = append(nets, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+
+			continue
+		}
+
+		_, ipNet, err := net.ParseCIDR(proxy)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy: %w", err)
+		}
+
+		nets = append(nets, ipNet)
+	}
+
+	return nets, nil
+}
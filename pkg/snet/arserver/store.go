@@ -0,0 +1,62 @@
+package arserver
+
+import (
+	"net"
+	"sync"
+
+	"github.com/skycoin/dmsg/cipher"
+
+	"github.com/skycoin/skywire/pkg/snet/arclient"
+)
+
+// binding is the address visor is bound to for a transport type.
+type binding struct {
+	// remoteIP is the public IP visor contacted address resolver from.
+	remoteIP string
+	data     arclient.VisorData
+	// conn is only set for SUDPH and is used to notify visor about dialing remotes.
+	conn net.Conn
+}
+
+// store keeps bindings of visors per transport type.
+type store struct {
+	mu       sync.RWMutex
+	bindings map[string]map[cipher.PubKey]*binding
+}
+
+func newStore() *store {
+	return &store{
+		bindings: make(map[string]map[cipher.PubKey]*binding),
+	}
+}
+
+func (s *store) bind(tType string, pk cipher.PubKey, b *binding) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.bindings[tType]; !ok {
+		s.bindings[tType] = make(map[cipher.PubKey]*binding)
+	}
+
+	s.bindings[tType][pk] = b
+}
+
+// unbind removes binding of `pk` only if it's still `b`, so that
+// a closing stale connection doesn't remove the newer binding.
+func (s *store) unbind(tType string, pk cipher.PubKey, b *binding) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.bindings[tType][pk] == b {
+		delete(s.bindings[tType], pk)
+	}
+}
+
+func (s *store) binding(tType string, pk cipher.PubKey) (*binding, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	b, ok := s.bindings[tType][pk]
+
+	return b, ok
+}
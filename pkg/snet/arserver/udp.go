@@ -0,0 +1,127 @@
+package arserver
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/skycoin/dmsg"
+	"github.com/xtaci/kcp-go"
+
+	"github.com/skycoin/skywire/pkg/snet/arclient"
+	"github.com/skycoin/skywire/pkg/snet/directtp/tpconn"
+	"github.com/skycoin/skywire/pkg/snet/directtp/tphandshake"
+	"github.com/skycoin/skywire/pkg/snet/directtp/tptypes"
+)
+
+const (
+	// sudphTimeout is the time after which SUDPH binding is removed if visor stops sending keep alive messages.
+	sudphTimeout  = time.Minute
+	sudphReadSize = 4096
+)
+
+// ServeUDP serves SUDPH bindings on `conn` until it is closed.
+// Visors connect via KCP, perform a handshake, send their local addresses
+// and then keep NAT mapping alive sending keep alive messages.
+func (api *API) ServeUDP(conn net.PacketConn) error {
+	l, err := kcp.ServeConn(nil, 0, 0, conn)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if err := l.Close(); err != nil {
+			api.log.WithError(err).Warn("Failed to close KCP listener.")
+		}
+	}()
+
+	for {
+		kcpConn, err := l.AcceptKCP()
+		if err != nil {
+			return err
+		}
+
+		go api.serveSUDPH(kcpConn)
+	}
+}
+
+func (api *API) serveSUDPH(kcpConn *kcp.UDPSession) {
+	remoteAddr := kcpConn.RemoteAddr().String()
+	log := api.log.WithField("addr", remoteAddr)
+
+	conn, err := tpconn.NewConn(tpconn.Config{
+		Conn:     kcpConn,
+		Deadline: time.Now().Add(tphandshake.Timeout),
+		Handshake: tphandshake.ResponderHandshake(func(tphandshake.Frame2) error {
+			return nil
+		}),
+	})
+	if err != nil {
+		log.WithError(err).Warn("SUDPH handshake failed.")
+		return
+	}
+
+	defer func() {
+		if err := conn.Close(); err != nil {
+			log.WithError(err).Warn("Failed to close SUDPH connection.")
+		}
+	}()
+
+	pk := conn.RemoteAddr().(dmsg.Addr).PK
+	log = log.WithField("pk", pk)
+
+	buf := make([]byte, sudphReadSize)
+
+	localAddresses, err := readLocalAddresses(conn, buf)
+	if err != nil {
+		log.WithError(err).Warn("Failed to read SUDPH local addresses.")
+		return
+	}
+
+	b := &binding{
+		remoteIP: hostOf(remoteAddr),
+		data: arclient.VisorData{
+			RemoteAddr:     remoteAddr,
+			LocalAddresses: localAddresses,
+		},
+		conn: conn,
+	}
+
+	api.store.bind(tptypes.SUDPH, pk, b)
+	defer api.store.unbind(tptypes.SUDPH, pk, b)
+
+	log.Info("Bound SUDPH address.")
+
+	// Visor is expected to send keep alive messages, its binding is removed otherwise.
+	for {
+		if err := conn.SetReadDeadline(time.Now().Add(sudphTimeout)); err != nil {
+			log.WithError(err).Warn("Failed to set SUDPH read deadline.")
+			return
+		}
+
+		if _, err := conn.Read(buf); err != nil {
+			log.WithError(err).Info("SUDPH binding removed.")
+			return
+		}
+	}
+}
+
+func readLocalAddresses(conn net.Conn, buf []byte) (arclient.LocalAddresses, error) {
+	var localAddresses arclient.LocalAddresses
+
+	if err := conn.SetReadDeadline(time.Now().Add(tphandshake.Timeout)); err != nil {
+		return localAddresses, err
+	}
+
+	n, err := conn.Read(buf)
+	if err != nil {
+		return localAddresses, err
+	}
+
+	if err := json.Unmarshal(buf[:n], &localAddresses); err != nil {
+		return localAddresses, fmt.Errorf("decode local addresses: %w", err)
+	}
+
+	return localAddresses, nil
+}
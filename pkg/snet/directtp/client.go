@@ -69,6 +69,7 @@ type Config struct {
 	SK                 cipher.SecKey
 	LocalAddr          string
 	Table              pktable.PKTable
+	Proxy              string // HTTP proxy URL, SWS connections are tunneled through it if set
 	AddressResolver    arclient.APIClient
 	BeforeDialCallback BeforeDialCallback
 }
@@ -109,7 +110,7 @@ func NewClient(conf Config) Client {
 // Serve serves the listening portion of the client.
 func (c *client) Serve() error {
 	switch c.conf.Type {
-	case tptypes.STCP, tptypes.STCPR:
+	case tptypes.STCP, tptypes.STCPR, tptypes.QUIC, tptypes.SWS:
 		if c.listener != nil {
 			return ErrAlreadyListening
 		}
@@ -129,7 +130,7 @@ func (c *client) Serve() error {
 		c.listener = l
 		close(c.listening)
 
-		if c.conf.Type == tptypes.STCPR {
+		if c.conf.Type == tptypes.STCPR || c.conf.Type == tptypes.QUIC {
 			localAddr := c.listener.Addr().String()
 			_, port, err := net.SplitHostPort(localAddr)
 			if err != nil {
@@ -137,8 +138,8 @@ func (c *client) Serve() error {
 				return
 			}
 
-			if err := c.conf.AddressResolver.BindSTCPR(context.Background(), port); err != nil {
-				c.log.Errorf("Failed to bind STCPR: %v", err)
+			if err := c.bind(port); err != nil {
+				c.log.Errorf("Failed to bind %s: %v", c.conf.Type, err)
 				return
 			}
 		}
@@ -168,7 +169,7 @@ func (c *client) LocalAddr() (net.Addr, error) {
 	<-c.listening
 
 	switch c.conf.Type {
-	case tptypes.STCP, tptypes.STCPR:
+	case tptypes.STCP, tptypes.STCPR, tptypes.QUIC, tptypes.SWS:
 		if c.listener == nil {
 			return nil, ErrNotListening
 		}
@@ -185,6 +186,15 @@ func (c *client) LocalAddr() (net.Addr, error) {
 	return nil, ErrUnknownTransportType
 }
 
+// bind binds listening port on address resolver.
+func (c *client) bind(port string) error {
+	if c.conf.Type == tptypes.QUIC {
+		return c.conf.AddressResolver.BindQUIC(context.Background(), port)
+	}
+
+	return c.conf.AddressResolver.BindSTCPR(context.Background(), port)
+}
+
 func (c *client) acceptConn() error {
 	if c.isClosed() {
 		return io.ErrClosedPipe
@@ -248,7 +258,7 @@ func (c *client) Dial(ctx context.Context, rPK cipher.PubKey, rPort uint16) (*tp
 	var visorConn net.Conn
 
 	switch c.conf.Type {
-	case tptypes.STCP:
+	case tptypes.STCP, tptypes.SWS:
 		addr, ok := c.conf.Table.Addr(rPK)
 		if !ok {
 			return nil, fmt.Errorf("pk table: entry of %s does not exist", rPK)
@@ -261,7 +271,7 @@ func (c *client) Dial(ctx context.Context, rPK cipher.PubKey, rPort uint16) (*tp
 
 		visorConn = conn
 
-	case tptypes.STCPR, tptypes.SUDPH:
+	case tptypes.STCPR, tptypes.SUDPH, tptypes.QUIC:
 		visorData, err := c.conf.AddressResolver.Resolve(ctx, c.Type(), rPK)
 		if err != nil {
 			return nil, fmt.Errorf("resolve PK: %w", err)
@@ -312,6 +322,12 @@ func (c *client) dial(addr string) (net.Conn, error) {
 	case tptypes.SUDPH:
 		return c.dialUDPWithTimeout(addr)
 
+	case tptypes.QUIC:
+		return dialQUIC(addr)
+
+	case tptypes.SWS:
+		return c.dialWS(addr)
+
 	default:
 		return nil, ErrUnknownTransportType
 	}
@@ -361,6 +377,12 @@ func (c *client) listen(addr string) (net.Listener, error) {
 
 		return kcp.ServeConn(nil, 0, 0, c.sudphVisorsConn)
 
+	case tptypes.QUIC:
+		return listenQUIC(addr)
+
+	case tptypes.SWS:
+		return listenWS(addr)
+
 	default:
 		return nil, ErrUnknownTransportType
 	}
@@ -488,7 +510,7 @@ func (c *client) Close() error {
 		}
 
 		switch c.Type() {
-		case tptypes.STCPR, tptypes.SUDPH:
+		case tptypes.STCPR, tptypes.SUDPH, tptypes.QUIC:
 			if err := c.conf.AddressResolver.Close(); err != nil {
 				c.log.WithError(err).Warnf("Failed to close address-resolver")
 			}
@@ -13,15 +13,22 @@ const HSTimeout = 5 * time.Second
 
 // WrapConn wraps `conn` with noise.
 func WrapConn(config noise.Config, conn net.Conn) (net.Conn, error) {
+	wrappedConn, _, err := WrapConnNoise(config, conn)
+	return wrappedConn, err
+}
+
+// WrapConnNoise wraps `conn` with noise like WrapConn and also returns the noise object
+// the handshake was performed with.
+func WrapConnNoise(config noise.Config, conn net.Conn) (net.Conn, *noise.Noise, error) {
 	ns, err := noise.New(noise.HandshakeKK, config)
 	if err != nil {
-		return nil, fmt.Errorf("failed to prepare stream noise object: %w", err)
+		return nil, nil, fmt.Errorf("failed to prepare stream noise object: %w", err)
 	}
 
 	wrappedConn, err := noise.WrapConn(conn, ns, HSTimeout)
 	if err != nil {
-		return nil, fmt.Errorf("error performing noise handshake: %w", err)
+		return nil, nil, fmt.Errorf("error performing noise handshake: %w", err)
 	}
 
-	return wrappedConn, nil
+	return wrappedConn, ns, nil
 }
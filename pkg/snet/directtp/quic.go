@@ -0,0 +1,215 @@
+package directtp
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io"
+	"math/big"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/quic-go/quic-go"
+
+	"github.com/skycoin/skywire/pkg/snet/directtp/tphandshake"
+)
+
+const (
+	quicALPN            = "skywire"
+	quicKeepAlivePeriod = 15 * time.Second
+	quicMaxIdleTimeout  = time.Minute
+	quicCloseTimeout    = 5 * time.Second
+)
+
+// quicConfig is shared by both sides of QUIC connections.
+var quicConfig = &quic.Config{
+	KeepAlivePeriod: quicKeepAlivePeriod,
+	MaxIdleTimeout:  quicMaxIdleTimeout,
+}
+
+// quicConn is a net.Conn over a single stream of QUIC connection.
+type quicConn struct {
+	quic.Stream
+	conn quic.Connection
+
+	eofOnce sync.Once
+	eof     chan struct{} // closed once the remote closes its side of the stream
+}
+
+func newQUICConn(stream quic.Stream, conn quic.Connection) *quicConn {
+	return &quicConn{
+		Stream: stream,
+		conn:   conn,
+		eof:    make(chan struct{}),
+	}
+}
+
+func (c *quicConn) Read(b []byte) (int, error) {
+	n, err := c.Stream.Read(b)
+	if err == io.EOF {
+		c.eofOnce.Do(func() { close(c.eof) })
+	}
+
+	return n, err
+}
+
+func (c *quicConn) LocalAddr() net.Addr {
+	return c.conn.LocalAddr()
+}
+
+func (c *quicConn) RemoteAddr() net.Addr {
+	return c.conn.RemoteAddr()
+}
+
+// Close closes the stream and then the connection. Closing the connection discards data
+// which is not delivered yet, so the connection is closed in the background once the remote
+// either closes its side of the stream or the whole connection, which it does once it read
+// everything.
+func (c *quicConn) Close() error {
+	err := c.Stream.Close()
+
+	go func() {
+		select {
+		case <-c.eof:
+		case <-c.conn.Context().Done():
+		case <-time.After(quicCloseTimeout):
+		}
+
+		_ = c.conn.CloseWithError(0, "") // nolint:errcheck
+	}()
+
+	return err
+}
+
+// quicListener implements net.Listener accepting the first stream of each QUIC connection.
+// Streams are awaited in the background, so that remotes which don't open one don't block
+// the rest of them.
+type quicListener struct {
+	*quic.Listener
+
+	conns chan net.Conn
+	done  chan struct{}
+	err   error // set before done is closed
+}
+
+func newQUICListener(l *quic.Listener) *quicListener {
+	ql := &quicListener{
+		Listener: l,
+		conns:    make(chan net.Conn),
+		done:     make(chan struct{}),
+	}
+
+	go ql.acceptLoop()
+
+	return ql
+}
+
+func (l *quicListener) Accept() (net.Conn, error) {
+	select {
+	case conn := <-l.conns:
+		return conn, nil
+	case <-l.done:
+		return nil, l.err
+	}
+}
+
+func (l *quicListener) acceptLoop() {
+	defer close(l.done)
+
+	for {
+		conn, err := l.Listener.Accept(context.Background())
+		if err != nil {
+			l.err = err
+			return
+		}
+
+		go l.acceptStream(conn)
+	}
+}
+
+func (l *quicListener) acceptStream(conn quic.Connection) {
+	ctx, cancel := context.WithTimeout(context.Background(), tphandshake.Timeout)
+	defer cancel()
+
+	stream, err := conn.AcceptStream(ctx)
+	if err != nil {
+		_ = conn.CloseWithError(0, err.Error()) // nolint:errcheck
+		return
+	}
+
+	select {
+	case l.conns <- newQUICConn(stream, conn):
+	case <-l.done:
+		_ = conn.CloseWithError(0, "listener closed") // nolint:errcheck
+	}
+}
+
+func listenQUIC(addr string) (net.Listener, error) {
+	tlsConf, err := quicServerTLSConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	l, err := quic.ListenAddr(addr, tlsConf, quicConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	return newQUICListener(l), nil
+}
+
+func dialQUIC(addr string) (net.Conn, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), dialTimeout)
+	defer cancel()
+
+	conn, err := quic.DialAddr(ctx, addr, quicClientTLSConfig(), quicConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	stream, err := conn.OpenStreamSync(ctx)
+	if err != nil {
+		_ = conn.CloseWithError(0, err.Error()) // nolint:errcheck
+		return nil, fmt.Errorf("open QUIC stream: %w", err)
+	}
+
+	return newQUICConn(stream, conn), nil
+}
+
+// QUIC requires TLS, but visors are authenticated by handshake and connection
+// is encrypted with noise afterwards, just like for other transports.
+// So certificates are self-signed and are not verified.
+func quicServerTLSConfig() (*tls.Config, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+
+	template := x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(10 * 365 * 24 * time.Hour),
+	}
+
+	cert, err := x509.CreateCertificate(rand.Reader, &template, &template, &key.PublicKey, key)
+	if err != nil {
+		return nil, err
+	}
+
+	return &tls.Config{
+		Certificates: []tls.Certificate{{Certificate: [][]byte{cert}, PrivateKey: key}},
+		NextProtos:   []string{quicALPN},
+	}, nil
+}
+
+func quicClientTLSConfig() *tls.Config {
+	return &tls.Config{
+		InsecureSkipVerify: true, // nolint:gosec
+		NextProtos:         []string{quicALPN},
+	}
+}
@@ -0,0 +1,152 @@
+package directtp
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/skycoin/dmsg/cipher"
+	"github.com/quic-go/quic-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/skycoin/skywire/pkg/snet/arclient"
+	"github.com/skycoin/skywire/pkg/snet/directtp/tptypes"
+)
+
+func TestClient_QUIC(t *testing.T) {
+	const lPort = 10
+
+	pkA, skA := cipher.GenerateKeyPair()
+	pkB, skB := cipher.GenerateKeyPair()
+
+	portCh := make(chan string, 1)
+
+	arA := new(arclient.MockAPIClient)
+	arA.On("BindQUIC", mock.Anything, mock.Anything).Return(func(_ context.Context, port string) error {
+		portCh <- port
+		return nil
+	})
+	arA.On("Close").Return(nil)
+
+	clientA := NewClient(Config{
+		Type:            tptypes.QUIC,
+		PK:              pkA,
+		SK:              skA,
+		LocalAddr:       "127.0.0.1:0",
+		AddressResolver: arA,
+	})
+	require.NoError(t, clientA.Serve())
+
+	defer func() { assert.NoError(t, clientA.Close()) }()
+
+	lis, err := clientA.Listen(lPort)
+	require.NoError(t, err)
+
+	port := <-portCh
+
+	arB := new(arclient.MockAPIClient)
+	arB.On("Resolve", mock.Anything, tptypes.QUIC, pkA).
+		Return(arclient.VisorData{RemoteAddr: "127.0.0.1", LocalAddresses: arclient.LocalAddresses{Port: port}}, nil)
+	arB.On("Close").Return(nil)
+
+	clientB := NewClient(Config{
+		Type:            tptypes.QUIC,
+		PK:              pkB,
+		SK:              skB,
+		AddressResolver: arB,
+	})
+
+	defer func() { assert.NoError(t, clientB.Close()) }()
+
+	acceptCh := make(chan net.Conn, 1)
+
+	go func() {
+		conn, err := lis.Accept()
+		assert.NoError(t, err)
+		acceptCh <- conn
+	}()
+
+	connB, err := clientB.Dial(context.TODO(), pkA, lPort)
+	require.NoError(t, err)
+
+	defer func() { assert.NoError(t, connB.Close()) }()
+
+	connA := <-acceptCh
+	require.NotNil(t, connA)
+
+	defer func() { assert.NoError(t, connA.Close()) }()
+
+	msg := []byte("hello")
+
+	_, err = connB.Write(msg)
+	require.NoError(t, err)
+
+	buf := make([]byte, len(msg))
+	_, err = connA.Read(buf)
+	require.NoError(t, err)
+	assert.Equal(t, msg, buf)
+}
+
+func TestQUICListener(t *testing.T) {
+	lis, err := listenQUIC("127.0.0.1:0")
+	require.NoError(t, err)
+
+	defer func() { assert.NoError(t, lis.Close()) }()
+
+	// remote which never opens a stream doesn't block the rest of them
+	ctx, cancel := context.WithTimeout(context.Background(), dialTimeout)
+	defer cancel()
+
+	silent, err := quic.DialAddr(ctx, lis.Addr().String(), quicClientTLSConfig(), quicConfig)
+	require.NoError(t, err)
+
+	defer func() { assert.NoError(t, silent.CloseWithError(0, "")) }()
+
+	connB, err := dialQUIC(lis.Addr().String())
+	require.NoError(t, err)
+
+	msg := make([]byte, 1<<20)
+	_, err = rand.Read(msg)
+	require.NoError(t, err)
+
+	// data written right before closing is still delivered
+	closeErr := make(chan error, 1)
+
+	go func() {
+		if _, err := connB.Write(msg); err != nil {
+			closeErr <- err
+			return
+		}
+
+		closeErr <- connB.Close()
+	}()
+
+	acceptCh := make(chan net.Conn, 1)
+
+	go func() {
+		conn, err := lis.Accept()
+		assert.NoError(t, err)
+		acceptCh <- conn
+	}()
+
+	var connA net.Conn
+
+	select {
+	case connA = <-acceptCh:
+	case <-time.After(5 * time.Second):
+		t.Fatal("listener got blocked by the silent remote")
+	}
+
+	got, err := io.ReadAll(connA)
+	require.NoError(t, err)
+	assert.True(t, bytes.Equal(msg, got))
+
+	require.NoError(t, connA.Close())
+	require.NoError(t, <-closeErr)
+}
@@ -8,4 +8,9 @@ const (
 	// SUDPH is a type of a transport that works via UDP, resolves addresses using address-resolver service,
 	// and uses UDP hole punching.
 	SUDPH = "sudph"
+	// QUIC is a type of a transport that works via QUIC and resolves addresses using address-resolver service.
+	QUIC = "quic"
+	// SWS is a type of a transport that works via WebSocket and resolves addresses using PK table.
+	// It's meant for visors which are only allowed to make outgoing HTTP(S) connections.
+	SWS = "sws"
 )
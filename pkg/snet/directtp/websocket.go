@@ -0,0 +1,228 @@
+package directtp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	"nhooyr.io/websocket"
+
+	"github.com/skycoin/skywire/internal/netutil"
+)
+
+// wsReadLimit is the maximum size of a single WebSocket message.
+// Every write to a connection is sent as a separate message,
+// so it has to fit the largest noise frame.
+const wsReadLimit = 1 << 20
+
+// wsListener implements net.Listener accepting WebSocket connections over HTTP.
+type wsListener struct {
+	l      net.Listener
+	srv    *http.Server
+	connCh chan net.Conn
+	done   chan struct{}
+	once   sync.Once
+}
+
+func listenWS(addr string) (net.Listener, error) {
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+
+	wl := &wsListener{
+		l:      l,
+		connCh: make(chan net.Conn),
+		done:   make(chan struct{}),
+	}
+
+	wl.srv = &http.Server{Handler: http.HandlerFunc(wl.upgrade)}
+
+	go func() {
+		_ = wl.srv.Serve(l) // nolint:errcheck
+		_ = wl.Close()      // nolint:errcheck
+	}()
+
+	return wl, nil
+}
+
+func (wl *wsListener) upgrade(w http.ResponseWriter, r *http.Request) {
+	ws, err := websocket.Accept(w, r, &websocket.AcceptOptions{
+		// Data is encrypted with noise, so it can't be compressed anyway.
+		CompressionMode: websocket.CompressionDisabled,
+	})
+	if err != nil {
+		return // response is already written by Accept
+	}
+
+	ws.SetReadLimit(wsReadLimit)
+
+	conn := &wsConn{
+		Conn:  websocket.NetConn(context.Background(), ws, websocket.MessageBinary),
+		lAddr: wl.l.Addr(),
+		rAddr: wsAddr(r.RemoteAddr),
+	}
+
+	select {
+	case wl.connCh <- conn:
+	case <-wl.done:
+		_ = conn.Close() // nolint:errcheck
+	}
+}
+
+func (wl *wsListener) Accept() (net.Conn, error) {
+	select {
+	case conn := <-wl.connCh:
+		return conn, nil
+	case <-wl.done:
+		return nil, io.ErrClosedPipe
+	}
+}
+
+func (wl *wsListener) Close() error {
+	err := io.ErrClosedPipe
+
+	wl.once.Do(func() {
+		close(wl.done)
+		err = wl.srv.Close()
+	})
+
+	return err
+}
+
+func (wl *wsListener) Addr() net.Addr {
+	return wl.l.Addr()
+}
+
+// wsConn is a net.Conn over WebSocket reporting addresses of the underlying connection.
+type wsConn struct {
+	net.Conn
+	lAddr net.Addr
+	rAddr net.Addr
+}
+
+func (c *wsConn) LocalAddr() net.Addr {
+	return c.lAddr
+}
+
+func (c *wsConn) RemoteAddr() net.Addr {
+	return c.rAddr
+}
+
+// Close closes the connection. Closing waits for the remote side to acknowledge it,
+// which it may never do if it's not reading, but the connection is closed either way.
+func (c *wsConn) Close() error {
+	if err := c.Conn.Close(); err != nil && !errors.Is(err, context.DeadlineExceeded) {
+		return err
+	}
+
+	return nil
+}
+
+// wsAddr is an address of WebSocket peer.
+type wsAddr string
+
+func (a wsAddr) Network() string {
+	return "ws"
+}
+
+func (a wsAddr) String() string {
+	return string(a)
+}
+
+// dialWS dials WebSocket listener at `addr`, which is either host:port or ws:// or wss:// URL.
+// If proxy is configured, connection is tunneled through it with HTTP CONNECT.
+func (c *client) dialWS(addr string) (net.Conn, error) {
+	u, err := wsURL(addr)
+	if err != nil {
+		return nil, err
+	}
+
+	var proxyURL *url.URL
+
+	if c.conf.Proxy != "" {
+		if proxyURL, err = url.Parse(c.conf.Proxy); err != nil {
+			return nil, fmt.Errorf("parse proxy URL: %w", err)
+		}
+	}
+
+	var localAddr, remoteAddr net.Addr
+
+	dialContext := func(ctx context.Context, network, addr string) (net.Conn, error) {
+		dialAddr := addr
+		if proxyURL != nil {
+			dialAddr = proxyURL.Host
+		}
+
+		if c.beforeDialCallback != nil {
+			if err := c.beforeDialCallback(c.conf.Type, dialAddr); err != nil {
+				return nil, err
+			}
+		}
+
+		var conn net.Conn
+		var err error
+
+		if proxyURL != nil {
+			conn, err = netutil.DialHTTPProxy(ctx, proxyURL, addr)
+		} else {
+			var d net.Dialer
+			conn, err = d.DialContext(ctx, network, addr)
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		localAddr, remoteAddr = conn.LocalAddr(), conn.RemoteAddr()
+
+		return conn, nil
+	}
+
+	httpC := &http.Client{
+		Transport: &http.Transport{DialContext: dialContext},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), dialTimeout)
+	defer cancel()
+
+	ws, _, err := websocket.Dial(ctx, u.String(), &websocket.DialOptions{
+		HTTPClient:      httpC,
+		CompressionMode: websocket.CompressionDisabled,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	ws.SetReadLimit(wsReadLimit)
+
+	return &wsConn{
+		Conn:  websocket.NetConn(context.Background(), ws, websocket.MessageBinary),
+		lAddr: localAddr,
+		rAddr: remoteAddr,
+	}, nil
+}
+
+// wsURL makes WebSocket URL from `addr` taken from PK table.
+func wsURL(addr string) (*url.URL, error) {
+	if !strings.Contains(addr, "://") {
+		addr = "ws://" + addr
+	}
+
+	u, err := url.Parse(addr)
+	if err != nil {
+		return nil, err
+	}
+
+	if u.Scheme != "ws" && u.Scheme != "wss" {
+		return nil, errors.New("WebSocket address should have ws or wss scheme")
+	}
+
+	return u, nil
+}
@@ -0,0 +1,134 @@
+package directtp
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/skycoin/dmsg/cipher"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/skycoin/skywire/pkg/snet/directtp/pktable"
+	"github.com/skycoin/skywire/pkg/snet/directtp/tptypes"
+)
+
+func TestClient_SWS(t *testing.T) {
+	t.Run("direct", func(t *testing.T) {
+		testSWS(t, "")
+	})
+
+	t.Run("proxy", func(t *testing.T) {
+		var connects int32
+
+		proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodConnect {
+				w.WriteHeader(http.StatusMethodNotAllowed)
+				return
+			}
+
+			atomic.AddInt32(&connects, 1)
+
+			dst, err := net.Dial("tcp", r.Host)
+			if err != nil {
+				w.WriteHeader(http.StatusBadGateway)
+				return
+			}
+
+			src, _, err := w.(http.Hijacker).Hijack()
+			if err != nil {
+				_ = dst.Close() // nolint:errcheck
+				return
+			}
+
+			if _, err := src.Write([]byte("HTTP/1.1 200 Connection established\r\n\r\n")); err != nil {
+				_ = src.Close() // nolint:errcheck
+				_ = dst.Close() // nolint:errcheck
+				return
+			}
+
+			go func() {
+				_, _ = io.Copy(dst, src) // nolint:errcheck
+				_ = dst.Close()          // nolint:errcheck
+			}()
+
+			_, _ = io.Copy(src, dst) // nolint:errcheck
+			_ = src.Close()          // nolint:errcheck
+		}))
+		defer proxy.Close()
+
+		testSWS(t, proxy.URL)
+
+		assert.EqualValues(t, 1, atomic.LoadInt32(&connects))
+	})
+}
+
+func testSWS(t *testing.T, proxy string) {
+	const lPort = 10
+
+	pkA, skA := cipher.GenerateKeyPair()
+	pkB, skB := cipher.GenerateKeyPair()
+
+	clientA := NewClient(Config{
+		Type:      tptypes.SWS,
+		PK:        pkA,
+		SK:        skA,
+		LocalAddr: "127.0.0.1:0",
+		Table:     pktable.NewTable(nil),
+	})
+	require.NoError(t, clientA.Serve())
+
+	defer func() { assert.NoError(t, clientA.Close()) }()
+
+	lis, err := clientA.Listen(lPort)
+	require.NoError(t, err)
+
+	addrA, err := clientA.LocalAddr()
+	require.NoError(t, err)
+
+	clientB := NewClient(Config{
+		Type:  tptypes.SWS,
+		PK:    pkB,
+		SK:    skB,
+		Table: pktable.NewTable(map[cipher.PubKey]string{pkA: "ws://" + addrA.String()}),
+		Proxy: proxy,
+	})
+
+	defer func() { assert.NoError(t, clientB.Close()) }()
+
+	acceptCh := make(chan net.Conn, 1)
+
+	go func() {
+		conn, err := lis.Accept()
+		assert.NoError(t, err)
+		acceptCh <- conn
+	}()
+
+	connB, err := clientB.Dial(context.TODO(), pkA, lPort)
+	require.NoError(t, err)
+
+	defer func() { assert.NoError(t, connB.Close()) }()
+
+	connA := <-acceptCh
+	require.NotNil(t, connA)
+
+	defer func() { assert.NoError(t, connA.Close()) }()
+
+	msg := []byte("hello")
+
+	_, err = connB.Write(msg)
+	require.NoError(t, err)
+
+	buf := make([]byte, len(msg))
+	_, err = connA.Read(buf)
+	require.NoError(t, err)
+	assert.Equal(t, msg, buf)
+
+	// keep reading so that closing WebSocket handshake completes without timing out
+	go io.Copy(io.Discard, connA) // nolint:errcheck
+	go io.Copy(io.Discard, connB) // nolint:errcheck
+}
@@ -12,6 +12,10 @@ import (
 var (
 	// ErrEdgeIndexNotFound is returned when no edge index was found.
 	ErrEdgeIndexNotFound = errors.New("edge index not found")
+	// ErrEmptyEntry is returned when signed entry contains no entry.
+	ErrEmptyEntry = errors.New("empty entry")
+	// ErrEntryIDMismatch is returned when entry ID doesn't correspond to its edges and type.
+	ErrEntryIDMismatch = errors.New("entry ID doesn't match edges and type")
 )
 
 // Entry is the unsigned representation of a Transport.
@@ -150,6 +154,26 @@ func (se *SignedEntry) Signature(pk cipher.PubKey) (cipher.Sig, error) {
 	return se.Signatures[idx], nil
 }
 
+// Verify checks that entry ID corresponds to its edges and type
+// and that entry is signed by both of its edges.
+func (se *SignedEntry) Verify() error {
+	if se.Entry == nil {
+		return ErrEmptyEntry
+	}
+
+	if se.Entry.ID != MakeTransportID(se.Entry.Edges[0], se.Entry.Edges[1], se.Entry.Type) {
+		return ErrEntryIDMismatch
+	}
+
+	for i, pk := range se.Entry.Edges {
+		if err := cipher.VerifyPubKeySignedPayload(pk, se.Signatures[i], se.Entry.ToBinary()); err != nil {
+			return fmt.Errorf("invalid signature of edge %s: %w", pk, err)
+		}
+	}
+
+	return nil
+}
+
 // NewSignedEntry creates a SignedEntry with first signature
 func NewSignedEntry(entry *Entry, pk cipher.PubKey, secKey cipher.SecKey) (*SignedEntry, error) {
 	se := &SignedEntry{Entry: entry}
@@ -6,6 +6,7 @@ import (
 
 	"github.com/skycoin/dmsg/cipher"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/skycoin/skywire/pkg/transport"
 )
@@ -38,6 +39,23 @@ func TestEntry_SetEdges(t *testing.T) {
 	assert.NotNil(t, entryBA.ID)
 }
 
+func TestSignedEntry_Verify(t *testing.T) {
+	pkA, skA := cipher.GenerateKeyPair()
+	pkB, skB := cipher.GenerateKeyPair()
+
+	sEntry := &transport.SignedEntry{Entry: transport.NewEntry(pkA, pkB, "mock", true)}
+	require.NoError(t, sEntry.Sign(pkA, skA))
+	assert.Error(t, sEntry.Verify())
+
+	require.NoError(t, sEntry.Sign(pkB, skB))
+	assert.NoError(t, sEntry.Verify())
+
+	sEntry.Entry.Type = "other"
+	assert.Equal(t, transport.ErrEntryIDMismatch, sEntry.Verify())
+
+	assert.Equal(t, transport.ErrEmptyEntry, (&transport.SignedEntry{}).Verify())
+}
+
 func ExampleSignedEntry_Sign() {
 	pkA, skA := cipher.GenerateKeyPair()
 	pkB, skB := cipher.GenerateKeyPair()
@@ -23,6 +23,10 @@ import (
 
 const logWriteInterval = time.Second * 3
 
+// statusRefreshInterval is how often the status of a transport which is up is
+// reported to the discovery, so that the discovery doesn't expire it.
+const statusRefreshInterval = time.Minute * 5
+
 // Records number of managedTransports.
 var mTpCount int32
 
@@ -51,6 +55,7 @@ type ManagedTransportConfig struct {
 	RemotePK    cipher.PubKey
 	NetName     string
 	AfterClosed TPCloseCallback
+	OnStatus    TPStatusCallback
 }
 
 // ManagedTransport manages a direct line of communication between two visor nodes.
@@ -89,6 +94,10 @@ type ManagedTransport struct {
 
 	afterClosedMu sync.RWMutex
 	afterClosed   TPCloseCallback
+
+	localUp    bool // whether the transport is up locally, regardless of the discovery
+	onStatus   TPStatusCallback
+	onStatusMx sync.Mutex // serializes status callbacks, so they are called in order of changes
 }
 
 // NewManagedTransport creates a new ManagedTransport.
@@ -105,6 +114,7 @@ func NewManagedTransport(conf ManagedTransportConfig) *ManagedTransport {
 		connCh:      make(chan struct{}, 1),
 		done:        make(chan struct{}),
 		afterClosed: conf.AfterClosed,
+		onStatus:    conf.OnStatus,
 	}
 	mt.wg.Add(2)
 	return mt
@@ -190,12 +200,17 @@ func (mt *ManagedTransport) Serve(readCh chan<- routing.Packet) {
 
 	// Logging & redialing loop.
 	logTicker := time.NewTicker(logWriteInterval)
+	statusTicker := time.NewTicker(statusRefreshInterval)
 	for {
 		select {
 		case <-mt.done:
 			logTicker.Stop()
+			statusTicker.Stop()
 			return
 
+		case <-statusTicker.C:
+			mt.refreshStatus()
+
 		case <-logTicker.C:
 			if mt.logMod() {
 				if err := mt.ls.Record(mt.Entry.ID, mt.LogEntry); err != nil {
@@ -470,6 +485,8 @@ func (mt *ManagedTransport) updateStatus(isUp bool, tries int) (err error) {
 		}
 	}()
 
+	mt.setLocalStatus(isUp)
+
 	mt.isUpMux.Lock()
 	defer mt.isUpMux.Unlock()
 
@@ -518,6 +535,38 @@ func (mt *ManagedTransport) updateStatus(isUp bool, tries int) (err error) {
 	return err
 }
 
+// setLocalStatus records whether the transport is up locally and reports the change to the status callback
+// right away, even if the status doesn't get to the discovery.
+func (mt *ManagedTransport) setLocalStatus(isUp bool) {
+	mt.onStatusMx.Lock()
+	defer mt.onStatusMx.Unlock()
+
+	if mt.localUp == isUp {
+		return
+	}
+
+	mt.localUp = isUp
+
+	if mt.onStatus != nil {
+		mt.onStatus(mt, isUp)
+	}
+}
+
+// refreshStatus reports the current status to the discovery even if it hasn't changed.
+func (mt *ManagedTransport) refreshStatus() {
+	mt.isUpMux.Lock()
+	isUp := mt.isUp
+	mt.isUpMux.Unlock()
+
+	if !isUp {
+		return
+	}
+
+	if _, err := mt.dc.UpdateStatuses(context.Background(), &Status{ID: mt.Entry.ID, IsUp: isUp}); err != nil {
+		mt.log.WithError(err).Warn("Failed to refresh transport status.")
+	}
+}
+
 func statusString(isUp bool) string {
 	if isUp {
 		return "UP"
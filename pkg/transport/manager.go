@@ -29,6 +29,9 @@ const (
 // TPCloseCallback triggers after a session is closed.
 type TPCloseCallback func(network, addr string)
 
+// TPStatusCallback triggers after a transport goes up or down.
+type TPStatusCallback func(tp *ManagedTransport, isUp bool)
+
 // ManagerConfig configures a Manager.
 type ManagerConfig struct {
 	PubKey          cipher.PubKey
@@ -58,6 +61,8 @@ type Manager struct {
 	done          chan struct{}
 
 	afterTPClosed TPCloseCallback
+	onTPStatusMu  sync.RWMutex
+	onTPStatus    []TPStatusCallback
 }
 
 // NewManager creates a Manager with the provided configuration and transport factories.
@@ -91,6 +96,26 @@ func (tm *Manager) OnAfterTPClosed(f TPCloseCallback) {
 	}
 }
 
+// OnTPStatusChanged adds callback which will fire after transport goes up or down.
+// Callbacks are called in the order they were added.
+func (tm *Manager) OnTPStatusChanged(f TPStatusCallback) {
+	tm.onTPStatusMu.Lock()
+	defer tm.onTPStatusMu.Unlock()
+
+	tm.onTPStatus = append(tm.onTPStatus, f)
+}
+
+// tpStatusChanged calls all transport status callbacks.
+func (tm *Manager) tpStatusChanged(tp *ManagedTransport, isUp bool) {
+	tm.onTPStatusMu.RLock()
+	callbacks := tm.onTPStatus
+	tm.onTPStatusMu.RUnlock()
+
+	for _, f := range callbacks {
+		f(tp, isUp)
+	}
+}
+
 // Serve runs listening loop across all registered factories.
 func (tm *Manager) Serve(ctx context.Context) {
 	tm.serveOnce.Do(func() {
@@ -235,6 +260,7 @@ func (tm *Manager) acceptTransport(ctx context.Context, lis *snet.Listener) erro
 			RemotePK:    conn.RemotePK(),
 			NetName:     lis.Network(),
 			AfterClosed: tm.afterTPClosed,
+			OnStatus:    tm.tpStatusChanged,
 		})
 
 		go func() {
@@ -335,6 +361,7 @@ func (tm *Manager) saveTransport(remote cipher.PubKey, netName string) (*Managed
 		RemotePK:    remote,
 		NetName:     netName,
 		AfterClosed: afterTPClosed,
+		OnStatus:    tm.tpStatusChanged,
 	})
 
 	if mTp.netName == tptypes.STCPR {
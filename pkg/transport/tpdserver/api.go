@@ -0,0 +1,269 @@
+// Package tpdserver implements transport discovery server.
+package tpdserver
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi"
+	"github.com/go-chi/chi/middleware"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"github.com/skycoin/dmsg/buildinfo"
+	"github.com/skycoin/dmsg/cipher"
+	"github.com/skycoin/dmsg/httputil"
+
+	"github.com/skycoin/skywire/internal/httpauth"
+	"github.com/skycoin/skywire/pkg/transport"
+)
+
+const (
+	httpTimeout = 30 * time.Second
+
+	// DefaultEntryTimeout is the default time after which transports
+	// without status updates are removed.
+	DefaultEntryTimeout = 15 * time.Minute
+)
+
+var (
+	// ErrNotEdge is returned when the authenticated visor is not an edge of the transport.
+	ErrNotEdge = errors.New("visor is not an edge of the transport")
+)
+
+// Config configures transport discovery API.
+type Config struct {
+	// EntryTimeout is the time after which transports which weren't
+	// registered again or had their status updated are removed.
+	EntryTimeout time.Duration
+}
+
+// API serves transport discovery HTTP API compatible with tpdclient.
+type API struct {
+	http.Handler
+
+	log   logrus.FieldLogger
+	store Store
+	conf  Config
+}
+
+// New constructs a new transport discovery API.
+func New(log logrus.FieldLogger, store Store, conf Config) *API {
+	if conf.EntryTimeout <= 0 {
+		conf.EntryTimeout = DefaultEntryTimeout
+	}
+
+	api := &API{
+		log:   log,
+		store: store,
+		conf:  conf,
+	}
+
+	auth := httpauth.NewServer(store)
+
+	r := chi.NewRouter()
+
+	r.Use(middleware.RequestID)
+	r.Use(middleware.RealIP)
+	r.Use(middleware.Logger)
+	r.Use(middleware.Recoverer)
+	r.Use(middleware.Timeout(httpTimeout))
+	r.Use(httputil.SetLoggerMiddleware(log))
+
+	r.Get("/health", api.health)
+	r.Get("/security/nonces/{pk}", auth.NonceHandler)
+
+	r.Group(func(r chi.Router) {
+		r.Use(auth.Middleware)
+
+		r.Post("/transports/", api.registerTransports)
+		r.Get("/transports/id:{id}", api.getTransportByID)
+		r.Get("/transports/edge:{edge}", api.getTransportsByEdge)
+		r.Delete("/transports/id:{id}", api.deleteTransport)
+		r.Post("/statuses", api.updateStatuses)
+	})
+
+	api.Handler = r
+
+	return api
+}
+
+// RemoveExpired periodically removes transports which weren't updated
+// for longer than the entry timeout until `ctx` is done.
+func (api *API) RemoveExpired(ctx context.Context) {
+	ticker := time.NewTicker(api.conf.EntryTimeout / 4)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			expired, err := api.store.RemoveExpired(ctx, time.Now().Add(-api.conf.EntryTimeout))
+			if err != nil {
+				api.log.WithError(err).Error("Failed to remove expired transports.")
+				continue
+			}
+
+			if len(expired) > 0 {
+				api.log.WithField("transports", expired).Info("Removed expired transports.")
+			}
+		}
+	}
+}
+
+func (api *API) registerTransports(w http.ResponseWriter, r *http.Request) {
+	pk, _ := httpauth.PKFromContext(r.Context())
+
+	var entries []*transport.SignedEntry
+	if err := json.NewDecoder(r.Body).Decode(&entries); err != nil {
+		httpauth.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	for _, se := range entries {
+		if se == nil || se.Entry == nil {
+			httpauth.WriteError(w, http.StatusBadRequest, transport.ErrEmptyEntry)
+			return
+		}
+
+		if !se.Entry.HasEdge(pk) {
+			httpauth.WriteError(w, http.StatusForbidden, ErrNotEdge)
+			return
+		}
+
+		if err := se.Verify(); err != nil {
+			httpauth.WriteError(w, http.StatusBadRequest, err)
+			return
+		}
+	}
+
+	registered := make([]*transport.EntryWithStatus, 0, len(entries))
+
+	for _, se := range entries {
+		entry, err := api.store.RegisterTransport(r.Context(), se)
+		if err != nil {
+			httpauth.WriteError(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		registered = append(registered, entry)
+	}
+
+	api.writeJSON(w, http.StatusOK, registered)
+}
+
+func (api *API) getTransportByID(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		httpauth.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	entry, err := api.store.GetTransportByID(r.Context(), id)
+	if err != nil {
+		api.writeStoreError(w, err)
+		return
+	}
+
+	api.writeJSON(w, http.StatusOK, entry)
+}
+
+func (api *API) getTransportsByEdge(w http.ResponseWriter, r *http.Request) {
+	var pk cipher.PubKey
+	if err := pk.UnmarshalText([]byte(chi.URLParam(r, "edge"))); err != nil {
+		httpauth.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	entries, err := api.store.GetTransportsByEdge(r.Context(), pk)
+	if err != nil {
+		api.writeStoreError(w, err)
+		return
+	}
+
+	api.writeJSON(w, http.StatusOK, entries)
+}
+
+func (api *API) deleteTransport(w http.ResponseWriter, r *http.Request) {
+	pk, _ := httpauth.PKFromContext(r.Context())
+
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		httpauth.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	entry, err := api.store.GetTransportByID(r.Context(), id)
+	if err != nil {
+		api.writeStoreError(w, err)
+		return
+	}
+
+	if !entry.Entry.HasEdge(pk) {
+		httpauth.WriteError(w, http.StatusForbidden, ErrNotEdge)
+		return
+	}
+
+	if err := api.store.DeregisterTransport(r.Context(), id); err != nil {
+		api.writeStoreError(w, err)
+		return
+	}
+
+	api.writeJSON(w, http.StatusOK, true)
+}
+
+func (api *API) updateStatuses(w http.ResponseWriter, r *http.Request) {
+	pk, _ := httpauth.PKFromContext(r.Context())
+
+	var statuses []*transport.Status
+	if err := json.NewDecoder(r.Body).Decode(&statuses); err != nil {
+		httpauth.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	entries := make([]*transport.EntryWithStatus, 0, len(statuses))
+
+	for _, status := range statuses {
+		if status == nil {
+			continue
+		}
+
+		entry, err := api.store.UpdateStatus(r.Context(), pk, status)
+		if err != nil {
+			api.writeStoreError(w, fmt.Errorf("transport %s: %w", status.ID, err))
+			return
+		}
+
+		entries = append(entries, entry)
+	}
+
+	api.writeJSON(w, http.StatusOK, entries)
+}
+
+func (api *API) health(w http.ResponseWriter, _ *http.Request) {
+	api.writeJSON(w, http.StatusOK, buildinfo.Get())
+}
+
+func (api *API) writeStoreError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, ErrNotFound):
+		httpauth.WriteError(w, http.StatusNotFound, err)
+	case errors.Is(err, transport.ErrEdgeIndexNotFound):
+		httpauth.WriteError(w, http.StatusForbidden, ErrNotEdge)
+	default:
+		httpauth.WriteError(w, http.StatusInternalServerError, err)
+	}
+}
+
+func (api *API) writeJSON(w http.ResponseWriter, code int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		api.log.WithError(err).Warn("Failed to write response.")
+	}
+}
@@ -0,0 +1,146 @@
+package tpdserver
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/skycoin/dmsg/cipher"
+	"github.com/skycoin/skycoin/src/util/logging"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/skycoin/skywire/pkg/transport"
+	"github.com/skycoin/skywire/pkg/transport/tpdclient"
+)
+
+func TestMain(m *testing.M) {
+	loggingLevel, ok := os.LookupEnv("TEST_LOGGING_LEVEL")
+	if ok {
+		lvl, err := logging.LevelFromString(loggingLevel)
+		if err != nil {
+			panic(err)
+		}
+		logging.SetLevel(lvl)
+	} else {
+		logging.Disable()
+	}
+
+	os.Exit(m.Run())
+}
+
+func signedEntry(t *testing.T, pkA cipher.PubKey, skA cipher.SecKey, pkB cipher.PubKey, skB cipher.SecKey) *transport.SignedEntry {
+	se, err := transport.NewSignedEntry(transport.NewEntry(pkA, pkB, "dmsg", true), pkA, skA)
+	require.NoError(t, err)
+	require.NoError(t, se.Sign(pkB, skB))
+
+	return se
+}
+
+func TestAPI(t *testing.T) {
+	pkA, skA := cipher.GenerateKeyPair()
+	pkB, skB := cipher.GenerateKeyPair()
+	pkC, skC := cipher.GenerateKeyPair()
+
+	api := New(logging.MustGetLogger("tpd"), NewMemoryStore(), Config{})
+	ts := httptest.NewServer(api)
+	defer ts.Close()
+
+	ctx := context.TODO()
+
+	clientA, err := tpdclient.NewHTTP(ts.URL, pkA, skA)
+	require.NoError(t, err)
+	clientC, err := tpdclient.NewHTTP(ts.URL, pkC, skC)
+	require.NoError(t, err)
+
+	se := signedEntry(t, pkA, skA, pkB, skB)
+
+	t.Run("register", func(t *testing.T) {
+		require.NoError(t, clientA.RegisterTransports(ctx, se))
+
+		entry, err := clientA.GetTransportByID(ctx, se.Entry.ID)
+		require.NoError(t, err)
+		assert.Equal(t, *se.Entry, *entry.Entry)
+		assert.True(t, entry.IsUp)
+
+		entries, err := clientC.GetTransportsByEdge(ctx, pkB)
+		require.NoError(t, err)
+		require.Len(t, entries, 1)
+		assert.Equal(t, se.Entry.ID, entries[0].Entry.ID)
+	})
+
+	t.Run("register_bad_signature", func(t *testing.T) {
+		bad := signedEntry(t, pkA, skA, pkC, skC)
+		bad.Signatures[bad.Entry.EdgeIndex(pkC)] = cipher.Sig{}
+		assert.Error(t, clientA.RegisterTransports(ctx, bad))
+
+		// only edges may register transports
+		assert.Error(t, clientC.RegisterTransports(ctx, se))
+	})
+
+	t.Run("update_statuses", func(t *testing.T) {
+		entries, err := clientA.UpdateStatuses(ctx, &transport.Status{ID: se.Entry.ID, IsUp: false})
+		require.NoError(t, err)
+		require.Len(t, entries, 1)
+		assert.False(t, entries[0].IsUp)
+
+		_, err = clientC.UpdateStatuses(ctx, &transport.Status{ID: se.Entry.ID, IsUp: false})
+		assert.Error(t, err)
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		assert.Error(t, clientC.DeleteTransport(ctx, se.Entry.ID))
+		require.NoError(t, clientA.DeleteTransport(ctx, se.Entry.ID))
+
+		_, err := clientA.GetTransportByID(ctx, se.Entry.ID)
+		assert.Error(t, err)
+	})
+}
+
+func TestBBoltStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tpd")
+	require.NoError(t, err)
+	defer func() { require.NoError(t, os.RemoveAll(dir)) }()
+
+	path := filepath.Join(dir, "tpd.db")
+
+	pkA, skA := cipher.GenerateKeyPair()
+	pkB, skB := cipher.GenerateKeyPair()
+	se := signedEntry(t, pkA, skA, pkB, skB)
+
+	ctx := context.TODO()
+
+	s, err := NewBBoltStore(path)
+	require.NoError(t, err)
+
+	_, err = s.RegisterTransport(ctx, se)
+	require.NoError(t, err)
+	_, err = s.IncrementNonce(ctx, pkA)
+	require.NoError(t, err)
+	require.NoError(t, s.Close())
+
+	s, err = NewBBoltStore(path)
+	require.NoError(t, err)
+	defer func() { require.NoError(t, s.Close()) }()
+
+	entry, err := s.GetTransportByID(ctx, se.Entry.ID)
+	require.NoError(t, err)
+	assert.Equal(t, *se.Entry, *entry.Entry)
+
+	nonce, err := s.Nonce(ctx, pkA)
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, nonce)
+
+	// transports without updates expire
+	expired, err := s.RemoveExpired(ctx, time.Now().Add(time.Minute))
+	require.NoError(t, err)
+	assert.Equal(t, []uuid.UUID{se.Entry.ID}, expired)
+
+	_, err = s.GetTransportByID(ctx, se.Entry.ID)
+	assert.Equal(t, ErrNotFound, err)
+}
@@ -0,0 +1,338 @@
+package tpdserver
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/skycoin/dmsg/cipher"
+	"go.etcd.io/bbolt"
+
+	"github.com/skycoin/skywire/internal/httpauth"
+	"github.com/skycoin/skywire/pkg/transport"
+)
+
+var (
+	// ErrNotFound is returned when requested transport is not registered.
+	ErrNotFound = errors.New("transport not found")
+
+	transportsBucket = []byte("transports")
+	noncesBucket     = []byte("nonces")
+)
+
+// Store keeps transport entries along with nonces of the clients.
+type Store interface {
+	httpauth.NonceStore
+
+	RegisterTransport(ctx context.Context, se *transport.SignedEntry) (*transport.EntryWithStatus, error)
+	DeregisterTransport(ctx context.Context, id uuid.UUID) error
+	GetTransportByID(ctx context.Context, id uuid.UUID) (*transport.EntryWithStatus, error)
+	GetTransportsByEdge(ctx context.Context, pk cipher.PubKey) ([]*transport.EntryWithStatus, error)
+	UpdateStatus(ctx context.Context, edge cipher.PubKey, status *transport.Status) (*transport.EntryWithStatus, error)
+	// RemoveExpired removes transports which weren't registered or updated since `before`.
+	RemoveExpired(ctx context.Context, before time.Time) ([]uuid.UUID, error)
+	Close() error
+}
+
+// record is a registered transport.
+type record struct {
+	Entry      *transport.Entry `json:"entry"`
+	Signatures [2]cipher.Sig    `json:"signatures"`
+	Registered int64            `json:"registered"`
+	Statuses   [2]bool          `json:"statuses"`
+	Updated    int64            `json:"updated"`
+}
+
+func (rec *record) entryWithStatus() *transport.EntryWithStatus {
+	entry := *rec.Entry
+
+	return &transport.EntryWithStatus{
+		Entry:      &entry,
+		IsUp:       rec.Statuses[0] && rec.Statuses[1],
+		Registered: rec.Registered,
+		Statuses:   rec.Statuses,
+	}
+}
+
+// store keeps everything in memory. If bbolt database is set, all the changes
+// are written through to it and restored on start.
+type store struct {
+	mu      sync.RWMutex
+	records map[uuid.UUID]*record
+	nonces  map[cipher.PubKey]httpauth.Nonce
+	db      *bbolt.DB
+}
+
+// NewMemoryStore returns Store which keeps everything in memory.
+func NewMemoryStore() Store {
+	return &store{
+		records: make(map[uuid.UUID]*record),
+		nonces:  make(map[cipher.PubKey]httpauth.Nonce),
+	}
+}
+
+// NewBBoltStore returns Store persisted to the bbolt database at `path`.
+func NewBBoltStore(path string) (Store, error) {
+	db, err := bbolt.Open(path, 0600, &bbolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open transport discovery db: %w", err)
+	}
+
+	s := NewMemoryStore().(*store)
+	s.db = db
+
+	if err := s.restore(); err != nil {
+		if closeErr := db.Close(); closeErr != nil {
+			err = fmt.Errorf("%v (close: %v)", err, closeErr)
+		}
+
+		return nil, err
+	}
+
+	return s, nil
+}
+
+func (s *store) restore() error {
+	return s.db.Update(func(tx *bbolt.Tx) error {
+		tps, err := tx.CreateBucketIfNotExists(transportsBucket)
+		if err != nil {
+			return fmt.Errorf("failed to create bucket: %w", err)
+		}
+
+		nonces, err := tx.CreateBucketIfNotExists(noncesBucket)
+		if err != nil {
+			return fmt.Errorf("failed to create bucket: %w", err)
+		}
+
+		err = tps.ForEach(func(_, v []byte) error {
+			var rec record
+			if err := json.Unmarshal(v, &rec); err != nil {
+				return fmt.Errorf("failed to decode transport: %w", err)
+			}
+
+			if rec.Entry != nil {
+				s.records[rec.Entry.ID] = &rec
+			}
+
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		return nonces.ForEach(func(k, v []byte) error {
+			var pk cipher.PubKey
+			if len(k) != len(pk) || len(v) != 8 {
+				return nil
+			}
+
+			copy(pk[:], k)
+			s.nonces[pk] = httpauth.Nonce(binary.BigEndian.Uint64(v))
+
+			return nil
+		})
+	})
+}
+
+func (s *store) Nonce(_ context.Context, pk cipher.PubKey) (httpauth.Nonce, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.nonces[pk], nil
+}
+
+func (s *store) IncrementNonce(_ context.Context, pk cipher.PubKey) (httpauth.Nonce, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	nonce := s.nonces[pk] + 1
+
+	err := s.update(func(tx *bbolt.Tx) error {
+		v := make([]byte, 8)
+		binary.BigEndian.PutUint64(v, uint64(nonce))
+
+		return tx.Bucket(noncesBucket).Put(pk[:], v)
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	s.nonces[pk] = nonce
+
+	return nonce, nil
+}
+
+// RegisterTransport registers a new transport or refreshes the existing one
+// marking it as up for both edges.
+func (s *store) RegisterTransport(_ context.Context, se *transport.SignedEntry) (*transport.EntryWithStatus, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	entry := *se.Entry
+
+	rec := &record{
+		Entry:      &entry,
+		Signatures: se.Signatures,
+		Registered: now.Unix(),
+		Statuses:   [2]bool{true, true},
+		Updated:    now.UnixNano(),
+	}
+
+	if old, ok := s.records[entry.ID]; ok {
+		rec.Registered = old.Registered
+	}
+
+	if err := s.put(rec); err != nil {
+		return nil, err
+	}
+
+	s.records[entry.ID] = rec
+
+	return rec.entryWithStatus(), nil
+}
+
+func (s *store) DeregisterTransport(_ context.Context, id uuid.UUID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.records[id]; !ok {
+		return ErrNotFound
+	}
+
+	if err := s.delete(id); err != nil {
+		return err
+	}
+
+	delete(s.records, id)
+
+	return nil
+}
+
+func (s *store) GetTransportByID(_ context.Context, id uuid.UUID) (*transport.EntryWithStatus, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	rec, ok := s.records[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+
+	return rec.entryWithStatus(), nil
+}
+
+func (s *store) GetTransportsByEdge(_ context.Context, pk cipher.PubKey) ([]*transport.EntryWithStatus, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	entries := make([]*transport.EntryWithStatus, 0)
+
+	for _, rec := range s.records {
+		if rec.Entry.HasEdge(pk) {
+			entries = append(entries, rec.entryWithStatus())
+		}
+	}
+
+	return entries, nil
+}
+
+// UpdateStatus updates status of the transport as seen by `edge`.
+func (s *store) UpdateStatus(_ context.Context, edge cipher.PubKey, status *transport.Status) (*transport.EntryWithStatus, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	old, ok := s.records[status.ID]
+	if !ok {
+		return nil, ErrNotFound
+	}
+
+	idx := old.Entry.EdgeIndex(edge)
+	if idx == -1 {
+		return nil, transport.ErrEdgeIndexNotFound
+	}
+
+	rec := *old
+	rec.Statuses[idx] = status.IsUp
+	rec.Updated = time.Now().UnixNano()
+
+	if err := s.put(&rec); err != nil {
+		return nil, err
+	}
+
+	s.records[status.ID] = &rec
+
+	return rec.entryWithStatus(), nil
+}
+
+func (s *store) RemoveExpired(_ context.Context, before time.Time) ([]uuid.UUID, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var expired []uuid.UUID
+
+	for id, rec := range s.records {
+		if rec.Updated < before.UnixNano() {
+			expired = append(expired, id)
+		}
+	}
+
+	if err := s.delete(expired...); err != nil {
+		return nil, err
+	}
+
+	for _, id := range expired {
+		delete(s.records, id)
+	}
+
+	return expired, nil
+}
+
+func (s *store) Close() error {
+	if s.db == nil {
+		return nil
+	}
+
+	return s.db.Close()
+}
+
+func (s *store) put(rec *record) error {
+	return s.update(func(tx *bbolt.Tx) error {
+		v, err := json.Marshal(rec)
+		if err != nil {
+			return err
+		}
+
+		return tx.Bucket(transportsBucket).Put(rec.Entry.ID[:], v)
+	})
+}
+
+func (s *store) delete(ids ...uuid.UUID) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	return s.update(func(tx *bbolt.Tx) error {
+		b := tx.Bucket(transportsBucket)
+		for _, id := range ids {
+			if err := b.Delete(id[:]); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// update runs `fn` within a database transaction, if store is persisted.
+func (s *store) update(fn func(tx *bbolt.Tx) error) error {
+	if s.db == nil {
+		return nil
+	}
+
+	return s.db.Update(fn)
+}
@@ -0,0 +1,44 @@
+package updater
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/skycoin/dmsg/cipher"
+)
+
+const signatureFilename = checksumsFilename + ".sig"
+
+var (
+	// ErrNoReleaseKeys is returned when no release public keys are pinned,
+	// so no release can be verified.
+	ErrNoReleaseKeys = errors.New("no release public keys configured")
+	// ErrInvalidSignature is returned when checksums file is not signed by any of release public keys.
+	ErrInvalidSignature = errors.New("checksums signature is not valid")
+)
+
+// SignChecksums signs checksums file of a release with release secret key.
+// The returned signature is published as a detached hex-encoded signature file.
+func SignChecksums(checksums []byte, sk cipher.SecKey) (cipher.Sig, error) {
+	return cipher.SignPayload(checksums, sk)
+}
+
+// verifyChecksums checks that hex-encoded `signature` of `checksums` is made by one of `keys`.
+func verifyChecksums(checksums []byte, signature string, keys []cipher.PubKey) error {
+	if len(keys) == 0 {
+		return ErrNoReleaseKeys
+	}
+
+	var sig cipher.Sig
+	if err := sig.UnmarshalText([]byte(strings.TrimSpace(signature))); err != nil {
+		return ErrInvalidSignature
+	}
+
+	for _, pk := range keys {
+		if err := cipher.VerifyPubKeySignedPayload(pk, sig, checksums); err == nil {
+			return nil
+		}
+	}
+
+	return ErrInvalidSignature
+}
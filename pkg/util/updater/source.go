@@ -0,0 +1,231 @@
+package updater
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/google/go-github/github"
+)
+
+// Types of release sources.
+const (
+	// SourceGitHub is the source of releases published on GitHub.
+	SourceGitHub = "github"
+	// SourceHTTP is the source of releases served over HTTP along with a manifest, e.g. by a mirror.
+	SourceHTTP = "http"
+	// SourceDir is the source of releases kept in a local directory along with a manifest.
+	SourceDir = "dir"
+)
+
+// manifestFilename is the name of the manifest file in the root of HTTP and directory sources.
+const manifestFilename = "manifest.json"
+
+var (
+	// ErrUnknownSource is returned when release source type is unknown.
+	ErrUnknownSource = errors.New("release source type is unknown")
+)
+
+// Source provides releases to the updater.
+type Source interface {
+	// LatestVersion returns the latest version released in `channel`.
+	LatestVersion(ctx context.Context, channel Channel) (*Version, error)
+	// Open opens release file `filename` of `version`.
+	// Size of the file is returned if it's known, -1 otherwise.
+	Open(ctx context.Context, version, filename string) (io.ReadCloser, int64, error)
+}
+
+// SourceConfig configures a release source.
+type SourceConfig struct {
+	Type string `json:"type"`           // github (default), http or dir
+	URL  string `json:"url,omitempty"`  // base URL of http source
+	Path string `json:"path,omitempty"` // path of dir source
+}
+
+// NewSource creates a release source from `conf`.
+// GitHub source is returned if `conf` is nil.
+func NewSource(conf *SourceConfig) (Source, error) {
+	if conf == nil {
+		return NewGitHubSource(), nil
+	}
+
+	switch conf.Type {
+	case SourceGitHub, "":
+		return NewGitHubSource(), nil
+	case SourceHTTP:
+		return NewHTTPSource(conf.URL), nil
+	case SourceDir:
+		return NewDirSource(conf.Path), nil
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnknownSource, conf.Type)
+	}
+}
+
+// Manifest describes releases of HTTP and directory sources.
+// Files of a release are placed into a subdirectory named after its version.
+type Manifest struct {
+	Channels map[Channel]string `json:"channels"` // latest version of every channel
+}
+
+func (m *Manifest) latestVersion(channel Channel) (*Version, error) {
+	if channel != ChannelStable && channel != ChannelTesting {
+		return nil, ErrUnknownChannel
+	}
+
+	version, ok := m.Channels[channel]
+	if !ok || version == "" {
+		return nil, ErrNoReleases
+	}
+
+	return VersionFromString(version)
+}
+
+type githubSource struct{}
+
+// NewGitHubSource returns a source of releases published on GitHub.
+func NewGitHubSource() Source {
+	return githubSource{}
+}
+
+func (githubSource) LatestVersion(ctx context.Context, channel Channel) (*Version, error) {
+	client := github.NewClient(nil)
+
+	switch channel {
+	case ChannelStable:
+		release, _, err := client.Repositories.GetLatestRelease(ctx, owner, gitProjectName)
+		if err != nil {
+			return nil, err
+		}
+
+		if release.TagName == nil {
+			return nil, ErrTagNameEmpty
+		}
+
+		return VersionFromString(*release.TagName)
+
+	case ChannelTesting:
+		releases, _, err := client.Repositories.ListReleases(ctx, owner, gitProjectName, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(releases) == 0 {
+			return nil, ErrNoReleases
+		}
+
+		// Latest release should be the first one.
+		release := releases[0]
+
+		if release.TagName == nil {
+			return nil, ErrTagNameEmpty
+		}
+
+		return VersionFromString(*release.TagName)
+
+	default:
+		return nil, ErrUnknownChannel
+	}
+}
+
+func (githubSource) Open(ctx context.Context, version, filename string) (io.ReadCloser, int64, error) {
+	return openURL(ctx, fileURL(version, filename))
+}
+
+type httpSource struct {
+	baseURL string
+}
+
+// NewHTTPSource returns a source of releases served over HTTP at `baseURL`.
+func NewHTTPSource(baseURL string) Source {
+	return &httpSource{baseURL: strings.TrimSuffix(baseURL, "/")}
+}
+
+func (s *httpSource) LatestVersion(ctx context.Context, channel Channel) (*Version, error) {
+	body, _, err := openURL(ctx, s.baseURL+"/"+manifestFilename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get manifest: %w", err)
+	}
+
+	defer func() {
+		_ = body.Close() // nolint:errcheck
+	}()
+
+	var manifest Manifest
+	if err := json.NewDecoder(body).Decode(&manifest); err != nil {
+		return nil, fmt.Errorf("failed to decode manifest: %w", err)
+	}
+
+	return manifest.latestVersion(channel)
+}
+
+func (s *httpSource) Open(ctx context.Context, version, filename string) (io.ReadCloser, int64, error) {
+	return openURL(ctx, s.baseURL+"/"+path.Join(version, filename))
+}
+
+type dirSource struct {
+	path string
+}
+
+// NewDirSource returns a source of releases kept in directory `path`.
+func NewDirSource(path string) Source {
+	return &dirSource{path: path}
+}
+
+func (s *dirSource) LatestVersion(_ context.Context, channel Channel) (*Version, error) {
+	f, err := os.Open(filepath.Join(s.path, manifestFilename))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open manifest: %w", err)
+	}
+
+	defer func() {
+		_ = f.Close() // nolint:errcheck
+	}()
+
+	var manifest Manifest
+	if err := json.NewDecoder(f).Decode(&manifest); err != nil {
+		return nil, fmt.Errorf("failed to decode manifest: %w", err)
+	}
+
+	return manifest.latestVersion(channel)
+}
+
+func (s *dirSource) Open(_ context.Context, version, filename string) (io.ReadCloser, int64, error) {
+	f, err := os.Open(filepath.Join(s.path, filepath.Base(version), filepath.Base(filename)))
+	if err != nil {
+		return nil, 0, err
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		_ = f.Close() // nolint:errcheck
+		return nil, 0, err
+	}
+
+	return f, info.Size(), nil
+}
+
+func openURL(ctx context.Context, url string) (io.ReadCloser, int64, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		_ = resp.Body.Close() // nolint:errcheck
+		return nil, 0, fmt.Errorf("received bad status code: %d", resp.StatusCode)
+	}
+
+	return resp.Body, resp.ContentLength, nil
+}
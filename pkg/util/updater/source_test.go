@@ -0,0 +1,122 @@
+package updater
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/mholt/archiver/v3"
+	"github.com/skycoin/dmsg/cipher"
+	"github.com/skycoin/skycoin/src/util/logging"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	fixtureStable  = "v1.2.3"
+	fixtureTesting = "v1.3.0-rc1"
+)
+
+// releaseFixture creates a directory with a manifest and a signed release of version fixtureStable.
+func releaseFixture(t *testing.T, sk cipher.SecKey) string {
+	dir := t.TempDir()
+
+	manifest, err := json.Marshal(Manifest{Channels: map[Channel]string{
+		ChannelStable:  fixtureStable,
+		ChannelTesting: fixtureTesting,
+	}})
+	require.NoError(t, err)
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, manifestFilename), manifest, 0600))
+
+	releaseDir := filepath.Join(dir, fixtureStable)
+	require.NoError(t, os.Mkdir(releaseDir, 0700))
+
+	binary := filepath.Join(t.TempDir(), visorBinary)
+	require.NoError(t, ioutil.WriteFile(binary, []byte("visor"), 0600))
+
+	archiveName := archiveFilename(projectName, fixtureStable, runtime.GOOS, runtime.GOARCH)
+	archivePath := filepath.Join(releaseDir, archiveName)
+	require.NoError(t, archiver.Archive([]string{binary}, archivePath))
+
+	archive, err := ioutil.ReadFile(archivePath)
+	require.NoError(t, err)
+
+	sum := sha256.Sum256(archive)
+	checksums := []byte(fmt.Sprintf("%s  %s\n", hex.EncodeToString(sum[:]), archiveName))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(releaseDir, checksumsFilename), checksums, 0600))
+
+	sig, err := SignChecksums(checksums, sk)
+	require.NoError(t, err)
+	require.NoError(t, ioutil.WriteFile(filepath.Join(releaseDir, signatureFilename), []byte(sig.Hex()), 0600))
+
+	return dir
+}
+
+func TestSources(t *testing.T) {
+	pk, sk := cipher.GenerateKeyPair()
+	otherPK, _ := cipher.GenerateKeyPair()
+
+	dir := releaseFixture(t, sk)
+
+	srv := httptest.NewServer(http.FileServer(http.Dir(dir)))
+	defer srv.Close()
+
+	sources := map[string]*SourceConfig{
+		SourceDir:  {Type: SourceDir, Path: dir},
+		SourceHTTP: {Type: SourceHTTP, URL: srv.URL + "/"},
+	}
+
+	for name, conf := range sources {
+		conf := conf
+		t.Run(name, func(t *testing.T) {
+			source, err := NewSource(conf)
+			require.NoError(t, err)
+
+			v, err := source.LatestVersion(context.TODO(), ChannelStable)
+			require.NoError(t, err)
+			assert.Equal(t, fixtureStable, v.String())
+
+			v, err = source.LatestVersion(context.TODO(), ChannelTesting)
+			require.NoError(t, err)
+			assert.Equal(t, fixtureTesting, v.String())
+
+			_, err = source.LatestVersion(context.TODO(), "unknown")
+			assert.Equal(t, ErrUnknownChannel, err)
+
+			u := New(logging.MustGetLogger("updater"), nil, t.TempDir(), []cipher.PubKey{pk}, source)
+
+			path, err := u.download(context.TODO(), UpdateConfig{}, fixtureStable)
+			require.NoError(t, err)
+
+			data, err := ioutil.ReadFile(filepath.Join(path, visorBinary))
+			require.NoError(t, err)
+			assert.Equal(t, "visor", string(data))
+
+			u.removeFiles(path)
+
+			u = New(logging.MustGetLogger("updater"), nil, t.TempDir(), []cipher.PubKey{otherPK}, source)
+
+			_, err = u.download(context.TODO(), UpdateConfig{}, fixtureStable)
+			assert.True(t, errors.Is(err, ErrInvalidSignature))
+		})
+	}
+}
+
+func TestNewSource(t *testing.T) {
+	source, err := NewSource(nil)
+	require.NoError(t, err)
+	assert.Equal(t, NewGitHubSource(), source)
+
+	_, err = NewSource(&SourceConfig{Type: "ftp"})
+	assert.True(t, errors.Is(err, ErrUnknownSource))
+}
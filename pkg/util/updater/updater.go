@@ -10,7 +10,6 @@ import (
 	"fmt"
 	"io"
 	"io/ioutil"
-	"net/http"
 	"os"
 	"path/filepath"
 	"runtime"
@@ -18,10 +17,10 @@ import (
 	"sync/atomic"
 	"unicode"
 
-	"github.com/google/go-github/github"
 	"github.com/mholt/archiver/v3"
 	"github.com/schollz/progressbar/v2"
 	"github.com/skycoin/dmsg/buildinfo"
+	"github.com/skycoin/dmsg/cipher"
 	"github.com/skycoin/skycoin/src/util/logging"
 
 	"github.com/skycoin/skywire/pkg/restart"
@@ -38,8 +37,8 @@ const (
 	checksumsFilename = "checksums.txt"
 	checkSumLength    = 64
 	permRWX           = 0755
-	oldSuffix         = ".old"
 	appsSubfolder     = "apps"
+	backupSubfolder   = "backup"
 	archiveFormat     = ".tar.gz"
 	visorBinary       = "skywire-visor"
 	cliBinary         = "skywire-cli"
@@ -65,32 +64,41 @@ var (
 // Updater checks if a new version of skywire is available, downloads its binary files
 // and runs them, substituting the current binary files.
 type Updater struct {
-	log        *logging.Logger
-	restartCtx *restart.Context
-	appsPath   string
-	updating   int32
-	status     *status
+	log         *logging.Logger
+	restartCtx  *restart.Context
+	appsPath    string
+	releaseKeys []cipher.PubKey
+	source      Source
+	updating    int32
+	status      *status
 }
 
 // New returns a new Updater.
-func New(log *logging.Logger, restartCtx *restart.Context, appsPath string) *Updater {
+// Releases are taken from `source`, only the ones with checksums signed
+// by one of `releaseKeys` are installed.
+func New(log *logging.Logger, restartCtx *restart.Context, appsPath string, releaseKeys []cipher.PubKey,
+	source Source) *Updater {
 	return &Updater{
-		log:        log,
-		restartCtx: restartCtx,
-		appsPath:   appsPath,
-		status:     newStatus(),
+		log:         log,
+		restartCtx:  restartCtx,
+		appsPath:    appsPath,
+		releaseKeys: releaseKeys,
+		source:      source,
+		status:      newStatus(),
 	}
 }
 
 // UpdateConfig defines a config for updater.
 // If a config field is not empty, a default value is overridden.
 // Version overrides Channel.
-// ArchiveURL/ChecksumURL override Version and channel.
+// ArchiveURL/ChecksumURL/SignatureURL override Version and channel.
+// SignatureURL defaults to ChecksumsURL with ".sig" suffix if ChecksumsURL is set.
 type UpdateConfig struct {
 	Channel      Channel `json:"channel"`
 	Version      string  `json:"version"`
 	ArchiveURL   string  `json:"archive_url"`
 	ChecksumsURL string  `json:"checksums_url"`
+	SignatureURL string  `json:"signature_url"`
 }
 
 // Channel defines channel for updating.
@@ -103,9 +111,11 @@ const (
 	ChannelTesting Channel = "testing"
 )
 
-// Update performs an update operation.
+// Update performs an update operation. Cancelling `ctx` aborts the update until binaries start being replaced.
+// Replaced binaries are kept in the backup folder next to the visor binary until the next update,
+// so that the update may be reverted with Rollback.
 // NOTE: Update may call os.Exit.
-func (u *Updater) Update(updateConfig UpdateConfig) (updated bool, err error) {
+func (u *Updater) Update(ctx context.Context, updateConfig UpdateConfig) (updated bool, err error) {
 	if !atomic.CompareAndSwapInt32(&u.updating, 0, 1) {
 		return false, ErrAlreadyStarted
 	}
@@ -113,7 +123,7 @@ func (u *Updater) Update(updateConfig UpdateConfig) (updated bool, err error) {
 
 	u.status.Set("Started, checking update")
 
-	version, err := u.getVersion(updateConfig)
+	version, err := u.getVersion(ctx, updateConfig)
 	if err != nil {
 		return false, err
 	}
@@ -125,25 +135,29 @@ func (u *Updater) Update(updateConfig UpdateConfig) (updated bool, err error) {
 
 	u.status.Set(fmt.Sprintf("Found version %q, downloading", version))
 
-	downloadedBinariesPath, err := u.download(updateConfig, version)
+	downloadedBinariesPath, err := u.download(ctx, updateConfig, version)
 	if err != nil {
 		return false, err
 	}
 
+	if err := ctx.Err(); err != nil {
+		u.removeFiles(downloadedBinariesPath)
+		return false, err
+	}
+
 	u.status.Set("Downloading completed, updating binaries")
 
 	currentBasePath := filepath.Dir(u.restartCtx.CmdPath())
-	if err := u.updateBinaries(downloadedBinariesPath, currentBasePath); err != nil {
+	backupPath := filepath.Join(currentBasePath, backupSubfolder)
+
+	if err := u.updateBinaries(downloadedBinariesPath, currentBasePath, backupPath); err != nil {
 		return false, err
 	}
 
 	u.status.Set("Binaries updated, restarting current process")
 
 	if err := u.restartCurrentProcess(); err != nil {
-		currentVisorPath := filepath.Join(currentBasePath, visorBinary)
-		oldVisorPath := filepath.Join(downloadedBinariesPath, visorBinary+oldSuffix)
-
-		u.restore(currentVisorPath, oldVisorPath)
+		u.restoreBinaries(currentBasePath, backupPath)
 
 		return false, err
 	}
@@ -157,6 +171,38 @@ func (u *Updater) Update(updateConfig UpdateConfig) (updated bool, err error) {
 	return true, nil
 }
 
+// Rollback restores binaries replaced by the last update and restarts the current process.
+// It returns false if there are no binaries to restore.
+// NOTE: Rollback may call os.Exit.
+func (u *Updater) Rollback() (rolledBack bool, err error) {
+	if !atomic.CompareAndSwapInt32(&u.updating, 0, 1) {
+		return false, ErrAlreadyStarted
+	}
+	defer atomic.StoreInt32(&u.updating, 0)
+
+	currentBasePath := filepath.Dir(u.restartCtx.CmdPath())
+	backupPath := filepath.Join(currentBasePath, backupSubfolder)
+
+	if _, err := os.Stat(filepath.Join(backupPath, visorBinary)); err != nil {
+		u.log.Infof("No binaries to roll back to")
+		return false, nil
+	}
+
+	u.status.Set("Restoring previous binaries")
+
+	u.restoreBinaries(currentBasePath, backupPath)
+
+	u.status.Set("Binaries restored, restarting current process")
+
+	if err := u.restartCurrentProcess(); err != nil {
+		return false, err
+	}
+
+	u.status.Set("")
+
+	return true, nil
+}
+
 // Status returns status of the current update operation.
 // An empty string is returned if no operation is running.
 func (u *Updater) Status() string {
@@ -167,9 +213,13 @@ func (u *Updater) Status() string {
 // If it is, the method returns the last available version.
 // Otherwise, it returns nil.
 func (u *Updater) UpdateAvailable(channel Channel) (*Version, error) {
+	return u.updateAvailable(context.Background(), channel)
+}
+
+func (u *Updater) updateAvailable(ctx context.Context, channel Channel) (*Version, error) {
 	u.log.Infof("Looking for updates")
 
-	latestVersion, err := latestVersion(channel)
+	latestVersion, err := u.source.LatestVersion(ctx, channel)
 	if err != nil {
 		return nil, err
 	}
@@ -184,10 +234,10 @@ func (u *Updater) UpdateAvailable(channel Channel) (*Version, error) {
 	return latestVersion, nil
 }
 
-func (u *Updater) getVersion(updateConfig UpdateConfig) (string, error) {
+func (u *Updater) getVersion(ctx context.Context, updateConfig UpdateConfig) (string, error) {
 	version := updateConfig.Version
 	if version == "" {
-		latestVersion, err := u.UpdateAvailable(updateConfig.Channel)
+		latestVersion, err := u.updateAvailable(ctx, updateConfig.Channel)
 		if err != nil {
 			return "", fmt.Errorf("failed to get last Skywire version: %w", err)
 		}
@@ -205,25 +255,32 @@ func (u *Updater) getVersion(updateConfig UpdateConfig) (string, error) {
 	return version, nil
 }
 
-func (u *Updater) updateBinaries(downloadedBinariesPath string, currentBasePath string) error {
+func (u *Updater) updateBinaries(downloadedBinariesPath, currentBasePath, backupPath string) error {
+	// Backup of the previous update is replaced entirely, so that it doesn't mix binaries of different versions.
+	u.removeFiles(backupPath)
+
+	if err := os.MkdirAll(backupPath, permRWX); err != nil {
+		return fmt.Errorf("failed to create backup folder: %w", err)
+	}
+
 	for _, app := range apps() {
-		if err := u.updateBinary(downloadedBinariesPath, u.appsPath, app); err != nil {
+		if err := u.updateBinary(downloadedBinariesPath, u.appsPath, backupPath, app); err != nil {
 			return fmt.Errorf("failed to update %s binary: %w", app, err)
 		}
 	}
 
-	if err := u.updateBinary(downloadedBinariesPath, currentBasePath, cliBinary); err != nil {
+	if err := u.updateBinary(downloadedBinariesPath, currentBasePath, backupPath, cliBinary); err != nil {
 		return fmt.Errorf("failed to update %s binary: %w", cliBinary, err)
 	}
 
-	if err := u.updateBinary(downloadedBinariesPath, currentBasePath, visorBinary); err != nil {
+	if err := u.updateBinary(downloadedBinariesPath, currentBasePath, backupPath, visorBinary); err != nil {
 		return fmt.Errorf("failed to update %s binary: %w", visorBinary, err)
 	}
 
 	return nil
 }
 
-func (u *Updater) updateBinary(downloadedBinariesPath, basePath, binary string) error {
+func (u *Updater) updateBinary(downloadedBinariesPath, basePath, backupPath, binary string) error {
 	downloadedBinaryPath := filepath.Join(downloadedBinariesPath, binary)
 	if _, err := os.Stat(downloadedBinaryPath); os.IsNotExist(err) {
 		downloadedBinaryPath = filepath.Join(downloadedBinariesPath, appsSubfolder, binary)
@@ -235,7 +292,7 @@ func (u *Updater) updateBinary(downloadedBinariesPath, basePath, binary string)
 	}
 
 	currentBinaryPath := filepath.Join(basePath, binary)
-	oldBinaryPath := downloadedBinaryPath + oldSuffix
+	oldBinaryPath := filepath.Join(backupPath, binary)
 
 	if _, err := os.Stat(oldBinaryPath); err == nil {
 		if err := os.Remove(oldBinaryPath); err != nil {
@@ -267,6 +324,16 @@ func (u *Updater) updateBinary(downloadedBinariesPath, basePath, binary string)
 	return nil
 }
 
+// restoreBinaries restores binaries kept in `backupPath`.
+func (u *Updater) restoreBinaries(currentBasePath, backupPath string) {
+	for _, app := range apps() {
+		u.restore(filepath.Join(u.appsPath, app), filepath.Join(backupPath, app))
+	}
+
+	u.restore(filepath.Join(currentBasePath, cliBinary), filepath.Join(backupPath, cliBinary))
+	u.restore(filepath.Join(currentBasePath, visorBinary), filepath.Join(backupPath, visorBinary))
+}
+
 // restore restores old binary file.
 func (u *Updater) restore(currentPath, oldPath string) {
 	if _, err := os.Stat(oldPath); err != nil {
@@ -280,20 +347,26 @@ func (u *Updater) restore(currentPath, oldPath string) {
 	}
 }
 
-func (u *Updater) download(updateConfig UpdateConfig, version string) (string, error) {
-	checksumsURL := fileURL(version, checksumsFilename)
-	if updateConfig.ChecksumsURL != "" {
-		checksumsURL = updateConfig.ChecksumsURL
+func (u *Updater) download(ctx context.Context, updateConfig UpdateConfig, version string) (string, error) {
+	checksums, err := u.downloadString(ctx, updateConfig.ChecksumsURL, version, checksumsFilename)
+	if err != nil {
+		return "", fmt.Errorf("failed to download checksums: %w", err)
 	}
 
-	u.log.Infof("Checksums file URL: %q", checksumsURL)
+	u.log.Infof("Checksums file downloaded")
 
-	checksums, err := u.downloadChecksums(checksumsURL)
+	signature, err := u.downloadString(ctx, signatureURL(updateConfig), version, signatureFilename)
 	if err != nil {
-		return "", fmt.Errorf("failed to download checksums: %w", err)
+		return "", fmt.Errorf("failed to download checksums signature: %w", err)
 	}
 
-	u.log.Infof("Checksums file downloaded")
+	u.status.Set("Verifying checksums signature")
+
+	if err := verifyChecksums([]byte(checksums), signature, u.releaseKeys); err != nil {
+		return "", fmt.Errorf("failed to verify release: %w", err)
+	}
+
+	u.log.Infof("Checksums signature is valid")
 
 	archiveFilename := archiveFilename(projectName, version, runtime.GOOS, runtime.GOARCH)
 	u.log.Infof("Archive filename: %v", archiveFilename)
@@ -305,16 +378,9 @@ func (u *Updater) download(updateConfig UpdateConfig, version string) (string, e
 
 	u.log.Infof("Archive checksum should be %q", checksum)
 
-	archiveURL := fileURL(version, archiveFilename)
-	if updateConfig.ArchiveURL != "" {
-		archiveURL = updateConfig.ArchiveURL
-	}
-
-	u.log.Infof("Downloading archive from %q", archiveURL)
-
-	archivePath, err := u.downloadFile(archiveURL, archiveFilename)
+	archivePath, err := u.downloadFile(ctx, updateConfig.ArchiveURL, version, archiveFilename)
 	if err != nil {
-		return "", fmt.Errorf("failed to download archive file from URL %q: %w", archiveURL, err)
+		return "", fmt.Errorf("failed to download archive file: %w", err)
 	}
 
 	u.log.Infof("Downloaded archive file to %q", archivePath)
@@ -405,22 +471,31 @@ func getChecksum(checksums, filename string) (string, error) {
 	return checksums[first:last], nil
 }
 
-func (u *Updater) downloadChecksums(url string) (checksums string, err error) {
-	resp, err := http.Get(url) // nolint:gosec
+// open opens release file `filename` of `version` from the source,
+// or from `url` if it's not empty.
+func (u *Updater) open(ctx context.Context, url, version, filename string) (io.ReadCloser, int64, error) {
+	if url != "" {
+		u.log.Infof("Downloading %s from %q", filename, url)
+		return openURL(ctx, url)
+	}
+
+	u.log.Infof("Downloading %s of %s", filename, version)
+
+	return u.source.Open(ctx, version, filename)
+}
+
+func (u *Updater) downloadString(ctx context.Context, url, version, filename string) (data string, err error) {
+	body, size, err := u.open(ctx, url, version, filename)
 	if err != nil {
 		return "", err
 	}
 
 	defer func() {
-		if closeErr := resp.Body.Close(); closeErr != nil && err == nil {
+		if closeErr := body.Close(); closeErr != nil && err == nil {
 			err = closeErr
 		}
 	}()
 
-	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("received bad status code: %d", resp.StatusCode)
-	}
-
 	capturer := stdio.NewCapturer()
 	stdoutWriter, err := capturer.CaptureStdout()
 	if err != nil {
@@ -433,14 +508,14 @@ func (u *Updater) downloadChecksums(url string) (checksums string, err error) {
 		}
 	}()
 
-	r := io.TeeReader(resp.Body, u.progressBar(stdoutWriter, resp.ContentLength, checksumsFilename))
+	r := io.TeeReader(body, u.progressBar(stdoutWriter, size, filename))
 
-	data, err := ioutil.ReadAll(r)
+	b, err := ioutil.ReadAll(r)
 	if err != nil {
 		return "", err
 	}
 
-	return string(data), nil
+	return string(b), nil
 }
 
 func (u *Updater) progressBar(w io.Writer, contentLength int64, filename string) io.Writer {
@@ -454,22 +529,18 @@ func (u *Updater) progressBar(w io.Writer, contentLength int64, filename string)
 	return progressbar.NewOptions64(contentLength, speed, completion, width, theme, desc, writer)
 }
 
-func (u *Updater) downloadFile(url, filename string) (path string, err error) {
-	resp, err := http.Get(url) // nolint:gosec
+func (u *Updater) downloadFile(ctx context.Context, url, version, filename string) (path string, err error) {
+	body, size, err := u.open(ctx, url, version, filename)
 	if err != nil {
 		return "", err
 	}
 
 	defer func() {
-		if closeErr := resp.Body.Close(); closeErr != nil && err == nil {
+		if closeErr := body.Close(); closeErr != nil && err == nil {
 			err = closeErr
 		}
 	}()
 
-	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("bad HTTP response status code %d", resp.StatusCode)
-	}
-
 	tmpDir := os.TempDir()
 	path = filepath.Join(tmpDir, filename)
 
@@ -497,9 +568,9 @@ func (u *Updater) downloadFile(url, filename string) (path string, err error) {
 		}
 	}()
 
-	out := io.MultiWriter(f, u.progressBar(stdoutWriter, resp.ContentLength, filename))
+	out := io.MultiWriter(f, u.progressBar(stdoutWriter, size, filename))
 
-	if _, err := io.Copy(out, resp.Body); err != nil {
+	if _, err := io.Copy(out, body); err != nil {
 		return "", err
 	}
 
@@ -518,6 +589,16 @@ func fileURL(version, filename string) string {
 	return releaseURL + "/download/" + version + "/" + filename
 }
 
+// signatureURL returns URL of the checksums signature, which is published along with
+// the checksums file unless set explicitly.
+func signatureURL(updateConfig UpdateConfig) string {
+	if updateConfig.SignatureURL == "" && updateConfig.ChecksumsURL != "" {
+		return updateConfig.ChecksumsURL + ".sig"
+	}
+
+	return updateConfig.SignatureURL
+}
+
 func archiveFilename(file, version, os, arch string) string {
 	return file + "-" + version + "-" + os + "-" + arch + archiveFormat
 }
@@ -532,47 +613,6 @@ func needUpdate(last *Version) bool {
 	return last.Cmp(current) > 0
 }
 
-func latestVersion(channel Channel) (*Version, error) {
-	ctx := context.Background()
-	client := github.NewClient(nil)
-
-	switch channel {
-	case ChannelStable:
-		release, _, err := client.Repositories.GetLatestRelease(ctx, owner, gitProjectName)
-		if err != nil {
-			return nil, err
-		}
-
-		if release.TagName == nil {
-			return nil, ErrTagNameEmpty
-		}
-
-		return VersionFromString(*release.TagName)
-
-	case ChannelTesting:
-		releases, _, err := client.Repositories.ListReleases(ctx, owner, gitProjectName, nil)
-		if err != nil {
-			return nil, err
-		}
-
-		if len(releases) == 0 {
-			return nil, ErrNoReleases
-		}
-
-		// Latest release should be the first one.
-		release := releases[0]
-
-		if release.TagName == nil {
-			return nil, ErrTagNameEmpty
-		}
-
-		return VersionFromString(*release.TagName)
-
-	default:
-		return nil, ErrUnknownChannel
-	}
-}
-
 func currentVersion() (*Version, error) {
 	return VersionFromString(buildinfo.Version())
 }
@@ -8,8 +8,12 @@ import (
 	"path/filepath"
 	"testing"
 
+	"github.com/skycoin/dmsg/cipher"
+	"github.com/skycoin/skycoin/src/util/logging"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+
+	"github.com/skycoin/skywire/pkg/skyenv"
 )
 
 func Test_getChecksum(t *testing.T) {
@@ -139,6 +143,37 @@ func Test_fileURL(t *testing.T) {
 	}
 }
 
+func Test_signatureURL(t *testing.T) {
+	tests := []struct {
+		name string
+		conf UpdateConfig
+		want string
+	}{
+		{
+			name: "Default",
+			conf: UpdateConfig{},
+			want: "",
+		},
+		{
+			name: "Derived from checksums URL",
+			conf: UpdateConfig{ChecksumsURL: "https://example.com/checksums.txt"},
+			want: "https://example.com/checksums.txt.sig",
+		},
+		{
+			name: "Explicit",
+			conf: UpdateConfig{ChecksumsURL: "https://example.com/checksums.txt", SignatureURL: "https://example.com/sig"},
+			want: "https://example.com/sig",
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.want, signatureURL(tc.conf))
+		})
+	}
+}
+
 func Test_binaryFilename(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -165,3 +200,103 @@ func Test_binaryFilename(t *testing.T) {
 		})
 	}
 }
+
+func Test_verifyChecksums(t *testing.T) {
+	pk, sk := cipher.GenerateKeyPair()
+	otherPK, otherSK := cipher.GenerateKeyPair()
+
+	checksums := []byte("2f505da2a905889aa978597814f91dbe32ee46fffe44657bd7af56a942d92470 skywire-v1.2.3-linux-amd64.tar.gz\n")
+
+	sig, err := SignChecksums(checksums, sk)
+	require.NoError(t, err)
+
+	otherSig, err := SignChecksums(checksums, otherSK)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name      string
+		checksums []byte
+		signature string
+		keys      []cipher.PubKey
+		wantErr   error
+	}{
+		{
+			name:      "Valid",
+			checksums: checksums,
+			signature: sig.Hex() + "\n",
+			keys:      []cipher.PubKey{otherPK, pk},
+		},
+		{
+			name:      "No keys",
+			checksums: checksums,
+			signature: sig.Hex(),
+			wantErr:   ErrNoReleaseKeys,
+		},
+		{
+			name:      "Unknown key",
+			checksums: checksums,
+			signature: otherSig.Hex(),
+			keys:      []cipher.PubKey{pk},
+			wantErr:   ErrInvalidSignature,
+		},
+		{
+			name:      "Tampered checksums",
+			checksums: append([]byte("0"), checksums[1:]...),
+			signature: sig.Hex(),
+			keys:      []cipher.PubKey{pk},
+			wantErr:   ErrInvalidSignature,
+		},
+		{
+			name:      "Malformed signature",
+			checksums: checksums,
+			signature: "not a signature",
+			keys:      []cipher.PubKey{pk},
+			wantErr:   ErrInvalidSignature,
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.wantErr, verifyChecksums(tc.checksums, tc.signature, tc.keys))
+		})
+	}
+}
+
+func Test_updateBinaries(t *testing.T) {
+	downloadedPath, basePath, appsPath := t.TempDir(), t.TempDir(), t.TempDir()
+	backupPath := filepath.Join(basePath, backupSubfolder)
+
+	write := func(path, data string) {
+		require.NoError(t, ioutil.WriteFile(path, []byte(data), 0600))
+	}
+
+	read := func(path string) string {
+		data, err := ioutil.ReadFile(path) // nolint: gosec
+		require.NoError(t, err)
+		return string(data)
+	}
+
+	write(filepath.Join(basePath, visorBinary), "old visor")
+	write(filepath.Join(downloadedPath, visorBinary), "new visor")
+	write(filepath.Join(appsPath, skyenv.SkychatName), "old skychat")
+	require.NoError(t, os.Mkdir(filepath.Join(downloadedPath, appsSubfolder), permRWX))
+	write(filepath.Join(downloadedPath, appsSubfolder, skyenv.SkychatName), "new skychat")
+
+	// Backup of an older update is dropped.
+	require.NoError(t, os.Mkdir(backupPath, permRWX))
+	write(filepath.Join(backupPath, cliBinary), "older cli")
+
+	u := New(logging.MustGetLogger("updater"), nil, appsPath, nil, nil)
+
+	require.NoError(t, u.updateBinaries(downloadedPath, basePath, backupPath))
+	assert.Equal(t, "new visor", read(filepath.Join(basePath, visorBinary)))
+	assert.Equal(t, "new skychat", read(filepath.Join(appsPath, skyenv.SkychatName)))
+	assert.Equal(t, "old visor", read(filepath.Join(backupPath, visorBinary)))
+	assert.NoFileExists(t, filepath.Join(backupPath, cliBinary))
+
+	u.restoreBinaries(basePath, backupPath)
+	assert.Equal(t, "old visor", read(filepath.Join(basePath, visorBinary)))
+	assert.Equal(t, "old skychat", read(filepath.Join(appsPath, skyenv.SkychatName)))
+	assert.NoFileExists(t, filepath.Join(backupPath, visorBinary))
+}
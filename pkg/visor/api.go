@@ -1,12 +1,17 @@
 package visor
 
 import (
+	"bytes"
 	"context"
 	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"net/http"
+	"os"
 	"os/exec"
+	"path/filepath"
 	"strings"
 	"sync"
 	"time"
@@ -15,11 +20,14 @@ import (
 	"github.com/skycoin/dmsg/buildinfo"
 	"github.com/skycoin/dmsg/cipher"
 
+	"github.com/skycoin/skywire/internal/vpn"
+	"github.com/skycoin/skywire/pkg/app/appbundle"
 	"github.com/skycoin/skywire/pkg/app/appserver"
 	"github.com/skycoin/skywire/pkg/app/launcher"
 	"github.com/skycoin/skywire/pkg/routing"
 	"github.com/skycoin/skywire/pkg/skyenv"
 	"github.com/skycoin/skywire/pkg/transport"
+	"github.com/skycoin/skywire/pkg/util/pathutil"
 	"github.com/skycoin/skywire/pkg/util/updater"
 	"github.com/skycoin/skywire/pkg/visor/dmsgtracker"
 )
@@ -41,6 +49,11 @@ type API interface {
 	SetAppPK(appName string, pk cipher.PubKey) error
 	SetAppSecure(appName string, isSecure bool) error
 	SetAppKillswitch(appName string, killswitch bool) error
+	SetAppIncludeRoutes(appName string, routes []string) error
+	SetAppExcludeRoutes(appName string, routes []string) error
+	InstallApp(bundle []byte, permissions []string) (*appbundle.Manifest, error)
+	UpgradeApp(bundle []byte, permissions []string) (*appbundle.Manifest, error)
+	UninstallApp(appName string) error
 	LogsSince(timestamp time.Time, appName string) ([]string, error)
 	GetAppConnectionsSummary(appName string) ([]appserver.ConnectionSummary, error)
 
@@ -62,7 +75,9 @@ type API interface {
 
 	Restart() error
 	Exec(command string) ([]byte, error)
-	Update(config updater.UpdateConfig) (bool, error)
+	Update(ctx context.Context, config updater.UpdateConfig) (bool, error)
+	CancelUpdate() (bool, error)
+	Rollback() (bool, error)
 	UpdateWithStatus(config updater.UpdateConfig) <-chan StatusMessage
 	UpdateAvailable(channel updater.Channel) (*updater.Version, error)
 	UpdateStatus() (string, error)
@@ -344,6 +359,39 @@ func (v *Visor) SetAppKillswitch(appName string, killswitch bool) error {
 	return nil
 }
 
+// SetAppIncludeRoutes implements API.
+func (v *Visor) SetAppIncludeRoutes(appName string, routes []string) error {
+	return v.setAppRoutes(appName, "-include", routes)
+}
+
+// SetAppExcludeRoutes implements API.
+func (v *Visor) SetAppExcludeRoutes(appName string, routes []string) error {
+	return v.setAppRoutes(appName, "-exclude", routes)
+}
+
+// setAppRoutes saves split tunneling routes of VPN client as a comma-separated arg.
+func (v *Visor) setAppRoutes(appName, argName string, routes []string) error {
+	if appName != skyenv.VPNClientName {
+		return fmt.Errorf("app %s is not allowed to set routes", appName)
+	}
+
+	for _, route := range routes {
+		if err := vpn.ValidateRoute(route); err != nil {
+			return fmt.Errorf("invalid route %q: %w", route, err)
+		}
+	}
+
+	v.log.Infof("Setting %s %s routes to %v", appName, argName, routes)
+
+	if err := v.conf.UpdateAppArg(v.appL, appName, argName, strings.Join(routes, ",")); err != nil {
+		return err
+	}
+
+	v.log.Infof("Updated %v %s routes", appName, argName)
+
+	return nil
+}
+
 // SetAppSecure implements API.
 func (v *Visor) SetAppSecure(appName string, isSecure bool) error {
 	if appName != skyenv.VPNServerName {
@@ -396,6 +444,187 @@ func (v *Visor) SetAppPK(appName string, pk cipher.PubKey) error {
 	return nil
 }
 
+// appManifestSuffix is the suffix of manifest files of apps installed from bundles, kept next to their binaries.
+const appManifestSuffix = ".manifest.json"
+
+// InstallApp implements API.
+// InstallApp installs an app from a signed bundle and registers it in the config.
+func (v *Visor) InstallApp(bundle []byte, permissions []string) (*appbundle.Manifest, error) {
+	b, err := v.readAppBundle(bundle, permissions)
+	if err != nil {
+		return nil, err
+	}
+
+	name := b.Manifest.Name
+
+	if _, ok := v.appL.AppState(name); ok {
+		return nil, fmt.Errorf("app %s is already installed", name)
+	}
+
+	if _, err := os.Stat(filepath.Join(v.conf.Launcher.BinPath, name)); err == nil {
+		return nil, fmt.Errorf("app binary %s already exists", name)
+	}
+
+	if err := v.placeApp(b); err != nil {
+		return nil, err
+	}
+
+	ac := launcher.AppConfig{
+		Name:        name,
+		Args:        b.Manifest.Args,
+		Port:        b.Manifest.Port,
+		Bundled:     true,
+		Permissions: b.Manifest.Permissions,
+	}
+
+	if err := v.conf.AddApp(v.appL, ac); err != nil {
+		v.removeAppFiles(name)
+		return nil, err
+	}
+
+	v.log.Infof("Installed app %s %s", name, b.Manifest.Version)
+
+	return &b.Manifest, nil
+}
+
+// UpgradeApp implements API.
+// UpgradeApp replaces an app installed from a bundle with a newer version, keeping its config.
+// The app is restarted if it's running.
+func (v *Visor) UpgradeApp(bundle []byte, permissions []string) (*appbundle.Manifest, error) {
+	b, err := v.readAppBundle(bundle, permissions)
+	if err != nil {
+		return nil, err
+	}
+
+	name := b.Manifest.Name
+
+	installed, err := v.installedApp(name)
+	if err != nil {
+		return nil, err
+	}
+
+	if !b.Manifest.NewerThan(installed.Version) {
+		return nil, fmt.Errorf("app %s %s is not newer than installed %s", name, b.Manifest.Version, installed.Version)
+	}
+
+	_, running := v.procM.ProcByName(name)
+	if running {
+		if err := v.StopApp(name); err != nil {
+			return nil, fmt.Errorf("failed to stop app: %w", err)
+		}
+	}
+
+	if err := v.placeApp(b); err != nil {
+		return nil, err
+	}
+
+	if err := v.conf.UpdateAppPermissions(v.appL, name, b.Manifest.Permissions); err != nil {
+		return nil, err
+	}
+
+	v.log.Infof("Upgraded app %s from %s to %s", name, installed.Version, b.Manifest.Version)
+
+	if running {
+		if err := v.StartApp(name); err != nil {
+			return nil, fmt.Errorf("failed to start app: %w", err)
+		}
+	}
+
+	return &b.Manifest, nil
+}
+
+// UninstallApp implements API.
+// UninstallApp stops and removes an app installed from a bundle.
+func (v *Visor) UninstallApp(appName string) error {
+	if _, err := v.installedApp(appName); err != nil {
+		return err
+	}
+
+	if _, ok := v.procM.ProcByName(appName); ok {
+		if err := v.StopApp(appName); err != nil {
+			return fmt.Errorf("failed to stop app: %w", err)
+		}
+	}
+
+	if err := v.conf.RemoveApp(v.appL, appName); err != nil {
+		return err
+	}
+
+	v.removeAppFiles(appName)
+	v.log.Infof("Uninstalled app %s", appName)
+
+	return nil
+}
+
+func (v *Visor) readAppBundle(bundle []byte, permissions []string) (*appbundle.Bundle, error) {
+	b, err := appbundle.Read(bytes.NewReader(bundle), v.conf.Launcher.BundlePKs)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := b.Manifest.CheckPermissions(permissions); err != nil {
+		return nil, err
+	}
+
+	return b, nil
+}
+
+// installedApp returns manifest of an app installed from a bundle.
+func (v *Visor) installedApp(appName string) (*appbundle.Manifest, error) {
+	data, err := ioutil.ReadFile(filepath.Join(v.conf.Launcher.BinPath, filepath.Base(appName)+appManifestSuffix))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("app %s is not installed from a bundle", appName)
+		}
+
+		return nil, err
+	}
+
+	var m appbundle.Manifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("failed to decode manifest of app %s: %w", appName, err)
+	}
+
+	return &m, nil
+}
+
+// placeApp writes binary and manifest of a bundled app into the apps directory.
+func (v *Visor) placeApp(b *appbundle.Bundle) error {
+	binPath := filepath.Join(v.conf.Launcher.BinPath, b.Manifest.Name)
+
+	// Binary is replaced by renaming, so a running copy is not affected.
+	tmpPath := binPath + ".tmp"
+	if err := ioutil.WriteFile(tmpPath, b.Binary, 0755); err != nil { // nolint:gosec
+		return fmt.Errorf("failed to write app binary: %w", err)
+	}
+
+	if err := os.Rename(tmpPath, binPath); err != nil {
+		_ = os.Remove(tmpPath) // nolint:errcheck
+		return fmt.Errorf("failed to place app binary: %w", err)
+	}
+
+	manifest, err := json.MarshalIndent(b.Manifest, "", "\t")
+	if err != nil {
+		return err
+	}
+
+	if err := pathutil.AtomicWriteFile(binPath+appManifestSuffix, manifest); err != nil {
+		return fmt.Errorf("failed to write app manifest: %w", err)
+	}
+
+	return nil
+}
+
+func (v *Visor) removeAppFiles(appName string) {
+	binPath := filepath.Join(v.conf.Launcher.BinPath, filepath.Base(appName))
+
+	for _, path := range []string{binPath, binPath + appManifestSuffix} {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			v.log.WithError(err).Warnf("Failed to remove %s", path)
+		}
+	}
+}
+
 // LogsSince implements API.
 func (v *Visor) LogsSince(timestamp time.Time, appName string) ([]string, error) {
 	proc, ok := v.procM.ProcByName(appName)
@@ -591,8 +820,26 @@ func (v *Visor) Exec(command string) ([]byte, error) {
 // Update updates visor.
 // It checks if visor update is available.
 // If it is, the method downloads a new visor versions, starts it and kills the current process.
-func (v *Visor) Update(updateConfig updater.UpdateConfig) (bool, error) {
-	updated, err := v.updater.Update(updateConfig)
+// Cancelling `ctx` aborts downloading the update.
+func (v *Visor) Update(ctx context.Context, updateConfig updater.UpdateConfig) (bool, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	v.updateMu.Lock()
+	if v.updateCancel != nil {
+		v.updateMu.Unlock()
+		return false, updater.ErrAlreadyStarted
+	}
+	v.updateCancel = cancel
+	v.updateMu.Unlock()
+
+	defer func() {
+		v.updateMu.Lock()
+		v.updateCancel = nil
+		v.updateMu.Unlock()
+	}()
+
+	updated, err := v.updater.Update(ctx, updateConfig)
 	if err != nil {
 		v.log.Errorf("Failed to update visor: %v", err)
 		return false, err
@@ -601,6 +848,36 @@ func (v *Visor) Update(updateConfig updater.UpdateConfig) (bool, error) {
 	return updated, nil
 }
 
+// CancelUpdate implements API.
+// CancelUpdate cancels the running update. It returns false if no update is running.
+// Update which already started replacing binaries runs to the end.
+func (v *Visor) CancelUpdate() (bool, error) {
+	v.updateMu.Lock()
+	defer v.updateMu.Unlock()
+
+	if v.updateCancel == nil {
+		return false, nil
+	}
+
+	v.updateCancel()
+	v.log.Infof("Update cancelled")
+
+	return true, nil
+}
+
+// Rollback implements API.
+// Rollback restores binaries replaced by the last update, starts the previous visor version and kills the current process.
+// It returns false if there is nothing to roll back to.
+func (v *Visor) Rollback() (bool, error) {
+	rolledBack, err := v.updater.Rollback()
+	if err != nil {
+		v.log.Errorf("Failed to roll back visor: %v", err)
+		return false, err
+	}
+
+	return rolledBack, nil
+}
+
 // UpdateWithStatus implements API.
 // UpdateWithStatus combines results of Update and UpdateStatus.
 func (v *Visor) UpdateWithStatus(config updater.UpdateConfig) <-chan StatusMessage {
@@ -644,7 +921,7 @@ func (v *Visor) UpdateWithStatus(config updater.UpdateConfig) <-chan StatusMessa
 			close(ch)
 		}()
 
-		updated, err := v.Update(config)
+		updated, err := v.Update(context.Background(), config)
 		if err != nil {
 			ch <- StatusMessage{
 				Text:    err.Error(),
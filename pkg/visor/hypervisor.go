@@ -10,6 +10,7 @@ import (
 	"math/rand"
 	"net/http"
 	"runtime"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
@@ -70,6 +71,8 @@ type Hypervisor struct {
 	visorMu      sync.Mutex
 	visorChanMux map[cipher.PubKey]*chanMux
 	selfConn     Conn
+	rolloutMu    sync.Mutex
+	rollouts     map[uuid.UUID]*rolloutJob
 }
 
 // New creates a new Hypervisor.
@@ -100,6 +103,7 @@ func New(config hypervisorconfig.Config, assets http.FileSystem, visor *Visor, d
 		mu:           new(sync.RWMutex),
 		visorChanMux: make(map[cipher.PubKey]*chanMux),
 		selfConn:     selfConn,
+		rollouts:     make(map[uuid.UUID]*rolloutJob),
 	}
 
 	return hv, nil
@@ -257,6 +261,11 @@ func (hv *Hypervisor) makeMux() chi.Router {
 				r.Get("/visors/{pk}/update/ws/running", hv.isVisorWSUpdateRunning())
 				r.Get("/visors/{pk}/update/available", hv.visorUpdateAvailable())
 				r.Get("/visors/{pk}/update/available/{channel}", hv.visorUpdateAvailable())
+				r.Get("/rollouts", hv.getRollouts())
+				r.Post("/rollouts", hv.postRollout())
+				r.Get("/rollouts/{id}", hv.getRollout())
+				r.Post("/rollouts/{id}/cancel", hv.cancelRollout())
+				r.Get("/rollouts/{id}/ws", hv.rolloutWS())
 			})
 		})
 
@@ -520,12 +529,15 @@ func (hv *Hypervisor) putApp() http.HandlerFunc {
 			Status     *int           `json:"status,omitempty"`
 			Passcode   *string        `json:"passcode,omitempty"`
 			PK         *cipher.PubKey `json:"pk,omitempty"`
+			// split tunneling routes of VPN client, empty list clears them
+			IncludeRoutes *[]string `json:"include_routes,omitempty"`
+			ExcludeRoutes *[]string `json:"exclude_routes,omitempty"`
 		}
 
 		shouldRestartApp := func(r req) bool {
 			// we restart the app if one of these fields was changed
 			return r.Killswitch != nil || r.Secure != nil || r.Passcode != nil ||
-				r.PK != nil
+				r.PK != nil || r.IncludeRoutes != nil || r.ExcludeRoutes != nil
 		}
 
 		var reqBody req
@@ -569,6 +581,20 @@ func (hv *Hypervisor) putApp() http.HandlerFunc {
 			}
 		}
 
+		if reqBody.IncludeRoutes != nil {
+			if err := ctx.API.SetAppIncludeRoutes(ctx.App.Name, *reqBody.IncludeRoutes); err != nil {
+				httputil.WriteJSON(w, r, http.StatusInternalServerError, err)
+				return
+			}
+		}
+
+		if reqBody.ExcludeRoutes != nil {
+			if err := ctx.API.SetAppExcludeRoutes(ctx.App.Name, *reqBody.ExcludeRoutes); err != nil {
+				httputil.WriteJSON(w, r, http.StatusInternalServerError, err)
+				return
+			}
+		}
+
 		if reqBody.Secure != nil {
 			if err := ctx.API.SetAppSecure(ctx.App.Name, *reqBody.Secure); err != nil {
 				httputil.WriteJSON(w, r, http.StatusInternalServerError, err)
@@ -1053,7 +1079,7 @@ func (hv *Hypervisor) updateVisor() http.HandlerFunc {
 			updateConfig.Channel = updater.ChannelStable
 		}
 
-		updated, err := ctx.API.Update(updateConfig)
+		updated, err := ctx.API.Update(r.Context(), updateConfig)
 		if err != nil {
 			httputil.WriteJSON(w, r, http.StatusInternalServerError, err)
 			return
@@ -1164,6 +1190,188 @@ func (hv *Hypervisor) isVisorWSUpdateRunning() http.HandlerFunc {
 	})
 }
 
+func (hv *Hypervisor) getRollouts() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		hv.rolloutMu.Lock()
+		rollouts := make([]Rollout, 0, len(hv.rollouts))
+		for _, job := range hv.rollouts {
+			rollouts = append(rollouts, job.snapshot())
+		}
+		hv.rolloutMu.Unlock()
+
+		sort.Slice(rollouts, func(i, j int) bool {
+			return rollouts[i].StartedAt.Before(rollouts[j].StartedAt)
+		})
+
+		httputil.WriteJSON(w, r, http.StatusOK, rollouts)
+	}
+}
+
+func (hv *Hypervisor) postRollout() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var conf RolloutConfig
+		if err := httputil.ReadJSON(r, &conf); err != nil {
+			if err != io.EOF {
+				hv.log(r).Warnf("postRollout request: %v", err)
+			}
+
+			httputil.WriteJSON(w, r, http.StatusBadRequest, usermanager.ErrMalformedRequest)
+
+			return
+		}
+
+		if len(conf.Visors) == 0 {
+			hv.mu.RLock()
+			for pk := range hv.visors {
+				conf.Visors = append(conf.Visors, pk)
+			}
+			hv.mu.RUnlock()
+		}
+
+		for _, pk := range conf.Visors {
+			if pk == hv.c.PK {
+				httputil.WriteJSON(w, r, http.StatusBadRequest, ErrRolloutSelf)
+				return
+			}
+		}
+
+		job, err := newRolloutJob(conf, hv.rolloutAPI)
+		if err != nil {
+			httputil.WriteJSON(w, r, http.StatusBadRequest, err)
+			return
+		}
+
+		hv.rolloutMu.Lock()
+		for _, other := range hv.rollouts {
+			if !other.finished() {
+				hv.rolloutMu.Unlock()
+				httputil.WriteJSON(w, r, http.StatusConflict, ErrRolloutRunning)
+
+				return
+			}
+		}
+
+		hv.rollouts[job.r.ID] = job
+		job.start()
+		hv.rolloutMu.Unlock()
+
+		httputil.WriteJSON(w, r, http.StatusOK, job.snapshot())
+	}
+}
+
+func (hv *Hypervisor) getRollout() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		job, ok := hv.rolloutJob(w, r)
+		if !ok {
+			return
+		}
+
+		httputil.WriteJSON(w, r, http.StatusOK, job.snapshot())
+	}
+}
+
+func (hv *Hypervisor) cancelRollout() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		job, ok := hv.rolloutJob(w, r)
+		if !ok {
+			return
+		}
+
+		if job.finished() {
+			httputil.WriteJSON(w, r, http.StatusConflict, ErrRolloutFinished)
+			return
+		}
+
+		job.stop()
+
+		httputil.WriteJSON(w, r, http.StatusOK, job.snapshot())
+	}
+}
+
+func (hv *Hypervisor) rolloutWS() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		job, ok := hv.rolloutJob(w, r)
+		if !ok {
+			return
+		}
+
+		ws, err := websocket.Accept(w, r, nil)
+		if err != nil {
+			hv.log(r).WithError(err).Warnf("Failed to upgrade to websocket.")
+			w.WriteHeader(http.StatusInternalServerError)
+
+			return
+		}
+
+		updates, unsubscribe := job.subscribe()
+		defer unsubscribe()
+
+		write := func(rollout Rollout) bool {
+			raw, err := json.Marshal(rollout)
+			if err != nil {
+				hv.log(r).WithError(err).Errorf("Failed to marshal JSON: %#v", rollout)
+				return false
+			}
+
+			if err := ws.Write(r.Context(), websocket.MessageText, raw); err != nil {
+				hv.log(r).WithError(err).Warnf("Failed to write WebSocket response")
+				return false
+			}
+
+			return true
+		}
+
+		last := job.snapshot()
+		if !write(last) {
+			return
+		}
+
+		for last = range updates {
+			if !write(last) {
+				return
+			}
+		}
+
+		// The final update may be dropped for a slow consumer.
+		if last.FinishedAt == nil && !write(job.snapshot()) {
+			return
+		}
+
+		if err := ws.Close(websocket.StatusNormalClosure, "finished"); err != nil {
+			hv.log(r).WithError(err).Warnf("failed to close WebSocket (normal)")
+		}
+	}
+}
+
+func (hv *Hypervisor) rolloutJob(w http.ResponseWriter, r *http.Request) (*rolloutJob, bool) {
+	id, err := uuidFromParam(r, "id")
+	if err != nil {
+		httputil.WriteJSON(w, r, http.StatusBadRequest, err)
+		return nil, false
+	}
+
+	hv.rolloutMu.Lock()
+	job, ok := hv.rollouts[id]
+	hv.rolloutMu.Unlock()
+
+	if !ok {
+		httputil.WriteJSON(w, r, http.StatusNotFound, ErrRolloutNotFound)
+		return nil, false
+	}
+
+	return job, true
+}
+
+// rolloutAPI returns API of a connected remote visor for rollouts.
+func (hv *Hypervisor) rolloutAPI(pk cipher.PubKey) (API, bool) {
+	conn, ok := hv.visorConn(pk)
+	if !ok {
+		return nil, false
+	}
+
+	return conn.API, true
+}
+
 func (hv *Hypervisor) visorUpdateAvailable() http.HandlerFunc {
 	return hv.withCtx(hv.visorCtx, func(w http.ResponseWriter, r *http.Request, ctx *httpCtx) {
 		channel := updater.Channel(chi.URLParam(r, "channel"))
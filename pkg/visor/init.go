@@ -26,6 +26,7 @@ import (
 	"github.com/skycoin/skywire/pkg/app/launcher"
 	"github.com/skycoin/skywire/pkg/routefinder/rfclient"
 	"github.com/skycoin/skywire/pkg/router"
+	"github.com/skycoin/skywire/pkg/routing"
 	"github.com/skycoin/skywire/pkg/setup/setupclient"
 	"github.com/skycoin/skywire/pkg/skyenv"
 	"github.com/skycoin/skywire/pkg/snet"
@@ -64,7 +65,19 @@ func initUpdater(v *Visor) bool {
 
 	v.restartCtx.SetCheckDelay(time.Duration(v.conf.RestartCheckDelay))
 	v.restartCtx.RegisterLogger(v.log)
-	v.updater = updater.New(v.log, v.restartCtx, v.conf.Launcher.BinPath)
+
+	source, err := updater.NewSource(v.conf.UpdateSource)
+	if err != nil {
+		return report(err)
+	}
+
+	// configs generated by older versions don't have release keys
+	releasePKs := v.conf.ReleasePKs
+	if len(releasePKs) == 0 {
+		releasePKs = skyenv.DefaultReleasePKs()
+	}
+
+	v.updater = updater.New(v.log, v.restartCtx, v.conf.Launcher.BinPath, releasePKs, source)
 	return report(nil)
 }
 
@@ -80,6 +93,11 @@ func initEventBroadcaster(v *Visor) bool {
 	})
 
 	v.ebc = ebc
+
+	v.conf.OnChange(func(appName string) {
+		v.broadcastEvent(appevent.ConfigChangedData{App: appName})
+	})
+
 	return report(nil)
 }
 
@@ -89,6 +107,7 @@ func initSNet(v *Visor) bool {
 	nc := snet.NetworkConfigs{
 		Dmsg: v.conf.Dmsg,
 		STCP: v.conf.STCP,
+		SWS:  v.conf.SWS,
 	}
 
 	conf := snet.Config{
@@ -98,6 +117,7 @@ func initSNet(v *Visor) bool {
 		NetworkConfigs: nc,
 		ServiceDisc:    v.serviceDisc,
 		PublicTrusted:  v.conf.PublicTrustedVisor,
+		DisableQUIC:    v.conf.Transport.DisableQUIC,
 	}
 
 	n, err := snet.New(conf, v.ebc)
@@ -199,6 +219,14 @@ func initTransport(v *Visor) bool {
 		}
 	})
 
+	tpM.OnTPStatusChanged(func(tp *transport.ManagedTransport, isUp bool) {
+		if isUp {
+			v.broadcastEvent(appevent.TransportUpData{TpID: tp.Entry.ID, TpType: tp.Type(), RemotePK: tp.Remote()})
+		} else {
+			v.broadcastEvent(appevent.TransportDownData{TpID: tp.Entry.ID, TpType: tp.Type(), RemotePK: tp.Remote()})
+		}
+	})
+
 	ctx, cancel := context.WithCancel(context.Background())
 	wg := new(sync.WaitGroup)
 	wg.Add(1)
@@ -234,8 +262,47 @@ func initRouter(v *Visor) bool {
 		RouteGroupDialer: setupclient.NewSetupNodeDialer(),
 		SetupNodes:       conf.SetupNodes,
 		RulesGCInterval:  0, // TODO
+		OnRouteGroup: func(desc routing.RouteDescriptor, established bool) {
+			local, remote := desc.Dst(), desc.Src()
+			if established {
+				v.broadcastEvent(appevent.RouteGroupUpData{RemotePK: remote.PubKey, LocalPort: local.Port, RemotePort: remote.Port})
+			} else {
+				v.broadcastEvent(appevent.RouteGroupCloseData{RemotePK: remote.PubKey, LocalPort: local.Port, RemotePort: remote.Port})
+			}
+		},
+	}
+
+	if conf.Table != nil {
+		switch conf.Table.Type {
+		case visorconfig.BBoltRoutingTable:
+			rt, err := routing.NewBBoltTable(conf.Table.Location)
+			if err != nil {
+				return report(fmt.Errorf("failed to create %s routing table: %w", visorconfig.BBoltRoutingTable, err))
+			}
+
+			rConf.RoutingTable = rt
+		case visorconfig.MemoryRoutingTable, "":
+		default:
+			return report(fmt.Errorf("invalid routing table type: %s", conf.Table.Type))
+		}
 	}
 
+	dialOpts := router.DefaultDialOptions()
+
+	if conf.MaxRoutes > 1 {
+		dialOpts.MaxForwardRts = conf.MaxRoutes
+		dialOpts.MaxConsumeRts = conf.MaxRoutes
+	}
+
+	switch scheduler := router.Scheduler(conf.RouteScheduler); scheduler {
+	case router.SchedulerFailover, router.SchedulerRoundRobin, router.SchedulerLatency, "":
+		dialOpts.Scheduler = scheduler
+	default:
+		return report(fmt.Errorf("invalid route scheduler: %s", conf.RouteScheduler))
+	}
+
+	rConf.DialOptions = dialOpts
+
 	r, err := router.New(v.net, &rConf)
 	if err != nil {
 		return report(fmt.Errorf("failed to create router: %w", err))
@@ -317,6 +384,11 @@ func initLauncher(v *Visor) bool {
 		return report(fmt.Errorf("failed to start launcher: %w", err))
 	}
 
+	// Pushed after the proc manager, so scheduled app restarts are cancelled before it's closed.
+	v.pushCloseStack("launcher", func() bool {
+		return report(launch.Close())
+	})
+
 	err = launch.AutoStart(map[string]func() ([]string, error){
 		skyenv.VPNClientName: func() ([]string, error) { return makeVPNEnvs(v.conf, v.net, v.tpM.STCPRRemoteAddrs()) },
 		skyenv.VPNServerName: func() ([]string, error) { return makeVPNEnvs(v.conf, v.net, nil) },
@@ -0,0 +1,581 @@
+package visor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/rpc"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/skycoin/dmsg/cipher"
+	"github.com/skycoin/skycoin/src/util/logging"
+
+	"github.com/skycoin/skywire/pkg/util/updater"
+	"github.com/skycoin/skywire/pkg/visor/visorconfig"
+)
+
+const (
+	defaultRolloutCanaryPercent = 10
+	defaultRolloutBatchSize     = 10
+	defaultRolloutVisorTimeout  = 10 * time.Minute
+	rolloutPollInterval         = 5 * time.Second
+	rolloutSubscriberBuffer     = 16
+)
+
+var (
+	// ErrRolloutRunning is returned when a rollout is requested while another one is running.
+	ErrRolloutRunning = errors.New("another rollout is running")
+	// ErrRolloutNoVersion is returned when rollout target version is not set.
+	ErrRolloutNoVersion = errors.New("rollout target version is not set")
+	// ErrRolloutNoVisors is returned when there are no visors to roll out to.
+	ErrRolloutNoVisors = errors.New("no visors to roll out to")
+	// ErrRolloutSelf is returned when the hypervisor's own visor is included into a rollout,
+	// updating it would restart the hypervisor running the rollout.
+	ErrRolloutSelf = errors.New("hypervisor's own visor cannot be included into a rollout")
+	// ErrRolloutNotFound is returned when rollout of the given ID does not exist.
+	ErrRolloutNotFound = errors.New("rollout not found")
+	// ErrRolloutFinished is returned when cancelling a rollout which is already finished.
+	ErrRolloutFinished = errors.New("rollout is already finished")
+)
+
+// RolloutState is a state of a rollout.
+type RolloutState string
+
+// Rollout states.
+const (
+	RolloutRunning   RolloutState = "running"
+	RolloutSucceeded RolloutState = "succeeded"
+	RolloutHalted    RolloutState = "halted"
+	RolloutCancelled RolloutState = "cancelled"
+)
+
+// RolloutVisorState is a state of a single visor in a rollout.
+type RolloutVisorState string
+
+// Rollout visor states.
+const (
+	RolloutVisorPending     RolloutVisorState = "pending"
+	RolloutVisorUpdating    RolloutVisorState = "updating"
+	RolloutVisorUpdated     RolloutVisorState = "updated"
+	RolloutVisorFailed      RolloutVisorState = "failed"
+	RolloutVisorRollingBack RolloutVisorState = "rolling_back"
+	RolloutVisorRolledBack  RolloutVisorState = "rolled_back"
+)
+
+// RolloutConfig configures a staged rollout of a release over visors connected to the hypervisor.
+type RolloutConfig struct {
+	Version       string               `json:"version"`                  // target version
+	Visors        []cipher.PubKey      `json:"visors,omitempty"`         // visors to update, all connected visors if empty
+	CanaryPercent int                  `json:"canary_percent,omitempty"` // share of visors updated in the first wave, 10 by default
+	BatchSize     int                  `json:"batch_size,omitempty"`     // number of visors updated in every next wave, 10 by default
+	Timeout       visorconfig.Duration `json:"timeout,omitempty"`        // time for a visor to come back healthy with the target version, 10m by default
+	Rollback      bool                 `json:"rollback,omitempty"`       // roll visors of a failed wave back to their previous versions
+}
+
+// RolloutVisor is a progress of a single visor in a rollout.
+type RolloutVisor struct {
+	PK          cipher.PubKey     `json:"pk"`
+	Wave        int               `json:"wave"`
+	PrevVersion string            `json:"prev_version,omitempty"`
+	State       RolloutVisorState `json:"state"`
+	Error       string            `json:"error,omitempty"`
+}
+
+// Rollout is a snapshot of a rollout job.
+type Rollout struct {
+	ID         uuid.UUID      `json:"id"`
+	Config     RolloutConfig  `json:"config"`
+	State      RolloutState   `json:"state"`
+	Wave       int            `json:"wave"`
+	Waves      int            `json:"waves"`
+	Visors     []RolloutVisor `json:"visors"`
+	Error      string         `json:"error,omitempty"`
+	StartedAt  time.Time      `json:"started_at"`
+	FinishedAt *time.Time     `json:"finished_at,omitempty"`
+}
+
+// rolloutWaves splits `n` visors into waves: a canary of `canaryPercent` percent of visors
+// (at least one) followed by batches of `batchSize` visors. Returned values are wave sizes.
+func rolloutWaves(n, canaryPercent, batchSize int) []int {
+	if n == 0 {
+		return nil
+	}
+
+	canary := (n*canaryPercent + 99) / 100
+	if canary < 1 {
+		canary = 1
+	}
+
+	if canary > n {
+		canary = n
+	}
+
+	waves := []int{canary}
+
+	for left := n - canary; left > 0; left -= batchSize {
+		if left < batchSize {
+			waves = append(waves, left)
+			break
+		}
+
+		waves = append(waves, batchSize)
+	}
+
+	return waves
+}
+
+// rolloutJob runs a rollout and keeps its progress.
+type rolloutJob struct {
+	log     *logging.Logger
+	api     func(pk cipher.PubKey) (API, bool)
+	pks     []cipher.PubKey // visors in the order of waves
+	timeout time.Duration
+	poll    time.Duration
+	cancel  context.CancelFunc
+	done    chan struct{}
+
+	mu   sync.Mutex
+	r    Rollout
+	subs map[chan Rollout]struct{}
+}
+
+func newRolloutJob(conf RolloutConfig, api func(pk cipher.PubKey) (API, bool)) (*rolloutJob, error) {
+	if conf.Version == "" {
+		return nil, ErrRolloutNoVersion
+	}
+
+	if len(conf.Visors) == 0 {
+		return nil, ErrRolloutNoVisors
+	}
+
+	if conf.CanaryPercent <= 0 || conf.CanaryPercent > 100 {
+		conf.CanaryPercent = defaultRolloutCanaryPercent
+	}
+
+	if conf.BatchSize <= 0 {
+		conf.BatchSize = defaultRolloutBatchSize
+	}
+
+	if conf.Timeout <= 0 {
+		conf.Timeout = visorconfig.Duration(defaultRolloutVisorTimeout)
+	}
+
+	waves := rolloutWaves(len(conf.Visors), conf.CanaryPercent, conf.BatchSize)
+
+	visors := make([]RolloutVisor, 0, len(conf.Visors))
+	for wave, size := range waves {
+		for i := 0; i < size; i++ {
+			visors = append(visors, RolloutVisor{
+				PK:    conf.Visors[len(visors)],
+				Wave:  wave,
+				State: RolloutVisorPending,
+			})
+		}
+	}
+
+	pks := make([]cipher.PubKey, len(visors))
+	for i, v := range visors {
+		pks[i] = v.PK
+	}
+
+	id := uuid.New()
+
+	return &rolloutJob{
+		log:     logging.MustGetLogger("rollout:" + id.String()),
+		api:     api,
+		pks:     pks,
+		timeout: time.Duration(conf.Timeout),
+		poll:    rolloutPollInterval,
+		done:    make(chan struct{}),
+		r: Rollout{
+			ID:        id,
+			Config:    conf,
+			State:     RolloutRunning,
+			Waves:     len(waves),
+			Visors:    visors,
+			StartedAt: time.Now(),
+		},
+		subs: make(map[chan Rollout]struct{}),
+	}, nil
+}
+
+// start runs the rollout in background.
+func (j *rolloutJob) start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	j.cancel = cancel
+
+	go func() {
+		defer close(j.done)
+		defer cancel()
+
+		j.run(ctx)
+	}()
+}
+
+// stop cancels the rollout and waits for it to finish.
+func (j *rolloutJob) stop() {
+	j.cancel()
+	<-j.done
+}
+
+// finished returns whether the rollout is finished.
+func (j *rolloutJob) finished() bool {
+	select {
+	case <-j.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// snapshot returns a copy of the current rollout progress.
+func (j *rolloutJob) snapshot() Rollout {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	return j.snapshotLocked()
+}
+
+func (j *rolloutJob) snapshotLocked() Rollout {
+	r := j.r
+	r.Visors = append([]RolloutVisor(nil), j.r.Visors...)
+
+	return r
+}
+
+// subscribe returns a channel receiving rollout snapshots on every change.
+// The channel is closed once the rollout finishes. Slow subscribers may miss intermediate snapshots.
+func (j *rolloutJob) subscribe() (<-chan Rollout, func()) {
+	ch := make(chan Rollout, rolloutSubscriberBuffer)
+
+	j.mu.Lock()
+	if j.r.FinishedAt != nil {
+		close(ch)
+	} else {
+		j.subs[ch] = struct{}{}
+	}
+	j.mu.Unlock()
+
+	unsubscribe := func() {
+		j.mu.Lock()
+		if _, ok := j.subs[ch]; ok {
+			delete(j.subs, ch)
+			close(ch)
+		}
+		j.mu.Unlock()
+	}
+
+	return ch, unsubscribe
+}
+
+// update applies `fn` to the rollout and notifies subscribers.
+func (j *rolloutJob) update(fn func(r *Rollout)) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	fn(&j.r)
+
+	r := j.snapshotLocked()
+	for ch := range j.subs {
+		select {
+		case ch <- r:
+		default:
+		}
+	}
+}
+
+func (j *rolloutJob) setVisor(i int, state RolloutVisorState, err error) {
+	j.update(func(r *Rollout) {
+		r.Visors[i].State = state
+		if err != nil {
+			r.Visors[i].Error = err.Error()
+		}
+	})
+}
+
+func (j *rolloutJob) finish(state RolloutState, err error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	now := time.Now()
+	j.r.State = state
+	j.r.FinishedAt = &now
+
+	if err != nil {
+		j.r.Error = err.Error()
+	}
+
+	r := j.snapshotLocked()
+	for ch := range j.subs {
+		select {
+		case ch <- r:
+		default:
+		}
+
+		delete(j.subs, ch)
+		close(ch)
+	}
+}
+
+func (j *rolloutJob) run(ctx context.Context) {
+	r := j.snapshot()
+	j.log.Infof("Rolling out version %q to %d visors in %d waves", r.Config.Version, len(r.Visors), r.Waves)
+
+	start := 0
+
+	for wave := 0; wave < r.Waves; wave++ {
+		end := start
+		for end < len(r.Visors) && r.Visors[end].Wave == wave {
+			end++
+		}
+
+		j.update(func(r *Rollout) { r.Wave = wave })
+		j.log.Infof("Starting wave %d/%d of %d visors", wave+1, r.Waves, end-start)
+
+		failed := j.runWave(ctx, start, end, func(i int) error {
+			return j.updateVisor(ctx, i, r.Config.Version)
+		})
+
+		if ctx.Err() != nil {
+			j.log.Infof("Rollout cancelled")
+			j.finish(RolloutCancelled, nil)
+
+			return
+		}
+
+		if failed > 0 {
+			err := fmt.Errorf("wave %d: %d of %d visors failed", wave+1, failed, end-start)
+			j.log.WithError(err).Warn("Halting rollout")
+
+			if r.Config.Rollback {
+				j.rollbackWave(ctx, start, end)
+			}
+
+			j.finish(RolloutHalted, err)
+
+			return
+		}
+
+		start = end
+	}
+
+	j.log.Infof("Rollout succeeded")
+	j.finish(RolloutSucceeded, nil)
+}
+
+// runWave runs `fn` for visors [start, end) concurrently and returns the number of failures.
+func (j *rolloutJob) runWave(ctx context.Context, start, end int, fn func(i int) error) int {
+	errCh := make(chan error, end-start)
+
+	var wg sync.WaitGroup
+	wg.Add(end - start)
+
+	for i := start; i < end; i++ {
+		go func(i int) {
+			defer wg.Done()
+
+			if err := fn(i); err != nil {
+				j.log.WithError(err).Warnf("Visor %s failed", j.pks[i])
+				errCh <- err
+			}
+		}(i)
+	}
+
+	wg.Wait()
+	close(errCh)
+
+	return len(errCh)
+}
+
+// updateVisor updates visor `i` to `version` and waits for it to come back healthy.
+func (j *rolloutJob) updateVisor(ctx context.Context, i int, version string) error {
+	pk := j.pks[i]
+
+	api, ok := j.api(pk)
+	if !ok {
+		err := fmt.Errorf("visor %s is not connected", pk)
+		j.setVisor(i, RolloutVisorFailed, err)
+
+		return err
+	}
+
+	summary, err := api.Summary()
+	if err != nil {
+		err = fmt.Errorf("failed to get summary: %w", err)
+		j.setVisor(i, RolloutVisorFailed, err)
+
+		return err
+	}
+
+	prevVersion := ""
+	if summary.BuildInfo != nil {
+		prevVersion = summary.BuildInfo.Version
+	}
+
+	j.update(func(r *Rollout) {
+		r.Visors[i].PrevVersion = prevVersion
+		r.Visors[i].State = RolloutVisorUpdating
+	})
+
+	if !sameVersion(prevVersion, version) {
+		if err := j.installVersion(ctx, pk, api, version); err != nil {
+			if ctx.Err() != nil {
+				err = fmt.Errorf("cancelled, visor may have already replaced its binaries: %w", err)
+			}
+
+			j.setVisor(i, RolloutVisorFailed, err)
+			return err
+		}
+	}
+
+	j.setVisor(i, RolloutVisorUpdated, nil)
+
+	return nil
+}
+
+// rollbackWave restores visors [start, end) which were touched by the failed wave to the binaries they ran before.
+func (j *rolloutJob) rollbackWave(ctx context.Context, start, end int) {
+	r := j.snapshot()
+
+	j.runWave(ctx, start, end, func(i int) error {
+		v := r.Visors[i]
+		if v.PrevVersion == "" || v.State == RolloutVisorPending || sameVersion(v.PrevVersion, r.Config.Version) {
+			return nil
+		}
+
+		j.setVisor(i, RolloutVisorRollingBack, nil)
+
+		api, ok := j.api(v.PK)
+		if !ok {
+			err := fmt.Errorf("rollback: visor %s is not connected", v.PK)
+			j.setVisor(i, RolloutVisorFailed, err)
+
+			return err
+		}
+
+		if err := j.restoreVersion(ctx, v.PK, api, v.PrevVersion); err != nil {
+			err = fmt.Errorf("rollback: %w", err)
+			j.setVisor(i, RolloutVisorFailed, err)
+
+			return err
+		}
+
+		j.setVisor(i, RolloutVisorRolledBack, nil)
+
+		return nil
+	})
+}
+
+// installVersion updates visor `pk` to `version` with the updater and waits for it to come back healthy.
+func (j *rolloutJob) installVersion(ctx context.Context, pk cipher.PubKey, api API, version string) error {
+	// Visor restarts after updating, so the call may be interrupted by the connection drop.
+	if _, err := api.Update(ctx, updater.UpdateConfig{Version: version}); err != nil && !isConnDropped(err) {
+		return fmt.Errorf("failed to update: %w", err)
+	}
+
+	return j.waitHealthy(ctx, pk, version)
+}
+
+// restoreVersion rolls visor `pk` back to binaries kept from the last update and waits for it
+// to come back healthy running `version`. Falls back to installing `version` if visor has no binaries kept.
+func (j *rolloutJob) restoreVersion(ctx context.Context, pk cipher.PubKey, api API, version string) error {
+	rolledBack, err := api.Rollback()
+	if err != nil && !isConnDropped(err) {
+		return fmt.Errorf("failed to roll back: %w", err)
+	}
+
+	if err == nil && !rolledBack {
+		j.log.WithField("visor", pk).Warnf("No binaries to roll back to, installing %s", version)
+		return j.installVersion(ctx, pk, api, version)
+	}
+
+	return j.waitHealthy(ctx, pk, version)
+}
+
+// waitHealthy waits for visor `pk` to report `version` and healthy services.
+func (j *rolloutJob) waitHealthy(ctx context.Context, pk cipher.PubKey, version string) error {
+	ctx, cancel := context.WithTimeout(ctx, j.timeout)
+	defer cancel()
+
+	ticker := time.NewTicker(j.poll)
+	defer ticker.Stop()
+
+	lastErr := fmt.Errorf("visor %s did not reconnect", pk)
+
+	for {
+		err := checkVisor(j.api, pk, version)
+		if err == nil {
+			return nil
+		}
+
+		lastErr = err
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("visor did not become healthy in %s: %w", j.timeout, lastErr)
+		case <-ticker.C:
+		}
+	}
+}
+
+// checkVisor checks that visor `pk` is connected, runs `version` and its services are healthy.
+func checkVisor(apiFn func(pk cipher.PubKey) (API, bool), pk cipher.PubKey, version string) error {
+	api, ok := apiFn(pk)
+	if !ok {
+		return fmt.Errorf("visor %s is not connected", pk)
+	}
+
+	summary, err := api.Summary()
+	if err != nil {
+		return fmt.Errorf("failed to get summary: %w", err)
+	}
+
+	if summary.BuildInfo == nil || !sameVersion(summary.BuildInfo.Version, version) {
+		current := ""
+		if summary.BuildInfo != nil {
+			current = summary.BuildInfo.Version
+		}
+
+		return fmt.Errorf("visor runs version %q instead of %q", current, version)
+	}
+
+	health, err := api.Health()
+	if err != nil {
+		return fmt.Errorf("failed to get health: %w", err)
+	}
+
+	statuses := map[string]int{
+		"transport_discovery": health.TransportDiscovery,
+		"route_finder":        health.RouteFinder,
+		"setup_node":          health.SetupNode,
+		"uptime_tracker":      health.UptimeTracker,
+		"address_resolver":    health.AddressResolver,
+	}
+
+	for service, status := range statuses {
+		if status >= http.StatusInternalServerError {
+			return fmt.Errorf("service %s is unhealthy: status %d", service, status)
+		}
+	}
+
+	return nil
+}
+
+func sameVersion(v1, v2 string) bool {
+	ver1, err1 := updater.VersionFromString(v1)
+	ver2, err2 := updater.VersionFromString(v2)
+
+	if err1 != nil || err2 != nil {
+		return v1 == v2
+	}
+
+	return ver1.Cmp(ver2) == 0
+}
+
+func isConnDropped(err error) bool {
+	return errors.Is(err, rpc.ErrShutdown) || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF)
+}
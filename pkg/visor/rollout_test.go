@@ -0,0 +1,265 @@
+package visor
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/skycoin/dmsg/buildinfo"
+	"github.com/skycoin/dmsg/cipher"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/skycoin/skywire/pkg/util/updater"
+	"github.com/skycoin/skywire/pkg/visor/visorconfig"
+)
+
+func TestRolloutWaves(t *testing.T) {
+	tests := []struct {
+		name          string
+		n             int
+		canaryPercent int
+		batchSize     int
+		want          []int
+	}{
+		{name: "no visors", n: 0, canaryPercent: 10, batchSize: 10, want: nil},
+		{name: "canary at least one", n: 5, canaryPercent: 10, batchSize: 2, want: []int{1, 2, 2}},
+		{name: "canary rounded up", n: 25, canaryPercent: 10, batchSize: 10, want: []int{3, 10, 10, 2}},
+		{name: "canary of all", n: 4, canaryPercent: 100, batchSize: 10, want: []int{4}},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.want, rolloutWaves(tc.n, tc.canaryPercent, tc.batchSize))
+		})
+	}
+}
+
+// fakeRolloutVisor is a visor which installs any version instantly, versions in `broken` are unhealthy.
+// If `hang` is set, updates never complete until cancelled.
+type fakeRolloutVisor struct {
+	API
+
+	mu          sync.Mutex
+	version     string
+	prevVersion string
+	updates     []string
+	rollbacks   int
+	broken      map[string]bool
+	hang        bool
+}
+
+func (v *fakeRolloutVisor) Update(ctx context.Context, config updater.UpdateConfig) (bool, error) {
+	if v.hang {
+		<-ctx.Done()
+		return false, ctx.Err()
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	v.prevVersion = v.version
+	v.version = config.Version
+	v.updates = append(v.updates, config.Version)
+
+	return true, nil
+}
+
+func (v *fakeRolloutVisor) Rollback() (bool, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if v.prevVersion == "" {
+		return false, nil
+	}
+
+	v.version, v.prevVersion = v.prevVersion, ""
+	v.rollbacks++
+
+	return true, nil
+}
+
+func (v *fakeRolloutVisor) Summary() (*Summary, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	return &Summary{BuildInfo: &buildinfo.Info{Version: v.version}}, nil
+}
+
+func (v *fakeRolloutVisor) Health() (*HealthInfo, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	status := http.StatusOK
+	if v.broken[v.version] {
+		status = http.StatusInternalServerError
+	}
+
+	return &HealthInfo{TransportDiscovery: status}, nil
+}
+
+func (v *fakeRolloutVisor) getRollbacks() int {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	return v.rollbacks
+}
+
+func (v *fakeRolloutVisor) getUpdates() []string {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	return append([]string(nil), v.updates...)
+}
+
+func runFakeRollout(t *testing.T, conf RolloutConfig, visors map[cipher.PubKey]*fakeRolloutVisor) Rollout {
+	job, err := newRolloutJob(conf, func(pk cipher.PubKey) (API, bool) {
+		v, ok := visors[pk]
+		return v, ok
+	})
+	require.NoError(t, err)
+
+	job.poll = time.Millisecond
+
+	updates, unsubscribe := job.subscribe()
+	defer unsubscribe()
+
+	job.start()
+
+	select {
+	case <-job.done:
+	case <-time.After(10 * time.Second):
+		t.Fatal("rollout did not finish")
+	}
+
+	// Channel is closed when the rollout finishes.
+	for range updates {
+	}
+
+	return job.snapshot()
+}
+
+func TestRollout(t *testing.T) {
+	const (
+		oldVersion = "v1.0.0"
+		newVersion = "v1.1.0"
+	)
+
+	makeVisors := func(n int, broken map[string]bool) ([]cipher.PubKey, map[cipher.PubKey]*fakeRolloutVisor) {
+		pks := make([]cipher.PubKey, n)
+		visors := make(map[cipher.PubKey]*fakeRolloutVisor, n)
+
+		for i := range pks {
+			pks[i], _ = cipher.GenerateKeyPair()
+			visors[pks[i]] = &fakeRolloutVisor{version: oldVersion, broken: broken}
+		}
+
+		return pks, visors
+	}
+
+	t.Run("succeeded", func(t *testing.T) {
+		pks, visors := makeVisors(5, nil)
+
+		r := runFakeRollout(t, RolloutConfig{Version: newVersion, Visors: pks, CanaryPercent: 20, BatchSize: 2}, visors)
+
+		assert.Equal(t, RolloutSucceeded, r.State)
+		assert.Equal(t, 3, r.Waves)
+		assert.NotNil(t, r.FinishedAt)
+
+		for _, v := range r.Visors {
+			assert.Equal(t, RolloutVisorUpdated, v.State)
+			assert.Equal(t, oldVersion, v.PrevVersion)
+			assert.Equal(t, []string{newVersion}, visors[v.PK].getUpdates())
+		}
+	})
+
+	t.Run("halted with rollback", func(t *testing.T) {
+		pks, visors := makeVisors(5, map[string]bool{newVersion: true})
+
+		conf := RolloutConfig{
+			Version:       newVersion,
+			Visors:        pks,
+			CanaryPercent: 20,
+			BatchSize:     2,
+			Timeout:       visorconfig.Duration(50 * time.Millisecond),
+			Rollback:      true,
+		}
+
+		r := runFakeRollout(t, conf, visors)
+
+		assert.Equal(t, RolloutHalted, r.State)
+		assert.NotEmpty(t, r.Error)
+		assert.Equal(t, 0, r.Wave)
+
+		// Only the canary is touched and rolled back to the kept binaries.
+		assert.Equal(t, RolloutVisorRolledBack, r.Visors[0].State)
+		assert.NotEmpty(t, r.Visors[0].Error)
+		assert.Equal(t, []string{newVersion}, visors[r.Visors[0].PK].getUpdates())
+		assert.Equal(t, 1, visors[r.Visors[0].PK].getRollbacks())
+
+		for _, v := range r.Visors[1:] {
+			assert.Equal(t, RolloutVisorPending, v.State)
+			assert.Empty(t, visors[v.PK].getUpdates())
+		}
+	})
+
+	t.Run("stopped while updating", func(t *testing.T) {
+		pks, visors := makeVisors(2, nil)
+		for _, v := range visors {
+			v.hang = true
+		}
+
+		job, err := newRolloutJob(RolloutConfig{Version: newVersion, Visors: pks, CanaryPercent: 100}, func(pk cipher.PubKey) (API, bool) {
+			v, ok := visors[pk]
+			return v, ok
+		})
+		require.NoError(t, err)
+
+		job.start()
+
+		stopped := make(chan struct{})
+		go func() {
+			job.stop()
+			close(stopped)
+		}()
+
+		select {
+		case <-stopped:
+		case <-time.After(10 * time.Second):
+			t.Fatal("rollout did not stop")
+		}
+
+		r := job.snapshot()
+		assert.Equal(t, RolloutCancelled, r.State)
+
+		// Visors interrupted while updating are not reported as untouched.
+		for _, v := range r.Visors {
+			assert.Equal(t, RolloutVisorFailed, v.State)
+			assert.Contains(t, v.Error, "cancelled")
+		}
+	})
+
+	t.Run("disconnected visor", func(t *testing.T) {
+		pks, visors := makeVisors(2, nil)
+		missing, _ := cipher.GenerateKeyPair()
+
+		conf := RolloutConfig{Version: newVersion, Visors: append([]cipher.PubKey{missing}, pks...), CanaryPercent: 1}
+
+		r := runFakeRollout(t, conf, visors)
+
+		assert.Equal(t, RolloutHalted, r.State)
+		assert.Equal(t, RolloutVisorFailed, r.Visors[0].State)
+		assert.Equal(t, RolloutVisorPending, r.Visors[1].State)
+	})
+
+	t.Run("invalid config", func(t *testing.T) {
+		_, err := newRolloutJob(RolloutConfig{Visors: []cipher.PubKey{{}}}, nil)
+		assert.Equal(t, ErrRolloutNoVersion, err)
+
+		_, err = newRolloutJob(RolloutConfig{Version: newVersion}, nil)
+		assert.Equal(t, ErrRolloutNoVisors, err)
+	})
+}
@@ -1,6 +1,7 @@
 package visor
 
 import (
+	"context"
 	"encoding/hex"
 	"errors"
 	"fmt"
@@ -11,6 +12,7 @@ import (
 	"github.com/sirupsen/logrus"
 	"github.com/skycoin/dmsg/cipher"
 
+	"github.com/skycoin/skywire/pkg/app/appbundle"
 	"github.com/skycoin/skywire/pkg/app/appserver"
 	"github.com/skycoin/skywire/pkg/app/launcher"
 	"github.com/skycoin/skywire/pkg/routing"
@@ -257,6 +259,43 @@ func (r *RPC) SetAppPassword(in *SetAppPasswordIn, _ *struct{}) (err error) {
 	return r.visor.SetAppPassword(in.AppName, in.Password)
 }
 
+// InstallAppIn is input for InstallApp and UpgradeApp.
+type InstallAppIn struct {
+	Bundle      []byte
+	Permissions []string
+}
+
+// InstallApp installs an app from a signed bundle.
+func (r *RPC) InstallApp(in *InstallAppIn, out *appbundle.Manifest) (err error) {
+	defer rpcutil.LogCall(r.log, "InstallApp", len(in.Bundle))(out, &err)
+
+	manifest, err := r.visor.InstallApp(in.Bundle, in.Permissions)
+	if manifest != nil {
+		*out = *manifest
+	}
+
+	return err
+}
+
+// UpgradeApp upgrades an app installed from a bundle.
+func (r *RPC) UpgradeApp(in *InstallAppIn, out *appbundle.Manifest) (err error) {
+	defer rpcutil.LogCall(r.log, "UpgradeApp", len(in.Bundle))(out, &err)
+
+	manifest, err := r.visor.UpgradeApp(in.Bundle, in.Permissions)
+	if manifest != nil {
+		*out = *manifest
+	}
+
+	return err
+}
+
+// UninstallApp uninstalls an app installed from a bundle.
+func (r *RPC) UninstallApp(name *string, _ *struct{}) (err error) {
+	defer rpcutil.LogCall(r.log, "UninstallApp", name)(nil, &err)
+
+	return r.visor.UninstallApp(*name)
+}
+
 // SetAppPKIn is input for SetAppPK.
 type SetAppPKIn struct {
 	AppName string
@@ -269,6 +308,12 @@ type SetAppBoolIn struct {
 	Val     bool
 }
 
+// SetAppRoutesIn is input for SetApp routes.
+type SetAppRoutesIn struct {
+	AppName string
+	Routes  []string
+}
+
 // SetAppPK sets PK for the app.
 func (r *RPC) SetAppPK(in *SetAppPKIn, _ *struct{}) (err error) {
 	defer rpcutil.LogCall(r.log, "SetAppPK", in)(nil, &err)
@@ -283,6 +328,20 @@ func (r *RPC) SetAppKillswitch(in *SetAppBoolIn, _ *struct{}) (err error) {
 	return r.visor.SetAppKillswitch(in.AppName, in.Val)
 }
 
+// SetAppIncludeRoutes sets routes the app sends through VPN.
+func (r *RPC) SetAppIncludeRoutes(in *SetAppRoutesIn, _ *struct{}) (err error) {
+	defer rpcutil.LogCall(r.log, "SetAppIncludeRoutes", in)(nil, &err)
+
+	return r.visor.SetAppIncludeRoutes(in.AppName, in.Routes)
+}
+
+// SetAppExcludeRoutes sets routes the app keeps out of VPN.
+func (r *RPC) SetAppExcludeRoutes(in *SetAppRoutesIn, _ *struct{}) (err error) {
+	defer rpcutil.LogCall(r.log, "SetAppExcludeRoutes", in)(nil, &err)
+
+	return r.visor.SetAppExcludeRoutes(in.AppName, in.Routes)
+}
+
 // SetAppSecure sets secure flag for the app
 func (r *RPC) SetAppSecure(in *SetAppBoolIn, _ *struct{}) (err error) {
 	defer rpcutil.LogCall(r.log, "SetAppSecure", in)(nil, &err)
@@ -483,7 +542,23 @@ func (r *RPC) Update(updateConfig *updater.UpdateConfig, updated *bool) (err err
 		config = *updateConfig
 	}
 
-	*updated, err = r.visor.Update(config)
+	*updated, err = r.visor.Update(context.Background(), config)
+	return
+}
+
+// CancelUpdate cancels the running visor update.
+func (r *RPC) CancelUpdate(_ *struct{}, cancelled *bool) (err error) {
+	defer rpcutil.LogCall(r.log, "CancelUpdate", nil)(cancelled, &err)
+
+	*cancelled, err = r.visor.CancelUpdate()
+	return
+}
+
+// Rollback restores visor binaries replaced by the last update.
+func (r *RPC) Rollback(_ *struct{}, rolledBack *bool) (err error) {
+	defer rpcutil.LogCall(r.log, "Rollback", nil)(rolledBack, &err)
+
+	*rolledBack, err = r.visor.Rollback()
 	return
 }
 
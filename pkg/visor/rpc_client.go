@@ -19,6 +19,7 @@ import (
 	"github.com/skycoin/dmsg/cipher"
 	"github.com/skycoin/skycoin/src/util/logging"
 
+	"github.com/skycoin/skywire/pkg/app/appbundle"
 	"github.com/skycoin/skywire/pkg/app/appcommon"
 	"github.com/skycoin/skywire/pkg/app/appserver"
 	"github.com/skycoin/skywire/pkg/app/launcher"
@@ -65,7 +66,13 @@ func NewRPCClient(log logrus.FieldLogger, conn io.ReadWriteCloser, prefix string
 
 // Call calls the internal rpc.Client with the serviceMethod arg prefixed.
 func (rc *rpcClient) Call(method string, args, reply interface{}) error {
-	ctx := context.Background()
+	return rc.callContext(context.Background(), method, args, reply)
+}
+
+// callContext is like Call, but stops waiting for the reply once `parent` is done.
+// The call itself isn't interrupted on the remote side then, Update cancels it with CancelUpdate.
+func (rc *rpcClient) callContext(parent context.Context, method string, args, reply interface{}) error {
+	ctx := parent
 	timeout := rc.timeout
 
 	switch method {
@@ -85,6 +92,10 @@ func (rc *rpcClient) Call(method string, args, reply interface{}) error {
 	case call := <-rc.client.Go(rc.prefix+"."+method, args, reply, nil).Done:
 		return call.Error
 	case <-ctx.Done():
+		if err := parent.Err(); err != nil {
+			return err
+		}
+
 		if err := rc.conn.Close(); err != nil {
 			rc.log.WithError(err).Warn("Failed to close rpc client after timeout error.")
 		}
@@ -174,6 +185,22 @@ func (rc *rpcClient) SetAppKillswitch(appName string, killswitch bool) error {
 	}, &struct{}{})
 }
 
+// SetAppIncludeRoutes implements API.
+func (rc *rpcClient) SetAppIncludeRoutes(appName string, routes []string) error {
+	return rc.Call("SetAppIncludeRoutes", &SetAppRoutesIn{
+		AppName: appName,
+		Routes:  routes,
+	}, &struct{}{})
+}
+
+// SetAppExcludeRoutes implements API.
+func (rc *rpcClient) SetAppExcludeRoutes(appName string, routes []string) error {
+	return rc.Call("SetAppExcludeRoutes", &SetAppRoutesIn{
+		AppName: appName,
+		Routes:  routes,
+	}, &struct{}{})
+}
+
 // SetAppSecure implements API.
 func (rc *rpcClient) SetAppSecure(appName string, isSecure bool) error {
 	return rc.Call("SetAppSecure", &SetAppBoolIn{
@@ -182,6 +209,31 @@ func (rc *rpcClient) SetAppSecure(appName string, isSecure bool) error {
 	}, &struct{}{})
 }
 
+// InstallApp calls InstallApp.
+func (rc *rpcClient) InstallApp(bundle []byte, permissions []string) (*appbundle.Manifest, error) {
+	var manifest appbundle.Manifest
+	if err := rc.Call("InstallApp", &InstallAppIn{Bundle: bundle, Permissions: permissions}, &manifest); err != nil {
+		return nil, err
+	}
+
+	return &manifest, nil
+}
+
+// UpgradeApp calls UpgradeApp.
+func (rc *rpcClient) UpgradeApp(bundle []byte, permissions []string) (*appbundle.Manifest, error) {
+	var manifest appbundle.Manifest
+	if err := rc.Call("UpgradeApp", &InstallAppIn{Bundle: bundle, Permissions: permissions}, &manifest); err != nil {
+		return nil, err
+	}
+
+	return &manifest, nil
+}
+
+// UninstallApp calls UninstallApp.
+func (rc *rpcClient) UninstallApp(appName string) error {
+	return rc.Call("UninstallApp", &appName, &struct{}{})
+}
+
 // LogsSince calls LogsSince
 func (rc *rpcClient) LogsSince(timestamp time.Time, appName string) ([]string, error) {
 	res := make([]string, 0)
@@ -306,13 +358,34 @@ func (rc *rpcClient) Exec(command string) ([]byte, error) {
 	return output, err
 }
 
-// Update calls Update.
-func (rc *rpcClient) Update(config updater.UpdateConfig) (bool, error) {
+// Update calls Update. If `ctx` is done before the update finishes, the remote update is cancelled with CancelUpdate.
+func (rc *rpcClient) Update(ctx context.Context, config updater.UpdateConfig) (bool, error) {
 	var updated bool
-	err := rc.Call("Update", &config, &updated)
+	err := rc.callContext(ctx, "Update", &config, &updated)
+
+	if ctx.Err() != nil {
+		if _, cErr := rc.CancelUpdate(); cErr != nil {
+			rc.log.WithError(cErr).Warn("Failed to cancel remote update.")
+		}
+	}
+
 	return updated, err
 }
 
+// CancelUpdate calls CancelUpdate.
+func (rc *rpcClient) CancelUpdate() (bool, error) {
+	var cancelled bool
+	err := rc.Call("CancelUpdate", &struct{}{}, &cancelled)
+	return cancelled, err
+}
+
+// Rollback calls Rollback.
+func (rc *rpcClient) Rollback() (bool, error) {
+	var rolledBack bool
+	err := rc.Call("Rollback", &struct{}{}, &rolledBack)
+	return rolledBack, err
+}
+
 // StatusMessage defines a status of visor update.
 type StatusMessage struct {
 	Text    string
@@ -683,6 +756,30 @@ func (mc *mockRPCClient) SetAppKillswitch(appName string, killswitch bool) error
 	})
 }
 
+// SetAppIncludeRoutes implements API.
+func (mc *mockRPCClient) SetAppIncludeRoutes(string, []string) error {
+	return mc.setAppRoutes()
+}
+
+// SetAppExcludeRoutes implements API.
+func (mc *mockRPCClient) SetAppExcludeRoutes(string, []string) error {
+	return mc.setAppRoutes()
+}
+
+func (mc *mockRPCClient) setAppRoutes() error {
+	return mc.do(true, func() error {
+		const vpnClientName = "vpn-client"
+
+		for i := range mc.s.Apps {
+			if mc.s.Apps[i].Name == vpnClientName {
+				return nil
+			}
+		}
+
+		return fmt.Errorf("app of name '%s' does not exist", vpnClientName)
+	})
+}
+
 // SetAppSecure implements API.
 func (mc *mockRPCClient) SetAppSecure(appName string, isSecure bool) error {
 	return mc.do(true, func() error {
@@ -698,6 +795,21 @@ func (mc *mockRPCClient) SetAppSecure(appName string, isSecure bool) error {
 	})
 }
 
+// InstallApp implements API.
+func (*mockRPCClient) InstallApp([]byte, []string) (*appbundle.Manifest, error) {
+	return nil, appbundle.ErrNoBundleKeys
+}
+
+// UpgradeApp implements API.
+func (*mockRPCClient) UpgradeApp([]byte, []string) (*appbundle.Manifest, error) {
+	return nil, appbundle.ErrNoBundleKeys
+}
+
+// UninstallApp implements API.
+func (*mockRPCClient) UninstallApp(appName string) error {
+	return fmt.Errorf("app %s is not installed from a bundle", appName)
+}
+
 // LogsSince implements API. Manually set (*mockRPPClient).logS before calling this function
 func (mc *mockRPCClient) LogsSince(timestamp time.Time, _ string) ([]string, error) {
 	return mc.logS.LogsSince(timestamp)
@@ -857,7 +969,17 @@ func (mc *mockRPCClient) Exec(string) ([]byte, error) {
 }
 
 // Update implements API.
-func (mc *mockRPCClient) Update(_ updater.UpdateConfig) (bool, error) {
+func (mc *mockRPCClient) Update(_ context.Context, _ updater.UpdateConfig) (bool, error) {
+	return false, nil
+}
+
+// CancelUpdate implements API.
+func (mc *mockRPCClient) CancelUpdate() (bool, error) {
+	return false, nil
+}
+
+// Rollback implements API.
+func (mc *mockRPCClient) Rollback() (bool, error) {
 	return false, nil
 }
 
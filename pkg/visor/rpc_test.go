@@ -1,13 +1,17 @@
 package visor
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"net/http"
+	"net/http/httptest"
 	"testing"
 	"time"
 
 	"github.com/sirupsen/logrus"
 	"github.com/skycoin/dmsg/cipher"
+	"github.com/skycoin/skycoin/src/util/logging"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
@@ -17,6 +21,7 @@ import (
 	"github.com/skycoin/skywire/pkg/routefinder/rfclient"
 	"github.com/skycoin/skywire/pkg/snet/arclient"
 	"github.com/skycoin/skywire/pkg/transport"
+	"github.com/skycoin/skywire/pkg/util/updater"
 	"github.com/skycoin/skywire/pkg/visor/visorconfig"
 )
 
@@ -106,6 +111,46 @@ func TestUptime(t *testing.T) {
 	assert.Contains(t, fmt.Sprintf("%f", res), "1.0")
 }
 
+func TestCancelUpdate(t *testing.T) {
+	// Release server never responds, so the update hangs downloading until cancelled.
+	srv := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+	defer srv.Close()
+
+	log := logging.MustGetLogger("test")
+	v := &Visor{
+		log:     log,
+		updater: updater.New(log, nil, "", nil, updater.NewHTTPSource(srv.URL)),
+	}
+	rpc := &RPC{visor: v, log: logrus.New()}
+
+	var cancelled bool
+	require.NoError(t, rpc.CancelUpdate(nil, &cancelled))
+	require.False(t, cancelled)
+
+	errCh := make(chan error, 1)
+	go func() {
+		var updated bool
+		errCh <- rpc.Update(&updater.UpdateConfig{Version: "v1.0.0"}, &updated)
+	}()
+
+	require.Eventually(t, func() bool {
+		require.NoError(t, rpc.CancelUpdate(nil, &cancelled))
+		return cancelled
+	}, 5*time.Second, 10*time.Millisecond)
+
+	select {
+	case err := <-errCh:
+		require.True(t, errors.Is(err, context.Canceled), err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("update was not cancelled")
+	}
+
+	require.NoError(t, rpc.CancelUpdate(nil, &cancelled))
+	require.False(t, cancelled)
+}
+
 // TODO(evanlinjin): These should be moved to /pkg/app/launcher
 //func TestListApps(t *testing.T) {
 //	apps := make(map[string]AppConfig)
@@ -2,12 +2,14 @@
 package visor
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"path/filepath"
 	"reflect"
 	"runtime"
 	"strings"
+	"sync"
 	"syscall"
 	"time"
 
@@ -56,6 +58,9 @@ type Visor struct {
 	updater       *updater.Updater
 	uptimeTracker utclient.APIClient
 
+	updateMu     sync.Mutex
+	updateCancel context.CancelFunc // cancels the running update, nil if none
+
 	ebc *appevent.Broadcaster // event broadcaster
 
 	net      *snet.Network
@@ -175,6 +180,11 @@ func (v *Visor) Close() error {
 	log := v.MasterLogger().PackageLogger("visor:shutdown")
 	log.Info("Begin shutdown.")
 
+	// apps are told before they get stopped, so they can clean up
+	if v.ebc != nil {
+		v.broadcastEvent(appevent.VisorShutdownData{})
+	}
+
 	for i := len(v.closeStack) - 1; i >= 0; i-- {
 		ce := v.closeStack[i]
 
@@ -212,6 +222,19 @@ func (v *Visor) Close() error {
 	return nil
 }
 
+// appEventData is data of an app event.
+type appEventData interface {
+	Type() string
+}
+
+// broadcastEvent broadcasts an event with `data` to the apps subscribed to it.
+func (v *Visor) broadcastEvent(data appEventData) {
+	err := v.ebc.Broadcast(context.Background(), appevent.NewEvent(data.Type(), data))
+	if err != nil && !errors.Is(err, appevent.ErrSubscriptionsClosed) {
+		v.log.WithError(err).WithField("event", data.Type()).Error("Failed to broadcast event.")
+	}
+}
+
 // tpDiscClient is a convenience function to obtain transport discovery client.
 func (v *Visor) tpDiscClient() transport.DiscoveryClient {
 	return v.tpM.Conf.DiscoveryClient
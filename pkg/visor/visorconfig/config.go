@@ -45,6 +45,7 @@ func MakeBaseConfig(common *Common) *V1 {
 	conf.LogLevel = skyenv.DefaultLogLevel
 	conf.ShutdownTimeout = DefaultTimeout
 	conf.RestartCheckDelay = Duration(restart.DefaultCheckDelay)
+	conf.ReleasePKs = skyenv.DefaultReleasePKs()
 	return conf
 }
 
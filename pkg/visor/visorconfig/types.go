@@ -12,6 +12,12 @@ const (
 	MemoryLogStore = "memory"
 )
 
+// Routing table types.
+const (
+	MemoryRoutingTable = "memory"
+	BBoltRoutingTable  = "bbolt"
+)
+
 const (
 	// DefaultTimeout is used for default config generation and if it is not set in config.
 	DefaultTimeout = Duration(10 * time.Second)
@@ -9,6 +9,7 @@ import (
 
 	"github.com/skycoin/skywire/pkg/app/launcher"
 	"github.com/skycoin/skywire/pkg/snet"
+	"github.com/skycoin/skywire/pkg/util/updater"
 	"github.com/skycoin/skywire/pkg/visor/hypervisorconfig"
 )
 
@@ -20,11 +21,13 @@ const V1Name = "v1.0.0"
 // V1 is visor config v1.0.0
 type V1 struct {
 	*Common
-	mu sync.RWMutex
+	mu       sync.RWMutex
+	onChange func(appName string)
 
 	Dmsg          *snet.DmsgConfig `json:"dmsg"`
 	Dmsgpty       *V1Dmsgpty       `json:"dmsgpty,omitempty"`
 	STCP          *snet.STCPConfig `json:"stcp,omitempty"`
+	SWS           *snet.SWSConfig  `json:"sws,omitempty"`
 	Transport     *V1Transport     `json:"transport"`
 	Routing       *V1Routing       `json:"routing"`
 	UptimeTracker *V1UptimeTracker `json:"uptime_tracker,omitempty"`
@@ -33,6 +36,9 @@ type V1 struct {
 	Hypervisors []cipher.PubKey `json:"hypervisors"`
 	CLIAddr     string          `json:"cli_addr"`
 
+	ReleasePKs   []cipher.PubKey       `json:"release_public_keys,omitempty"` // updates are only installed if signed by one of these keys
+	UpdateSource *updater.SourceConfig `json:"update_source,omitempty"`       // updates are downloaded from GitHub if not set
+
 	LogLevel          string   `json:"log_level"`
 	ShutdownTimeout   Duration `json:"shutdown_timeout,omitempty"`    // time value, examples: 10s, 1m, etc
 	RestartCheckDelay Duration `json:"restart_check_delay,omitempty"` // time value, examples: 10s, 1m, etc
@@ -56,6 +62,7 @@ type V1Transport struct {
 	AddressResolver string          `json:"address_resolver"`
 	LogStore        *V1LogStore     `json:"log_store"`
 	TrustedVisors   []cipher.PubKey `json:"trusted_visors"`
+	DisableQUIC     bool            `json:"disable_quic,omitempty"` // QUIC transports are neither listened for nor dialed if set
 }
 
 // V1LogStore configures a LogStore.
@@ -70,6 +77,17 @@ type V1Routing struct {
 	SetupNodes         []cipher.PubKey `json:"setup_nodes,omitempty"`
 	RouteFinder        string          `json:"route_finder"`
 	RouteFinderTimeout Duration        `json:"route_finder_timeout,omitempty"`
+	MaxRoutes          int             `json:"max_routes,omitempty"`      // max number of disjoint paths per route group
+	RouteScheduler     string          `json:"route_scheduler,omitempty"` // failover, round_robin or latency
+	Table              *V1RoutingTable `json:"table,omitempty"`
+}
+
+// V1RoutingTable configures a routing table.
+type V1RoutingTable struct {
+	// Type defines the routing table type. Valid values: memory, bbolt.
+	// In-memory table is used if the routing table is not configured.
+	Type     string `json:"type"`
+	Location string `json:"location,omitempty"`
 }
 
 // V1UptimeTracker configures uptime tracker.
@@ -90,6 +108,7 @@ type V1Launcher struct {
 	ServerAddr string               `json:"server_addr"`
 	BinPath    string               `json:"bin_path"`
 	LocalPath  string               `json:"local_path"`
+	BundlePKs  []cipher.PubKey      `json:"bundle_public_keys,omitempty"` // keys app bundles are verified with
 }
 
 // Flush flushes the config to file (if specified).
@@ -100,6 +119,28 @@ func (v1 *V1) Flush() error {
 	return v1.Common.flush(v1)
 }
 
+// OnChange sets callback which will fire after app configs are changed and flushed.
+func (v1 *V1) OnChange(f func(appName string)) {
+	v1.mu.Lock()
+	v1.onChange = f
+	v1.mu.Unlock()
+}
+
+// flushChanged flushes the config and reports the change of `appName` config.
+// Must be called with the lock held.
+func (v1 *V1) flushChanged(appName string) error {
+	if err := v1.flush(v1); err != nil {
+		return err
+	}
+
+	// callback may take a while, so it doesn't hold the lock
+	if v1.onChange != nil {
+		go v1.onChange(appName)
+	}
+
+	return nil
+}
+
 // UpdateAppAutostart modifies a single app's autostart value within the config and also the given launcher.
 // The updated config gets flushed to file if there are any changes.
 func (v1 *V1) UpdateAppAutostart(launch *launcher.Launcher, appName string, autoStart bool) error {
@@ -126,7 +167,7 @@ func (v1 *V1) UpdateAppAutostart(launch *launcher.Launcher, appName string, auto
 		Apps:       conf.Apps,
 		ServerAddr: conf.ServerAddr,
 	})
-	return v1.flush(v1)
+	return v1.flushChanged(appName)
 }
 
 // UpdateAppArg updates the cli flag of the specified app config and also within the launcher.
@@ -157,7 +198,97 @@ func (v1 *V1) UpdateAppArg(launch *launcher.Launcher, appName, argName string, v
 		ServerAddr: conf.ServerAddr,
 	})
 
-	return v1.flush(v1)
+	return v1.flushChanged(appName)
+}
+
+// UpdateAppPermissions sets permissions granted to an app installed from a bundle within the config
+// and also the given launcher. The updated config gets flushed to file.
+func (v1 *V1) UpdateAppPermissions(launch *launcher.Launcher, appName string, permissions []string) error {
+	v1.mu.Lock()
+	defer v1.mu.Unlock()
+
+	conf := v1.Launcher
+
+	changed := false
+	for i := range conf.Apps {
+		if conf.Apps[i].Name == appName {
+			conf.Apps[i].Bundled = true
+			conf.Apps[i].Permissions = permissions
+			changed = true
+			break
+		}
+	}
+
+	if !changed {
+		return fmt.Errorf("app %s is not found", appName)
+	}
+
+	launch.ResetConfig(launcher.Config{
+		VisorPK:    v1.PK,
+		Apps:       conf.Apps,
+		ServerAddr: conf.ServerAddr,
+	})
+
+	return v1.flushChanged(appName)
+}
+
+// AddApp adds an app config within the config and also the given launcher.
+// The updated config gets flushed to file.
+func (v1 *V1) AddApp(launch *launcher.Launcher, ac launcher.AppConfig) error {
+	v1.mu.Lock()
+	defer v1.mu.Unlock()
+
+	conf := v1.Launcher
+
+	for _, app := range conf.Apps {
+		if app.Name == ac.Name {
+			return fmt.Errorf("app %s already exists", ac.Name)
+		}
+
+		if app.Port == ac.Port {
+			return fmt.Errorf("port %d is already used by app %s", ac.Port, app.Name)
+		}
+	}
+
+	conf.Apps = append(conf.Apps, ac)
+
+	launch.ResetConfig(launcher.Config{
+		VisorPK:    v1.PK,
+		Apps:       conf.Apps,
+		ServerAddr: conf.ServerAddr,
+	})
+
+	return v1.flushChanged(ac.Name)
+}
+
+// RemoveApp removes an app config from the config and also from the given launcher.
+// The updated config gets flushed to file if there are any changes.
+func (v1 *V1) RemoveApp(launch *launcher.Launcher, appName string) error {
+	v1.mu.Lock()
+	defer v1.mu.Unlock()
+
+	conf := v1.Launcher
+
+	changed := false
+	for i := range conf.Apps {
+		if conf.Apps[i].Name == appName {
+			conf.Apps = append(conf.Apps[:i], conf.Apps[i+1:]...)
+			changed = true
+			break
+		}
+	}
+
+	if !changed {
+		return nil
+	}
+
+	launch.ResetConfig(launcher.Config{
+		VisorPK:    v1.PK,
+		Apps:       conf.Apps,
+		ServerAddr: conf.ServerAddr,
+	})
+
+	return v1.flushChanged(appName)
 }
 
 // updateStringArg updates the cli non-boolean flag of the specified app config and also within the launcher.